@@ -0,0 +1,9 @@
+-- Ephemeral files are files indexed on demand from outside any registered workspace (i.e. a lone
+-- script, or a file in `/tmp`). They are tracked separately to workspace files so that they can be
+-- evicted when closed, or once they have not been accessed for some time.
+ALTER TABLE file ADD COLUMN ephemeral BOOLEAN NOT NULL DEFAULT 0;
+
+ALTER TABLE file ADD COLUMN last_accessed_at STRING;
+
+CREATE INDEX IF NOT EXISTS idx_ephemeral_last_accessed_at
+ON file (ephemeral, last_accessed_at);
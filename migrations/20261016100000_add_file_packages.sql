@@ -0,0 +1,8 @@
+-- The package (i.e. crate, npm package, Go module, etc.) which owns each file, as detected from
+-- the nearest package manifest.
+ALTER TABLE file ADD COLUMN package varchar(255);
+
+ALTER TABLE file ADD COLUMN package_root varchar(1000);
+
+CREATE INDEX IF NOT EXISTS idx_package
+ON file (package);
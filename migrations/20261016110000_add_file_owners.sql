@@ -0,0 +1,14 @@
+-- The owners (i.e. `@user`, `@org/team`, or an email address) of each file, as defined by the
+-- workspace's CODEOWNERS file.
+CREATE TABLE IF NOT EXISTS file_owner (
+    id   INTEGER PRIMARY KEY,
+    file_id INTEGER NOT NULL,
+    owner varchar(255) NOT NULL,
+    FOREIGN KEY (file_id) REFERENCES file(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_file_owner
+ON file_owner (file_id, owner);
+
+CREATE INDEX IF NOT EXISTS idx_owner
+ON file_owner (owner);
@@ -0,0 +1,3 @@
+-- Whether each symbol is part of test code, as detected from the syntax of the file (i.e. a
+-- `#[cfg(test)]` module, or a `describe(…)` block), or from the path of the file.
+ALTER TABLE symbol ADD COLUMN test BOOLEAN NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+-- Whether each symbol has been marked as deprecated (i.e. `#[deprecated]` in Rust, or `@deprecated`
+-- in JSDoc), alongside the deprecation message, if one was given.
+ALTER TABLE symbol ADD COLUMN deprecated BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE symbol ADD COLUMN deprecation_message TEXT;
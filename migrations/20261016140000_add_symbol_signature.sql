@@ -0,0 +1,3 @@
+-- The normalised signature of each function-like symbol (i.e. `(Path, Context) -> Result<Vec<u8>>`),
+-- which can be searched by shape.
+ALTER TABLE symbol ADD COLUMN signature TEXT;
@@ -0,0 +1,3 @@
+-- Whether each symbol is a read or a write of a value (i.e. an environment variable), rather than
+-- a definition.
+ALTER TABLE symbol ADD COLUMN access TEXT;
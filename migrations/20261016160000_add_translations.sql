@@ -0,0 +1,16 @@
+-- The translation keys defined in each locale file (i.e. `locales/en.json`), which are linked to
+-- the translation key symbols used in source code by their key.
+CREATE TABLE IF NOT EXISTS translation (
+    id   INTEGER PRIMARY KEY,
+    file_id INTEGER NOT NULL,
+    key varchar(1000) NOT NULL,
+    locale varchar(255) NOT NULL,
+    start_line INTEGER NOT NULL,
+    start_column INTEGER NOT NULL,
+    end_line INTEGER NOT NULL,
+    end_column INTEGER NOT NULL,
+    FOREIGN KEY (file_id) REFERENCES file(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_translation_key
+ON translation (key);
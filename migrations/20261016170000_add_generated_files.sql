@@ -0,0 +1,19 @@
+-- Whether each file is generated code (i.e. `user.pb.go`), as detected from its header or path.
+ALTER TABLE file ADD COLUMN generated BOOLEAN NOT NULL DEFAULT 0;
+
+-- The definitions in each schema file (i.e. Protocol Buffers messages, or GraphQL types), which
+-- the symbols in generated code are linked back to by their name.
+CREATE TABLE IF NOT EXISTS schema_definition (
+    id   INTEGER PRIMARY KEY,
+    file_id INTEGER NOT NULL,
+    name varchar(1000) NOT NULL,
+    kind varchar(255) NOT NULL,
+    start_line INTEGER NOT NULL,
+    start_column INTEGER NOT NULL,
+    end_line INTEGER NOT NULL,
+    end_column INTEGER NOT NULL,
+    FOREIGN KEY (file_id) REFERENCES file(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_schema_definition_name
+ON schema_definition (name);
@@ -0,0 +1,20 @@
+-- Symbols which have been pinned (or bookmarked), with an optional label and group. Pins aren't
+-- tied to rows in the symbol table (which are replaced every time a file is re-indexed), and are
+-- instead re-attached to symbols by their identity: the path, container, name and kind of the
+-- symbol. The line the symbol was last seen on is kept to tell apart symbols with the same
+-- identity.
+CREATE TABLE IF NOT EXISTS pin (
+    id   INTEGER PRIMARY KEY,
+    kind varchar(255) NOT NULL,
+    label varchar(1000),
+    group_name varchar(255),
+    path varchar(1000) NOT NULL,
+    container varchar(1000) NOT NULL,
+    name varchar(255) NOT NULL,
+    symbol_kind varchar(255) NOT NULL,
+    line INTEGER NOT NULL,
+    created_at STRING NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pin_identity
+ON pin (kind, path, container, name, symbol_kind);
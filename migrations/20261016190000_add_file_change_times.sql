@@ -0,0 +1,5 @@
+-- When the content of each file last changed: its modification time, and (if the indexer reads
+-- the local git history) the time of the last commit which changed it. Files with uncommitted
+-- changes have no commit time, as the last commit no longer reflects their content.
+ALTER TABLE file ADD COLUMN modified_at STRING;
+ALTER TABLE file ADD COLUMN committed_at STRING;
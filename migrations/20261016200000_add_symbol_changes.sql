@@ -0,0 +1,24 @@
+-- An append-only log of the symbols added to (and removed from) each file, as it's re-indexed.
+-- Changes aren't tied to rows in the file or symbol tables, so that they outlive the files and
+-- symbols they describe. The commit which made the change is kept when it's known (when the
+-- indexer reads the git history of the workspace, and the file has no uncommitted changes).
+CREATE TABLE IF NOT EXISTS symbol_change (
+    id   INTEGER PRIMARY KEY,
+    change varchar(255) NOT NULL,
+    path varchar(1000) NOT NULL,
+    name varchar(255) NOT NULL,
+    kind varchar(255) NOT NULL,
+    language varchar(255) NOT NULL,
+    line INTEGER NOT NULL,
+    commit_hash varchar(255),
+    changed_at STRING NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_symbol_change_changed_at
+ON symbol_change (changed_at);
+
+CREATE INDEX IF NOT EXISTS idx_symbol_change_path
+ON symbol_change (path);
+
+CREATE INDEX IF NOT EXISTS idx_symbol_change_name
+ON symbol_change (name);
@@ -0,0 +1,154 @@
+//! Export an index as a static, cross-referenced code browser (see [`onoma::export`]).
+//!
+//! The workspaces are fully indexed before exporting (unless `--no-index` is given, in which case
+//! an existing index is exported as-is).
+//!
+//! ```sh
+//! onoma-export [--storage-path <path>] [--no-index] --output <directory> [<workspace>...]
+//! ```
+
+use std::{path::PathBuf, process::ExitCode};
+
+use onoma::{
+    export::SiteExporter,
+    indexer::{DatabaseBackedIndexer, Indexer},
+};
+
+const USAGE: &str =
+    "Usage: onoma-export [--storage-path <path>] [--no-index] --output <directory> [<workspace>...]
+
+Exports the index of the given workspaces (or the current directory, if none are given) as a
+static, cross-referenced code browser.
+
+Options:
+  --storage-path <path>  Where to store the index (defaults to $ONOMA_STORAGE_PATH, or a
+                         directory in the system's temporary directory)
+  --no-index             Export the existing index, without indexing the workspaces first
+  --output <directory>   The directory to export the site into
+  -h, --help             Print this help";
+
+/// The configuration of the export, parsed from the command line arguments.
+struct Arguments {
+    storage_path: PathBuf,
+    workspaces: Vec<PathBuf>,
+    output: PathBuf,
+    index: bool,
+}
+
+/// Parse the command line arguments.
+fn parse_arguments(
+    mut arguments: impl Iterator<Item = String>,
+) -> Result<Option<Arguments>, String> {
+    let mut storage_path = std::env::var_os("ONOMA_STORAGE_PATH").map(PathBuf::from);
+    let mut workspaces = Vec::new();
+    let mut output = None;
+    let mut index = true;
+
+    while let Some(argument) = arguments.next() {
+        match argument.as_str() {
+            "-h" | "--help" => return Ok(None),
+            "--storage-path" => {
+                storage_path = Some(
+                    arguments
+                        .next()
+                        .map(PathBuf::from)
+                        .ok_or("--storage-path requires a path")?,
+                );
+            }
+            "--output" => {
+                output = Some(
+                    arguments
+                        .next()
+                        .map(PathBuf::from)
+                        .ok_or("--output requires a directory")?,
+                );
+            }
+            "--no-index" => index = false,
+            argument if argument.starts_with('-') => {
+                return Err(format!("Unknown option: {argument}"));
+            }
+            workspace => workspaces.push(
+                std::fs::canonicalize(workspace)
+                    .map_err(|e| format!("Unable to read workspace {workspace}: {e}"))?,
+            ),
+        }
+    }
+
+    if workspaces.is_empty() {
+        workspaces.push(
+            std::env::current_dir()
+                .map_err(|e| format!("Unable to read the current directory: {e}"))?,
+        );
+    }
+
+    Ok(Some(Arguments {
+        storage_path: storage_path.unwrap_or_else(|| std::env::temp_dir().join("onoma")),
+        workspaces,
+        output: output.ok_or("An output directory is required")?,
+        index,
+    }))
+}
+
+#[tokio::main]
+async fn main() -> ExitCode {
+    let arguments = match parse_arguments(std::env::args().skip(1)) {
+        Ok(Some(arguments)) => arguments,
+        Ok(None) => {
+            println!("{USAGE}");
+
+            return ExitCode::SUCCESS;
+        }
+        Err(message) => {
+            eprintln!("{message}\n\n{USAGE}");
+
+            return ExitCode::FAILURE;
+        }
+    };
+
+    if arguments.index {
+        let indexer = match DatabaseBackedIndexer::new(
+            &arguments.storage_path,
+            arguments.workspaces.iter().map(PathBuf::as_path),
+        )
+        .await
+        {
+            Ok(indexer) => indexer,
+            Err(e) => {
+                eprintln!("Unable to create the index: {e}");
+
+                return ExitCode::FAILURE;
+            }
+        };
+
+        // A partially indexed workspace is still worth exporting
+        if let Err(errors) = indexer.index_workspaces().await {
+            for e in errors {
+                eprintln!("Unable to index workspace: {e}");
+            }
+        }
+    }
+
+    let exporter = SiteExporter::new(
+        &arguments.storage_path,
+        arguments.workspaces.iter().map(PathBuf::as_path),
+    );
+
+    match exporter.export(&arguments.output).await {
+        Ok(summary) => {
+            println!(
+                "Exported {} files ({} symbols, {} references) to {}",
+                summary.files,
+                summary.symbols,
+                summary.references,
+                arguments.output.display()
+            );
+
+            ExitCode::SUCCESS
+        }
+        Err(e) => {
+            eprintln!("Unable to export: {e}");
+
+            ExitCode::FAILURE
+        }
+    }
+}
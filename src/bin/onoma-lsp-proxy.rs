@@ -0,0 +1,458 @@
+//! A Language Server Protocol proxy, which spawns another language server and merges Onoma's
+//! results into its responses (see [`onoma::lsp`]).
+//!
+//! Without a language server command, Onoma serves the editor directly instead, as a language
+//! server of its own (see [`onoma::lsp::Proxy::serve`]).
+//!
+//! The workspaces are fully indexed in the background when the proxy starts, and then watched
+//! for changes, so that the index stays fresh for as long as the proxy is running.
+//!
+//! ```sh
+//! onoma-lsp-proxy [--storage-path <path>] [--workspace <path>]... [--replace-workspace-symbols] [-- <command> [<argument>...]]
+//! ```
+
+use std::{
+    path::PathBuf,
+    process::{ExitCode, ExitStatus, Stdio},
+    sync::Arc,
+};
+
+use onoma::{
+    indexer::DatabaseBackedIndexer,
+    lsp::{self, WorkspaceSymbolMode},
+    watcher::Watcher,
+};
+use tokio::{
+    io::{AsyncBufRead, AsyncWrite, BufReader},
+    process::{Child, ChildStdin, ChildStdout, Command},
+};
+
+const USAGE: &str = "Usage: onoma-lsp-proxy [--storage-path <path>] [--workspace <path>]... [--replace-workspace-symbols] [-- <command> [<argument>...]]
+
+Spawns a language server, and proxies the Language Server Protocol between it and the editor
+(over stdin and stdout), merging Onoma's results into workspace symbol, definition and completion
+responses.
+
+Without a language server command, answers workspace symbol, definition, hover and completion
+requests from the editor with only Onoma's results.
+
+Options:
+  --storage-path <path>        Where to store the index (defaults to $ONOMA_STORAGE_PATH, or a
+                               directory in the system's temporary directory)
+  --workspace <path>           A workspace to index (defaults to the current directory)
+  --replace-workspace-symbols  Answer workspace symbol requests with only Onoma's results,
+                               rather than augmenting the language server's results
+  -h, --help                   Print this help";
+
+/// The configuration of the proxy, parsed from the command line arguments.
+struct Arguments {
+    storage_path: PathBuf,
+    workspaces: Vec<PathBuf>,
+    workspace_symbol_mode: WorkspaceSymbolMode,
+    /// The command which starts the language server, if any.
+    command: Vec<String>,
+}
+
+/// Parse the command line arguments.
+fn parse_arguments(
+    mut arguments: impl Iterator<Item = String>,
+) -> Result<Option<Arguments>, String> {
+    let mut storage_path = std::env::var_os("ONOMA_STORAGE_PATH").map(PathBuf::from);
+    let mut workspaces = Vec::new();
+    let mut workspace_symbol_mode = WorkspaceSymbolMode::Augment;
+
+    while let Some(argument) = arguments.next() {
+        match argument.as_str() {
+            "-h" | "--help" => return Ok(None),
+            "--storage-path" => {
+                storage_path = Some(
+                    arguments
+                        .next()
+                        .map(PathBuf::from)
+                        .ok_or("--storage-path requires a path")?,
+                );
+            }
+            "--workspace" => {
+                let workspace = arguments.next().ok_or("--workspace requires a path")?;
+
+                workspaces.push(
+                    std::fs::canonicalize(&workspace)
+                        .map_err(|e| format!("Unable to read workspace {workspace}: {e}"))?,
+                );
+            }
+            "--replace-workspace-symbols" => workspace_symbol_mode = WorkspaceSymbolMode::Replace,
+            "--" => break,
+            argument => return Err(format!("Unknown option: {argument}")),
+        }
+    }
+
+    let command = arguments.collect::<Vec<_>>();
+
+    if workspaces.is_empty() {
+        workspaces.push(
+            std::env::current_dir()
+                .map_err(|e| format!("Unable to read the current directory: {e}"))?,
+        );
+    }
+
+    Ok(Some(Arguments {
+        storage_path: storage_path.unwrap_or_else(|| std::env::temp_dir().join("onoma")),
+        workspaces,
+        workspace_symbol_mode,
+        command,
+    }))
+}
+
+#[tokio::main]
+async fn main() -> ExitCode {
+    let arguments = match parse_arguments(std::env::args().skip(1)) {
+        Ok(Some(arguments)) => arguments,
+        Ok(None) => {
+            println!("{USAGE}");
+
+            return ExitCode::SUCCESS;
+        }
+        Err(message) => {
+            eprintln!("{message}\n\n{USAGE}");
+
+            return ExitCode::FAILURE;
+        }
+    };
+
+    // The language server decides the exit code, as the editor expects it to be the server
+    match run(
+        &arguments,
+        BufReader::new(tokio::io::stdin()),
+        tokio::io::stdout(),
+    )
+    .await
+    {
+        Ok(None) => ExitCode::SUCCESS,
+        Ok(Some(status)) if status.success() => ExitCode::SUCCESS,
+        Ok(Some(_)) => ExitCode::FAILURE,
+        Err(message) => {
+            eprintln!("{message}");
+
+            ExitCode::FAILURE
+        }
+    }
+}
+
+/// Spawn the language server from a command, alongside its stdout and stdin.
+fn spawn_language_server(
+    program: &str,
+    arguments: &[String],
+) -> Result<(Child, ChildStdout, ChildStdin), String> {
+    let mut server = Command::new(program)
+        .args(arguments)
+        .stdin(Stdio::piped())
+        .stdout(Stdio::piped())
+        .stderr(Stdio::inherit())
+        .kill_on_drop(true)
+        .spawn()
+        .map_err(|e| format!("Unable to start the language server: {e}"))?;
+
+    let (Some(server_writer), Some(server_reader)) = (server.stdin.take(), server.stdout.take())
+    else {
+        return Err(String::from(
+            "Unable to communicate with the language server",
+        ));
+    };
+
+    Ok((server, server_reader, server_writer))
+}
+
+/// Spawn the language server (if any), and proxy messages between it and the editor until the
+/// editor closes its side of the connection, returning how the language server exited.
+///
+/// Without a language server, the editor is served directly until it exits.
+async fn run<CR, CW>(
+    arguments: &Arguments,
+    client_reader: CR,
+    client_writer: CW,
+) -> Result<Option<ExitStatus>, String>
+where
+    CR: AsyncBufRead + Unpin,
+    CW: AsyncWrite + Unpin + Send + 'static,
+{
+    let server = arguments
+        .command
+        .split_first()
+        .map(|(program, arguments)| spawn_language_server(program, arguments))
+        .transpose()?;
+
+    let indexer = DatabaseBackedIndexer::new(
+        &arguments.storage_path,
+        arguments.workspaces.iter().map(PathBuf::as_path),
+    )
+    .await
+    .map_err(|e| format!("Unable to create the index: {e}"))?;
+
+    let watcher = Arc::new(Watcher::new(indexer));
+
+    // Index in the background, so that the editor isn't kept waiting for the language server.
+    // Until indexing completes, Onoma's results will be incomplete.
+    let indexing = tokio::spawn({
+        let watcher = Arc::clone(&watcher);
+
+        async move {
+            if let Err(errors) = watcher.run_full_index().await {
+                for e in errors {
+                    eprintln!("Unable to index workspace: {e}");
+                }
+            }
+
+            if let Err(e) = watcher.start().await {
+                eprintln!("Unable to watch for file changes: {e}");
+            }
+        }
+    });
+
+    let proxy = lsp::Proxy::new(
+        &arguments.storage_path,
+        arguments.workspaces.iter().map(PathBuf::as_path),
+    )
+    .with_workspace_symbol_mode(arguments.workspace_symbol_mode);
+
+    let (result, server) = match server {
+        Some((server, server_reader, server_writer)) => (
+            proxy
+                .run(
+                    client_reader,
+                    client_writer,
+                    BufReader::new(server_reader),
+                    server_writer,
+                )
+                .await,
+            Some(server),
+        ),
+        None => (proxy.serve(client_reader, client_writer).await, None),
+    };
+
+    indexing.abort();
+    watcher.stop().await;
+
+    result.map_err(|e| format!("Unable to serve the editor: {e}"))?;
+
+    match server {
+        Some(mut server) => server
+            .wait()
+            .await
+            .map(Some)
+            .map_err(|e| format!("Unable to wait for the language server to exit: {e}")),
+        None => Ok(None),
+    }
+}
+
+#[cfg(all(test, unix))]
+mod tests {
+    use onoma::lsp::{WorkspaceSymbolMode, read_message, write_message};
+    use serde_json::json;
+    use tempfile::tempdir;
+    use tokio::io::{self, BufReader};
+
+    use super::Arguments;
+
+    /// A stub language server, as a shell script, which responds to every request with an empty
+    /// result (except for `initialize`, and `workspace/symbol` which it doesn't support), and
+    /// exits once asked to.
+    ///
+    /// Messages are read byte-by-byte, so that nothing after a message is consumed with it.
+    const STUB_SERVER: &str = r#"
+        respond() {
+            printf 'Content-Length: %d\r\n\r\n%s' "${#1}" "$1"
+        }
+
+        respond '{"jsonrpc":"2.0","method":"window/logMessage","params":{"type":3,"message":"Starting"}}'
+
+        while IFS= read -r header; do
+            case "$header" in
+                Content-Length:*)
+                    length=$(printf '%s' "${header#Content-Length: }" | tr -d '\r')
+                    ;;
+                "$(printf '\r')")
+                    body=$(dd bs=1 count="$length" 2>/dev/null)
+                    id=$(printf '%s' "$body" | sed -n 's/.*"id":\([0-9]*\).*/\1/p')
+
+                    case "$body" in
+                        *'"method":"exit"'*) exit 0 ;;
+                        *'"method":"initialize"'*) response='"result":{"capabilities":{"hoverProvider":true}}' ;;
+                        *'"method":"workspace/symbol"'*) response='"error":{"code":-32601,"message":"Unsupported method"}' ;;
+                        *) response='"result":null' ;;
+                    esac
+
+                    if [ -n "$id" ]; then
+                        respond "{\"jsonrpc\":\"2.0\",\"id\":$id,$response}"
+                    fi
+                    ;;
+            esac
+        done
+    "#;
+
+    #[tokio::test]
+    pub async fn test_proxying_spawned_language_server() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        let arguments = Arguments {
+            storage_path: storage_path.path().to_path_buf(),
+            workspaces: vec![workspace.path().to_path_buf()],
+            workspace_symbol_mode: WorkspaceSymbolMode::Augment,
+            command: vec![
+                String::from("sh"),
+                String::from("-c"),
+                String::from(STUB_SERVER),
+            ],
+        };
+
+        let (client, proxy_client) = io::duplex(64 * 1024);
+        let (proxy_reader, proxy_writer) = io::split(proxy_client);
+
+        let run_client = async move {
+            let (reader, mut writer) = io::split(client);
+            let mut reader = BufReader::new(reader);
+
+            let mut messages = Vec::new();
+
+            for request in [
+                json!({"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"capabilities": {}}}),
+                json!({"jsonrpc": "2.0", "id": 2, "method": "workspace/symbol", "params": {"query": "getUser"}}),
+                json!({"jsonrpc": "2.0", "id": 3, "method": "shutdown"}),
+            ] {
+                write_message(&mut writer, &request)
+                    .await
+                    .expect("Should never fail to write to the proxy");
+
+                // Wait for the response, collecting any other messages along the way
+                loop {
+                    let message = read_message(&mut reader)
+                        .await
+                        .expect("Should never fail to read from the proxy")
+                        .expect("Proxy should respond to every request");
+
+                    let is_response = message.get("id") == request.get("id");
+
+                    messages.push(message);
+
+                    if is_response {
+                        break;
+                    }
+                }
+            }
+
+            write_message(&mut writer, &json!({"jsonrpc": "2.0", "method": "exit"}))
+                .await
+                .expect("Should never fail to write to the proxy");
+
+            messages
+        };
+
+        let (status, messages) = tokio::join!(
+            super::run(&arguments, BufReader::new(proxy_reader), proxy_writer),
+            run_client,
+        );
+
+        // The language server exited by itself, once asked to
+        assert!(
+            status
+                .expect("Language server should be spawned and proxied successfully")
+                .is_some_and(|status| status.success())
+        );
+
+        // Messages from the language server's stdout are forwarded untouched
+        assert_eq!(json!("window/logMessage"), messages[0]["method"]);
+
+        // Responses from the language server are still amended
+        assert_eq!(
+            json!({
+                "hoverProvider": true,
+                "workspaceSymbolProvider": true,
+                "definitionProvider": true,
+                "completionProvider": {},
+            }),
+            messages[1]["result"]["capabilities"]
+        );
+
+        // The language server doesn't support workspace symbols, so Onoma answers alone
+        assert_eq!(None, messages[2].get("error"));
+        assert!(messages[2]["result"].is_array());
+
+        assert_eq!(json!(null), messages[3]["result"]);
+    }
+
+    #[tokio::test]
+    pub async fn test_serving_editor_without_language_server() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        let arguments = super::parse_arguments(
+            vec![
+                String::from("--storage-path"),
+                storage_path.path().to_string_lossy().to_string(),
+                String::from("--workspace"),
+                workspace.path().to_string_lossy().to_string(),
+            ]
+            .into_iter(),
+        )
+        .expect("Arguments should be valid without a language server command")
+        .expect("Arguments should not ask for help");
+
+        let mut client = Vec::new();
+
+        for message in [
+            json!({"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"capabilities": {}}}),
+            json!({"jsonrpc": "2.0", "id": 2, "method": "textDocument/formatting", "params": {}}),
+            json!({"jsonrpc": "2.0", "id": 3, "method": "shutdown"}),
+            json!({"jsonrpc": "2.0", "method": "exit"}),
+        ] {
+            write_message(&mut client, &message)
+                .await
+                .expect("Should never fail to write to a buffer");
+        }
+
+        let (output, proxy_output) = io::duplex(64 * 1024);
+
+        let read_output = async move {
+            let mut output = BufReader::new(output);
+            let mut messages = Vec::new();
+
+            while let Some(message) = read_message(&mut output)
+                .await
+                .expect("Should never fail to read from the proxy")
+            {
+                messages.push(message);
+            }
+
+            messages
+        };
+
+        let (status, mut messages) = tokio::join!(
+            super::run(&arguments, client.as_slice(), proxy_output),
+            read_output,
+        );
+
+        // There's no language server to exit
+        assert!(
+            status
+                .expect("Editor should be served successfully")
+                .is_none()
+        );
+
+        // Requests are answered concurrently, so responses can be in any order
+        messages.sort_by_key(|message| message["id"].as_u64());
+
+        assert_eq!(3, messages.len());
+        assert_eq!(
+            json!(true),
+            messages[0]["result"]["capabilities"]["hoverProvider"]
+        );
+        assert_eq!(json!(-32601), messages[1]["error"]["code"]);
+        assert_eq!(json!(null), messages[2]["result"]);
+    }
+}
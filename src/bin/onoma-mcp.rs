@@ -0,0 +1,134 @@
+//! A Model Context Protocol server, which exposes symbol search to coding agents over stdin and
+//! stdout (see [`onoma::mcp`]).
+//!
+//! The workspaces are fully indexed in the background when the server starts, and then watched
+//! for changes, so that the index stays fresh for as long as the server is running.
+//!
+//! ```sh
+//! onoma-mcp [--storage-path <path>] [<workspace>...]
+//! ```
+
+use std::{path::PathBuf, process::ExitCode, sync::Arc};
+
+use onoma::{indexer::DatabaseBackedIndexer, mcp, watcher::Watcher};
+use tokio::io::BufReader;
+
+const USAGE: &str = "Usage: onoma-mcp [--storage-path <path>] [<workspace>...]
+
+Serves the Model Context Protocol over stdin and stdout, for the given workspaces (or the
+current directory, if none are given).
+
+Options:
+  --storage-path <path>  Where to store the index (defaults to $ONOMA_STORAGE_PATH, or a
+                         directory in the system's temporary directory)
+  -h, --help             Print this help";
+
+/// Parse the command line arguments into a storage path, and a set of (absolute) workspaces.
+fn parse_arguments(
+    mut arguments: impl Iterator<Item = String>,
+) -> Result<Option<(PathBuf, Vec<PathBuf>)>, String> {
+    let mut storage_path = std::env::var_os("ONOMA_STORAGE_PATH").map(PathBuf::from);
+    let mut workspaces = Vec::new();
+
+    while let Some(argument) = arguments.next() {
+        match argument.as_str() {
+            "-h" | "--help" => return Ok(None),
+            "--storage-path" => {
+                storage_path = Some(
+                    arguments
+                        .next()
+                        .map(PathBuf::from)
+                        .ok_or("--storage-path requires a path")?,
+                );
+            }
+            argument if argument.starts_with('-') => {
+                return Err(format!("Unknown option: {argument}"));
+            }
+            workspace => workspaces.push(
+                std::fs::canonicalize(workspace)
+                    .map_err(|e| format!("Unable to read workspace {workspace}: {e}"))?,
+            ),
+        }
+    }
+
+    if workspaces.is_empty() {
+        workspaces.push(
+            std::env::current_dir()
+                .map_err(|e| format!("Unable to read the current directory: {e}"))?,
+        );
+    }
+
+    Ok(Some((
+        storage_path.unwrap_or_else(|| std::env::temp_dir().join("onoma")),
+        workspaces,
+    )))
+}
+
+#[tokio::main]
+async fn main() -> ExitCode {
+    let (storage_path, workspaces) = match parse_arguments(std::env::args().skip(1)) {
+        Ok(Some(arguments)) => arguments,
+        Ok(None) => {
+            println!("{USAGE}");
+
+            return ExitCode::SUCCESS;
+        }
+        Err(message) => {
+            eprintln!("{message}\n\n{USAGE}");
+
+            return ExitCode::FAILURE;
+        }
+    };
+
+    let indexer =
+        match DatabaseBackedIndexer::new(&storage_path, workspaces.iter().map(PathBuf::as_path))
+            .await
+        {
+            Ok(indexer) => indexer,
+            Err(e) => {
+                eprintln!("Unable to create the index: {e}");
+
+                return ExitCode::FAILURE;
+            }
+        };
+
+    let watcher = Arc::new(Watcher::new(indexer));
+
+    // Index in the background, so that the client isn't kept waiting for the server to start.
+    // Until indexing completes, results will be incomplete.
+    let indexing = tokio::spawn({
+        let watcher = Arc::clone(&watcher);
+
+        async move {
+            if let Err(errors) = watcher.run_full_index().await {
+                for e in errors {
+                    eprintln!("Unable to index workspace: {e}");
+                }
+            }
+
+            if let Err(e) = watcher.start().await {
+                eprintln!("Unable to watch for file changes: {e}");
+            }
+        }
+    });
+
+    let server = mcp::Server::new(&storage_path, workspaces.iter().map(PathBuf::as_path));
+
+    // Responses are the only thing ever written to stdout, as anything else would corrupt the
+    // protocol
+    let result = server
+        .serve(BufReader::new(tokio::io::stdin()), tokio::io::stdout())
+        .await;
+
+    indexing.abort();
+    watcher.stop().await;
+
+    match result {
+        Ok(()) => ExitCode::SUCCESS,
+        Err(e) => {
+            eprintln!("Unable to communicate with the client: {e}");
+
+            ExitCode::FAILURE
+        }
+    }
+}
@@ -0,0 +1,9 @@
+/// The number of worker threads in the runtime owned by a blocking engine.
+///
+/// Indexing and resolving are mostly bound by SQLite (which runs on threads of its own), so a
+/// small number of workers is enough to keep queries, indexing and watching running alongside
+/// each other.
+pub const WORKER_THREADS: usize = 2;
+
+/// The name given to the worker threads of the runtime owned by a blocking engine.
+pub const WORKER_THREAD_NAME: &str = "onoma-worker";
@@ -0,0 +1,17 @@
+use thiserror::Error;
+
+use crate::indexer;
+
+/// Errors that can occur when building a blocking engine (see
+/// [`crate::engine::OnomaBuilder::build_blocking`]).
+#[derive(Error, Debug)]
+pub enum Error {
+    /// The runtime which runs the engine in the background could not be started.
+    #[error("Unable to start the runtime for the engine: {0}")]
+    RuntimeSetupFailed(std::io::Error),
+
+    /// The engine could not be built, usually because the database behind the index could not
+    /// be created or migrated.
+    #[error("Unable to build the engine: {0}")]
+    SetupFailed(indexer::Error),
+}
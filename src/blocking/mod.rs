@@ -0,0 +1,375 @@
+//! A synchronous API for hosts which don't run a tokio runtime (i.e. GUI applications, or the
+//! plugin hosts of editors).
+//!
+//! Every other API in the crate is `async`, and assumes it's called from inside a tokio runtime.
+//! The blocking [`Onoma`] engine owns a runtime of its own instead, which indexes, watches and
+//! resolves in the background, while its methods block the calling thread until they complete.
+//!
+//! ```no_run
+//! # use onoma::{resolver::Context, Onoma};
+//! # fn run() -> Result<(), onoma::blocking::Error> {
+//! let engine = Onoma::builder()
+//!     .with_workspace("/path/to/workspace")
+//!     .build_blocking()?;
+//!
+//! engine.wait_until_ready();
+//!
+//! let _subscription = engine.on_event(|event| println!("{event:?}"));
+//!
+//! for symbol in engine.query("getUser", Context::default()) {
+//!     println!("{}", symbol.name);
+//! }
+//! # Ok(())
+//! # }
+//! ```
+//!
+//! As with any other blocking API, the methods of the engine must not be called from inside an
+//! async runtime, as they would block (or panic) the runtime's own threads.
+
+use std::{future::Future, path::Path};
+
+use tokio::{
+    runtime::Runtime,
+    sync::{broadcast::error::RecvError, mpsc, oneshot},
+};
+
+mod constant;
+mod error;
+
+pub use error::Error;
+
+use crate::{
+    engine::{self, Event, OnomaBuilder, Status},
+    indexer,
+    models::resolved::ResolvedSymbol,
+    resolver::{Context, SymbolQuery},
+};
+
+impl OnomaBuilder {
+    /// Build a blocking engine (see [`crate::blocking`]), which runs on a runtime of its own,
+    /// rather than the caller's.
+    ///
+    /// As with [`OnomaBuilder::build`], the workspaces are indexed (and then watched) in the
+    /// background.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the runtime could not be started, or if the database could not be
+    /// created or migrated.
+    pub fn build_blocking(self) -> Result<Onoma, Error> {
+        let runtime = tokio::runtime::Builder::new_multi_thread()
+            .worker_threads(constant::WORKER_THREADS)
+            .thread_name(constant::WORKER_THREAD_NAME)
+            .enable_all()
+            .build()
+            .map_err(Error::RuntimeSetupFailed)?;
+
+        let engine = runtime.block_on(self.build()).map_err(Error::SetupFailed)?;
+
+        Ok(Onoma { engine, runtime })
+    }
+}
+
+/// A blocking version of [`engine::Onoma`], which owns the runtime it runs on.
+///
+/// The engine can be shared between threads (i.e. in an `Arc`), and is shut down when it's
+/// dropped.
+#[derive(Debug)]
+pub struct Onoma {
+    // NB: The engine must be dropped before the runtime it runs on
+    engine: engine::Onoma,
+    runtime: Runtime,
+}
+
+impl Onoma {
+    /// Create a builder for an engine, which is built with [`OnomaBuilder::build_blocking`].
+    #[must_use]
+    pub fn builder() -> OnomaBuilder {
+        OnomaBuilder::default()
+    }
+
+    /// Get the underlying (async) engine.
+    ///
+    /// Its methods must be run with [`Onoma::block_on`].
+    #[must_use]
+    pub const fn engine(&self) -> &engine::Onoma {
+        &self.engine
+    }
+
+    /// Run a future to completion on the engine's runtime, blocking the current thread until it
+    /// completes.
+    ///
+    /// This allows for any of the other async APIs in the crate (i.e.
+    /// [`crate::resolver::HoverResolver`]) to be used from synchronous code.
+    pub fn block_on<F: Future>(&self, future: F) -> F::Output {
+        self.runtime.block_on(future)
+    }
+
+    /// Get the current status of the engine.
+    #[must_use]
+    pub fn status(&self) -> Status {
+        self.engine.status()
+    }
+
+    /// Block until the workspaces have been indexed in full (or the engine has been shut down),
+    /// returning the status at that point.
+    pub fn wait_until_ready(&self) -> Status {
+        self.block_on(self.engine.wait_until_ready())
+    }
+
+    /// Run a fuzzy query against the indexed symbols, returning an iterator which blocks until
+    /// each symbol is resolved.
+    ///
+    /// See [`engine::Onoma::query`].
+    pub fn query(&self, query: impl Into<String>, ctx: Context) -> Symbols {
+        let _guard = self.runtime.enter();
+
+        Symbols::new(self.engine.query(query, ctx).into_inner())
+    }
+
+    /// Run a structured query against the indexed symbols, returning an iterator which blocks
+    /// until each symbol is resolved.
+    ///
+    /// See [`engine::Onoma::search`].
+    pub fn search(&self, query: SymbolQuery, ctx: Context) -> Symbols {
+        let _guard = self.runtime.enter();
+
+        Symbols::new(self.engine.search(query, ctx).into_inner())
+    }
+
+    /// Index a particular file, or folder, inside a workspace, blocking until it's indexed.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the path could not be indexed successfully.
+    pub fn index(&self, path: &Path) -> indexer::Result<()> {
+        self.block_on(self.engine.index(path))
+    }
+
+    /// De-index a particular file, or folder, in a workspace, blocking until it's de-indexed.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the path could not be de-indexed successfully.
+    pub fn deindex(&self, path: &Path) -> indexer::Result<()> {
+        self.block_on(self.engine.deindex(path))
+    }
+
+    /// Index a single file, even if it is outside every workspace, blocking until it's indexed.
+    ///
+    /// See [`engine::Onoma::index_ephemeral`].
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the file could not be indexed successfully.
+    pub fn index_ephemeral(&self, path: &Path) -> indexer::Result<()> {
+        self.block_on(self.engine.index_ephemeral(path))
+    }
+
+    /// Mark a file previously indexed with [`Onoma::index_ephemeral`] as accessed, blocking until
+    /// it's marked.
+    ///
+    /// See [`engine::Onoma::touch_ephemeral`].
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the file could not be marked as accessed.
+    pub fn touch_ephemeral(&self, path: &Path) -> indexer::Result<()> {
+        self.block_on(self.engine.touch_ephemeral(path))
+    }
+
+    /// Evict a file previously indexed with [`Onoma::index_ephemeral`], blocking until it's
+    /// evicted.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the file could not be evicted successfully.
+    pub fn evict_ephemeral(&self, path: &Path) -> indexer::Result<()> {
+        self.block_on(self.engine.evict_ephemeral(path))
+    }
+
+    /// Index every workspace in full again, blocking until they're indexed.
+    ///
+    /// # Errors
+    ///
+    /// Returns a list of errors for each workspace which could not be successfully indexed.
+    pub fn reindex(&self) -> std::result::Result<(), Vec<indexer::Error>> {
+        self.block_on(self.engine.reindex())
+    }
+
+    /// Call a function for every event emitted as the index changes (including changes picked up
+    /// by watching the workspaces), until the returned subscription is dropped.
+    ///
+    /// Callbacks are run on the engine's runtime, so should return quickly (i.e. by forwarding
+    /// events to a channel, or a UI's event loop).
+    pub fn on_event(&self, mut callback: impl FnMut(Event) + Send + 'static) -> Subscription {
+        let mut events = self.engine.subscribe();
+        let (cancel, mut cancelled) = oneshot::channel::<()>();
+
+        self.runtime.spawn(async move {
+            loop {
+                tokio::select! {
+                    _ = &mut cancelled => break,
+                    event = events.recv() => match event {
+                        Ok(event) => tokio::task::block_in_place(|| callback(event)),
+                        Err(RecvError::Lagged(skipped)) => {
+                            log::warn!("Event callback fell behind, and missed {skipped} events");
+                        }
+                        Err(RecvError::Closed) => break,
+                    },
+                }
+            }
+        });
+
+        Subscription { _cancel: cancel }
+    }
+
+    /// Shut the engine down, blocking until any indexing in progress has stopped, and every
+    /// connection to the index is closed.
+    ///
+    /// This happens automatically when the engine is dropped, though without waiting for
+    /// connections to close.
+    pub fn shutdown(self) {
+        self.block_on(self.engine.shutdown());
+    }
+}
+
+/// A subscription to the events of a blocking engine (see [`Onoma::on_event`]), which stops
+/// calling its callback once dropped.
+#[derive(Debug)]
+#[must_use = "Callbacks stop being called as soon as the subscription is dropped"]
+pub struct Subscription {
+    _cancel: oneshot::Sender<()>,
+}
+
+/// An iterator over the symbols resolved for a query, which blocks until each symbol is resolved.
+///
+/// The query is cancelled if the iterator is dropped before every symbol has been returned.
+#[derive(Debug)]
+pub struct Symbols {
+    receiver: mpsc::Receiver<ResolvedSymbol>,
+}
+
+impl Symbols {
+    /// Create an iterator over the symbols sent to a receiver.
+    const fn new(receiver: mpsc::Receiver<ResolvedSymbol>) -> Self {
+        Self { receiver }
+    }
+}
+
+impl Iterator for Symbols {
+    type Item = ResolvedSymbol;
+
+    fn next(&mut self) -> Option<Self::Item> {
+        self.receiver.blocking_recv()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use std::{
+        sync::{Arc, mpsc},
+        thread,
+        time::Duration,
+    };
+
+    use tempfile::tempdir;
+
+    use crate::{
+        engine::{Event, Status},
+        resolver::Context,
+    };
+
+    use super::Onoma;
+
+    #[test]
+    pub fn test_indexing_and_resolving_from_threads() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        let user = workspace.path().join("user.ts");
+        let session = workspace.path().join("session.ts");
+
+        std::fs::write(&user, "export function getUser() {}\n")
+            .expect("Should never fail to write user.ts");
+
+        let engine = Arc::new(
+            Onoma::builder()
+                .with_storage_path(storage_path.path())
+                .with_workspace(workspace.path())
+                .with_watching(false)
+                .build_blocking()
+                .expect("Engine should be built without a runtime"),
+        );
+
+        let (tx, rx) = mpsc::channel();
+
+        let subscription = engine.on_event(move |event| {
+            let _ = tx.send(event);
+        });
+
+        assert_eq!(Status::Ready, engine.wait_until_ready());
+
+        // Notice, every thread queries the engine at the same time, none of which are inside a
+        // runtime
+        let queries = (0..4)
+            .map(|_| {
+                let engine = Arc::clone(&engine);
+
+                thread::spawn(move || {
+                    engine
+                        .query("getUser", Context::default())
+                        .map(|symbol| symbol.path)
+                        .collect::<Vec<_>>()
+                })
+            })
+            .collect::<Vec<_>>();
+
+        for query in queries {
+            assert_eq!(
+                vec![user.clone()],
+                query.join().expect("Query thread should never panic")
+            );
+        }
+
+        std::fs::write(&session, "export class Session {}\n")
+            .expect("Should never fail to write session.ts");
+
+        thread::spawn({
+            let engine = Arc::clone(&engine);
+            let session = session.clone();
+
+            move || engine.index(&session)
+        })
+        .join()
+        .expect("Indexing thread should never panic")
+        .expect("session.ts should be indexed successfully");
+
+        loop {
+            let event = rx
+                .recv_timeout(Duration::from_secs(10))
+                .expect("Callback should be called for indexing session.ts");
+
+            if event == Event::Indexed(session.clone()) {
+                break;
+            }
+        }
+
+        assert_eq!(
+            vec!["Session"],
+            engine
+                .query("Session", Context::default())
+                .map(|symbol| symbol.name)
+                .collect::<Vec<_>>()
+        );
+
+        drop(subscription);
+
+        Arc::try_unwrap(engine)
+            .expect("Every other thread should have finished with the engine")
+            .shutdown();
+    }
+}
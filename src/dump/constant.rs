@@ -0,0 +1,5 @@
+/// The version of the dump format, which is written in the header of every dump.
+///
+/// This must be incremented whenever a change to the records would prevent older dumps from being
+/// loaded (or newer dumps from being loaded by older versions).
+pub const VERSION: u32 = 1;
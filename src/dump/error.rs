@@ -0,0 +1,49 @@
+use std::path::PathBuf;
+
+use thiserror::Error;
+
+/// Errors that can occur when writing or loading a dump of an index.
+#[derive(Error, Debug)]
+pub enum Error {
+    /// The dump couldn't be read.
+    #[error("Unable to read the dump: {0}")]
+    ReadFailed(std::io::Error),
+
+    /// The dump couldn't be written.
+    #[error("Unable to write the dump: {0}")]
+    WriteFailed(std::io::Error),
+
+    /// A record in the dump couldn't be serialised, or deserialised.
+    ///
+    /// - `usize` contains the (1-based) line of the record in the dump.
+    /// - `serde_json::Error` provides the underlying error.
+    #[error("Invalid record on line {0} of the dump: {1}")]
+    InvalidRecord(usize, serde_json::Error),
+
+    /// The dump doesn't start with a header, so isn't a dump of an index.
+    #[error("The dump is missing a header")]
+    MissingHeader,
+
+    /// The dump contains more than one header.
+    #[error("Unexpected header on line {0} of the dump")]
+    UnexpectedHeader(usize),
+
+    /// The dump was written with a version of the format which can't be loaded.
+    ///
+    /// - The first `u32` contains the version of the dump.
+    /// - The second `u32` contains the version which can be loaded.
+    #[error("Unsupported dump version {0} (expected version {1})")]
+    UnsupportedVersion(u32, u32),
+
+    /// A record in the dump is for a file which wasn't declared (by a file record) before it.
+    #[error("Record on line {0} of the dump is for an undeclared file ({1})")]
+    UndeclaredFile(usize, PathBuf),
+
+    /// The query for reading (or writing) the index was invalid.
+    #[error("Invalid query during dump: {0}")]
+    InvalidQuerySyntax(#[from] sea_query::error::Error),
+
+    /// The query for reading (or writing) the index failed.
+    #[error("Query error during dump: {0}")]
+    QueryFailed(#[from] sqlx::Error),
+}
@@ -0,0 +1,252 @@
+//! Dumps of a full index as JSON Lines, for post-processing in data tools, or diffing between
+//! runs.
+//!
+//! Every line of a dump is a single [`Record`], tagged by its `type`. The first record is always
+//! the header, which contains the [`VERSION`] of the format. Each file then follows (in order of
+//! path), with the symbols, translations and schema definitions in it after:
+//!
+//! ```json
+//! {"type":"header","version":1}
+//! {"type":"file","path":"src/user.ts","package":"app",…}
+//! {"type":"symbol","id":0,"name":"getUser","kind":"Function",…}
+//! ```
+//!
+//! Dumps are written by [`crate::resolver::DatabaseBackedResolver::dump`], and loaded back into a
+//! database by [`crate::indexer::DatabaseBackedIndexer::load`].
+
+use tokio::io::{AsyncBufRead, AsyncBufReadExt, AsyncWrite, AsyncWriteExt, Lines};
+
+mod constant;
+mod error;
+mod record;
+mod types;
+
+pub use constant::VERSION;
+pub use error::Error;
+pub use record::*;
+pub use types::Result;
+
+/// A summary of the records written to (or loaded from) a dump.
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq)]
+pub struct DumpSummary {
+    /// The number of files.
+    pub files: usize,
+
+    /// The number of symbols.
+    pub symbols: usize,
+
+    /// The number of translation keys.
+    pub translations: usize,
+
+    /// The number of schema definitions.
+    pub schema_definitions: usize,
+}
+
+impl DumpSummary {
+    /// Count a record towards the summary.
+    pub(crate) const fn add(&mut self, record: &Record) {
+        match record {
+            Record::Header { .. } => {}
+            Record::File(_) => self.files += 1,
+            Record::Symbol(_) => self.symbols += 1,
+            Record::Translation(_) => self.translations += 1,
+            Record::SchemaDefinition(_) => self.schema_definitions += 1,
+        }
+    }
+}
+
+/// Write a single record to a dump, as a line of JSON.
+pub(crate) async fn write_record(
+    writer: &mut (impl AsyncWrite + Unpin),
+    line: usize,
+    record: &Record,
+) -> Result<()> {
+    let mut json = serde_json::to_vec(record).map_err(|e| Error::InvalidRecord(line, e))?;
+
+    json.push(b'\n');
+
+    writer.write_all(&json).await.map_err(Error::WriteFailed)
+}
+
+/// A reader of the records in a dump, which checks the dump's header before any records are read.
+pub(crate) struct RecordReader<R> {
+    lines: Lines<R>,
+    line: usize,
+}
+
+impl<R: AsyncBufRead + Unpin> RecordReader<R> {
+    /// Start reading a dump.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the dump doesn't start with a header, or was written with an
+    /// unsupported version of the format.
+    pub(crate) async fn new(reader: R) -> Result<Self> {
+        let mut records = Self {
+            lines: reader.lines(),
+            line: 0,
+        };
+
+        match records.read().await? {
+            Some((_, Record::Header { version })) if version == VERSION => Ok(records),
+            Some((_, Record::Header { version })) => {
+                Err(Error::UnsupportedVersion(version, VERSION))
+            }
+            _ => Err(Error::MissingHeader),
+        }
+    }
+
+    /// Read the next record (alongside the line it's on) from the dump, skipping any blank lines.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the dump couldn't be read, or the record is invalid.
+    pub(crate) async fn next(&mut self) -> Result<Option<(usize, Record)>> {
+        match self.read().await? {
+            Some((line, Record::Header { .. })) => Err(Error::UnexpectedHeader(line)),
+            record => Ok(record),
+        }
+    }
+
+    /// Read the next record from the dump, whatever it is.
+    async fn read(&mut self) -> Result<Option<(usize, Record)>> {
+        while let Some(content) = self.lines.next_line().await.map_err(Error::ReadFailed)? {
+            self.line += 1;
+
+            if content.trim().is_empty() {
+                continue;
+            }
+
+            return serde_json::from_str(&content)
+                .map(|record| Some((self.line, record)))
+                .map_err(|e| Error::InvalidRecord(self.line, e));
+        }
+
+        Ok(None)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use std::path::PathBuf;
+
+    use rstest::rstest;
+    use tempfile::tempdir;
+
+    use crate::{
+        dump,
+        indexer::{self, Indexer},
+        resolver,
+    };
+
+    #[rstest]
+    #[case("")]
+    #[case("\n\n")]
+    #[case("{\"type\":\"symbol\"}\n")]
+    #[case(
+        "{\"type\":\"file\",\"path\":\"a.rs\",\"package\":null,\"package_root\":null,\"owners\":[],\"generated\":false,\"ephemeral\":false}\n"
+    )]
+    #[tokio::test]
+    pub async fn test_rejecting_dumps_without_a_header(#[case] content: &str) {
+        assert!(matches!(
+            super::RecordReader::new(content.as_bytes()).await,
+            Err(dump::Error::MissingHeader | dump::Error::InvalidRecord(1, _))
+        ));
+    }
+
+    #[tokio::test]
+    pub async fn test_rejecting_unsupported_versions() {
+        assert!(matches!(
+            super::RecordReader::new("{\"type\":\"header\",\"version\":999}\n".as_bytes()).await,
+            Err(dump::Error::UnsupportedVersion(999, super::VERSION))
+        ));
+    }
+
+    #[tokio::test]
+    pub async fn test_rejecting_records_for_undeclared_files() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let fixtures = PathBuf::from("tests/fixtures/");
+
+        let indexer =
+            indexer::DatabaseBackedIndexer::new(storage_path.path(), [fixtures.as_path()])
+                .await
+                .expect("Should be able to create the empty index");
+
+        let content = "{\"type\":\"header\",\"version\":1}\n{\"type\":\"translation\",\"key\":\"title\",\"locale\":\"en\",\"path\":\"en.json\",\"start_line\":1,\"end_line\":1,\"start_column\":2,\"end_column\":9}\n";
+
+        assert!(matches!(
+            indexer.load(content.as_bytes()).await,
+            Err(dump::Error::UndeclaredFile(2, path)) if path == PathBuf::from("en.json")
+        ));
+    }
+
+    #[tokio::test]
+    pub async fn test_round_tripping_dumps() {
+        let fixtures = PathBuf::from("tests/fixtures/");
+
+        let workspaces = vec![fixtures.as_path()];
+
+        let source_storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let indexer =
+            indexer::DatabaseBackedIndexer::new(source_storage_path.path(), workspaces.clone())
+                .await
+                .expect("Should be able to create the empty index");
+
+        assert!(indexer.index_workspaces().await.is_ok());
+
+        let mut dump = Vec::new();
+
+        let summary =
+            resolver::DatabaseBackedResolver::new(source_storage_path.path(), workspaces.clone())
+                .dump(&mut dump)
+                .await
+                .expect("Should never fail to dump an index");
+
+        assert_eq!(9, summary.files);
+        assert!(summary.symbols > 0);
+
+        let content = String::from_utf8(dump.clone()).expect("Dumps should always be UTF-8");
+
+        assert!(content.starts_with("{\"type\":\"header\",\"version\":1}\n"));
+        assert_eq!(1 + summary.files + summary.symbols, content.lines().count());
+
+        // Loading the dump into a new database should rebuild the same index
+        let target_storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let indexer =
+            indexer::DatabaseBackedIndexer::new(target_storage_path.path(), workspaces.clone())
+                .await
+                .expect("Should be able to create the empty index");
+
+        assert_eq!(
+            summary,
+            indexer
+                .load(dump.as_slice())
+                .await
+                .expect("Should never fail to load a valid dump")
+        );
+
+        let mut round_tripped_dump = Vec::new();
+
+        resolver::DatabaseBackedResolver::new(target_storage_path.path(), workspaces.clone())
+            .dump(&mut round_tripped_dump)
+            .await
+            .expect("Should never fail to dump an index");
+
+        assert_eq!(content, String::from_utf8_lossy(&round_tripped_dump));
+
+        // Loading replaces everything previously in the index
+        assert_eq!(
+            summary,
+            indexer
+                .load(dump.as_slice())
+                .await
+                .expect("Should never fail to load a valid dump")
+        );
+    }
+}
@@ -0,0 +1,56 @@
+use std::path::PathBuf;
+
+use serde::{Deserialize, Serialize};
+
+use crate::models::resolved::{Owners, ResolvedSymbol, SchemaDefinition, Translation};
+
+/// An indexed file in a dump.
+#[derive(Debug, Clone, sqlx::FromRow, PartialEq, Eq, Serialize, Deserialize)]
+pub struct File {
+    /// The path to the file, as it was indexed.
+    #[sqlx[try_from = "String"]]
+    pub path: PathBuf,
+
+    /// The name of the package (i.e. crate, npm package, Go module, etc.) which owns the file, if
+    /// one was detected.
+    pub package: Option<String>,
+
+    /// The root directory of the package which owns the file, if one was detected.
+    pub package_root: Option<String>,
+
+    /// The owners of the file, as declared by the `CODEOWNERS` file of its workspace.
+    #[sqlx[try_from = "String"]]
+    pub owners: Owners,
+
+    /// Whether the file is generated code (i.e. `user.pb.go`).
+    pub generated: bool,
+
+    /// Whether the file was indexed on demand from outside any workspace.
+    pub ephemeral: bool,
+}
+
+/// A single record (line) of a dump.
+///
+/// Records are tagged by their `type` (i.e. `{"type":"file",…}`), so that they can be filtered
+/// easily in data tools.
+#[derive(Debug, PartialEq, Eq, Serialize, Deserialize)]
+#[serde(tag = "type", rename_all = "snake_case")]
+pub enum Record {
+    /// The header of the dump, which is always the first record.
+    Header {
+        /// The version of the format the dump was written in (see [`crate::dump::VERSION`]).
+        version: u32,
+    },
+
+    /// An indexed file, which is always written before the records defined in it.
+    File(File),
+
+    /// A symbol defined in a file.
+    Symbol(ResolvedSymbol),
+
+    /// A translation key defined in a locale file.
+    Translation(Translation),
+
+    /// A definition in a schema file.
+    SchemaDefinition(SchemaDefinition),
+}
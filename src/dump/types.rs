@@ -0,0 +1,5 @@
+use crate::dump;
+
+#[allow(missing_docs)]
+#[doc(hidden)]
+pub type Result<T> = std::result::Result<T, dump::Error>;
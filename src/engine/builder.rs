@@ -0,0 +1,198 @@
+use std::{
+    path::PathBuf,
+    sync::{Arc, Mutex},
+    time::Duration,
+};
+
+use tokio::{
+    runtime::Handle,
+    sync::{broadcast, watch},
+};
+
+use crate::{
+    engine::{Onoma, Status, constant, observed_indexer::ObservedIndexer},
+    indexer::{self, DatabaseBackedIndexer},
+    resolver::DatabaseBackedResolver,
+    watcher::Watcher,
+};
+
+/// Builder for an [`Onoma`] engine (see [`Onoma::builder`]).
+#[derive(Debug, Clone)]
+pub struct OnomaBuilder {
+    storage_path: Option<PathBuf>,
+    workspaces: Vec<PathBuf>,
+    watching: bool,
+    git_history: bool,
+    translation_functions: Option<Vec<String>>,
+    ephemeral_file_ttl: Option<Duration>,
+    change_log_retention: Option<(Duration, u64)>,
+}
+
+impl Default for OnomaBuilder {
+    fn default() -> Self {
+        Self {
+            storage_path: None,
+            workspaces: Vec::new(),
+            watching: true,
+            git_history: false,
+            translation_functions: None,
+            ephemeral_file_ttl: None,
+            change_log_retention: None,
+        }
+    }
+}
+
+impl OnomaBuilder {
+    /// Set the directory the index is stored in.
+    ///
+    /// Defaults to an `onoma` directory inside the system's temporary directory.
+    #[must_use]
+    pub fn with_storage_path(mut self, storage_path: impl Into<PathBuf>) -> Self {
+        self.storage_path = Some(storage_path.into());
+
+        self
+    }
+
+    /// Add a workspace to index.
+    ///
+    /// The index is stored per set of workspaces (in the order they're added), so the same
+    /// workspaces should always be added in the same order to reuse an existing index.
+    #[must_use]
+    pub fn with_workspace(mut self, workspace: impl Into<PathBuf>) -> Self {
+        self.workspaces.push(workspace.into());
+
+        self
+    }
+
+    /// Add a set of workspaces to index, in order.
+    ///
+    /// See [`OnomaBuilder::with_workspace`].
+    #[must_use]
+    pub fn with_workspaces(
+        mut self,
+        workspaces: impl IntoIterator<Item = impl Into<PathBuf>>,
+    ) -> Self {
+        self.workspaces
+            .extend(workspaces.into_iter().map(Into::into));
+
+        self
+    }
+
+    /// Set whether the workspaces are watched for changes once they've been indexed.
+    ///
+    /// Defaults to `true`. Without watching, files must be re-indexed on demand (see
+    /// [`Onoma::index`]).
+    #[must_use]
+    pub const fn with_watching(mut self, watching: bool) -> Self {
+        self.watching = watching;
+
+        self
+    }
+
+    /// Record when files were last committed to git, alongside when they were last modified.
+    ///
+    /// See [`DatabaseBackedIndexer::with_git_history`].
+    #[must_use]
+    pub const fn with_git_history(mut self) -> Self {
+        self.git_history = true;
+
+        self
+    }
+
+    /// Set the functions which translation keys are passed to (i.e. `t` or `i18n.t`).
+    ///
+    /// See [`DatabaseBackedIndexer::with_translation_functions`].
+    #[must_use]
+    pub fn with_translation_functions(
+        mut self,
+        functions: impl IntoIterator<Item = impl Into<String>>,
+    ) -> Self {
+        self.translation_functions = Some(functions.into_iter().map(Into::into).collect());
+
+        self
+    }
+
+    /// Set how long ephemeral files are kept for, after they were last accessed.
+    ///
+    /// See [`DatabaseBackedIndexer::with_ephemeral_file_ttl`].
+    #[must_use]
+    pub const fn with_ephemeral_file_ttl(mut self, ttl: Duration) -> Self {
+        self.ephemeral_file_ttl = Some(ttl);
+
+        self
+    }
+
+    /// Set how long changes to symbols are kept in the change log, and the maximum number of
+    /// changes it keeps.
+    ///
+    /// See [`DatabaseBackedIndexer::with_change_log_retention`].
+    #[must_use]
+    pub const fn with_change_log_retention(mut self, max_age: Duration, max_entries: u64) -> Self {
+        self.change_log_retention = Some((max_age, max_entries));
+
+        self
+    }
+
+    /// Build the engine, and start indexing (and then watching) its workspaces in the background.
+    ///
+    /// The database is created and migrated before this returns, so the engine can be queried
+    /// straight away, though results will be incomplete until the workspaces have been indexed
+    /// (see [`Onoma::wait_until_ready`]).
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the database could not be created, or migrated.
+    pub async fn build(self) -> indexer::Result<Onoma> {
+        let storage_path = self
+            .storage_path
+            .unwrap_or_else(|| std::env::temp_dir().join(constant::DEFAULT_STORAGE_DIRECTORY));
+
+        let mut indexer =
+            DatabaseBackedIndexer::new(&storage_path, self.workspaces.iter().map(PathBuf::as_path))
+                .await?;
+
+        if self.git_history {
+            indexer = indexer.with_git_history();
+        }
+
+        if let Some(functions) = self.translation_functions {
+            indexer = indexer.with_translation_functions(functions);
+        }
+
+        if let Some(ttl) = self.ephemeral_file_ttl {
+            indexer = indexer.with_ephemeral_file_ttl(ttl);
+        }
+
+        if let Some((max_age, max_entries)) = self.change_log_retention {
+            indexer = indexer.with_change_log_retention(max_age, max_entries);
+        }
+
+        // The indexer has already migrated the database, so the resolver can safely share its
+        // pool (rather than connecting to a database which may not exist yet)
+        let pool = indexer.get_pool().clone();
+        let resolver = DatabaseBackedResolver::from_pool(pool.clone(), self.workspaces.clone());
+
+        let (events, _) = broadcast::channel(constant::EVENT_CHANNEL_CAPACITY);
+        let (status, _) = watch::channel(Status::Starting);
+
+        let indexer = ObservedIndexer::new(indexer, events.clone());
+        let watcher = Arc::new(Watcher::new(indexer.clone()));
+
+        let engine = Onoma {
+            storage_path,
+            workspaces: self.workspaces,
+            pool,
+            indexer,
+            resolver,
+            watcher,
+            events,
+            status: Arc::new(status),
+            startup: Mutex::default(),
+            runtime: Handle::current(),
+        };
+
+        engine.start(self.watching);
+
+        Ok(engine)
+    }
+}
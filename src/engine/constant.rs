@@ -0,0 +1,6 @@
+/// The directory (inside the system's temporary directory) indexes are stored in, when no storage
+/// path is given.
+pub const DEFAULT_STORAGE_DIRECTORY: &str = "onoma";
+
+/// The number of events kept for subscribers which fall behind, before the oldest are dropped.
+pub const EVENT_CHANNEL_CAPACITY: usize = 1024;
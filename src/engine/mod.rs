@@ -0,0 +1,447 @@
+//! A high-level engine which owns an indexer, watcher and resolver together.
+//!
+//! The indexer, watcher and resolver can be wired together by hand, but they must then agree on
+//! the same storage path and the same order of workspaces (as the database is named after them),
+//! and the resolver must not connect until the indexer has created and migrated the database.
+//! [`Onoma`] handles all of this, sharing a single connection pool between each of them.
+//!
+//! ```no_run
+//! # use onoma::{Onoma, resolver::Context};
+//! # use tokio_stream::StreamExt;
+//! # async fn run() -> Result<(), onoma::indexer::Error> {
+//! let engine = Onoma::builder()
+//!     .with_storage_path("/tmp/onoma")
+//!     .with_workspace("/path/to/workspace")
+//!     .build()
+//!     .await?;
+//!
+//! engine.wait_until_ready().await;
+//!
+//! let symbols = engine
+//!     .query("getUser", Context::default())
+//!     .collect::<Vec<_>>()
+//!     .await;
+//! # Ok(())
+//! # }
+//! ```
+
+use std::{
+    path::{Path, PathBuf},
+    sync::{Arc, Mutex, PoisonError},
+};
+
+use tokio::{
+    runtime::Handle,
+    sync::{broadcast, watch},
+    task::JoinHandle,
+};
+use tokio_stream::wrappers::ReceiverStream;
+
+mod builder;
+mod constant;
+mod observed_indexer;
+mod types;
+
+pub use builder::OnomaBuilder;
+pub use types::{Event, Status};
+
+use crate::{
+    engine::observed_indexer::ObservedIndexer,
+    indexer::{self, Indexer},
+    models::resolved::ResolvedSymbol,
+    resolver::{Context, DatabaseBackedResolver, Resolver, SymbolQuery},
+    watcher::Watcher,
+};
+
+/// A single engine owning an index of a set of workspaces, which indexes them in full, watches
+/// them for changes, and resolves queries against them.
+///
+/// Engines are created with [`Onoma::builder`], and shut down either with [`Onoma::shutdown`], or
+/// when they're dropped. Dropping an engine closes its connections to the index in the background,
+/// whereas [`Onoma::shutdown`] waits until they're closed.
+#[derive(Debug)]
+pub struct Onoma {
+    storage_path: PathBuf,
+    workspaces: Vec<PathBuf>,
+    pool: sqlx::Pool<sqlx::Sqlite>,
+    indexer: ObservedIndexer,
+    resolver: DatabaseBackedResolver,
+    watcher: Arc<Watcher<ObservedIndexer>>,
+    events: broadcast::Sender<Event>,
+    status: Arc<watch::Sender<Status>>,
+    startup: Mutex<Option<JoinHandle<()>>>,
+    runtime: Handle,
+}
+
+impl Onoma {
+    /// Create a builder for an engine.
+    #[must_use]
+    pub fn builder() -> OnomaBuilder {
+        OnomaBuilder::default()
+    }
+
+    /// Index the workspaces in full, and then (optionally) start watching them for changes, in
+    /// the background.
+    fn start(&self, watching: bool) {
+        let watcher = Arc::clone(&self.watcher);
+        let status = Arc::clone(&self.status);
+        let events = self.events.clone();
+
+        let handle = tokio::spawn(async move {
+            set_status(&status, Status::Indexing);
+
+            if let Err(errors) = watcher.run_full_index().await {
+                for e in errors {
+                    log::error!("Unable to index workspace: {e}");
+                }
+            }
+
+            if watching {
+                match watcher.start().await {
+                    Ok(()) => {
+                        let _ = events.send(Event::WatchingStarted);
+                    }
+                    Err(e) => log::error!("Unable to watch for file changes: {e}"),
+                }
+            }
+
+            set_status(&status, Status::Ready);
+        });
+
+        *self.startup.lock().unwrap_or_else(PoisonError::into_inner) = Some(handle);
+    }
+
+    /// Get the directory the index is stored in.
+    #[must_use]
+    pub fn get_storage_path(&self) -> &Path {
+        &self.storage_path
+    }
+
+    /// Get the workspaces being indexed, in order.
+    ///
+    /// Along with [`Onoma::get_storage_path`], these can be used to create any of the other
+    /// resolvers (i.e. [`crate::resolver::HoverResolver`]) for the same index.
+    #[must_use]
+    pub fn get_workspaces(&self) -> &[PathBuf] {
+        &self.workspaces
+    }
+
+    /// Get the current status of the engine.
+    #[must_use]
+    pub fn status(&self) -> Status {
+        *self.status.borrow()
+    }
+
+    /// Wait until the workspaces have been indexed in full (or the engine has been shut down),
+    /// returning the status at that point.
+    pub async fn wait_until_ready(&self) -> Status {
+        self.status
+            .subscribe()
+            .wait_for(|status| matches!(status, Status::Ready | Status::Stopped))
+            .await
+            .map_or(Status::Stopped, |status| *status)
+    }
+
+    /// Subscribe to the events emitted as the index changes.
+    ///
+    /// Only events emitted after subscribing are received.
+    #[must_use]
+    pub fn subscribe(&self) -> broadcast::Receiver<Event> {
+        self.events.subscribe()
+    }
+
+    /// Get the resolver for the index, which shares the engine's connection pool.
+    #[must_use]
+    pub const fn resolver(&self) -> &DatabaseBackedResolver {
+        &self.resolver
+    }
+
+    /// Run a fuzzy query against the indexed symbols.
+    ///
+    /// See [`Resolver::query`].
+    pub fn query(&self, query: impl Into<String>, ctx: Context) -> ReceiverStream<ResolvedSymbol> {
+        self.resolver.query(query.into(), ctx)
+    }
+
+    /// Run a structured query against the indexed symbols.
+    ///
+    /// See [`DatabaseBackedResolver::search`].
+    pub fn search(&self, query: SymbolQuery, ctx: Context) -> ReceiverStream<ResolvedSymbol> {
+        self.resolver.search(query, ctx)
+    }
+
+    /// Index a particular file, or folder, inside a workspace on demand.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the path could not be indexed successfully.
+    pub async fn index(&self, path: &Path) -> indexer::Result<()> {
+        self.indexer.index(path).await
+    }
+
+    /// De-index a particular file, or folder, in a workspace on demand.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the path could not be de-indexed successfully.
+    pub async fn deindex(&self, path: &Path) -> indexer::Result<()> {
+        self.indexer.deindex(path).await
+    }
+
+    /// Index a single file on demand, even if it is outside every workspace.
+    ///
+    /// See [`Indexer::index_ephemeral`].
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the file could not be indexed successfully.
+    pub async fn index_ephemeral(&self, path: &Path) -> indexer::Result<()> {
+        self.indexer.index_ephemeral(path).await
+    }
+
+    /// Mark a file previously indexed with [`Onoma::index_ephemeral`] as accessed, so that it
+    /// doesn't expire while it's still open.
+    ///
+    /// See [`Indexer::touch_ephemeral`].
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the file could not be marked as accessed.
+    pub async fn touch_ephemeral(&self, path: &Path) -> indexer::Result<()> {
+        self.indexer.touch_ephemeral(path).await
+    }
+
+    /// Evict a file previously indexed with [`Onoma::index_ephemeral`].
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the file could not be evicted successfully.
+    pub async fn evict_ephemeral(&self, path: &Path) -> indexer::Result<()> {
+        self.indexer.evict_ephemeral(path).await
+    }
+
+    /// Index every workspace in full again.
+    ///
+    /// When the workspaces are still being indexed (i.e. straight after the engine is built),
+    /// they're indexed again once that finishes.
+    ///
+    /// # Errors
+    ///
+    /// Returns a list of errors for each workspace which could not be successfully indexed.
+    pub async fn reindex(&self) -> std::result::Result<(), Vec<indexer::Error>> {
+        // Otherwise, the engine would be marked as ready while the indexing in the background
+        // is still in progress
+        self.wait_until_ready().await;
+
+        set_status(&self.status, Status::Indexing);
+
+        let result = self.indexer.index_workspaces().await;
+
+        set_status(&self.status, Status::Ready);
+
+        result
+    }
+
+    /// Shut the engine down, stopping any indexing still in progress, no longer watching for
+    /// changes, and closing every connection to the index.
+    ///
+    /// Once shut down, the engine can no longer index or resolve anything.
+    pub async fn shutdown(&self) {
+        self.stop();
+
+        self.watcher.stop().await;
+        self.pool.close().await;
+    }
+
+    /// Stop any indexing still in progress, and mark the engine as stopped.
+    fn stop(&self) {
+        if let Some(handle) = self
+            .startup
+            .lock()
+            .unwrap_or_else(PoisonError::into_inner)
+            .take()
+        {
+            handle.abort();
+        }
+
+        if set_status(&self.status, Status::Stopped) {
+            let _ = self.events.send(Event::Stopped);
+        }
+    }
+}
+
+impl Drop for Onoma {
+    fn drop(&mut self) {
+        // The watcher stops watching for changes as soon as it's dropped (which happens once the
+        // indexing in the background has been stopped too)
+        self.stop();
+
+        // Resolvers cloned from the engine share its pool, so would otherwise keep connections
+        // to the index open. Closing connections is async, so can only happen in the background
+        let pool = self.pool.clone();
+
+        self.runtime.spawn(async move {
+            pool.close().await;
+        });
+    }
+}
+
+/// Update the status of an engine, returning whether it changed.
+///
+/// Once stopped, the status of an engine never changes again.
+fn set_status(status: &watch::Sender<Status>, new_status: Status) -> bool {
+    status.send_if_modified(|status| {
+        if *status == Status::Stopped || *status == new_status {
+            return false;
+        }
+
+        *status = new_status;
+
+        true
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use tempfile::tempdir;
+    use tokio::fs;
+    use tokio_stream::StreamExt;
+
+    use crate::{
+        engine::{Event, Onoma, Status},
+        resolver::Context,
+    };
+
+    #[tokio::test]
+    pub async fn test_indexing_and_resolving_with_engine() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        let user = workspace.path().join("user.ts");
+        let session = workspace.path().join("session.ts");
+
+        fs::write(&user, "export function getUser() {}\n")
+            .await
+            .expect("Should never fail to write user.ts");
+
+        // Notice, the database doesn't exist until the engine is built, so the resolver would
+        // fail to connect if it wasn't created after the database is migrated
+        let engine = Onoma::builder()
+            .with_storage_path(storage_path.path())
+            .with_workspace(workspace.path())
+            .with_watching(false)
+            .build()
+            .await
+            .expect("Engine should be built successfully");
+
+        let mut events = engine.subscribe();
+
+        assert_eq!(Status::Ready, engine.wait_until_ready().await);
+
+        let symbols = engine
+            .query("getUser", Context::default())
+            .collect::<Vec<_>>()
+            .await;
+
+        assert_eq!(1, symbols.len());
+        assert_eq!(user, symbols[0].path);
+
+        fs::write(&session, "export class Session {}\n")
+            .await
+            .expect("Should never fail to write session.ts");
+
+        engine
+            .index(&session)
+            .await
+            .expect("session.ts should be indexed successfully");
+
+        loop {
+            let event = events
+                .recv()
+                .await
+                .expect("Should receive an event for indexing session.ts");
+
+            if event == Event::Indexed(session.clone()) {
+                break;
+            }
+        }
+
+        let symbols = engine
+            .query("Session", Context::default())
+            .collect::<Vec<_>>()
+            .await;
+
+        assert_eq!(1, symbols.len());
+        assert_eq!(session, symbols[0].path);
+
+        engine.shutdown().await;
+
+        assert_eq!(Status::Stopped, engine.status());
+        assert_eq!(Ok(Event::Stopped), events.recv().await);
+    }
+
+    #[tokio::test]
+    pub async fn test_reindexing_while_starting() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        fs::write(
+            workspace.path().join("user.ts"),
+            "export function getUser() {}\n",
+        )
+        .await
+        .expect("Should never fail to write user.ts");
+
+        let engine = Onoma::builder()
+            .with_storage_path(storage_path.path())
+            .with_workspace(workspace.path())
+            .with_watching(false)
+            .build()
+            .await
+            .expect("Engine should be built successfully");
+
+        let mut events = engine.subscribe();
+
+        // Notice, the workspace is still being indexed in the background
+        engine
+            .reindex()
+            .await
+            .expect("Workspace should be re-indexed successfully");
+
+        assert_eq!(Status::Ready, engine.status());
+
+        let mut indexing_events = Vec::new();
+
+        while let Ok(event) = events.try_recv() {
+            if matches!(
+                event,
+                Event::IndexingStarted | Event::IndexingFinished { .. }
+            ) {
+                indexing_events.push(event);
+            }
+        }
+
+        // Re-indexing only starts once the indexing in the background has finished
+        assert_eq!(
+            vec![
+                Event::IndexingStarted,
+                Event::IndexingFinished {
+                    failed_workspaces: 0
+                },
+                Event::IndexingStarted,
+                Event::IndexingFinished {
+                    failed_workspaces: 0
+                },
+            ],
+            indexing_events
+        );
+
+        engine.shutdown().await;
+    }
+}
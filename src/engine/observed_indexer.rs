@@ -0,0 +1,112 @@
+use std::{
+    path::{Path, PathBuf},
+    sync::Arc,
+    time::Duration,
+};
+
+use tokio::sync::broadcast;
+
+use crate::{
+    engine::Event,
+    indexer::{self, DatabaseBackedIndexer, Indexer},
+};
+
+/// A wrapper around an [`Indexer`] which broadcasts an [`Event`] whenever the index changes,
+/// regardless of whether the change was made on demand, or by a [`crate::watcher::Watcher`].
+#[derive(Debug, Clone)]
+pub struct ObservedIndexer {
+    indexer: DatabaseBackedIndexer,
+    events: broadcast::Sender<Event>,
+}
+
+impl ObservedIndexer {
+    /// Wrap an indexer, broadcasting its events to a channel.
+    pub const fn new(indexer: DatabaseBackedIndexer, events: broadcast::Sender<Event>) -> Self {
+        Self { indexer, events }
+    }
+
+    /// Broadcast an event to every subscriber.
+    fn emit(&self, event: Event) {
+        // Sending only fails when nothing is subscribed, in which case nobody needs the event
+        let _ = self.events.send(event);
+    }
+
+    /// Broadcast the outcome of a change to the index.
+    fn emit_result<T>(
+        &self,
+        path: &Path,
+        result: &indexer::Result<T>,
+        event: impl FnOnce(PathBuf) -> Event,
+    ) {
+        match result {
+            Ok(_) => self.emit(event(path.to_path_buf())),
+            Err(e) => self.emit(Event::Failed(path.to_path_buf(), e.to_string())),
+        }
+    }
+}
+
+impl Indexer for ObservedIndexer {
+    fn get_workspaces(&self) -> Vec<Arc<PathBuf>> {
+        self.indexer.get_workspaces()
+    }
+
+    fn is_inside_workspace(&self, path: &Path) -> bool {
+        self.indexer.is_inside_workspace(path)
+    }
+
+    async fn index_workspaces(&self) -> std::result::Result<(), Vec<indexer::Error>> {
+        self.emit(Event::IndexingStarted);
+
+        let result = self.indexer.index_workspaces().await;
+
+        self.emit(Event::IndexingFinished {
+            failed_workspaces: result.as_ref().map_or_else(Vec::len, |_| 0),
+        });
+
+        result
+    }
+
+    async fn index(&self, path: &Path) -> indexer::Result<()> {
+        let result = self.indexer.index(path).await;
+
+        self.emit_result(path, &result, Event::Indexed);
+
+        result
+    }
+
+    async fn deindex(&self, path: &Path) -> indexer::Result<()> {
+        let result = self.indexer.deindex(path).await;
+
+        self.emit_result(path, &result, Event::Deindexed);
+
+        result
+    }
+
+    async fn index_ephemeral(&self, path: &Path) -> indexer::Result<()> {
+        let result = self.indexer.index_ephemeral(path).await;
+
+        self.emit_result(path, &result, Event::Indexed);
+
+        result
+    }
+
+    async fn touch_ephemeral(&self, path: &Path) -> indexer::Result<()> {
+        self.indexer.touch_ephemeral(path).await
+    }
+
+    async fn evict_ephemeral(&self, path: &Path) -> indexer::Result<()> {
+        let result = self.indexer.evict_ephemeral(path).await;
+
+        self.emit_result(path, &result, Event::Deindexed);
+
+        result
+    }
+
+    async fn evict_expired_ephemeral(&self, ttl: Duration) -> indexer::Result<u64> {
+        self.indexer.evict_expired_ephemeral(ttl).await
+    }
+
+    fn get_ephemeral_file_ttl(&self) -> Duration {
+        self.indexer.get_ephemeral_file_ttl()
+    }
+}
@@ -0,0 +1,50 @@
+use std::path::PathBuf;
+
+/// The status of an [`crate::engine::Onoma`] engine.
+#[derive(Debug, Clone, Copy, Hash, Eq, PartialEq)]
+pub enum Status {
+    /// The engine has been built, but hasn't started indexing its workspaces yet.
+    Starting,
+
+    /// The workspaces are being indexed in full. Until indexing completes, queries will return
+    /// incomplete results.
+    Indexing,
+
+    /// The workspaces have been indexed (and, unless disabled, are being watched for changes).
+    Ready,
+
+    /// The engine has been shut down, and will no longer index (or resolve) anything.
+    Stopped,
+}
+
+/// An event emitted by an [`crate::engine::Onoma`] engine, as the index changes.
+///
+/// Events are broadcast to every subscriber (see [`crate::engine::Onoma::subscribe`]), and are
+/// only kept for a short while for subscribers which fall behind.
+#[derive(Debug, Clone, Hash, Eq, PartialEq)]
+pub enum Event {
+    /// A full index of every workspace started.
+    IndexingStarted,
+
+    /// A full index of every workspace finished, with the number of workspaces which couldn't be
+    /// indexed successfully.
+    IndexingFinished {
+        /// The number of workspaces which failed to index.
+        failed_workspaces: usize,
+    },
+
+    /// The engine started watching its workspaces for changes.
+    WatchingStarted,
+
+    /// A file (or directory) was indexed, whether on demand or because it changed.
+    Indexed(PathBuf),
+
+    /// A file (or directory) was de-indexed, usually because it was removed.
+    Deindexed(PathBuf),
+
+    /// A file (or directory) couldn't be indexed (or de-indexed), with the reason why.
+    Failed(PathBuf, String),
+
+    /// The engine was shut down.
+    Stopped,
+}
@@ -0,0 +1,131 @@
+// Client-side fuzzy search over the exported symbol index (`symbols.js`), which is loaded as a
+// script (rather than fetched) so that the site also works when opened from the filesystem.
+(function () {
+  const MAX_RESULTS = 50;
+
+  const root = document.body.dataset.root || "";
+  const index = window.ONOMA_SYMBOLS || { kinds: [], symbols: [] };
+
+  const input = document.getElementById("search");
+  const results = document.getElementById("results");
+
+  let selected = 0;
+
+  // Score a name against a query, where every character of the query must appear in order.
+  // Consecutive characters, characters at the start of a word and exact matches score higher.
+  function score(name, query) {
+    const lowerName = name.toLowerCase();
+
+    if (lowerName === query) {
+      return Infinity;
+    }
+
+    let total = 0;
+    let streak = 0;
+    let position = 0;
+
+    for (const character of query) {
+      const found = lowerName.indexOf(character, position);
+
+      if (found === -1) {
+        return -1;
+      }
+
+      streak = found === position ? streak + 1 : 0;
+
+      const previous = name[found - 1];
+      const isWordStart =
+        found === 0 ||
+        previous === "_" ||
+        previous === "-" ||
+        (name[found] !== lowerName[found] && previous === lowerName[found - 1]);
+
+      total += 1 + streak * 2 + (isWordStart ? 3 : 0);
+      position = found + 1;
+    }
+
+    return total - name.length * 0.01;
+  }
+
+  function render(matches) {
+    results.replaceChildren(
+      ...matches.map(([name, kind, href], position) => {
+        const item = document.createElement("li");
+        const link = document.createElement("a");
+        const kindLabel = document.createElement("span");
+        const path = document.createElement("span");
+
+        link.href = root + href;
+        link.textContent = name;
+        kindLabel.className = "kind";
+        kindLabel.textContent = " " + index.kinds[kind] + " ";
+        path.className = "path";
+        path.textContent = href.split("#")[0].replace(/\.html$/, "");
+
+        if (position === selected) {
+          item.className = "selected";
+        }
+
+        item.append(link, kindLabel, path);
+
+        return item;
+      }),
+    );
+  }
+
+  function search() {
+    const query = input.value.trim().toLowerCase();
+
+    selected = 0;
+
+    if (query === "") {
+      render([]);
+
+      return;
+    }
+
+    const matches = [];
+
+    for (const symbol of index.symbols) {
+      const value = score(symbol[0], query);
+
+      if (value >= 0) {
+        matches.push([value, symbol]);
+      }
+    }
+
+    matches.sort((a, b) => b[0] - a[0] || a[1][0].localeCompare(b[1][0]));
+
+    render(matches.slice(0, MAX_RESULTS).map(([, symbol]) => symbol));
+  }
+
+  input.addEventListener("input", search);
+
+  input.addEventListener("keydown", (event) => {
+    const count = results.children.length;
+
+    if (event.key === "ArrowDown" && count > 0) {
+      selected = (selected + 1) % count;
+    } else if (event.key === "ArrowUp" && count > 0) {
+      selected = (selected - 1 + count) % count;
+    } else if (event.key === "Enter" && count > 0) {
+      results.children[selected].querySelector("a").click();
+
+      return;
+    } else if (event.key === "Escape") {
+      input.value = "";
+    } else {
+      return;
+    }
+
+    event.preventDefault();
+
+    for (const [position, item] of Array.from(results.children).entries()) {
+      item.className = position === selected ? "selected" : "";
+    }
+
+    if (event.key === "Escape") {
+      search();
+    }
+  });
+})();
@@ -0,0 +1,8 @@
+/// The stylesheet shared by every page of the site.
+pub const STYLESHEET: &str = include_str!("assets/style.css");
+
+/// The client-side fuzzy symbol search, which runs against the exported symbol index.
+pub const SEARCH_SCRIPT: &str = include_str!("assets/search.js");
+
+/// The name of the script (in the root of the site) which contains the compact symbol index.
+pub const SYMBOL_INDEX_FILENAME: &str = "symbols.js";
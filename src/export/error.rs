@@ -0,0 +1,18 @@
+use std::path::PathBuf;
+
+use thiserror::Error;
+
+/// Errors that can occur when exporting a site.
+///
+/// Source files which can't be read are skipped (with a warning), rather than failing the whole
+/// export.
+#[derive(Error, Debug)]
+pub enum Error {
+    /// The output directory (or one of its subdirectories) couldn't be created.
+    #[error("An error occurred when creating the directory {0}: {1}")]
+    CreateDirectoryFailed(PathBuf, std::io::Error),
+
+    /// A page (or asset) of the site couldn't be written.
+    #[error("An error occurred when writing {0}: {1}")]
+    WriteFailed(PathBuf, std::io::Error),
+}
@@ -0,0 +1,153 @@
+use std::ops::Range;
+
+use tree_sitter_language::LanguageFn;
+
+use crate::models::parsed::Language;
+
+/// The class of a highlighted token, which is used as its CSS class.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum TokenClass {
+    Comment,
+    String,
+    Number,
+    Keyword,
+
+    /// An identifier, which may be the definition of a symbol, or a reference to one.
+    Identifier,
+}
+
+impl TokenClass {
+    /// Get the CSS class of the token.
+    pub const fn get_css_class(self) -> &'static str {
+        match self {
+            Self::Comment => "c",
+            Self::String => "s",
+            Self::Number => "n",
+            Self::Keyword => "k",
+            Self::Identifier => "i",
+        }
+    }
+}
+
+/// A highlighted range of a file.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct Token {
+    /// The byte range of the token in the file.
+    pub range: Range<usize>,
+
+    /// The class of the token.
+    pub class: TokenClass,
+}
+
+/// Get the class of a node in the tree, if it should be highlighted.
+///
+/// Classes are decided from the kinds of node alone, so that highlighting works the same way in
+/// every language, without language-specific highlighting queries.
+fn get_token_class(node: &tree_sitter::Node<'_>) -> Option<TokenClass> {
+    let kind = node.kind();
+
+    // Comments and strings are highlighted as a whole, even if they contain other nodes (i.e.
+    // escape sequences, or doc comment markers)
+    if kind.contains("comment") {
+        return Some(TokenClass::Comment);
+    }
+
+    if kind.contains("string") || kind.contains("char_literal") || kind == "str_lit" {
+        return Some(TokenClass::String);
+    }
+
+    if node.child_count() > 0 {
+        return None;
+    }
+
+    if ["number", "integer", "float", "int_literal", "num_lit"]
+        .iter()
+        .any(|number| kind.contains(number))
+    {
+        Some(TokenClass::Number)
+    } else if !node.is_named()
+        && kind.len() > 1
+        && kind.chars().all(|c| c.is_ascii_lowercase() || c == '_')
+    {
+        Some(TokenClass::Keyword)
+    } else if node.is_named() && (kind.contains("identifier") || kind == "sym_name") {
+        Some(TokenClass::Identifier)
+    } else {
+        None
+    }
+}
+
+/// Get the highlighted tokens of a file, in the order they appear.
+///
+/// Returns no tokens if the file couldn't be parsed.
+pub fn get_tokens(content: &str, language: Language) -> Vec<Token> {
+    let mut parser = tree_sitter::Parser::new();
+
+    let parser_language: tree_sitter::Language = LanguageFn::from(language).into();
+
+    if let Err(e) = parser.set_language(&parser_language) {
+        log::warn!("Unable to highlight {language} file: {e}");
+
+        return Vec::new();
+    }
+
+    let Some(tree) = parser.parse(content, None) else {
+        return Vec::new();
+    };
+
+    let mut tokens = Vec::new();
+    let mut cursor = tree.walk();
+
+    loop {
+        let node = cursor.node();
+        let class = get_token_class(&node);
+
+        if let Some(class) = class
+            && node.start_byte() < node.end_byte()
+        {
+            tokens.push(Token {
+                range: node.byte_range(),
+                class,
+            });
+        }
+
+        if class.is_none() && cursor.goto_first_child() {
+            continue;
+        }
+
+        while !cursor.goto_next_sibling() {
+            if !cursor.goto_parent() {
+                return tokens;
+            }
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use crate::models::parsed::Language;
+
+    #[test]
+    pub fn test_highlighting_tokens() {
+        let content = "// Parse\nfn parse(input: u8) {\n    let value = \"a\\n\";\n    42\n}\n";
+
+        let tokens = super::get_tokens(content, Language::Rust)
+            .into_iter()
+            .map(|token| (content[token.range].trim_end(), token.class.get_css_class()))
+            .collect::<Vec<_>>();
+
+        assert_eq!(
+            vec![
+                ("// Parse", "c"),
+                ("fn", "k"),
+                ("parse", "i"),
+                ("input", "i"),
+                ("let", "k"),
+                ("value", "i"),
+                ("\"a\\n\"", "s"),
+                ("42", "n"),
+            ],
+            tokens
+        );
+    }
+}
@@ -0,0 +1,120 @@
+/// Escape text for use in HTML (including inside attribute values).
+pub fn escape(text: &str) -> String {
+    let mut escaped = String::with_capacity(text.len());
+
+    for c in text.chars() {
+        match c {
+            '&' => escaped.push_str("&amp;"),
+            '<' => escaped.push_str("&lt;"),
+            '>' => escaped.push_str("&gt;"),
+            '"' => escaped.push_str("&quot;"),
+            '\'' => escaped.push_str("&#39;"),
+            c => escaped.push(c),
+        }
+    }
+
+    escaped
+}
+
+/// Get the relative prefix which leads from a page back to the root of the site (i.e. `../../`
+/// for `app/src/user.ts.html`).
+pub fn get_root_prefix(page: &str) -> String {
+    "../".repeat(page.matches('/').count())
+}
+
+/// Get the breadcrumbs leading from the root of the site to a page, where every ancestor
+/// directory links to its listing.
+pub fn get_breadcrumbs(page: &str) -> String {
+    let root = get_root_prefix(page);
+
+    let parts = page
+        .trim_end_matches("/index.html")
+        .split('/')
+        .collect::<Vec<_>>();
+
+    let mut breadcrumbs = format!("<a href=\"{root}index.html\">/</a>");
+
+    for (index, part) in parts.iter().enumerate() {
+        let name = escape(part.trim_end_matches(".html"));
+
+        if index + 1 == parts.len() {
+            breadcrumbs.push_str(&format!(" <span>{name}</span>"));
+        } else {
+            let path = parts[..=index].join("/");
+
+            breadcrumbs.push_str(&format!(
+                " <a href=\"{root}{}/index.html\">{name}</a> /",
+                escape(&path)
+            ));
+        }
+    }
+
+    breadcrumbs
+}
+
+/// Render a full page of the site, with the symbol search in its header.
+pub fn render_page(page: &str, title: &str, body: &str) -> String {
+    let root = get_root_prefix(page);
+    let title = escape(title);
+    let breadcrumbs = if page == "index.html" {
+        String::new()
+    } else {
+        get_breadcrumbs(page)
+    };
+
+    format!(
+        r#"<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{title}</title>
+<link rel="stylesheet" href="{root}style.css">
+</head>
+<body data-root="{root}">
+<header>
+<nav>{breadcrumbs}</nav>
+<div class="search">
+<input id="search" type="search" placeholder="Search symbols" autocomplete="off" spellcheck="false">
+<ol id="results"></ol>
+</div>
+</header>
+<main>
+{body}
+</main>
+<script src="{root}symbols.js"></script>
+<script src="{root}search.js"></script>
+</body>
+</html>
+"#
+    )
+}
+
+#[cfg(test)]
+mod tests {
+    use rstest::rstest;
+
+    #[rstest]
+    #[case("fn main() {}", "fn main() {}")]
+    #[case("if a < b && c > \"d\"", "if a &lt; b &amp;&amp; c &gt; &quot;d&quot;")]
+    #[case("'é'", "&#39;é&#39;")]
+    pub fn test_escaping_html(#[case] text: &str, #[case] expected: &str) {
+        assert_eq!(expected, super::escape(text));
+    }
+
+    #[rstest]
+    #[case("index.html", "")]
+    #[case("app/index.html", "../")]
+    #[case("app/src/user.ts.html", "../../")]
+    pub fn test_getting_root_prefix(#[case] page: &str, #[case] expected: &str) {
+        assert_eq!(expected, super::get_root_prefix(page));
+    }
+
+    #[test]
+    pub fn test_getting_breadcrumbs() {
+        assert_eq!(
+            "<a href=\"../../index.html\">/</a> <a href=\"../../app/index.html\">app</a> / <a href=\"../../app/src/index.html\">src</a> / <span>user.ts</span>",
+            super::get_breadcrumbs("app/src/user.ts.html")
+        );
+    }
+}
@@ -0,0 +1,844 @@
+//! Export of an index as a static, cross-referenced code browser, for documentation and offline
+//! review.
+//!
+//! The exported site contains:
+//!
+//! 1. A syntax-highlighted page for every indexed file, with an anchor at every definition
+//! 2. Links from identifiers to the definitions they most likely refer to, and from definitions
+//!    to their references (when any are found)
+//! 3. A listing of the directories, files and symbols in every directory
+//! 4. A client-side fuzzy symbol search, backed by a compact index of every symbol's name
+//!
+//! As with the rest of Onoma, references are found by name alone (see
+//! [`crate::resolver::RenameResolver::get_references`]), so are best-effort.
+
+use std::{
+    collections::{BTreeMap, BTreeSet, HashMap, HashSet},
+    fmt::Write as _,
+    path::{Path, PathBuf},
+};
+
+use itertools::Itertools;
+use serde_json::json;
+
+mod constant;
+mod error;
+mod highlight;
+mod html;
+mod types;
+
+pub use error::Error;
+pub use types::Result;
+
+use crate::{
+    export::highlight::{Token, TokenClass},
+    models::{
+        parsed::{Language, SymbolKind},
+        resolved::ResolvedSymbol,
+    },
+    resolver::DatabaseBackedResolver,
+};
+
+/// A summary of an exported site.
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq)]
+pub struct ExportSummary {
+    /// The number of source files with a page in the site.
+    pub files: usize,
+
+    /// The number of symbols defined in those files.
+    pub symbols: usize,
+
+    /// The number of references linked to a definition.
+    pub references: usize,
+}
+
+/// Check if symbols of a kind can only be referenced from the file they're defined in.
+const fn is_local(kind: SymbolKind) -> bool {
+    matches!(
+        kind,
+        SymbolKind::Parameter
+            | SymbolKind::ParameterLabel
+            | SymbolKind::SelfParameter
+            | SymbolKind::ThisParameter
+            | SymbolKind::MethodReceiver
+    )
+}
+
+/// Check if symbols of a kind are included in listings and the symbol search.
+///
+/// Local symbols, and symbols which are usages rather than definitions (i.e. environment
+/// variables), are still anchored in their file's page.
+const fn is_listed(kind: SymbolKind) -> bool {
+    !is_local(kind)
+        && !matches!(
+            kind,
+            SymbolKind::EnvironmentVariable | SymbolKind::TranslationKey
+        )
+}
+
+/// Get the (1-based) line and column a symbol is defined at.
+fn get_position(symbol: &ResolvedSymbol) -> (usize, usize) {
+    (
+        usize::try_from(symbol.start_line).unwrap_or_default(),
+        usize::try_from(symbol.start_column).unwrap_or_default(),
+    )
+}
+
+/// Get the anchor of a symbol's definition in its file's page (i.e. `L3C17`).
+fn get_anchor(symbol: &ResolvedSymbol) -> String {
+    format!("L{}C{}", symbol.start_line, symbol.start_column)
+}
+
+/// Get the byte offset each line of some content starts at.
+fn get_line_starts(content: &str) -> Vec<usize> {
+    std::iter::once(0)
+        .chain(content.match_indices('\n').map(|(index, _)| index + 1))
+        .collect()
+}
+
+/// Get the (1-based) line and column (in bytes) of a byte offset, in the same form as the index.
+fn get_line_and_column(line_starts: &[usize], offset: usize) -> (usize, usize) {
+    let line = line_starts
+        .partition_point(|&start| start <= offset)
+        .saturating_sub(1);
+
+    (line + 1, offset - line_starts[line] + 1)
+}
+
+/// Append (escaped) text to the lines of a page, wrapping each line of the text in the given
+/// tags, so that every line can be rendered on its own.
+fn push_text(lines: &mut Vec<String>, text: &str, open: &str, close: &str) {
+    for (index, part) in text.split('\n').enumerate() {
+        if index > 0 {
+            lines.push(String::new());
+        }
+
+        if part.is_empty() {
+            continue;
+        }
+
+        if let Some(line) = lines.last_mut() {
+            line.push_str(open);
+            line.push_str(&html::escape(part));
+            line.push_str(close);
+        }
+    }
+}
+
+/// Get the name of each workspace in the site, which is the top-level directory its files are
+/// exported into.
+fn get_roots(workspaces: &[PathBuf]) -> Vec<(&Path, String)> {
+    let mut names = HashSet::new();
+
+    workspaces
+        .iter()
+        .map(|workspace| {
+            let base = workspace.file_name().map_or_else(
+                || String::from("workspace"),
+                |name| name.to_string_lossy().to_string(),
+            );
+
+            let mut name = base.clone();
+            let mut suffix = 2;
+
+            while !names.insert(name.clone()) {
+                name = format!("{base}-{suffix}");
+                suffix += 1;
+            }
+
+            (workspace.as_path(), name)
+        })
+        .collect()
+}
+
+/// A source file in the site.
+#[derive(Debug)]
+struct SiteFile {
+    path: PathBuf,
+
+    /// The path of the file's page, relative to the root of the site (i.e.
+    /// `app/src/user.ts.html`).
+    page: String,
+
+    language: Option<Language>,
+
+    /// The symbols defined in the file, in the order they're defined.
+    symbols: Vec<ResolvedSymbol>,
+}
+
+/// A reference to a definition.
+#[derive(Debug)]
+struct Occurrence {
+    file: usize,
+    line: usize,
+    column: usize,
+}
+
+/// A directory in the site, which lists its contents.
+#[derive(Debug, Default)]
+struct Directory {
+    directories: BTreeSet<String>,
+    files: Vec<usize>,
+}
+
+/// Everything known about the files being exported, which every page is rendered from.
+///
+/// Definitions are identified by the index of their file, and their index in that file.
+#[derive(Debug, Default)]
+struct Site {
+    files: Vec<SiteFile>,
+    definitions: HashMap<String, Vec<(usize, usize)>>,
+    references: HashMap<(usize, usize), Vec<Occurrence>>,
+}
+
+impl Site {
+    /// Find the definition an identifier in a file most likely refers to, preferring definitions
+    /// in the same file, and then the same language.
+    fn resolve(&self, name: &str, file: usize) -> Option<(usize, usize)> {
+        let language = self.files[file].language;
+
+        self.definitions
+            .get(name)?
+            .iter()
+            .copied()
+            .filter(|&(other, symbol)| {
+                other == file || !is_local(self.files[other].symbols[symbol].kind)
+            })
+            .min_by_key(|&(other, symbol)| {
+                (
+                    other != file,
+                    self.files[other].language != language,
+                    other,
+                    symbol,
+                )
+            })
+    }
+
+    /// Find the references in a file, alongside the definition each refers to.
+    fn find_references(
+        &self,
+        file: usize,
+        content: &str,
+        tokens: &[Token],
+    ) -> Vec<((usize, usize), Occurrence)> {
+        let line_starts = get_line_starts(content);
+
+        let definitions = self.files[file]
+            .symbols
+            .iter()
+            .map(get_position)
+            .collect::<HashSet<_>>();
+
+        tokens
+            .iter()
+            .filter(|token| token.class == TokenClass::Identifier)
+            .filter_map(|token| {
+                let (line, column) = get_line_and_column(&line_starts, token.range.start);
+
+                if definitions.contains(&(line, column)) {
+                    return None;
+                }
+
+                let definition = self.resolve(&content[token.range.clone()], file)?;
+
+                Some((definition, Occurrence { file, line, column }))
+            })
+            .collect()
+    }
+
+    /// Get the directories in the site, by their path relative to the root of the site (where
+    /// the root itself is an empty path).
+    fn get_directories(&self) -> BTreeMap<String, Directory> {
+        let mut directories: BTreeMap<String, Directory> = BTreeMap::new();
+
+        for (index, file) in self.files.iter().enumerate() {
+            let parts = file.page.split('/').collect::<Vec<_>>();
+
+            for depth in 0..parts.len() - 1 {
+                directories
+                    .entry(parts[..depth].join("/"))
+                    .or_default()
+                    .directories
+                    .insert(parts[depth].to_string());
+            }
+
+            directories
+                .entry(parts[..parts.len() - 1].join("/"))
+                .or_default()
+                .files
+                .push(index);
+        }
+
+        directories
+    }
+
+    /// Render the page of a source file.
+    fn render_file(&self, file: usize, content: &str, tokens: &[Token]) -> String {
+        let site_file = &self.files[file];
+        let root = html::get_root_prefix(&site_file.page);
+        let line_starts = get_line_starts(content);
+
+        let definitions = site_file
+            .symbols
+            .iter()
+            .enumerate()
+            .map(|(index, symbol)| (get_position(symbol), index))
+            .collect::<HashMap<_, _>>();
+
+        let mut lines = vec![String::new()];
+        let mut position = 0;
+
+        for token in tokens {
+            if token.range.start < position {
+                continue;
+            }
+
+            push_text(&mut lines, &content[position..token.range.start], "", "");
+
+            let text = &content[token.range.clone()];
+
+            let (open, close) = match token.class {
+                TokenClass::Identifier => {
+                    let (line, column) = get_line_and_column(&line_starts, token.range.start);
+
+                    if let Some(&index) = definitions.get(&(line, column)) {
+                        let symbol = &site_file.symbols[index];
+                        let anchor = get_anchor(symbol);
+                        let title = html::escape(&match &symbol.signature {
+                            Some(signature) => format!("{} {signature}", symbol.kind),
+                            None => symbol.kind.to_string(),
+                        });
+
+                        if self.references.contains_key(&(file, index)) {
+                            (
+                                format!(
+                                    "<a class=\"d\" id=\"{anchor}\" href=\"#R-{anchor}\" title=\"{title}\">"
+                                ),
+                                "</a>",
+                            )
+                        } else {
+                            (
+                                format!("<span class=\"d\" id=\"{anchor}\" title=\"{title}\">"),
+                                "</span>",
+                            )
+                        }
+                    } else if let Some((other, index)) = self.resolve(text, file) {
+                        let target = &self.files[other];
+                        let anchor = get_anchor(&target.symbols[index]);
+
+                        let href = if other == file {
+                            format!("#{anchor}")
+                        } else {
+                            format!("{root}{}#{anchor}", html::escape(&target.page))
+                        };
+
+                        (format!("<a class=\"r\" href=\"{href}\">"), "</a>")
+                    } else {
+                        (String::new(), "")
+                    }
+                }
+                class => (
+                    format!("<span class=\"{}\">", class.get_css_class()),
+                    "</span>",
+                ),
+            };
+
+            push_text(&mut lines, text, &open, close);
+
+            position = token.range.end;
+        }
+
+        push_text(&mut lines, &content[position..], "", "");
+
+        // The final newline of a file doesn't start another line
+        if content.ends_with('\n') {
+            lines.pop();
+        }
+
+        let mut body = String::new();
+
+        let outline = site_file
+            .symbols
+            .iter()
+            .filter(|symbol| is_listed(symbol.kind))
+            .map(|symbol| {
+                format!(
+                    "<li><a href=\"#{}\">{}</a> <span class=\"kind\">{}</span></li>",
+                    get_anchor(symbol),
+                    html::escape(&symbol.name),
+                    symbol.kind
+                )
+            })
+            .join("\n");
+
+        if !outline.is_empty() {
+            let _ = write!(
+                body,
+                "<aside class=\"outline\">\n<h2>Symbols</h2>\n<ul>\n{outline}\n</ul>\n</aside>\n"
+            );
+        }
+
+        body.push_str("<pre class=\"code\"><code>");
+
+        for (index, line) in lines.iter().enumerate() {
+            let number = index + 1;
+
+            let _ = write!(
+                body,
+                "<span class=\"line\" id=\"L{number}\"><a class=\"ln\" href=\"#L{number}\">{number}</a>{line}\n</span>"
+            );
+        }
+
+        body.push_str("</code></pre>\n");
+
+        let references = site_file
+            .symbols
+            .iter()
+            .enumerate()
+            .filter_map(|(index, symbol)| Some((symbol, self.references.get(&(file, index))?)))
+            .map(|(symbol, occurrences)| {
+                let anchor = get_anchor(symbol);
+
+                let links = occurrences
+                    .iter()
+                    .map(|occurrence| {
+                        let page = &self.files[occurrence.file].page;
+
+                        format!(
+                            "<li><a href=\"{root}{}#L{}\">{}:{}:{}</a></li>",
+                            html::escape(page),
+                            occurrence.line,
+                            html::escape(page.trim_end_matches(".html")),
+                            occurrence.line,
+                            occurrence.column
+                        )
+                    })
+                    .join("\n");
+
+                format!(
+                    "<section id=\"R-{anchor}\">\n<h3><a href=\"#{anchor}\">{}</a> <span class=\"count\">({})</span></h3>\n<ul>\n{links}\n</ul>\n</section>",
+                    html::escape(&symbol.name),
+                    occurrences.len()
+                )
+            })
+            .join("\n");
+
+        if !references.is_empty() {
+            let _ = write!(
+                body,
+                "<div class=\"references\">\n<h2>References</h2>\n{references}\n</div>\n"
+            );
+        }
+
+        html::render_page(
+            &site_file.page,
+            site_file.page.trim_end_matches(".html"),
+            &body,
+        )
+    }
+
+    /// Render the listing of a directory.
+    fn render_directory(&self, path: &str, directory: &Directory) -> String {
+        let page = if path.is_empty() {
+            String::from("index.html")
+        } else {
+            format!("{path}/index.html")
+        };
+
+        let mut body = format!(
+            "<h1>{}</h1>\n",
+            html::escape(if path.is_empty() { "Workspaces" } else { path })
+        );
+
+        if !directory.directories.is_empty() {
+            let _ = write!(
+                body,
+                "<h2>Directories</h2>\n<ul>\n{}\n</ul>\n",
+                directory
+                    .directories
+                    .iter()
+                    .map(|name| {
+                        let name = html::escape(name);
+
+                        format!("<li><a href=\"{name}/index.html\">{name}/</a></li>")
+                    })
+                    .join("\n")
+            );
+        }
+
+        if !directory.files.is_empty() {
+            let _ = write!(
+                body,
+                "<h2>Files</h2>\n<ul>\n{}\n</ul>\n",
+                directory
+                    .files
+                    .iter()
+                    .map(|&file| {
+                        let file = &self.files[file];
+                        let filename = html::escape(file.page.rsplit('/').next().unwrap_or_default());
+
+                        format!(
+                            "<li><a href=\"{filename}\">{}</a> <span class=\"count\">({} symbols)</span></li>",
+                            filename.trim_end_matches(".html"),
+                            file.symbols.len()
+                        )
+                    })
+                    .join("\n")
+            );
+        }
+
+        let symbols = directory
+            .files
+            .iter()
+            .flat_map(|&file| {
+                let file = &self.files[file];
+
+                file.symbols
+                    .iter()
+                    .filter(|symbol| is_listed(symbol.kind))
+                    .map(move |symbol| (file, symbol))
+            })
+            .sorted_by(|(_, a), (_, b)| a.name.cmp(&b.name).then_with(|| a.cmp(b)))
+            .map(|(file, symbol)| {
+                let filename = html::escape(file.page.rsplit('/').next().unwrap_or_default());
+
+                format!(
+                    "<tr><td><a href=\"{filename}#{}\">{}</a></td><td class=\"kind\">{}</td><td class=\"path\">{}</td></tr>",
+                    get_anchor(symbol),
+                    html::escape(&symbol.name),
+                    symbol.kind,
+                    filename.trim_end_matches(".html")
+                )
+            })
+            .join("\n");
+
+        if !symbols.is_empty() {
+            let _ = write!(
+                body,
+                "<h2>Symbols</h2>\n<table>\n<tr><th>Name</th><th>Kind</th><th>File</th></tr>\n{symbols}\n</table>\n"
+            );
+        }
+
+        html::render_page(&page, if path.is_empty() { "Onoma" } else { path }, &body)
+    }
+
+    /// Get the compact index of every listed symbol, which the client-side search runs
+    /// against.
+    ///
+    /// Each symbol is `[name, kind, href]`, where the kind is an index into a list of kinds, to
+    /// keep the index small.
+    fn get_symbol_index(&self) -> String {
+        let mut kinds = Vec::new();
+        let mut kind_indices = HashMap::new();
+        let mut symbols = Vec::new();
+
+        for file in &self.files {
+            for symbol in file.symbols.iter().filter(|symbol| is_listed(symbol.kind)) {
+                let kind = *kind_indices.entry(symbol.kind).or_insert_with(|| {
+                    kinds.push(symbol.kind.to_string());
+
+                    kinds.len() - 1
+                });
+
+                symbols.push(json!([
+                    symbol.name,
+                    kind,
+                    format!("{}#{}", file.page, get_anchor(symbol))
+                ]));
+            }
+        }
+
+        format!(
+            "window.ONOMA_SYMBOLS = {};\n",
+            json!({ "kinds": kinds, "symbols": symbols })
+        )
+    }
+}
+
+/// Write a file of the site, creating its directory if needed.
+async fn write_file(path: &Path, content: &str) -> Result<()> {
+    if let Some(parent) = path.parent() {
+        tokio::fs::create_dir_all(parent)
+            .await
+            .map_err(|e| Error::CreateDirectoryFailed(parent.to_path_buf(), e))?;
+    }
+
+    tokio::fs::write(path, content)
+        .await
+        .map_err(|e| Error::WriteFailed(path.to_path_buf(), e))
+}
+
+/// Read a source file, and highlight it.
+///
+/// Returns [`None`] if the file couldn't be read.
+async fn read_source(file: &SiteFile) -> Option<(String, Vec<Token>)> {
+    let content = tokio::fs::read_to_string(&file.path)
+        .await
+        .inspect_err(|e| log::warn!("Unable to read {} for export: {e}", file.path.display()))
+        .ok()?;
+
+    let tokens = file
+        .language
+        .map(|language| highlight::get_tokens(&content, language))
+        .unwrap_or_default();
+
+    Some((content, tokens))
+}
+
+/// Site exporter, which renders an existing index (and the files it was indexed from) as a
+/// static, cross-referenced code browser.
+#[derive(Debug, Clone)]
+pub struct SiteExporter {
+    resolver: DatabaseBackedResolver,
+    workspaces: Vec<PathBuf>,
+}
+
+impl SiteExporter {
+    /// Initialize a site exporter at a given database path, for a set of workspaces.
+    ///
+    /// As with [`crate::resolver::DatabaseBackedResolver::new`], the storage path and workspaces
+    /// should match those provided to the indexer.
+    #[must_use]
+    pub fn new<'a, 'b>(
+        storage_path: &'b Path,
+        workspaces: impl IntoIterator<Item = &'a Path>,
+    ) -> Self {
+        let workspaces = workspaces.into_iter().collect::<Vec<_>>();
+
+        Self {
+            resolver: DatabaseBackedResolver::new(storage_path, workspaces.iter().copied()),
+            workspaces: workspaces.into_iter().map(Path::to_path_buf).collect(),
+        }
+    }
+
+    /// Export the site into a directory.
+    ///
+    /// Every indexed file inside the workspaces has a page at the same path (relative to its
+    /// workspace) in a directory named after the workspace, with `.html` appended (i.e.
+    /// `app/src/user.ts.html`). Files outside every workspace (i.e. ephemeral files) are never
+    /// exported.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if any page of the site couldn't be written.
+    pub async fn export(&self, output: &Path) -> Result<ExportSummary> {
+        let site = self.load_site().await;
+
+        let mut summary = ExportSummary {
+            symbols: site.files.iter().map(|file| file.symbols.len()).sum(),
+            ..ExportSummary::default()
+        };
+
+        write_file(&output.join("style.css"), constant::STYLESHEET).await?;
+        write_file(&output.join("search.js"), constant::SEARCH_SCRIPT).await?;
+        write_file(
+            &output.join(constant::SYMBOL_INDEX_FILENAME),
+            &site.get_symbol_index(),
+        )
+        .await?;
+
+        for (file, site_file) in site.files.iter().enumerate() {
+            let Some((content, tokens)) = read_source(site_file).await else {
+                continue;
+            };
+
+            write_file(
+                &output.join(&site_file.page),
+                &site.render_file(file, &content, &tokens),
+            )
+            .await?;
+
+            summary.files += 1;
+        }
+
+        for (path, directory) in site.get_directories() {
+            write_file(
+                &output.join(&path).join("index.html"),
+                &site.render_directory(&path, &directory),
+            )
+            .await?;
+        }
+
+        summary.references = site.references.values().map(Vec::len).sum();
+
+        log::info!(
+            "Exported {} files ({} symbols, {} references) to {}",
+            summary.files,
+            summary.symbols,
+            summary.references,
+            output.display()
+        );
+
+        Ok(summary)
+    }
+
+    /// Load every file in the workspaces from the index, and find the references between them.
+    async fn load_site(&self) -> Site {
+        let roots = get_roots(&self.workspaces);
+
+        let mut site = Site::default();
+
+        for path in self.resolver.get_indexed_files().await {
+            let Some(page) = roots.iter().find_map(|(workspace, name)| {
+                let relative = path.strip_prefix(workspace).ok()?;
+
+                Some(format!(
+                    "{name}/{}.html",
+                    relative
+                        .components()
+                        .map(|component| component.as_os_str().to_string_lossy())
+                        .join("/")
+                ))
+            }) else {
+                continue;
+            };
+
+            let symbols = self.resolver.get_symbols_in_file(&path).await;
+
+            site.files.push(SiteFile {
+                language: Language::try_from(path.as_path()).ok(),
+                path,
+                page,
+                symbols,
+            });
+        }
+
+        for (file, site_file) in site.files.iter().enumerate() {
+            for (index, symbol) in site_file.symbols.iter().enumerate() {
+                site.definitions
+                    .entry(symbol.name.clone())
+                    .or_default()
+                    .push((file, index));
+            }
+        }
+
+        // References need to be known before any page is rendered, as definitions link to them
+        let mut references = Vec::new();
+
+        for (file, site_file) in site.files.iter().enumerate() {
+            let Some((content, tokens)) = read_source(site_file).await else {
+                continue;
+            };
+
+            references.extend(site.find_references(file, &content, &tokens));
+        }
+
+        for (definition, occurrence) in references {
+            site.references
+                .entry(definition)
+                .or_default()
+                .push(occurrence);
+        }
+
+        site
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use tempfile::tempdir;
+    use tokio::fs;
+
+    use crate::indexer::{self, Indexer};
+
+    #[test]
+    pub fn test_getting_line_and_column() {
+        let line_starts = super::get_line_starts("ab\n\ncd\n");
+
+        assert_eq!(vec![0, 3, 4, 7], line_starts);
+        assert_eq!((1, 1), super::get_line_and_column(&line_starts, 0));
+        assert_eq!((1, 3), super::get_line_and_column(&line_starts, 2));
+        assert_eq!((3, 2), super::get_line_and_column(&line_starts, 5));
+    }
+
+    #[tokio::test]
+    pub async fn test_exporting_site() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        let output =
+            tempdir().expect("Should never fail when creating a temp directory for the output");
+
+        fs::create_dir(workspace.path().join("api"))
+            .await
+            .expect("Should never fail to create a directory in the workspace");
+
+        fs::write(
+            workspace.path().join("api/user.ts"),
+            "// Users\nexport function getUser(id: number) {\n  return id < 10;\n}\n",
+        )
+        .await
+        .expect("Should never fail to write a file into the workspace");
+        fs::write(
+            workspace.path().join("app.ts"),
+            "const user = getUser(1);\n",
+        )
+        .await
+        .expect("Should never fail to write a file into the workspace");
+
+        let workspaces = vec![workspace.path()];
+
+        let indexer = indexer::DatabaseBackedIndexer::new(storage_path.path(), workspaces.clone())
+            .await
+            .expect("Should be able to create the empty index");
+
+        assert!(indexer.index_workspaces().await.is_ok());
+
+        let summary = super::SiteExporter::new(storage_path.path(), workspaces)
+            .export(output.path())
+            .await
+            .expect("Should never fail to export into a temporary directory");
+
+        assert_eq!(2, summary.files);
+
+        let name = workspace
+            .path()
+            .file_name()
+            .expect("Workspace should have a name")
+            .to_string_lossy()
+            .to_string();
+
+        let read = |path: String| {
+            std::fs::read_to_string(output.path().join(path))
+                .expect("Page should have been exported")
+        };
+
+        let definition_page = read(format!("{name}/api/user.ts.html"));
+
+        // Definitions are anchored, highlighted, and linked to their references
+        assert!(
+            definition_page
+                .contains("<a class=\"d\" id=\"L2C17\" href=\"#R-L2C17\" title=\"Function")
+        );
+        assert!(definition_page.contains("<span class=\"c\">// Users</span>"));
+        assert!(definition_page.contains("id &lt; 10"));
+        assert!(definition_page.contains(&format!(
+            "<a href=\"../../{name}/app.ts.html#L1\">{name}/app.ts:1:14</a>"
+        )));
+
+        // References link to their definition
+        assert!(read(format!("{name}/app.ts.html")).contains(&format!(
+            "<a class=\"r\" href=\"../{name}/api/user.ts.html#L2C17\">getUser</a>"
+        )));
+
+        // Every directory lists its contents
+        assert!(
+            read(String::from("index.html")).contains(&format!("<a href=\"{name}/index.html\">"))
+        );
+        assert!(read(format!("{name}/index.html")).contains("<a href=\"api/index.html\">api/</a>"));
+        assert!(
+            read(format!("{name}/api/index.html"))
+                .contains("<a href=\"user.ts.html#L2C17\">getUser</a>")
+        );
+
+        let symbol_index = read(String::from("symbols.js"));
+
+        assert!(symbol_index.starts_with("window.ONOMA_SYMBOLS = "));
+        assert!(symbol_index.contains(&format!(
+            "[\"getUser\",0,\"{name}/api/user.ts.html#L2C17\"]"
+        )));
+    }
+}
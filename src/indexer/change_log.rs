@@ -0,0 +1,275 @@
+use std::{collections::HashSet, time::Duration};
+
+use chrono::{DateTime, Utc};
+use itertools::Itertools;
+use sea_query::{Expr, ExprTrait, Func, Order, Query, SqliteQueryBuilder};
+use sea_query_sqlx::SqlxBinder;
+
+use crate::{
+    indexer::{constant, git::Commit, types::Result},
+    models::resolved::SymbolChangeKind,
+};
+
+/// A symbol in a file, as it's identified in the change log.
+#[derive(Debug, Clone, sqlx::FromRow)]
+pub struct LoggedSymbol {
+    /// The name of the symbol.
+    pub name: String,
+
+    /// The kind of the symbol.
+    pub kind: String,
+
+    /// The language the symbol is defined in.
+    pub language: String,
+
+    /// The line the symbol is defined on.
+    pub line: i64,
+}
+
+/// Get the symbols which have been added to, or removed from, a file since it was last indexed.
+///
+/// Symbols are identified by their name and kind, so moving a symbol within a file isn't a
+/// change. Symbols which share both (i.e. overloads) are added when the first of them appears,
+/// and removed when the last of them disappears.
+pub fn get_changes<'a>(
+    previous: &'a [LoggedSymbol],
+    current: &'a [LoggedSymbol],
+) -> Vec<(SymbolChangeKind, &'a LoggedSymbol)> {
+    let previous_identities = previous.iter().map(identify).collect::<HashSet<_>>();
+    let current_identities = current.iter().map(identify).collect::<HashSet<_>>();
+
+    let added = current
+        .iter()
+        .filter(|symbol| !previous_identities.contains(&identify(symbol)))
+        .unique_by(|symbol| identify(*symbol))
+        .map(|symbol| (SymbolChangeKind::Added, symbol));
+
+    let removed = previous
+        .iter()
+        .filter(|symbol| !current_identities.contains(&identify(symbol)))
+        .unique_by(|symbol| identify(*symbol))
+        .map(|symbol| (SymbolChangeKind::Removed, symbol));
+
+    added.chain(removed).collect()
+}
+
+/// Get the identity of a symbol in the change log: its name and kind.
+fn identify(symbol: &LoggedSymbol) -> (&str, &str) {
+    (&symbol.name, &symbol.kind)
+}
+
+/// Record changes to the symbols in a file, optionally alongside the commit which made them.
+///
+/// # Errors
+///
+/// Returns an error if the changes could not be recorded.
+pub async fn record_changes(
+    connection: &mut sqlx::SqliteConnection,
+    path: &str,
+    changes: &[(SymbolChangeKind, &LoggedSymbol)],
+    commit: Option<&Commit>,
+    changed_at: DateTime<Utc>,
+) -> Result<()> {
+    let commit_hash = commit.map(|commit| commit.hash.clone());
+
+    for changes in changes.chunks(constant::CHANGE_LOG_BATCH_SIZE) {
+        let mut query = Query::insert();
+
+        query.into_table("symbol_change").columns([
+            "change",
+            "path",
+            "name",
+            "kind",
+            "language",
+            "line",
+            "commit_hash",
+            "changed_at",
+        ]);
+
+        for (change, symbol) in changes {
+            query.values([
+                change.to_string().into(),
+                path.into(),
+                symbol.name.clone().into(),
+                symbol.kind.clone().into(),
+                symbol.language.clone().into(),
+                symbol.line.into(),
+                commit_hash.clone().into(),
+                changed_at.into(),
+            ])?;
+        }
+
+        let (sql, values) = query.build_sqlx(SqliteQueryBuilder);
+
+        sqlx::query_with(&sql, values)
+            .execute(&mut *connection)
+            .await?;
+    }
+
+    Ok(())
+}
+
+/// Record the removal of every symbol in the (non-ephemeral) files matching a path pattern,
+/// before the files themselves are removed from the index.
+///
+/// # Errors
+///
+/// Returns an error if the changes could not be recorded.
+pub async fn record_removed_files(
+    connection: &mut sqlx::SqliteConnection,
+    path_pattern: &str,
+    changed_at: DateTime<Utc>,
+) -> Result<()> {
+    let (sql, values) = Query::insert()
+        .into_table("symbol_change")
+        .columns([
+            "change",
+            "path",
+            "name",
+            "kind",
+            "language",
+            "line",
+            "changed_at",
+        ])
+        .select_from(
+            Query::select()
+                .expr(Expr::val(SymbolChangeKind::Removed.to_string()))
+                .columns([
+                    ("file", "path"),
+                    ("symbol", "name"),
+                    ("symbol", "kind"),
+                    ("symbol", "language"),
+                ])
+                .expr(Func::min(Expr::col(("symbol", "start_line"))))
+                .expr(Expr::val(changed_at))
+                .from("symbol")
+                .inner_join(
+                    "file",
+                    Expr::col(("symbol", "file_id")).equals(("file", "id")),
+                )
+                .and_where(Expr::col(("file", "path")).like(path_pattern))
+                .and_where(Expr::col(("file", "ephemeral")).eq(false))
+                // As with re-indexing, symbols which share a name and kind are only removed once
+                .group_by_columns([
+                    ("file", "path"),
+                    ("symbol", "name"),
+                    ("symbol", "kind"),
+                    ("symbol", "language"),
+                ])
+                .to_owned(),
+        )?
+        .build_sqlx(SqliteQueryBuilder);
+
+    sqlx::query_with(&sql, values).execute(connection).await?;
+
+    Ok(())
+}
+
+/// Remove the changes which are older than the maximum age, and then the oldest changes beyond
+/// the maximum number of changes kept.
+///
+/// Returns the number of changes which were removed.
+///
+/// # Errors
+///
+/// Returns an error if the changes could not be removed.
+pub async fn prune(
+    pool: &sqlx::Pool<sqlx::Sqlite>,
+    max_age: Duration,
+    max_changes: u64,
+) -> Result<u64> {
+    let expires_before = chrono::TimeDelta::from_std(max_age)
+        .ok()
+        .and_then(|max_age| Utc::now().checked_sub_signed(max_age))
+        .unwrap_or(DateTime::<Utc>::MIN_UTC);
+
+    let (sql, values) = Query::delete()
+        .from_table("symbol_change")
+        .and_where(Expr::col(("symbol_change", "changed_at")).lt(expires_before))
+        .build_sqlx(SqliteQueryBuilder);
+
+    let mut pruned = sqlx::query_with(&sql, values)
+        .execute(pool)
+        .await?
+        .rows_affected();
+
+    // Changes are only ever appended, so the newest changes have the highest IDs
+    let (sql, values) = Query::select()
+        .column(("symbol_change", "id"))
+        .from("symbol_change")
+        .order_by(("symbol_change", "id"), Order::Desc)
+        .limit(1)
+        .offset(max_changes)
+        .build_sqlx(SqliteQueryBuilder);
+
+    let oldest_removed = sqlx::query_scalar_with::<_, i64, _>(&sql, values)
+        .fetch_optional(pool)
+        .await?;
+
+    if let Some(oldest_removed) = oldest_removed {
+        let (sql, values) = Query::delete()
+            .from_table("symbol_change")
+            .and_where(Expr::col(("symbol_change", "id")).lte(oldest_removed))
+            .build_sqlx(SqliteQueryBuilder);
+
+        pruned += sqlx::query_with(&sql, values)
+            .execute(pool)
+            .await?
+            .rows_affected();
+    }
+
+    Ok(pruned)
+}
+
+#[cfg(test)]
+mod tests {
+    use crate::models::resolved::SymbolChangeKind;
+
+    use super::LoggedSymbol;
+
+    fn symbol(name: &str, kind: &str, line: i64) -> LoggedSymbol {
+        LoggedSymbol {
+            name: name.to_string(),
+            kind: kind.to_string(),
+            language: "TypeScript".to_string(),
+            line,
+        }
+    }
+
+    #[test]
+    pub fn test_getting_changes_between_symbols() {
+        let previous = [
+            symbol("UserService", "Class", 1),
+            symbol("getUser", "Method", 2),
+            symbol("parse", "Function", 10),
+            symbol("parse", "Function", 12),
+            symbol("legacyLogin", "Function", 20),
+        ];
+
+        let current = [
+            // Notice, moving a symbol isn't a change
+            symbol("UserService", "Class", 5),
+            symbol("getUser", "Method", 6),
+            // Notice, removing one of many symbols with the same name and kind isn't a change
+            symbol("parse", "Function", 10),
+            symbol("login", "Function", 20),
+            symbol("login", "Function", 24),
+            // Notice, changing the kind of a symbol is a change
+            symbol("getUser", "Function", 30),
+        ];
+
+        let changes = super::get_changes(&previous, &current)
+            .into_iter()
+            .map(|(change, symbol)| (change, symbol.name.as_str(), symbol.line))
+            .collect::<Vec<_>>();
+
+        assert_eq!(
+            vec![
+                (SymbolChangeKind::Added, "login", 20),
+                (SymbolChangeKind::Added, "getUser", 30),
+                (SymbolChangeKind::Removed, "legacyLogin", 20),
+            ],
+            changes
+        );
+    }
+}
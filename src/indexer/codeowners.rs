@@ -0,0 +1,163 @@
+use std::{
+    collections::HashMap,
+    path::{Path, PathBuf},
+};
+
+use ignore::gitignore::{Gitignore, GitignoreBuilder};
+
+/// The locations (relative to a workspace root) a `CODEOWNERS` file is read from.
+///
+/// Like GitHub, only the first `CODEOWNERS` file found is used.
+const CODEOWNERS_LOCATIONS: [&str; 3] = [".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"];
+
+/// The set of ownership rules defined in a workspace's `CODEOWNERS` file.
+///
+/// Patterns follow the same semantics as `.gitignore` files, and (as with `CODEOWNERS` files on
+/// GitHub and GitLab) the _last_ matching pattern for a path decides its owners.
+#[derive(Debug, Clone)]
+pub struct CodeOwners {
+    root: PathBuf,
+    matcher: Gitignore,
+
+    /// The owners for each pattern, alongside the position of the pattern in the file (so that
+    /// the last matching pattern can be found).
+    rules: HashMap<String, (usize, Vec<String>)>,
+}
+
+impl CodeOwners {
+    /// Load the `CODEOWNERS` file for a workspace, if it has one.
+    pub fn load(workspace: &Path) -> Option<Self> {
+        let (path, content) = CODEOWNERS_LOCATIONS.iter().find_map(|location| {
+            let path = workspace.join(location);
+
+            std::fs::read_to_string(&path)
+                .ok()
+                .map(|content| (path, content))
+        })?;
+
+        log::debug!("Loading code owners from {}", path.display());
+
+        Some(Self::parse(workspace, &content))
+    }
+
+    /// Parse the content of a `CODEOWNERS` file, for a particular workspace.
+    pub fn parse(workspace: &Path, content: &str) -> Self {
+        let mut builder = GitignoreBuilder::new(workspace);
+        let mut rules = HashMap::new();
+
+        for (index, line) in content.lines().map(str::trim).enumerate() {
+            // Skip comments, blank lines, and GitLab section headers (i.e. `[Documentation]`)
+            if line.is_empty() || line.starts_with('#') || line.starts_with(['[', '^']) {
+                continue;
+            }
+
+            let mut parts = line.split_whitespace();
+
+            let Some(pattern) = parts.next() else {
+                continue;
+            };
+
+            if let Err(e) = builder.add_line(None, pattern) {
+                log::warn!("Invalid pattern ({pattern}) in CODEOWNERS file: {e}");
+
+                continue;
+            }
+
+            // Patterns without any owners are valid, and explicitly mark the path as having no
+            // owners
+            let pattern_owners = parts
+                .take_while(|owner| !owner.starts_with('#'))
+                .map(ToString::to_string)
+                .collect();
+
+            rules.insert(pattern.to_string(), (index, pattern_owners));
+        }
+
+        let matcher = builder.build().unwrap_or_else(|e| {
+            log::error!("CODEOWNERS file could not be parsed: {e}");
+
+            Gitignore::empty()
+        });
+
+        Self {
+            root: workspace.to_path_buf(),
+            matcher,
+            rules,
+        }
+    }
+
+    /// Get the owners (i.e. `@user`, `@org/team`, or an email address) of a particular file.
+    ///
+    /// Files which do not match any pattern have no owners.
+    pub fn get_owners(&self, path: &Path) -> Vec<String> {
+        if !path.starts_with(&self.root) {
+            return Vec::new();
+        }
+
+        // A pattern can match the file itself, or any of its parent directories (i.e. `/docs/`),
+        // so the last matching pattern has to be found across all of them
+        path.ancestors()
+            .take_while(|ancestor| *ancestor != self.root)
+            .enumerate()
+            .filter_map(
+                |(depth, ancestor)| match self.matcher.matched(ancestor, depth > 0) {
+                    ignore::Match::Ignore(glob) => self.rules.get(glob.original()),
+                    ignore::Match::None | ignore::Match::Whitelist(_) => None,
+                },
+            )
+            .max_by_key(|(index, _)| *index)
+            .map(|(_, owners)| owners.clone())
+            .unwrap_or_default()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use std::path::PathBuf;
+
+    use rstest::rstest;
+
+    const CODEOWNERS: &str = "
+# Default owners for everything in the repo
+*       @acme/core
+
+# Front end
+/web/   @acme/frontend @alice
+*.tsx   @acme/design   # Components
+
+docs/   docs@acme.com
+
+/web/vendor/
+";
+
+    #[rstest]
+    #[case("README.md", vec!["@acme/core"])]
+    #[case("src/lib.rs", vec!["@acme/core"])]
+    #[case("web/index.ts", vec!["@acme/frontend", "@alice"])]
+    #[case("web/components/button.tsx", vec!["@acme/design"])]
+    #[case("some/nested/docs/guide.md", vec!["docs@acme.com"])]
+    #[case("web/vendor/library.js", vec![])]
+    pub fn test_resolving_code_owners(#[case] path: &str, #[case] expected_owners: Vec<&str>) {
+        let workspace = PathBuf::from_iter(["", "workspace"]);
+
+        let code_owners = super::CodeOwners::parse(&workspace, CODEOWNERS);
+
+        assert_eq!(
+            expected_owners,
+            code_owners.get_owners(&workspace.join(path))
+        );
+    }
+
+    #[test]
+    pub fn test_files_outside_of_workspace_have_no_owners() {
+        let workspace = PathBuf::from_iter(["", "workspace"]);
+
+        let code_owners = super::CodeOwners::parse(&workspace, CODEOWNERS);
+
+        assert!(
+            code_owners
+                .get_owners(&PathBuf::from_iter(["", "elsewhere", "lib.rs"]))
+                .is_empty()
+        );
+    }
+}
@@ -0,0 +1,32 @@
+/// The number of seconds an ephemeral file (a file indexed from outside any registered workspace)
+/// is kept in the index after it was last accessed, before it is evicted.
+///
+/// See [`crate::indexer::Indexer::index_ephemeral`].
+pub const DEFAULT_EPHEMERAL_FILE_TTL_SECS: u64 = 60 * 60;
+
+/// The number of bytes read from the start of a file, when checking if its header marks it as
+/// generated code.
+///
+/// See [`crate::indexer::generated::is_generated_header`].
+pub const GENERATED_HEADER_BYTES: u64 = 1024;
+
+/// The maximum number of commits read from the git history of a workspace, when finding when
+/// each file last changed.
+///
+/// See [`crate::indexer::DatabaseBackedIndexer::with_git_history`].
+pub const MAX_GIT_HISTORY_COMMITS: usize = 10_000;
+
+/// The number of seconds changes to symbols are kept in the change log, before they're pruned.
+///
+/// See [`crate::indexer::DatabaseBackedIndexer::with_change_log_retention`].
+pub const DEFAULT_CHANGE_LOG_MAX_AGE_SECS: u64 = 180 * 24 * 60 * 60;
+
+/// The maximum number of changes to symbols kept in the change log, beyond which the oldest
+/// changes are pruned.
+///
+/// See [`crate::indexer::DatabaseBackedIndexer::with_change_log_retention`].
+pub const DEFAULT_MAX_CHANGE_LOG_ENTRIES: u64 = 100_000;
+
+/// The number of changes to symbols recorded in the change log by a single query, which keeps
+/// each query well within the limit SQLite has on the number of bound values.
+pub const CHANGE_LOG_BATCH_SIZE: usize = 1_000;
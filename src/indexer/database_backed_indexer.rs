@@ -1,6 +1,18 @@
 use crate::{
-    indexer::{self, Error, Indexer, types},
-    models::parsed::{FileExtension, Language},
+    dump,
+    indexer::{
+        self, Error, Indexer,
+        change_log::{self, LoggedSymbol},
+        codeowners::CodeOwners,
+        constant,
+        git::GitHistory,
+        package::Package,
+        types,
+    },
+    models::{
+        self,
+        parsed::{FileExtension, Language},
+    },
     parser::{self, Parser},
     utils::get_database_path,
 };
@@ -9,12 +21,14 @@ use sea_query::{Expr, ExprTrait, OnConflict, Query, SqliteQueryBuilder};
 use sea_query_sqlx::SqlxBinder;
 use sqlx::sqlite::SqliteConnectOptions;
 use std::{
+    collections::{HashMap, HashSet},
     iter,
     path::{Path, PathBuf},
-    sync::Arc,
+    sync::{Arc, Mutex, PoisonError},
+    time::Duration,
 };
 use strum::IntoEnumIterator;
-use tokio::task::JoinSet;
+use tokio::{io::AsyncBufRead, task::JoinSet};
 use types::Result;
 
 /// Indexer acts as the layer around the language-agnostic models ([`crate::models`]),
@@ -33,6 +47,14 @@ pub struct DatabaseBackedIndexer {
     workspaces: Vec<Arc<PathBuf>>,
     pool: sqlx::Pool<sqlx::Sqlite>,
     parser: parser::treesitter::Parser,
+    ephemeral_file_ttl: Duration,
+    code_owners: Arc<Mutex<HashMap<PathBuf, Option<Arc<CodeOwners>>>>>,
+    packages: Arc<Mutex<HashMap<PathBuf, Option<Package>>>>,
+    translation_functions: Option<Arc<Vec<String>>>,
+    git_history: Option<Arc<tokio::sync::Mutex<HashMap<PathBuf, Option<Arc<GitHistory>>>>>>,
+    baseline_workspaces: Arc<Mutex<HashSet<PathBuf>>>,
+    change_log_max_age: Duration,
+    change_log_max_entries: u64,
 }
 
 impl DatabaseBackedIndexer {
@@ -62,11 +84,27 @@ impl DatabaseBackedIndexer {
                 .map(Arc::new)
                 .collect_vec(),
             parser: parser::treesitter::Parser::default(),
+            ephemeral_file_ttl: Duration::from_secs(constant::DEFAULT_EPHEMERAL_FILE_TTL_SECS),
+            code_owners: Arc::default(),
+            packages: Arc::default(),
+            translation_functions: None,
+            git_history: None,
+            baseline_workspaces: Arc::default(),
+            change_log_max_age: Duration::from_secs(constant::DEFAULT_CHANGE_LOG_MAX_AGE_SECS),
+            change_log_max_entries: constant::DEFAULT_MAX_CHANGE_LOG_ENTRIES,
         };
 
         Ok(indexer)
     }
 
+    /// Get the connection pool for the indexer's database, which has been fully migrated.
+    ///
+    /// This allows other components (namely, a [`crate::resolver::DatabaseBackedResolver`]) to
+    /// share the same pool, rather than opening their own.
+    pub(crate) const fn get_pool(&self) -> &sqlx::Pool<sqlx::Sqlite> {
+        &self.pool
+    }
+
     /// Initialize the database for the given workspaces, in a particular path.
     ///
     /// This will create the database (if it does not already exist), as well as
@@ -106,12 +144,14 @@ impl DatabaseBackedIndexer {
         Ok((PathBuf::from(database_path), pool))
     }
 
-    /// Index a particular file in a workspace.
+    /// Index a particular file.
+    ///
+    /// Unless the file is `ephemeral`, it must be inside one of the registered workspaces.
     ///
     /// # Errors
     ///
     /// Returns an error if the file could not be indexed successfully.
-    async fn index_file(&self, path: &Path) -> Result<()> {
+    async fn index_file(&self, path: &Path, ephemeral: bool) -> Result<()> {
         if !path.exists() {
             return Err(Error::InvalidPath(
                 path.to_path_buf(),
@@ -126,22 +166,106 @@ impl DatabaseBackedIndexer {
             ));
         }
 
-        if !self.is_inside_workspace(path) {
+        if !ephemeral && !self.is_inside_workspace(path) {
             return Err(Error::InvalidPath(
                 path.to_path_buf(),
                 "File is not inside any registered workspace".into(),
             ));
         }
 
-        let parser::treesitter::Output { index, .. } = self
-            .parser
-            .parse(path, &parser::treesitter::Context::default())
-            .await
-            .map_err(Error::ParsingFailed)?;
+        let (parsed_symbols, translations, schema_definitions) =
+            if indexer::locale::is_locale_file(path) {
+                // Locale files aren't source code, so only the translations they define are
+                // indexed
+                let content = tokio::fs::read_to_string(path)
+                    .await
+                    .map_err(|e| Error::ParsingFailed(parser::Error::InvalidFile(e)))?;
+
+                (
+                    HashSet::new(),
+                    indexer::locale::read_translations(path, &content),
+                    Vec::new(),
+                )
+            } else if indexer::schema::is_schema_file(path) {
+                // Similarly, only the definitions in schema files are indexed, so that generated
+                // code can be linked back to them
+                let content = tokio::fs::read_to_string(path)
+                    .await
+                    .map_err(|e| Error::ParsingFailed(parser::Error::InvalidFile(e)))?;
+
+                (
+                    HashSet::new(),
+                    Vec::new(),
+                    indexer::schema::read_schema_definitions(path, &content),
+                )
+            } else {
+                let mut ctx = parser::treesitter::Context::default();
+
+                if let Some(translation_functions) = &self.translation_functions {
+                    ctx = ctx.with_translation_functions(Arc::clone(translation_functions));
+                }
+
+                let parser::treesitter::Output { index, .. } = self
+                    .parser
+                    .parse(path, &ctx)
+                    .await
+                    .map_err(Error::ParsingFailed)?;
+
+                (index.symbols, Vec::new(), Vec::new())
+            };
 
         log::trace!("Parsed file: {}", path.display());
         let now = chrono::Utc::now();
 
+        let package = self.get_package(path).await;
+
+        // Files outside of any workspace (i.e. ephemeral files) have no root to be relative to, so
+        // only their name and the directory they're in are considered, rather than every directory
+        // above them
+        let relative_path = match self.get_workspace(path) {
+            Some(workspace) => path.strip_prefix(workspace).unwrap_or(path).to_path_buf(),
+            None => path
+                .components()
+                .rev()
+                .take(2)
+                .collect::<Vec<_>>()
+                .into_iter()
+                .rev()
+                .collect::<PathBuf>(),
+        };
+
+        // Symbols which can't be recognised as test code from the syntax alone can still be
+        // recognised by the path of the file they're in
+        let is_test_file = indexer::test_harness::is_part_of_test_harness(&relative_path);
+
+        let generated = indexer::generated::is_generated_file(path, &relative_path).await;
+
+        let modified_at = tokio::fs::metadata(path)
+            .await
+            .and_then(|metadata| metadata.modified())
+            .ok()
+            .map(chrono::DateTime::<chrono::Utc>::from);
+
+        let last_commit = match self.get_workspace(path) {
+            Some(workspace) => self
+                .get_git_history(workspace)
+                .await
+                .and_then(|history| history.get_last_commit(path, modified_at).cloned()),
+            None => None,
+        };
+
+        let committed_at = last_commit.as_ref().map(|commit| commit.committed_at);
+
+        // Ephemeral files come and go as they're opened and closed, and the symbols already in a
+        // workspace when it's first indexed weren't added then, so neither are logged as changes
+        let log_changes = !ephemeral && !self.is_baseline(path);
+
+        let owners = self
+            .get_workspace(path)
+            .and_then(|workspace| self.get_code_owners(workspace))
+            .map(|code_owners| code_owners.get_owners(path))
+            .unwrap_or_default();
+
         let mut transaction = self
             .pool
             .begin()
@@ -151,15 +275,50 @@ impl DatabaseBackedIndexer {
         let file_id: i64 = {
             let path = path.to_string_lossy();
 
+            // Ephemeral files record when they were last accessed, so that they can be evicted
+            // once they've expired
+            let last_accessed_at = ephemeral.then_some(now);
+
+            let package_name = package.as_ref().map(|package| package.name.clone());
+            let package_root = package
+                .as_ref()
+                .map(|package| package.root.to_string_lossy().to_string());
+
             let (sql, values) = sea_query::Query::insert()
                 .into_table("file")
-                .columns(["path", "indexed_at"])
-                .values([path.into(), now.into()])
+                .columns([
+                    "path",
+                    "indexed_at",
+                    "ephemeral",
+                    "last_accessed_at",
+                    "package",
+                    "package_root",
+                    "generated",
+                    "modified_at",
+                    "committed_at",
+                ])
+                .values([
+                    path.into(),
+                    now.into(),
+                    ephemeral.into(),
+                    last_accessed_at.into(),
+                    package_name.clone().into(),
+                    package_root.clone().into(),
+                    generated.into(),
+                    modified_at.into(),
+                    committed_at.into(),
+                ])
                 .map_err(indexer::Error::InvalidQuerySyntax)?
                 .on_conflict(
                     OnConflict::column("path")
-                        .update_column("indexed_at")
                         .value("indexed_at", now)
+                        .value("ephemeral", ephemeral)
+                        .value("last_accessed_at", last_accessed_at)
+                        .value("package", package_name)
+                        .value("package_root", package_root)
+                        .value("generated", generated)
+                        .value("modified_at", modified_at)
+                        .value("committed_at", committed_at)
                         .to_owned(),
                 )
                 .returning(Query::returning().column(("file", "id")))
@@ -171,6 +330,60 @@ impl DatabaseBackedIndexer {
                 .map_err(Error::QueryFailed)?
         };
 
+        // Replace the owners of the file, in case they've changed since it was last indexed
+        sqlx::query(
+            &sea_query::Query::delete()
+                .from_table("file_owner")
+                .and_where(Expr::col(("file_owner", "file_id")).equals(file_id.to_string()))
+                .build_sqlx(SqliteQueryBuilder)
+                .0,
+        )
+        .execute(&mut *transaction)
+        .await
+        .map_err(indexer::Error::QueryFailed)?;
+
+        if !owners.is_empty() {
+            let mut query = sea_query::Query::insert();
+
+            query.into_table("file_owner").columns(["file_id", "owner"]);
+
+            for owner in owners {
+                query
+                    .values([file_id.into(), owner.into()])
+                    .map_err(indexer::Error::InvalidQuerySyntax)?;
+            }
+
+            let (sql, values) = query.build_sqlx(SqliteQueryBuilder);
+
+            sqlx::query_with(&sql, values)
+                .execute(&mut *transaction)
+                .await
+                .map_err(indexer::Error::QueryFailed)?;
+        }
+
+        // Read the old symbols before they're removed, so that they can be compared to the current
+        // symbols for the change log
+        let previous_symbols = if log_changes {
+            let (sql, values) = sea_query::Query::select()
+                .columns([
+                    ("symbol", "name"),
+                    ("symbol", "kind"),
+                    ("symbol", "language"),
+                ])
+                .expr_as(Expr::col(("symbol", "start_line")), "line")
+                .from("symbol")
+                .and_where(Expr::col(("symbol", "file_id")).eq(file_id))
+                .order_by(("symbol", "start_line"), sea_query::Order::Asc)
+                .build_sqlx(SqliteQueryBuilder);
+
+            sqlx::query_as_with::<_, LoggedSymbol, _>(&sql, values)
+                .fetch_all(&mut *transaction)
+                .await
+                .map_err(indexer::Error::QueryFailed)?
+        } else {
+            Vec::new()
+        };
+
         // Remove all the old symbols, before persisting all the current symbols
         sqlx::query(
             &sea_query::Query::delete()
@@ -185,13 +398,136 @@ impl DatabaseBackedIndexer {
 
         log::debug!(
             "Parsed {} symbols found in {}.",
-            index.symbols.len(),
+            parsed_symbols.len(),
             path.display()
         );
 
+        // Remove all the old translations, before persisting all the current translations
+        sqlx::query(
+            &sea_query::Query::delete()
+                .from_table("translation")
+                .and_where(Expr::col(("translation", "file_id")).equals(file_id.to_string()))
+                .build_sqlx(SqliteQueryBuilder)
+                .0,
+        )
+        .execute(&mut *transaction)
+        .await
+        .map_err(indexer::Error::QueryFailed)?;
+
+        if !translations.is_empty() {
+            let locale = indexer::locale::get_locale(path);
+
+            let mut query = sea_query::Query::insert();
+
+            query.into_table("translation").columns([
+                "file_id",
+                "key",
+                "locale",
+                "start_line",
+                "start_column",
+                "end_line",
+                "end_column",
+            ]);
+
+            for translation in translations {
+                let range = translation.range;
+
+                query
+                    .values([
+                        file_id.into(),
+                        translation.key.into(),
+                        locale.clone().into(),
+                        i32::try_from(range.start_line)
+                            .map_err(|_| indexer::Error::InvalidRange(range.clone()))?
+                            .into(),
+                        i32::try_from(range.start_column)
+                            .map_err(|_| indexer::Error::InvalidRange(range.clone()))?
+                            .into(),
+                        i32::try_from(range.end_line)
+                            .map_err(|_| indexer::Error::InvalidRange(range.clone()))?
+                            .into(),
+                        i32::try_from(range.end_column)
+                            .map_err(|_| indexer::Error::InvalidRange(range.clone()))?
+                            .into(),
+                    ])
+                    .map_err(indexer::Error::InvalidQuerySyntax)?;
+            }
+
+            let (sql, values) = query.build_sqlx(SqliteQueryBuilder);
+
+            sqlx::query_with(&sql, values)
+                .execute(&mut *transaction)
+                .await
+                .map_err(indexer::Error::QueryFailed)?;
+        }
+
+        // Remove all the old schema definitions, before persisting all the current definitions
+        sqlx::query(
+            &sea_query::Query::delete()
+                .from_table("schema_definition")
+                .and_where(Expr::col(("schema_definition", "file_id")).equals(file_id.to_string()))
+                .build_sqlx(SqliteQueryBuilder)
+                .0,
+        )
+        .execute(&mut *transaction)
+        .await
+        .map_err(indexer::Error::QueryFailed)?;
+
+        if !schema_definitions.is_empty() {
+            let mut query = sea_query::Query::insert();
+
+            query.into_table("schema_definition").columns([
+                "file_id",
+                "name",
+                "kind",
+                "start_line",
+                "start_column",
+                "end_line",
+                "end_column",
+            ]);
+
+            for definition in schema_definitions {
+                let range = definition.range;
+
+                query
+                    .values([
+                        file_id.into(),
+                        definition.name.into(),
+                        definition.kind.to_string().into(),
+                        i32::try_from(range.start_line)
+                            .map_err(|_| indexer::Error::InvalidRange(range.clone()))?
+                            .into(),
+                        i32::try_from(range.start_column)
+                            .map_err(|_| indexer::Error::InvalidRange(range.clone()))?
+                            .into(),
+                        i32::try_from(range.end_line)
+                            .map_err(|_| indexer::Error::InvalidRange(range.clone()))?
+                            .into(),
+                        i32::try_from(range.end_column)
+                            .map_err(|_| indexer::Error::InvalidRange(range.clone()))?
+                            .into(),
+                    ])
+                    .map_err(indexer::Error::InvalidQuerySyntax)?;
+            }
+
+            let (sql, values) = query.build_sqlx(SqliteQueryBuilder);
+
+            sqlx::query_with(&sql, values)
+                .execute(&mut *transaction)
+                .await
+                .map_err(indexer::Error::QueryFailed)?;
+        }
+
         let mut symbols = 0;
-        for symbol in index.symbols {
-            let Some(definition) = symbol.definition else {
+        let mut current_symbols = Vec::new();
+        for mut symbol in parsed_symbols {
+            // Symbols which are only ever referenced (i.e. reads of an environment variable) are
+            // indexed at their first occurrence instead
+            let definition = symbol.definition.take().or_else(|| {
+                (!symbol.occurrences.is_empty()).then(|| symbol.occurrences.swap_remove(0))
+            });
+
+            let Some(definition) = definition else {
                 log::warn!("Symbol {} has no definition, skipping", symbol.name);
 
                 continue;
@@ -212,6 +548,20 @@ impl DatabaseBackedIndexer {
                 definition.absolute_path.display(),
             );
 
+            let access = if definition
+                .roles
+                .contains(&models::parsed::SymbolRole::WriteAccess)
+            {
+                Some(models::resolved::Access::Write)
+            } else if definition
+                .roles
+                .contains(&models::parsed::SymbolRole::ReadAccess)
+            {
+                Some(models::resolved::Access::Read)
+            } else {
+                None
+            };
+
             let range = &definition.range;
 
             let start_line: i32 = i32::try_from(range.start_line)
@@ -224,6 +574,15 @@ impl DatabaseBackedIndexer {
             let end_column: i32 = i32::try_from(range.end_column)
                 .map_err(|_| indexer::Error::InvalidRange(range.clone()))?;
 
+            if log_changes {
+                current_symbols.push(LoggedSymbol {
+                    name: symbol.name.clone(),
+                    kind: symbol.kind.to_string(),
+                    language: definition.language.to_string(),
+                    line: start_line.into(),
+                });
+            }
+
             let (sql, values) = sea_query::Query::insert()
                 .into_table("symbol")
                 .columns([
@@ -235,6 +594,11 @@ impl DatabaseBackedIndexer {
                     "end_line",
                     "end_column",
                     "language",
+                    "test",
+                    "deprecated",
+                    "deprecation_message",
+                    "signature",
+                    "access",
                     "indexed_at",
                 ])
                 .values([
@@ -246,6 +610,11 @@ impl DatabaseBackedIndexer {
                     end_line.into(),
                     end_column.into(),
                     definition.language.to_string().into(),
+                    (symbol.test || is_test_file).into(),
+                    symbol.deprecated.into(),
+                    symbol.deprecation_message.into(),
+                    symbol.signature.into(),
+                    access.map(|access| access.to_string()).into(),
                     now.into(),
                 ])
                 .map_err(indexer::Error::InvalidQuerySyntax)?
@@ -260,6 +629,19 @@ impl DatabaseBackedIndexer {
             symbols += 1;
         }
 
+        if log_changes {
+            let changes = change_log::get_changes(&previous_symbols, &current_symbols);
+
+            change_log::record_changes(
+                &mut transaction,
+                &path.to_string_lossy(),
+                &changes,
+                last_commit.as_ref(),
+                now,
+            )
+            .await?;
+        }
+
         // TODO: File bloom filter here?
         transaction
             .commit()
@@ -270,114 +652,105 @@ impl DatabaseBackedIndexer {
 
         Ok(())
     }
-}
-
-impl Indexer for DatabaseBackedIndexer {
-    /// Get the list of workspaces currently being managed by the indexer.
-    fn get_workspaces(&self) -> Vec<Arc<PathBuf>> {
-        self.workspaces.clone()
-    }
-
-    fn is_inside_workspace(&self, path: &Path) -> bool {
-        self.workspaces
-            .iter()
-            .any(|workspace| path.starts_with(workspace.as_ref()))
-    }
 
-    /// Run indexing on all relevant files in all workspaces.
+    /// Index a particular file, or all the relevant files inside a folder.
     ///
     /// # Errors
     ///
-    /// Returns a list of errors for each workspace which could not be successfully indexed.
-    async fn index_workspaces(&self) -> std::result::Result<(), Vec<indexer::Error>> {
-        let mut errors = vec![];
-        for workspace in &*self.workspaces {
-            // TODO: For indexes that already exist this will prove to be inefficient. We should
-            // hash the file content and only the parts of the workspace which have not changed.
-            // Currently, this will fully re-index the workspace even if no files have changed.
-            if let Err(e) = self.index(workspace.as_path()).await {
-                errors.push(e);
-            }
-        }
-
-        if !errors.is_empty() {
-            return Err(errors);
-        }
+    /// Returns an error if the folder could not be successfully indexed.
+    async fn index_path(&self, path: &Path) -> Result<()> {
+        let files: Box<dyn Iterator<Item = std::result::Result<PathBuf, _>> + Send> = if path
+            .is_dir()
+        {
+            // If it's a directory, we need to walk the directory and find all relevant files to
+            // index, based on the supported file extensions
+            let mut types = ignore::types::TypesBuilder::new();
+            for language in Language::iter() {
+                let file_extension = &*FileExtension::from(language);
+
+                if let Err(e) = types.add(file_extension, &format!("*.{file_extension}")) {
+                    log::error!(
+                        "File extension ({file_extension}) could not be added to indexer: {e}"
+                    );
+
+                    continue;
+                }
 
-        Ok(())
-    }
+                types.select(file_extension);
+            }
 
-    /// Index a particular file, or folder, inside a workspace.
-    ///
-    /// # Errors
-    ///
-    /// Returns an error if the folder could not be successfully indexed.
-    async fn index(&self, path: &Path) -> Result<()> {
-        if !path.exists() {
-            return Err(Error::InvalidPath(
-                path.to_path_buf(),
-                "Path does not exist".into(),
-            ));
-        }
+            // Dotenv files are named by convention, rather than by their extension
+            for glob in [".env", ".env.*"] {
+                if let Err(e) = types.add("dotenv", glob) {
+                    log::error!("Dotenv files ({glob}) could not be added to indexer: {e}");
+                }
+            }
 
-        if !self.is_inside_workspace(path) {
-            return Err(Error::InvalidPath(
-                path.to_path_buf(),
-                "Path is not inside any registered workspace".into(),
-            ));
-        }
+            types.select("dotenv");
 
-        let files: Box<dyn Iterator<Item = std::result::Result<PathBuf, _>> + Send> =
-            if path.is_dir() {
-                // If it's a directory, we need to walk the directory and find all relevant files to
-                // index, based on the supported file extensions
-                let mut types = ignore::types::TypesBuilder::new();
-                for language in Language::iter() {
-                    let file_extension = &*FileExtension::from(language);
+            // Locale and schema files aren't source code, but are still indexed. Locale files are
+            // only a subset of JSON and YAML files, so are filtered further once found
+            for extension in indexer::locale::LOCALE_EXTENSIONS
+                .into_iter()
+                .chain(indexer::schema::SCHEMA_EXTENSIONS)
+            {
+                if let Err(e) = types.add(extension, &format!("*.{extension}")) {
+                    log::error!("File extension ({extension}) could not be added to indexer: {e}");
 
-                    if let Err(e) = types.add(file_extension, &format!("*.{file_extension}")) {
-                        log::error!(
-                            "File extension ({file_extension}) could not be added to indexer: {e}"
-                        );
+                    continue;
+                }
 
-                        continue;
-                    }
+                types.select(extension);
+            }
 
-                    types.select(file_extension);
+            let types = types.build().expect("Failed to build ignore types");
+
+            let walker = ignore::WalkBuilder::new(path)
+                .types(types)
+                .git_global(true)
+                .ignore_case_insensitive(true)
+                // This prevents files from nested directories being indexed when not tracked
+                // by git (usually as part of a full index run).
+                //
+                // There's similar logic (handled by the `ignored` crate) in the Watcher, which
+                // filters out individual filesystem events for files which are matched by `.gitignore`.
+                .git_ignore(true)
+                .git_exclude(true)
+                // By default ignore will only observe `.gitignore` files if in a git repository unless we explicitly
+                // don't require git.
+                //
+                // If we don't do this, it can lead to unexpected scenarios where files are indexed
+                // which are part of `.gitignore` simply because the repository hasn't yet been
+                // initialised.
+                .require_git(false)
+                // Hidden files are still skipped, other than dotenv files (i.e. `.env.example`)
+                .hidden(false)
+                .filter_entry(|entry| {
+                    entry.depth() == 0
+                        || entry
+                            .file_name()
+                            .to_str()
+                            .is_none_or(|name| !name.starts_with('.'))
+                        || models::parsed::is_dotenv_file(entry.path())
+                })
+                .build();
+
+            Box::new(walker.into_iter().filter_map(|entry| match entry {
+                Ok(entry) if entry.metadata().is_ok_and(|m| m.is_file()) => {
+                    let path = entry.into_path();
+
+                    (Language::try_from(path.as_path()).is_ok()
+                        || indexer::locale::is_locale_file(&path)
+                        || indexer::schema::is_schema_file(&path))
+                    .then_some(Ok(path))
                 }
-                let types = types.build().expect("Failed to build ignore types");
-
-                let walker = ignore::WalkBuilder::new(path)
-                    .types(types)
-                    .git_global(true)
-                    .ignore_case_insensitive(true)
-                    // This prevents files from nested directories being indexed when not tracked
-                    // by git (usually as part of a full index run).
-                    //
-                    // There's similar logic (handled by the `ignored` crate) in the Watcher, which
-                    // filters out individual filesystem events for files which are matched by `.gitignore`.
-                    .git_ignore(true)
-                    .git_exclude(true)
-                    // By default ignore will only observe `.gitignore` files if in a git repository unless we explicitly
-                    // don't require git.
-                    //
-                    // If we don't do this, it can lead to unexpected scenarios where files are indexed
-                    // which are part of `.gitignore` simply because the repository hasn't yet been
-                    // initialised.
-                    .require_git(false)
-                    .build();
-
-                Box::new(walker.into_iter().filter_map(|entry| match entry {
-                    Ok(entry) if entry.metadata().is_ok_and(|m| m.is_file()) => {
-                        Some(Ok(entry.into_path()))
-                    }
-                    Ok(_) => None,
-                    Err(e) => Some(Err(e)),
-                }))
-            } else {
-                // If it's a file, we can short-circuit and just index that single file
-                Box::new(iter::once(Ok(path.to_path_buf())))
-            };
+                Ok(_) => None,
+                Err(e) => Some(Err(e)),
+            }))
+        } else {
+            // If it's a file, we can short-circuit and just index that single file
+            Box::new(iter::once(Ok(path.to_path_buf())))
+        };
 
         let mut tasks = JoinSet::<()>::new();
 
@@ -387,7 +760,7 @@ impl Indexer for DatabaseBackedIndexer {
                     let indexer = self.clone();
 
                     tasks.spawn(async move {
-                        if let Err(e) = indexer.index_file(entry.as_path()).await {
+                        if let Err(e) = indexer.index_file(entry.as_path(), false).await {
                             log::error!("Error indexing file {}: {e:?}", entry.display());
                         }
                     });
@@ -400,98 +773,795 @@ impl Indexer for DatabaseBackedIndexer {
 
         tasks.join_all().await;
 
+        self.prune_change_log().await;
+
         Ok(())
     }
 
-    /// De-index a particular file, or folder, in a workspace.
-    ///
-    /// Usually, this is necessary when a previously indexed file is deleted.
+    /// Get the registered workspace which contains a particular path, if there is one.
+    fn get_workspace(&self, path: &Path) -> Option<&Path> {
+        self.workspaces
+            .iter()
+            .find(|workspace| path.starts_with(workspace.as_ref()))
+            .map(|workspace| workspace.as_path())
+    }
+
+    /// Get the ownership rules (from the `CODEOWNERS` file) for a particular workspace.
     ///
-    /// # Errors
+    /// The rules are cached, so that the `CODEOWNERS` file is only read once for each workspace.
+    fn get_code_owners(&self, workspace: &Path) -> Option<Arc<CodeOwners>> {
+        self.code_owners
+            .lock()
+            .unwrap_or_else(PoisonError::into_inner)
+            .entry(workspace.to_path_buf())
+            .or_insert_with(|| CodeOwners::load(workspace).map(Arc::new))
+            .clone()
+    }
+
+    /// Check if a path is a `CODEOWNERS` file and, if it is, clear the cached ownership rules
+    /// for its workspace.
     ///
-    /// Returns an error if the file could not be de-indexed successfully.
-    async fn deindex(&self, path: &Path) -> Result<()> {
-        let path_pattern = format!("{}%", path.display());
+    /// Returns the workspace the ownership rules were cleared for.
+    fn invalidate_code_owners(&self, path: &Path) -> Option<&Path> {
+        if path
+            .file_name()
+            .is_none_or(|filename| filename != "CODEOWNERS")
+        {
+            return None;
+        }
 
-        let (sql, values) = sea_query::Query::delete()
-            .from_table("file")
-            .and_where(Expr::col(("file", "path")).like(path_pattern))
-            .build_sqlx(SqliteQueryBuilder);
+        let workspace = self.get_workspace(path)?;
 
-        // Removing the file will trigger a removal of any associated symbols as the FK
-        // is set to cascade delete
-        sqlx::query_with(&sql, values)
-            .execute(&self.pool)
-            .await
-            .map_err(indexer::Error::QueryFailed)?;
+        self.code_owners
+            .lock()
+            .unwrap_or_else(PoisonError::into_inner)
+            .remove(workspace);
 
-        Ok(())
+        Some(workspace)
     }
-}
 
-#[cfg(test)]
-mod tests {
-    use std::path::PathBuf;
+    /// Get the package which owns a file.
+    ///
+    /// Packages are cached for the directory the file is in, so that indexing many files in
+    /// the same directory only walks up (and reads) the manifests above them once.
+    async fn get_package(&self, path: &Path) -> Option<Package> {
+        let directory = path.parent()?.to_path_buf();
+
+        if let Some(package) = self
+            .packages
+            .lock()
+            .unwrap_or_else(PoisonError::into_inner)
+            .get(&directory)
+        {
+            return package.clone();
+        }
 
-    use insta::assert_json_snapshot;
-    use tempfile::tempdir;
-    use tokio::{
-        fs::{self, File},
-        io::AsyncWriteExt,
-    };
-    use tokio_stream::StreamExt;
+        let file = path.to_path_buf();
+        let boundary = self.get_workspace(path).map(Path::to_path_buf);
 
-    use crate::{
-        indexer::Indexer,
-        models,
-        resolver::{self, Resolver},
-    };
+        // Detecting the package means checking for manifests in every directory above the file,
+        // so it's kept off of the async runtime
+        let package = tokio::task::spawn_blocking(move || {
+            indexer::detect_package(&file, boundary.as_deref())
+        })
+        .await
+        .unwrap_or_default();
 
-    #[tokio::test]
-    pub async fn test_indexing_project() {
-        let storage_path = tempdir()
-            .expect("Should never fail when creating a temporary path for testing indexing");
+        self.packages
+            .lock()
+            .unwrap_or_else(PoisonError::into_inner)
+            .insert(directory, package.clone());
 
-        let fixtures = PathBuf::from("tests/fixtures/");
+        package
+    }
 
-        let workspaces = vec![fixtures.as_path()];
+    /// Check if a path is a package manifest and, if it is, clear the cached packages for every
+    /// directory below it.
+    ///
+    /// Returns the directory the packages were cleared for.
+    fn invalidate_packages<'a>(&self, path: &'a Path) -> Option<&'a Path> {
+        if !indexer::package::is_manifest_file(path) {
+            return None;
+        }
 
-        let indexer = super::DatabaseBackedIndexer::new(storage_path.path(), workspaces.clone())
-            .await
-            .expect("Should be able to create the empty index");
+        let root = path.parent()?;
 
-        let resolver =
-            resolver::DatabaseBackedResolver::new(storage_path.path(), workspaces.clone());
+        self.packages
+            .lock()
+            .unwrap_or_else(PoisonError::into_inner)
+            .retain(|directory, _| !directory.starts_with(root));
 
-        assert!(indexer.index_workspaces().await.is_ok());
+        Some(root)
+    }
 
-        let mut resolved_symbols: Vec<models::resolved::ResolvedSymbol> = resolver
-            .query(String::new(), resolver::Context::default())
-            .collect()
-            .await;
+    /// Check if any (non-ephemeral) files inside a workspace have already been indexed.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the index could not be queried.
+    async fn is_indexed(&self, workspace: &Path) -> Result<bool> {
+        let (sql, values) = sea_query::Query::select()
+            .column(("file", "id"))
+            .from("file")
+            .and_where(Expr::col(("file", "path")).like(format!("{}%", workspace.display())))
+            .and_where(Expr::col(("file", "ephemeral")).eq(false))
+            .limit(1)
+            .build_sqlx(SqliteQueryBuilder);
 
-        // The order of symbols is not guaranteed, so we need the sort symbols to keep the
-        // snapshot predictable
-        resolved_symbols.sort_unstable();
+        let file_id = sqlx::query_scalar_with::<_, i64, _>(&sql, values)
+            .fetch_optional(&self.pool)
+            .await
+            .map_err(indexer::Error::QueryFailed)?;
 
-        assert_json_snapshot!(
-            resolved_symbols,
-            {"[].id" => 0} // IDs are non-deterministic, so just blank them out
-        );
+        Ok(file_id.is_some())
     }
 
-    #[tokio::test]
-    pub async fn test_deindexing_files_in_project() {
-        let storage_path = tempdir()
-            .expect("Should never fail when creating a temporary path for testing indexing");
+    /// Check if a path is in a workspace which is being indexed for the first time, in which case
+    /// its symbols are the baseline the change log starts from.
+    fn is_baseline(&self, path: &Path) -> bool {
+        self.get_workspace(path).is_some_and(|workspace| {
+            self.baseline_workspaces
+                .lock()
+                .unwrap_or_else(PoisonError::into_inner)
+                .contains(workspace)
+        })
+    }
 
-        let fixtures = PathBuf::from("tests/fixtures/");
+    /// Prune the change log of symbols, according to its retention policy.
+    ///
+    /// Failing to prune the change log isn't fatal (as it will be pruned again after the next
+    /// change), so errors are only logged.
+    async fn prune_change_log(&self) {
+        match change_log::prune(
+            &self.pool,
+            self.change_log_max_age,
+            self.change_log_max_entries,
+        )
+        .await
+        {
+            Ok(pruned) if pruned > 0 => {
+                log::debug!("Pruned {pruned} changes from the change log.");
+            }
+            Ok(_) => {}
+            Err(e) => log::error!("Unable to prune the change log: {e}"),
+        }
+    }
 
-        let workspaces = vec![fixtures.as_path()];
+    /// Get the git history of a particular workspace, if reading git history is enabled and the
+    /// workspace is in a git repository.
+    ///
+    /// The history is cached, so that it's only read once for each workspace (until the next full
+    /// index).
+    async fn get_git_history(&self, workspace: &Path) -> Option<Arc<GitHistory>> {
+        let mut git_history = self.git_history.as_ref()?.lock().await;
 
-        let indexer = super::DatabaseBackedIndexer::new(storage_path.path(), workspaces.clone())
-            .await
-            .expect("Should be able to create the empty index");
+        if let Some(history) = git_history.get(workspace) {
+            return history.clone();
+        }
+
+        let history = GitHistory::load(workspace).await.map(Arc::new);
+
+        git_history.insert(workspace.to_path_buf(), history.clone());
+
+        history
+    }
+
+    /// Read the local git history of each workspace, so that the time of the last commit which
+    /// changed each file can be used (alongside its modification time) to favour recently
+    /// changed symbols.
+    ///
+    /// The last commit is also recorded alongside the changes to symbols in the change log, when
+    /// the file has no uncommitted changes.
+    ///
+    /// Reading the history requires `git` to be installed, and is skipped for workspaces which
+    /// aren't in a git repository.
+    ///
+    /// See [`crate::resolver::Context::with_recency_half_life`].
+    #[must_use]
+    pub fn with_git_history(mut self) -> Self {
+        self.git_history = Some(Arc::default());
+
+        self
+    }
+
+    /// Set how long changes to symbols are kept in the change log, and the maximum number of
+    /// changes it keeps, beyond which the oldest changes are pruned.
+    ///
+    /// Defaults to [`constant::DEFAULT_CHANGE_LOG_MAX_AGE_SECS`] and
+    /// [`constant::DEFAULT_MAX_CHANGE_LOG_ENTRIES`].
+    ///
+    /// See [`crate::resolver::HistoryResolver`].
+    #[must_use]
+    pub fn with_change_log_retention(mut self, max_age: Duration, max_entries: u64) -> Self {
+        self.change_log_max_age = max_age;
+        self.change_log_max_entries = max_entries;
+
+        self
+    }
+
+    /// Set the functions which translation keys are passed to (i.e. `t` or `i18n.t`), so that
+    /// translation keys can be linked to the locale files which define them.
+    ///
+    /// Defaults to [`parser::treesitter::DEFAULT_TRANSLATION_FUNCTIONS`].
+    #[must_use]
+    pub fn with_translation_functions(
+        mut self,
+        functions: impl IntoIterator<Item = impl Into<String>>,
+    ) -> Self {
+        self.translation_functions =
+            Some(Arc::new(functions.into_iter().map(Into::into).collect()));
+
+        self
+    }
+
+    /// Set how long ephemeral files (files indexed from outside any registered workspace) are
+    /// kept for, after they were last accessed.
+    ///
+    /// Expired ephemeral files are evicted whenever another ephemeral file is indexed, and
+    /// periodically by a running [`crate::watcher::Watcher`]. Files are accessed whenever they're
+    /// indexed, or marked as accessed (see [`Indexer::touch_ephemeral`]).
+    ///
+    /// Defaults to one hour.
+    #[must_use]
+    pub fn with_ephemeral_file_ttl(mut self, ttl: Duration) -> Self {
+        self.ephemeral_file_ttl = ttl;
+
+        self
+    }
+
+    /// Rebuild the index from a dump (see [`crate::dump`]), replacing everything currently in
+    /// it.
+    ///
+    /// The records are loaded as they were dumped, so the files in the dump needn't exist, or be
+    /// inside the registered workspaces (though the watcher will re-index any which change).
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the dump is invalid, or was written with an unsupported version of the
+    /// format. In which case, the index is left unchanged.
+    pub async fn load(&self, reader: impl AsyncBufRead + Unpin) -> dump::Result<dump::DumpSummary> {
+        let mut records = dump::RecordReader::new(reader).await?;
+
+        let now = chrono::Utc::now();
+
+        let mut transaction = self.pool.begin().await?;
+
+        for table in [
+            "file_owner",
+            "symbol",
+            "translation",
+            "schema_definition",
+            "file",
+        ] {
+            sqlx::query(
+                &sea_query::Query::delete()
+                    .from_table(table)
+                    .build_sqlx(SqliteQueryBuilder)
+                    .0,
+            )
+            .execute(&mut *transaction)
+            .await?;
+        }
+
+        let mut file_ids = HashMap::new();
+        let mut summary = dump::DumpSummary::default();
+
+        while let Some((line, record)) = records.next().await? {
+            let get_file_id = |file_ids: &HashMap<PathBuf, i64>, path: &Path| {
+                file_ids
+                    .get(path)
+                    .copied()
+                    .ok_or_else(|| dump::Error::UndeclaredFile(line, path.to_path_buf()))
+            };
+
+            let statement = match &record {
+                dump::Record::Header { .. } => None,
+                dump::Record::File(file) => {
+                    file_ids.insert(
+                        file.path.clone(),
+                        Self::load_file(&mut transaction, file, now).await?,
+                    );
+
+                    None
+                }
+                dump::Record::Symbol(symbol) => Some(
+                    sea_query::Query::insert()
+                        .into_table("symbol")
+                        .columns([
+                            "kind",
+                            "name",
+                            "file_id",
+                            "start_line",
+                            "start_column",
+                            "end_line",
+                            "end_column",
+                            "language",
+                            "test",
+                            "deprecated",
+                            "deprecation_message",
+                            "signature",
+                            "access",
+                            "indexed_at",
+                        ])
+                        .values([
+                            symbol.kind.to_string().into(),
+                            symbol.name.clone().into(),
+                            get_file_id(&file_ids, &symbol.path)?.into(),
+                            symbol.start_line.into(),
+                            symbol.start_column.into(),
+                            symbol.end_line.into(),
+                            symbol.end_column.into(),
+                            symbol.language.to_string().into(),
+                            symbol.test.into(),
+                            symbol.deprecated.into(),
+                            symbol.deprecation_message.clone().into(),
+                            symbol.signature.clone().into(),
+                            symbol.access.map(|access| access.to_string()).into(),
+                            now.into(),
+                        ])?
+                        .build_sqlx(SqliteQueryBuilder),
+                ),
+                dump::Record::Translation(translation) => Some(
+                    sea_query::Query::insert()
+                        .into_table("translation")
+                        .columns([
+                            "file_id",
+                            "key",
+                            "locale",
+                            "start_line",
+                            "start_column",
+                            "end_line",
+                            "end_column",
+                        ])
+                        .values([
+                            get_file_id(&file_ids, &translation.path)?.into(),
+                            translation.key.clone().into(),
+                            translation.locale.clone().into(),
+                            translation.start_line.into(),
+                            translation.start_column.into(),
+                            translation.end_line.into(),
+                            translation.end_column.into(),
+                        ])?
+                        .build_sqlx(SqliteQueryBuilder),
+                ),
+                dump::Record::SchemaDefinition(definition) => Some(
+                    sea_query::Query::insert()
+                        .into_table("schema_definition")
+                        .columns([
+                            "file_id",
+                            "name",
+                            "kind",
+                            "start_line",
+                            "start_column",
+                            "end_line",
+                            "end_column",
+                        ])
+                        .values([
+                            get_file_id(&file_ids, &definition.path)?.into(),
+                            definition.name.clone().into(),
+                            definition.kind.to_string().into(),
+                            definition.start_line.into(),
+                            definition.start_column.into(),
+                            definition.end_line.into(),
+                            definition.end_column.into(),
+                        ])?
+                        .build_sqlx(SqliteQueryBuilder),
+                ),
+            };
+
+            if let Some((sql, values)) = statement {
+                sqlx::query_with(&sql, values)
+                    .execute(&mut *transaction)
+                    .await?;
+            }
+
+            summary.add(&record);
+        }
+
+        transaction.commit().await?;
+
+        log::info!(
+            "Loaded {} files and {} symbols from dump",
+            summary.files,
+            summary.symbols
+        );
+
+        Ok(summary)
+    }
+
+    /// Load a file (and its owners) from a dump into the index.
+    ///
+    /// Returns the ID of the file.
+    async fn load_file(
+        transaction: &mut sqlx::Transaction<'_, sqlx::Sqlite>,
+        file: &dump::File,
+        now: chrono::DateTime<chrono::Utc>,
+    ) -> dump::Result<i64> {
+        let (sql, values) = sea_query::Query::insert()
+            .into_table("file")
+            .columns([
+                "path",
+                "indexed_at",
+                "ephemeral",
+                "last_accessed_at",
+                "package",
+                "package_root",
+                "generated",
+            ])
+            .values([
+                file.path.to_string_lossy().into(),
+                now.into(),
+                file.ephemeral.into(),
+                file.ephemeral.then_some(now).into(),
+                file.package.clone().into(),
+                file.package_root.clone().into(),
+                file.generated.into(),
+            ])?
+            .returning(Query::returning().column(("file", "id")))
+            .build_sqlx(SqliteQueryBuilder);
+
+        let file_id = sqlx::query_scalar_with::<_, i64, _>(&sql, values)
+            .fetch_one(&mut **transaction)
+            .await?;
+
+        if !file.owners.is_empty() {
+            let mut query = sea_query::Query::insert();
+
+            query.into_table("file_owner").columns(["file_id", "owner"]);
+
+            for owner in file.owners.iter() {
+                query.values([file_id.into(), owner.clone().into()])?;
+            }
+
+            let (sql, values) = query.build_sqlx(SqliteQueryBuilder);
+
+            sqlx::query_with(&sql, values)
+                .execute(&mut **transaction)
+                .await?;
+        }
+
+        Ok(file_id)
+    }
+
+    /// Mark a previously indexed ephemeral file as accessed at the given time.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the file could not be marked as accessed.
+    async fn touch_ephemeral_at(
+        &self,
+        path: &Path,
+        accessed_at: chrono::DateTime<chrono::Utc>,
+    ) -> Result<()> {
+        let (sql, values) = sea_query::Query::update()
+            .table("file")
+            .value("last_accessed_at", accessed_at)
+            .and_where(Expr::col(("file", "path")).eq(path.to_string_lossy().to_string()))
+            .and_where(Expr::col(("file", "ephemeral")).eq(true))
+            .build_sqlx(SqliteQueryBuilder);
+
+        sqlx::query_with(&sql, values)
+            .execute(&self.pool)
+            .await
+            .map_err(indexer::Error::QueryFailed)?;
+
+        Ok(())
+    }
+
+    /// Evict every ephemeral file which, as of the given time, has not been accessed within the
+    /// given time-to-live.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the expired files could not be evicted successfully.
+    async fn evict_expired_ephemeral_at(
+        &self,
+        ttl: Duration,
+        now: chrono::DateTime<chrono::Utc>,
+    ) -> Result<u64> {
+        let expires_before = chrono::TimeDelta::from_std(ttl)
+            .ok()
+            .and_then(|ttl| now.checked_sub_signed(ttl))
+            .unwrap_or(chrono::DateTime::<chrono::Utc>::MIN_UTC);
+
+        let (sql, values) = sea_query::Query::delete()
+            .from_table("file")
+            .and_where(Expr::col(("file", "ephemeral")).eq(true))
+            .and_where(Expr::col(("file", "last_accessed_at")).lt(expires_before))
+            .build_sqlx(SqliteQueryBuilder);
+
+        let result = sqlx::query_with(&sql, values)
+            .execute(&self.pool)
+            .await
+            .map_err(indexer::Error::QueryFailed)?;
+
+        Ok(result.rows_affected())
+    }
+}
+
+impl Indexer for DatabaseBackedIndexer {
+    /// Get the list of workspaces currently being managed by the indexer.
+    fn get_workspaces(&self) -> Vec<Arc<PathBuf>> {
+        self.workspaces.clone()
+    }
+
+    fn is_inside_workspace(&self, path: &Path) -> bool {
+        self.workspaces
+            .iter()
+            .any(|workspace| path.starts_with(workspace.as_ref()))
+    }
+
+    /// Run indexing on all relevant files in all workspaces.
+    ///
+    /// # Errors
+    ///
+    /// Returns a list of errors for each workspace which could not be successfully indexed.
+    async fn index_workspaces(&self) -> std::result::Result<(), Vec<indexer::Error>> {
+        if let Some(git_history) = &self.git_history {
+            // Commits could have been made since the history was last read
+            git_history.lock().await.clear();
+        }
+
+        let mut errors = vec![];
+        for workspace in &*self.workspaces {
+            let baseline = match self.is_indexed(workspace).await {
+                Ok(indexed) => !indexed,
+                Err(e) => {
+                    errors.push(e);
+
+                    continue;
+                }
+            };
+
+            if baseline {
+                self.baseline_workspaces
+                    .lock()
+                    .unwrap_or_else(PoisonError::into_inner)
+                    .insert(workspace.to_path_buf());
+            }
+
+            // TODO: For indexes that already exist this will prove to be inefficient. We should
+            // hash the file content and only the parts of the workspace which have not changed.
+            // Currently, this will fully re-index the workspace even if no files have changed.
+            if let Err(e) = self.index(workspace.as_path()).await {
+                errors.push(e);
+            }
+
+            if baseline {
+                self.baseline_workspaces
+                    .lock()
+                    .unwrap_or_else(PoisonError::into_inner)
+                    .remove(workspace.as_path());
+            }
+        }
+
+        if !errors.is_empty() {
+            return Err(errors);
+        }
+
+        Ok(())
+    }
+
+    /// Index a particular file, or folder, inside a workspace.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the folder could not be successfully indexed.
+    async fn index(&self, path: &Path) -> Result<()> {
+        if !path.exists() {
+            return Err(Error::InvalidPath(
+                path.to_path_buf(),
+                "Path does not exist".into(),
+            ));
+        }
+
+        if !self.is_inside_workspace(path) {
+            return Err(Error::InvalidPath(
+                path.to_path_buf(),
+                "Path is not inside any registered workspace".into(),
+            ));
+        }
+
+        if let Some(workspace) = self.invalidate_code_owners(path) {
+            // Ownership could have changed for any file in the workspace, so the whole workspace
+            // needs to be re-indexed
+            return self.index_path(workspace).await;
+        }
+
+        if let Some(root) = self.invalidate_packages(path) {
+            // Every file below the manifest could now belong to a different package
+            return self.index_path(root).await;
+        }
+
+        self.index_path(path).await
+    }
+
+    /// De-index a particular file, or folder, in a workspace.
+    ///
+    /// Usually, this is necessary when a previously indexed file is deleted.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the file could not be de-indexed successfully.
+    async fn deindex(&self, path: &Path) -> Result<()> {
+        if let Some(workspace) = self.invalidate_code_owners(path) {
+            // Without a CODEOWNERS file, every file in the workspace needs its ownership removing
+            return self.index_path(workspace).await;
+        }
+
+        if let Some(root) = self.invalidate_packages(path) {
+            // Without the manifest, files below it belong to whichever package is above it
+            return self.index_path(root).await;
+        }
+
+        let path_pattern = format!("{}%", path.display());
+
+        let mut transaction = self
+            .pool
+            .begin()
+            .await
+            .map_err(indexer::Error::QueryFailed)?;
+
+        change_log::record_removed_files(&mut transaction, &path_pattern, chrono::Utc::now())
+            .await?;
+
+        let (sql, values) = sea_query::Query::delete()
+            .from_table("file")
+            .and_where(Expr::col(("file", "path")).like(path_pattern))
+            // Ephemeral files are only ever removed through eviction, so that workspace-wide
+            // changes never affect them
+            .and_where(Expr::col(("file", "ephemeral")).eq(false))
+            .build_sqlx(SqliteQueryBuilder);
+
+        // Removing the file will trigger a removal of any associated symbols as the FK
+        // is set to cascade delete
+        sqlx::query_with(&sql, values)
+            .execute(&mut *transaction)
+            .await
+            .map_err(indexer::Error::QueryFailed)?;
+
+        transaction
+            .commit()
+            .await
+            .map_err(indexer::Error::QueryFailed)?;
+
+        self.prune_change_log().await;
+
+        Ok(())
+    }
+
+    /// Index a single file on demand, even if it is outside every registered workspace.
+    ///
+    /// Before indexing, any other ephemeral files which have expired are evicted.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the file could not be indexed successfully.
+    async fn index_ephemeral(&self, path: &Path) -> Result<()> {
+        if self.is_inside_workspace(path) {
+            // Files inside a workspace are already tracked (and kept up to date) as part of the
+            // workspace, so there's no need to treat them as ephemeral
+            return self.index_file(path, false).await;
+        }
+
+        let evicted = self
+            .evict_expired_ephemeral(self.ephemeral_file_ttl)
+            .await?;
+
+        if evicted > 0 {
+            log::debug!("Evicted {evicted} expired ephemeral files.");
+        }
+
+        self.index_file(path, true).await
+    }
+
+    /// Mark a previously indexed ephemeral file as accessed, refreshing when it expires.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the file could not be marked as accessed.
+    async fn touch_ephemeral(&self, path: &Path) -> Result<()> {
+        self.touch_ephemeral_at(path, chrono::Utc::now()).await
+    }
+
+    /// Evict a previously indexed ephemeral file, usually when it has been closed.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the file could not be evicted successfully.
+    async fn evict_ephemeral(&self, path: &Path) -> Result<()> {
+        let (sql, values) = sea_query::Query::delete()
+            .from_table("file")
+            .and_where(Expr::col(("file", "path")).eq(path.to_string_lossy().to_string()))
+            .and_where(Expr::col(("file", "ephemeral")).eq(true))
+            .build_sqlx(SqliteQueryBuilder);
+
+        sqlx::query_with(&sql, values)
+            .execute(&self.pool)
+            .await
+            .map_err(indexer::Error::QueryFailed)?;
+
+        Ok(())
+    }
+
+    /// Evict every ephemeral file which has not been accessed within the given time-to-live.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the expired files could not be evicted successfully.
+    async fn evict_expired_ephemeral(&self, ttl: Duration) -> Result<u64> {
+        self.evict_expired_ephemeral_at(ttl, chrono::Utc::now())
+            .await
+    }
+
+    fn get_ephemeral_file_ttl(&self) -> Duration {
+        self.ephemeral_file_ttl
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use std::{path::PathBuf, time::Duration};
+
+    use insta::assert_json_snapshot;
+    use tempfile::tempdir;
+    use tokio::{
+        fs::{self, File},
+        io::AsyncWriteExt,
+    };
+    use tokio_stream::StreamExt;
+
+    use crate::{
+        indexer::Indexer,
+        models,
+        resolver::{self, Resolver},
+    };
+
+    #[tokio::test]
+    pub async fn test_indexing_project() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let fixtures = PathBuf::from("tests/fixtures/");
+
+        let workspaces = vec![fixtures.as_path()];
+
+        let indexer = super::DatabaseBackedIndexer::new(storage_path.path(), workspaces.clone())
+            .await
+            .expect("Should be able to create the empty index");
+
+        let resolver =
+            resolver::DatabaseBackedResolver::new(storage_path.path(), workspaces.clone());
+
+        assert!(indexer.index_workspaces().await.is_ok());
+
+        let mut resolved_symbols: Vec<models::resolved::ResolvedSymbol> = resolver
+            .query(String::new(), resolver::Context::default())
+            .collect()
+            .await;
+
+        // The order of symbols is not guaranteed, so we need the sort symbols to keep the
+        // snapshot predictable
+        resolved_symbols.sort_unstable();
+
+        assert_json_snapshot!(
+            resolved_symbols,
+            {"[].id" => 0} // IDs are non-deterministic, so just blank them out
+        );
+    }
+
+    #[tokio::test]
+    pub async fn test_deindexing_files_in_project() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let fixtures = PathBuf::from("tests/fixtures/");
+
+        let workspaces = vec![fixtures.as_path()];
+
+        let indexer = super::DatabaseBackedIndexer::new(storage_path.path(), workspaces.clone())
+            .await
+            .expect("Should be able to create the empty index");
 
         let resolver =
             resolver::DatabaseBackedResolver::new(storage_path.path(), workspaces.clone());
@@ -566,4 +1636,363 @@ mod tests {
                 .is_empty()
         );
     }
+
+    #[tokio::test]
+    pub async fn test_indexing_ephemeral_file_outside_workspace() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        let outside_workspace = tempdir()
+            .expect("Should never fail when creating a temp directory outside the workspace");
+
+        let file = outside_workspace.path().join("script.rs");
+
+        fs::copy(PathBuf::from("tests/fixtures/").join("rust.rs"), &file)
+            .await
+            .expect("Should never fail to copy a file outside of the workspace");
+
+        let workspaces = vec![workspace.path()];
+
+        let indexer = super::DatabaseBackedIndexer::new(storage_path.path(), workspaces.clone())
+            .await
+            .expect("Should be able to create the empty index");
+
+        let resolver =
+            resolver::DatabaseBackedResolver::new(storage_path.path(), workspaces.clone());
+
+        // Files outside of the workspace can't be indexed as part of the workspace
+        assert!(indexer.index(file.as_path()).await.is_err());
+
+        assert!(indexer.index_ephemeral(file.as_path()).await.is_ok());
+
+        // Workspace-wide indexing and de-indexing never touches ephemeral files
+        assert!(indexer.index_workspaces().await.is_ok());
+        assert!(indexer.deindex(file.as_path()).await.is_ok());
+
+        let resolved_symbols: Vec<models::resolved::ResolvedSymbol> = resolver
+            .query(String::from("Point"), resolver::Context::default())
+            .collect()
+            .await;
+
+        assert!(
+            resolved_symbols
+                .iter()
+                .any(|symbol| symbol.name == "Point" && symbol.path == file)
+        );
+
+        assert!(indexer.evict_ephemeral(file.as_path()).await.is_ok());
+
+        assert!(
+            resolver
+                .query(String::new(), resolver::Context::default())
+                .collect::<Vec<models::resolved::ResolvedSymbol>>()
+                .await
+                .is_empty()
+        );
+    }
+
+    #[tokio::test]
+    pub async fn test_only_nearby_directories_mark_ephemeral_files_as_tests() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        let outside_workspace = tempdir()
+            .expect("Should never fail when creating a temp directory outside the workspace");
+
+        // Notice, only the file in `tests/` is a test, not the one nested further below it
+        let test_file = outside_workspace.path().join("tests").join("script.py");
+        let nested_file = outside_workspace
+            .path()
+            .join("tests")
+            .join("project")
+            .join("script.py");
+
+        for file in [&test_file, &nested_file] {
+            fs::create_dir_all(
+                file.parent()
+                    .expect("Should always have a parent directory"),
+            )
+            .await
+            .expect("Should never fail to create a directory outside of the workspace");
+
+            fs::copy(PathBuf::from("tests/fixtures/").join("python.py"), file)
+                .await
+                .expect("Should never fail to copy a file outside of the workspace");
+        }
+
+        let indexer =
+            super::DatabaseBackedIndexer::new(storage_path.path(), vec![workspace.path()])
+                .await
+                .expect("Should be able to create the empty index");
+
+        for file in [&test_file, &nested_file] {
+            assert!(indexer.index_ephemeral(file.as_path()).await.is_ok());
+        }
+
+        let is_test = async |file: &PathBuf| {
+            sqlx::query_scalar::<_, bool>(
+                "SELECT symbol.test FROM symbol
+                JOIN file ON file.id = symbol.file_id
+                WHERE symbol.name = 'MyClass' AND file.path = ?",
+            )
+            .bind(file.to_string_lossy().to_string())
+            .fetch_one(indexer.get_pool())
+            .await
+            .expect("Should be able to find the indexed symbol")
+        };
+
+        assert!(is_test(&test_file).await);
+        assert!(!is_test(&nested_file).await);
+    }
+
+    #[tokio::test]
+    pub async fn test_changing_package_manifest() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        let manifest = workspace.path().join("pyproject.toml");
+        let file = workspace.path().join("src").join("script.py");
+
+        fs::create_dir_all(workspace.path().join("src"))
+            .await
+            .expect("Should never fail to create a directory in the workspace");
+
+        fs::write(&manifest, "[project]\nname = \"before\"")
+            .await
+            .expect("Should never fail to write the manifest");
+
+        fs::copy(PathBuf::from("tests/fixtures/").join("python.py"), &file)
+            .await
+            .expect("Should never fail to copy a file into the workspace");
+
+        let indexer =
+            super::DatabaseBackedIndexer::new(storage_path.path(), vec![workspace.path()])
+                .await
+                .expect("Should be able to create the empty index");
+
+        let get_package = async || {
+            sqlx::query_scalar::<_, Option<String>>("SELECT package FROM file WHERE path = ?")
+                .bind(file.to_string_lossy().to_string())
+                .fetch_one(indexer.get_pool())
+                .await
+                .expect("Should be able to find the indexed file")
+        };
+
+        assert!(indexer.index_workspaces().await.is_ok());
+        assert_eq!(Some(String::from("before")), get_package().await);
+
+        fs::write(&manifest, "[project]\nname = \"after\"")
+            .await
+            .expect("Should never fail to write the manifest");
+
+        // Notice, only the manifest changed, not the file itself
+        assert!(indexer.index(manifest.as_path()).await.is_ok());
+        assert_eq!(Some(String::from("after")), get_package().await);
+
+        fs::remove_file(&manifest)
+            .await
+            .expect("Should never fail to remove the manifest");
+
+        assert!(indexer.deindex(manifest.as_path()).await.is_ok());
+        assert_eq!(None, get_package().await);
+    }
+
+    #[tokio::test]
+    pub async fn test_indexing_and_filtering_code_owners() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        fs::create_dir_all(workspace.path().join(".github"))
+            .await
+            .expect("Should never fail to create the .github directory");
+        fs::create_dir_all(workspace.path().join("web"))
+            .await
+            .expect("Should never fail to create a directory in the workspace");
+
+        fs::write(
+            workspace.path().join(".github").join("CODEOWNERS"),
+            "* @acme/core\n/web/ @acme/frontend",
+        )
+        .await
+        .expect("Should never fail to write the CODEOWNERS file");
+
+        let file = workspace.path().join("web").join("app.ts");
+
+        fs::copy(
+            PathBuf::from("tests/fixtures/").join("typescript.ts"),
+            &file,
+        )
+        .await
+        .expect("Should never fail to copy a file into the workspace");
+
+        let workspaces = vec![workspace.path()];
+
+        let indexer = super::DatabaseBackedIndexer::new(storage_path.path(), workspaces.clone())
+            .await
+            .expect("Should be able to create the empty index");
+
+        let resolver =
+            resolver::DatabaseBackedResolver::new(storage_path.path(), workspaces.clone());
+
+        assert!(indexer.index_workspaces().await.is_ok());
+
+        let resolved_symbols: Vec<models::resolved::ResolvedSymbol> = resolver
+            .query(
+                String::new(),
+                resolver::Context::default().with_owners(vec!["@acme/frontend".to_string()]),
+            )
+            .collect()
+            .await;
+
+        assert!(!resolved_symbols.is_empty());
+        assert!(
+            resolved_symbols
+                .iter()
+                .all(|symbol| *symbol.owners == ["@acme/frontend".to_string()])
+        );
+
+        // Changing the CODEOWNERS file should re-index the ownership of every file in the workspace
+        fs::write(
+            workspace.path().join(".github").join("CODEOWNERS"),
+            "* @acme/core",
+        )
+        .await
+        .expect("Should never fail to write the CODEOWNERS file");
+
+        assert!(
+            indexer
+                .index(&workspace.path().join(".github").join("CODEOWNERS"))
+                .await
+                .is_ok()
+        );
+
+        assert!(
+            resolver
+                .query(
+                    String::new(),
+                    resolver::Context::default().with_owners(vec!["@acme/frontend".to_string()]),
+                )
+                .collect::<Vec<models::resolved::ResolvedSymbol>>()
+                .await
+                .is_empty()
+        );
+    }
+
+    #[tokio::test]
+    pub async fn test_evicting_expired_ephemeral_files() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        let outside_workspace = tempdir()
+            .expect("Should never fail when creating a temp directory outside the workspace");
+
+        let file = outside_workspace.path().join("script.py");
+
+        fs::copy(PathBuf::from("tests/fixtures/").join("python.py"), &file)
+            .await
+            .expect("Should never fail to copy a file outside of the workspace");
+
+        let workspaces = vec![workspace.path()];
+
+        let indexer = super::DatabaseBackedIndexer::new(storage_path.path(), workspaces.clone())
+            .await
+            .expect("Should be able to create the empty index");
+
+        assert!(indexer.index_ephemeral(file.as_path()).await.is_ok());
+
+        let indexed_at = chrono::Utc::now();
+
+        // Nothing has expired yet
+        assert_eq!(
+            0,
+            indexer
+                .evict_expired_ephemeral_at(Duration::from_secs(60), indexed_at)
+                .await
+                .expect("Should be able to evict expired files")
+        );
+
+        assert_eq!(
+            1,
+            indexer
+                .evict_expired_ephemeral_at(
+                    Duration::from_secs(60),
+                    indexed_at + chrono::TimeDelta::minutes(2)
+                )
+                .await
+                .expect("Should be able to evict expired files")
+        );
+    }
+
+    #[tokio::test]
+    pub async fn test_touching_ephemeral_files_delays_expiry() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        let outside_workspace = tempdir()
+            .expect("Should never fail when creating a temp directory outside the workspace");
+
+        let file = outside_workspace.path().join("script.py");
+
+        fs::copy(PathBuf::from("tests/fixtures/").join("python.py"), &file)
+            .await
+            .expect("Should never fail to copy a file outside of the workspace");
+
+        let indexer =
+            super::DatabaseBackedIndexer::new(storage_path.path(), vec![workspace.path()])
+                .await
+                .expect("Should be able to create the empty index");
+
+        assert!(indexer.index_ephemeral(file.as_path()).await.is_ok());
+
+        let indexed_at = chrono::Utc::now();
+
+        // Notice, the file is read (but not re-indexed) before it would have expired
+        assert!(
+            indexer
+                .touch_ephemeral_at(file.as_path(), indexed_at + chrono::TimeDelta::minutes(3))
+                .await
+                .is_ok()
+        );
+
+        // Without the touch, the file would have expired by now
+        assert_eq!(
+            0,
+            indexer
+                .evict_expired_ephemeral_at(
+                    Duration::from_secs(120),
+                    indexed_at + chrono::TimeDelta::minutes(4)
+                )
+                .await
+                .expect("Should be able to evict expired files")
+        );
+
+        assert_eq!(
+            1,
+            indexer
+                .evict_expired_ephemeral_at(
+                    Duration::from_secs(120),
+                    indexed_at + chrono::TimeDelta::minutes(6)
+                )
+                .await
+                .expect("Should be able to evict expired files")
+        );
+    }
 }
@@ -0,0 +1,139 @@
+use std::{ffi::OsStr, path::Path};
+
+use tokio::io::AsyncReadExt;
+
+use crate::indexer::constant;
+
+/// Filename suffixes which are used for files generated by common code generators (i.e. `protoc`,
+/// GraphQL Code Generator, OpenAPI Generator, etc.) in various programming languages.
+const GENERATED_FILE_SUFFIXES: [&str; 15] = [
+    // Protocol Buffers / gRPC
+    ".pb.go",
+    ".pb.gw.go",
+    "_pb2.py",
+    "_pb2.pyi",
+    "_pb2_grpc.py",
+    ".pb.ts",
+    "_pb.ts",
+    "_pb.js",
+    "_pb.d.ts",
+    // GraphQL Code Generator, OpenAPI Generator, etc.
+    ".generated.ts",
+    ".generated.tsx",
+    ".generated.js",
+    ".gen.go",
+    ".gen.ts",
+    "_gen.go",
+];
+
+/// Directories which, by convention, only contain generated code (i.e. Relay's `__generated__`).
+const GENERATED_DIRECTORIES: [&str; 1] = ["__generated__"];
+
+/// Markers which code generators commonly write into the header of generated files (i.e. Go's
+/// `// Code generated by protoc-gen-go. DO NOT EDIT.`).
+///
+/// Markers are matched case-insensitively.
+const GENERATED_HEADER_MARKERS: [&str; 6] = [
+    "code generated",
+    "do not edit",
+    "@generated",
+    "auto-generated",
+    "autogenerated",
+    "generated by the protocol buffer compiler",
+];
+
+/// Check if a given file (i.e. `api/user.pb.go`) is generated code, from its path alone.
+///
+/// Paths are expected to be relative to the workspace, so that directories _above_ the
+/// workspace are never considered.
+pub fn is_generated_path(path: &Path) -> bool {
+    let is_generated_file = path
+        .file_name()
+        .and_then(OsStr::to_str)
+        .is_some_and(|filename| {
+            GENERATED_FILE_SUFFIXES
+                .iter()
+                .any(|suffix| filename.len() > suffix.len() && filename.ends_with(suffix))
+        });
+
+    is_generated_file
+        || path.parent().is_some_and(|parent| {
+            parent.components().any(|component| {
+                component
+                    .as_os_str()
+                    .to_str()
+                    .is_some_and(|name| GENERATED_DIRECTORIES.contains(&name))
+            })
+        })
+}
+
+/// Check if the header of a file (i.e. its first few lines) marks the file as generated code.
+pub fn is_generated_header(header: &str) -> bool {
+    let header = header.to_ascii_lowercase();
+
+    GENERATED_HEADER_MARKERS
+        .iter()
+        .any(|marker| header.contains(marker))
+}
+
+/// Check if a file is generated code, from either its header or its path.
+///
+/// The relative path is expected to be relative to the workspace (see [`is_generated_path`]).
+pub async fn is_generated_file(path: &Path, relative_path: &Path) -> bool {
+    if is_generated_path(relative_path) {
+        return true;
+    }
+
+    let Ok(file) = tokio::fs::File::open(path).await else {
+        return false;
+    };
+
+    let mut header = Vec::new();
+
+    if let Err(e) = file
+        .take(constant::GENERATED_HEADER_BYTES)
+        .read_to_end(&mut header)
+        .await
+    {
+        log::debug!("Unable to read header of {}: {e}", path.display());
+
+        return false;
+    }
+
+    is_generated_header(&String::from_utf8_lossy(&header))
+}
+
+#[cfg(test)]
+mod tests {
+    use std::path::PathBuf;
+
+    use rstest::rstest;
+
+    #[rstest]
+    #[case("api/user.pb.go", true)]
+    #[case("api/user_grpc.pb.go", true)]
+    #[case("api/user_pb2.py", true)]
+    #[case("src/gen/user.pb.ts", true)]
+    #[case("src/graphql/types.generated.ts", true)]
+    #[case("src/components/__generated__/UserQuery.graphql.ts", true)]
+    #[case("api/user.go", false)]
+    #[case("src/generated.ts", false)]
+    pub fn test_detecting_generated_paths(#[case] path: &str, #[case] expected: bool) {
+        assert_eq!(expected, super::is_generated_path(&PathBuf::from(path)));
+    }
+
+    #[rstest]
+    #[case(
+        "// Code generated by protoc-gen-go. DO NOT EDIT.\n// source: user.proto\n\npackage api\n",
+        true
+    )]
+    #[case(
+        "# -*- coding: utf-8 -*-\n# Generated by the protocol buffer compiler.  DO NOT EDIT!\n",
+        true
+    )]
+    #[case("/* eslint-disable */\n// @generated\n", true)]
+    #[case("package api\n\n// User is a user.\ntype User struct{}\n", false)]
+    pub fn test_detecting_generated_headers(#[case] header: &str, #[case] expected: bool) {
+        assert_eq!(expected, super::is_generated_header(header));
+    }
+}
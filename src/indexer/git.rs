@@ -0,0 +1,199 @@
+use std::{
+    collections::HashMap,
+    path::{Path, PathBuf},
+};
+
+use chrono::{DateTime, Utc};
+
+use crate::indexer::constant;
+
+/// The commit history of a workspace (from its local git repository), used to find when the
+/// content of each file last changed.
+///
+/// Modification times alone are a poor signal in git repositories, as every file touched by a
+/// checkout (or a fresh clone) is given the same modification time.
+#[derive(Debug, Clone)]
+pub struct GitHistory {
+    /// The last commit which changed each (committed) file.
+    commits: HashMap<PathBuf, Commit>,
+
+    /// When the history was loaded.
+    loaded_at: DateTime<Utc>,
+}
+
+impl GitHistory {
+    /// Load the history of the git repository a workspace is in, if it's in one.
+    ///
+    /// Only the most recent commits are read ([`constant::MAX_GIT_HISTORY_COMMITS`]), so files
+    /// which haven't changed in any of them have no commit time.
+    pub async fn load(workspace: &Path) -> Option<Self> {
+        let loaded_at = Utc::now();
+
+        let log = run_git(
+            workspace,
+            &[
+                "log",
+                &format!("--max-count={}", constant::MAX_GIT_HISTORY_COMMITS),
+                "--format=%x00%ct %H",
+                "--name-only",
+                "--no-renames",
+                "--relative",
+            ],
+        )
+        .await?;
+
+        let mut history = Self::parse(workspace, &log, loaded_at);
+
+        // Files with uncommitted changes have changed since their last commit, so its time no
+        // longer reflects their content
+        if let Some(diff) = run_git(workspace, &["diff", "HEAD", "--name-only", "--relative"]).await
+        {
+            for line in diff.lines().filter(|line| !line.is_empty()) {
+                history.commits.remove(&workspace.join(line));
+            }
+        }
+
+        log::debug!(
+            "Loaded git history for {} files in {}",
+            history.commits.len(),
+            workspace.display()
+        );
+
+        Some(history)
+    }
+
+    /// Parse the output of `git log --format="%x00%ct %H" --name-only`, for a particular
+    /// workspace.
+    ///
+    /// Each commit starts with a NUL character, its (Unix) timestamp and its hash, followed by the
+    /// paths it changed, from the most recent commit to the oldest.
+    pub fn parse(workspace: &Path, log: &str, loaded_at: DateTime<Utc>) -> Self {
+        let mut commits = HashMap::new();
+        let mut commit = None;
+
+        for line in log.lines() {
+            if let Some(header) = line.strip_prefix('\0') {
+                commit = header.trim().split_once(' ').and_then(|(timestamp, hash)| {
+                    let committed_at = timestamp
+                        .parse::<i64>()
+                        .ok()
+                        .and_then(|timestamp| DateTime::from_timestamp(timestamp, 0))?;
+
+                    Some(Commit {
+                        hash: hash.to_string(),
+                        committed_at,
+                    })
+                });
+            } else if !line.is_empty()
+                && let Some(commit) = &commit
+            {
+                // Commits are ordered from newest to oldest, so the first commit seen for a path
+                // is its most recent
+                commits
+                    .entry(workspace.join(line))
+                    .or_insert_with(|| commit.clone());
+            }
+        }
+
+        Self { commits, loaded_at }
+    }
+
+    /// Get the last commit which changed a file, if its content hasn't changed since.
+    ///
+    /// Files modified after the history was loaded (i.e. edited while the watcher is running)
+    /// are assumed to have uncommitted changes.
+    pub fn get_last_commit(
+        &self,
+        path: &Path,
+        modified_at: Option<DateTime<Utc>>,
+    ) -> Option<&Commit> {
+        if modified_at.is_some_and(|modified_at| modified_at > self.loaded_at) {
+            return None;
+        }
+
+        self.commits.get(path)
+    }
+}
+
+/// A commit in the history of a workspace.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct Commit {
+    /// The (full) hash of the commit.
+    pub hash: String,
+
+    /// When the commit was made.
+    pub committed_at: DateTime<Utc>,
+}
+
+/// Run a git command in a workspace, returning its output if it succeeded.
+async fn run_git(workspace: &Path, args: &[&str]) -> Option<String> {
+    let output = tokio::process::Command::new("git")
+        .arg("-C")
+        .arg(workspace)
+        // Paths with unusual characters would otherwise be quoted
+        .args(["-c", "core.quotePath=false"])
+        .args(args)
+        .kill_on_drop(true)
+        .output()
+        .await
+        .inspect_err(|e| log::debug!("Unable to run git in {}: {e}", workspace.display()))
+        .ok()?;
+
+    if !output.status.success() {
+        log::debug!(
+            "Unable to read git history of {}: {}",
+            workspace.display(),
+            String::from_utf8_lossy(&output.stderr).trim()
+        );
+
+        return None;
+    }
+
+    Some(String::from_utf8_lossy(&output.stdout).into_owned())
+}
+
+#[cfg(test)]
+mod tests {
+    use std::path::PathBuf;
+
+    use chrono::DateTime;
+
+    #[test]
+    pub fn test_parsing_git_log() {
+        let workspace = PathBuf::from("/workspace");
+        let loaded_at = DateTime::from_timestamp(2_000, 0).expect("Timestamp should be valid");
+
+        let history = super::GitHistory::parse(
+            &workspace,
+            "\01500 b2c4\n\nsrc/user.ts\nsrc/api.ts\n\01000 a1f3\n\nsrc/user.ts\nREADME.md\n",
+            loaded_at,
+        );
+
+        let user = history
+            .get_last_commit(&workspace.join("src/user.ts"), None)
+            .expect("Should find the last commit which changed user.ts");
+
+        assert_eq!("b2c4", user.hash);
+        assert_eq!(DateTime::from_timestamp(1_500, 0), Some(user.committed_at));
+
+        assert_eq!(
+            Some("a1f3"),
+            history
+                .get_last_commit(&workspace.join("README.md"), None)
+                .map(|commit| commit.hash.as_str())
+        );
+        assert_eq!(
+            None,
+            history.get_last_commit(&workspace.join("src/new.ts"), None)
+        );
+
+        // Files modified since the history was loaded have uncommitted changes
+        assert_eq!(
+            None,
+            history.get_last_commit(
+                &workspace.join("src/api.ts"),
+                DateTime::from_timestamp(2_500, 0)
+            )
+        );
+    }
+}
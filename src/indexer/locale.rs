@@ -0,0 +1,400 @@
+use std::{ffi::OsStr, path::Path};
+
+use crate::models;
+
+/// The names of the directories which locale files are kept in (i.e. `locales/en.json`).
+const LOCALE_DIRECTORIES: [&str; 5] = ["locales", "locale", "i18n", "lang", "translations"];
+
+/// The extensions of locale files which can be read.
+pub const LOCALE_EXTENSIONS: [&str; 3] = ["json", "yaml", "yml"];
+
+/// A translation key defined in a locale file.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct Translation {
+    /// The full path of the key (i.e. `checkout.payment.title`).
+    pub key: String,
+
+    /// The range of the (last segment of the) key in the locale file.
+    pub range: models::parsed::Range,
+}
+
+/// Check if a file is a locale file (i.e. `locales/en.json` or `i18n/fr/common.yaml`).
+///
+/// Locale files are JSON or YAML files inside a locale directory, at any depth.
+pub fn is_locale_file(path: &Path) -> bool {
+    let is_locale_extension = path
+        .extension()
+        .and_then(OsStr::to_str)
+        .is_some_and(|extension| {
+            LOCALE_EXTENSIONS.contains(&extension.to_ascii_lowercase().as_str())
+        });
+
+    is_locale_extension
+        && path.parent().is_some_and(|parent| {
+            parent.components().any(|component| {
+                component.as_os_str().to_str().is_some_and(|name| {
+                    LOCALE_DIRECTORIES.contains(&name.to_ascii_lowercase().as_str())
+                })
+            })
+        })
+}
+
+/// Get the locale a locale file defines translations for.
+///
+/// This is the name of the file (i.e. `locales/en.json`), unless the file is nested inside a
+/// directory for the locale (i.e. `locales/en/common.json`).
+pub fn get_locale(path: &Path) -> String {
+    let parent = path
+        .parent()
+        .and_then(Path::file_name)
+        .and_then(OsStr::to_str)
+        .unwrap_or_default();
+
+    if parent.is_empty() || LOCALE_DIRECTORIES.contains(&parent.to_ascii_lowercase().as_str()) {
+        return path
+            .file_stem()
+            .and_then(OsStr::to_str)
+            .unwrap_or_default()
+            .to_string();
+    }
+
+    parent.to_string()
+}
+
+/// Read the translation keys defined in the content of a locale file.
+///
+/// Nested keys are joined with a `.` (i.e. `{"checkout": {"title": "…"}}` defines
+/// `checkout.title`), and keys nested under the locale itself (as is common for Rails-style YAML
+/// files) have the locale removed.
+///
+/// This is intentionally lenient, and keys are read on a best-effort basis, as only the keys (and
+/// their positions) are needed from otherwise arbitrary files.
+pub fn read_translations(path: &Path, content: &str) -> Vec<Translation> {
+    let mut translations = match path.extension().and_then(OsStr::to_str) {
+        Some(extension) if extension.eq_ignore_ascii_case("json") => read_json_keys(content),
+        _ => read_yaml_keys(content),
+    };
+
+    let prefix = format!("{}.", get_locale(path));
+
+    if !translations.is_empty()
+        && translations
+            .iter()
+            .all(|translation| translation.key.starts_with(&prefix))
+    {
+        for translation in &mut translations {
+            translation.key.drain(..prefix.len());
+        }
+    }
+
+    translations
+}
+
+/// A cursor over the characters in a file, which tracks the current position (as lines and
+/// columns, starting from 1).
+struct Cursor<'a> {
+    chars: std::iter::Peekable<std::str::Chars<'a>>,
+    line: usize,
+    column: usize,
+}
+
+impl<'a> Cursor<'a> {
+    fn new(content: &'a str) -> Self {
+        Self {
+            chars: content.chars().peekable(),
+            line: 1,
+            column: 1,
+        }
+    }
+
+    fn peek(&mut self) -> Option<char> {
+        self.chars.peek().copied()
+    }
+
+    fn bump(&mut self) -> Option<char> {
+        let c = self.chars.next()?;
+
+        if c == '\n' {
+            self.line += 1;
+            self.column = 1;
+        } else {
+            self.column += 1;
+        }
+
+        Some(c)
+    }
+
+    fn skip_whitespace(&mut self) {
+        while self.peek().is_some_and(char::is_whitespace) {
+            self.bump();
+        }
+    }
+
+    /// Read a (quoted) string, returning its content without the quotes.
+    fn read_string(&mut self) -> Option<String> {
+        let quote = self.bump()?;
+        let mut string = String::new();
+
+        loop {
+            match self.bump()? {
+                '\\' => string.push(self.bump()?),
+                c if c == quote => return Some(string),
+                c => string.push(c),
+            }
+        }
+    }
+
+    /// Skip over a value of any type (i.e. a string, number, array, or object).
+    fn skip_value(&mut self) -> Option<()> {
+        let mut depth = 0_usize;
+
+        loop {
+            match self.peek()? {
+                '"' => {
+                    self.read_string()?;
+                }
+                '{' | '[' => {
+                    depth += 1;
+                    self.bump();
+                }
+                '}' | ']' if depth > 0 => {
+                    depth -= 1;
+                    self.bump();
+                }
+                ',' | '}' | ']' if depth == 0 => return Some(()),
+                _ => {
+                    self.bump();
+                }
+            }
+
+            if depth == 0 && self.peek().is_none_or(|c| matches!(c, ',' | '}' | ']')) {
+                return Some(());
+            }
+        }
+    }
+}
+
+/// Read the (leaf) keys defined in a JSON file.
+fn read_json_keys(content: &str) -> Vec<Translation> {
+    let mut cursor = Cursor::new(content);
+    let mut translations = Vec::new();
+
+    cursor.skip_whitespace();
+
+    if cursor.bump() == Some('{') && read_json_object(&mut cursor, "", &mut translations).is_none()
+    {
+        log::debug!("Locale file contains invalid JSON, only some keys were read");
+    }
+
+    translations
+}
+
+/// Read the keys of a JSON object, after its opening brace, prefixing each key with the path of
+/// the object.
+fn read_json_object(
+    cursor: &mut Cursor<'_>,
+    prefix: &str,
+    translations: &mut Vec<Translation>,
+) -> Option<()> {
+    loop {
+        cursor.skip_whitespace();
+
+        match cursor.peek()? {
+            '}' => {
+                cursor.bump();
+
+                return Some(());
+            }
+            ',' => {
+                cursor.bump();
+            }
+            '"' => {
+                let (start_line, start_column) = (cursor.line, cursor.column);
+
+                let key = cursor.read_string()?;
+                let key = if prefix.is_empty() {
+                    key
+                } else {
+                    format!("{prefix}.{key}")
+                };
+
+                let range = models::parsed::Range::new(
+                    start_line,
+                    cursor.line,
+                    start_column,
+                    cursor.column,
+                );
+
+                cursor.skip_whitespace();
+
+                if cursor.bump()? != ':' {
+                    return None;
+                }
+
+                cursor.skip_whitespace();
+
+                if cursor.peek()? == '{' {
+                    cursor.bump();
+
+                    read_json_object(cursor, &key, translations)?;
+                } else {
+                    // Everything else (including arrays, i.e. for plurals) is the translation
+                    // itself
+                    cursor.skip_value()?;
+
+                    translations.push(Translation { key, range });
+                }
+            }
+            _ => return None,
+        }
+    }
+}
+
+/// Read the (leaf) keys defined in a YAML file.
+///
+/// Only block mappings are supported, which covers the overwhelming majority of locale files.
+fn read_yaml_keys(content: &str) -> Vec<Translation> {
+    let mut translations = Vec::new();
+
+    // The keys of the mappings the current line is nested inside, alongside their indentation
+    let mut parents: Vec<(usize, String)> = Vec::new();
+
+    // The indentation of a multi-line value (i.e. `|` or `>`), whose lines should be skipped
+    let mut multiline_indent: Option<usize> = None;
+
+    for (index, line) in content.lines().enumerate() {
+        let trimmed = line.trim_start();
+        let indent = line.len() - trimmed.len();
+
+        if multiline_indent
+            .is_some_and(|multiline_indent| trimmed.is_empty() || indent > multiline_indent)
+        {
+            continue;
+        }
+
+        multiline_indent = None;
+
+        if trimmed.is_empty() || trimmed.starts_with(['#', '-']) || trimmed == "---" {
+            continue;
+        }
+
+        let Some((raw_key, value)) = split_yaml_key(trimmed) else {
+            continue;
+        };
+
+        let key = raw_key.trim_matches(['"', '\'']);
+
+        while parents
+            .last()
+            .is_some_and(|(parent_indent, _)| *parent_indent >= indent)
+        {
+            parents.pop();
+        }
+
+        let path = parents
+            .iter()
+            .map(|(_, parent)| parent.as_str())
+            .chain(std::iter::once(key))
+            .collect::<Vec<_>>()
+            .join(".");
+
+        let value = value.split(" #").next().unwrap_or_default().trim();
+
+        if value.is_empty() {
+            parents.push((indent, key.to_string()));
+
+            continue;
+        }
+
+        if value.starts_with(['|', '>']) {
+            multiline_indent = Some(indent);
+        }
+
+        translations.push(Translation {
+            key: path,
+            range: models::parsed::Range::new(
+                index + 1,
+                index + 1,
+                indent + 1,
+                indent + raw_key.chars().count() + 1,
+            ),
+        });
+    }
+
+    translations
+}
+
+/// Split a line of YAML into its (raw) key and value, if it's a key-value pair.
+fn split_yaml_key(line: &str) -> Option<(&str, &str)> {
+    let end = match line.chars().next()? {
+        quote @ ('"' | '\'') => line[1..].find(quote)? + 2,
+        _ => line
+            .find(": ")
+            .or_else(|| line.ends_with(':').then_some(line.len() - 1))?,
+    };
+
+    let value = line[end..].trim_start().strip_prefix(':')?;
+
+    Some((&line[..end], value))
+}
+
+#[cfg(test)]
+mod tests {
+    use std::path::PathBuf;
+
+    use rstest::rstest;
+
+    use crate::models;
+
+    #[rstest]
+    #[case("locales/en.json", true)]
+    #[case("src/i18n/fr/common.yaml", true)]
+    #[case("config/locales/de.yml", true)]
+    #[case("package.json", false)]
+    #[case("locales/en.ts", false)]
+    pub fn test_detecting_locale_files(#[case] path: &str, #[case] expected: bool) {
+        assert_eq!(expected, super::is_locale_file(&PathBuf::from(path)));
+    }
+
+    #[rstest]
+    #[case("locales/en.json", "en")]
+    #[case("locales/fr/common.json", "fr")]
+    pub fn test_detecting_locale(#[case] path: &str, #[case] expected_locale: &str) {
+        assert_eq!(expected_locale, super::get_locale(&PathBuf::from(path)));
+    }
+
+    #[rstest]
+    #[case(
+        "locales/en.json",
+        "{\n  \"checkout\": {\n    \"payment\": {\n      \"title\": \"Payment\"\n    },\n    \"items\": [\"one\", \"many\"]\n  },\n  \"home\": \"Home\"\n}\n",
+        vec![
+            ("checkout.payment.title", models::parsed::Range::new(4, 4, 7, 14)),
+            ("checkout.items", models::parsed::Range::new(6, 6, 5, 12)),
+            ("home", models::parsed::Range::new(8, 8, 3, 9)),
+        ]
+    )]
+    #[case(
+        "config/locales/en.yml",
+        "en:\n  checkout:\n    # The payment page\n    title: \"Payment\"\n    terms: |\n      Some: terms\n  home: Home\n",
+        vec![
+            ("checkout.title", models::parsed::Range::new(4, 4, 5, 10)),
+            ("checkout.terms", models::parsed::Range::new(5, 5, 5, 10)),
+            ("home", models::parsed::Range::new(7, 7, 3, 7)),
+        ]
+    )]
+    pub fn test_reading_translations(
+        #[case] path: &str,
+        #[case] content: &str,
+        #[case] expected_translations: Vec<(&str, models::parsed::Range)>,
+    ) {
+        let translations = super::read_translations(&PathBuf::from(path), content);
+
+        assert_eq!(
+            expected_translations,
+            translations
+                .iter()
+                .map(|translation| (translation.key.as_str(), translation.range.clone()))
+                .collect::<Vec<_>>()
+        );
+    }
+}
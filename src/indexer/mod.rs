@@ -3,10 +3,20 @@
 //! This _does not_ handle incremental updates, such as when files change. For that
 //! capability, refer to [`crate::watcher`].
 
+mod change_log;
+mod codeowners;
+pub(crate) mod constant;
 mod database_backed_indexer;
 mod error;
+mod generated;
+mod git;
+mod locale;
+mod package;
+mod schema;
+mod test_harness;
 mod types;
 
 pub use database_backed_indexer::DatabaseBackedIndexer;
 pub use error::Error;
+pub use package::{Manifest, Package, detect_package};
 pub use types::*;
@@ -0,0 +1,292 @@
+use std::{
+    ffi::OsStr,
+    path::{Path, PathBuf},
+};
+
+/// The kind of manifest which declared a [`Package`].
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum Manifest {
+    /// A Rust crate (`Cargo.toml`).
+    Cargo,
+
+    /// A JavaScript or TypeScript package (`package.json`).
+    Npm,
+
+    /// A Go module (`go.mod`).
+    GoModule,
+
+    /// A Python project (`pyproject.toml` or `setup.py`).
+    Python,
+
+    /// A Clojure project using the Clojure CLI (`deps.edn`).
+    ClojureDeps,
+
+    /// A Clojure project using Leiningen (`project.clj`).
+    Leiningen,
+}
+
+impl Manifest {
+    /// The manifest filenames which mark the root of a package, in the order they are checked
+    /// in each directory.
+    const FILENAMES: [(&'static str, Self); 7] = [
+        ("Cargo.toml", Self::Cargo),
+        ("package.json", Self::Npm),
+        ("go.mod", Self::GoModule),
+        ("pyproject.toml", Self::Python),
+        ("setup.py", Self::Python),
+        ("deps.edn", Self::ClojureDeps),
+        ("project.clj", Self::Leiningen),
+    ];
+}
+
+/// A package (i.e. a crate, npm package, Go module, etc.) which owns a set of files in a
+/// workspace.
+///
+/// In a monorepo, a single workspace will commonly contain many packages.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct Package {
+    /// The name of the package, as declared in its manifest.
+    ///
+    /// Where the manifest does not declare a name (i.e. `deps.edn`), the name of the directory
+    /// containing the manifest is used instead.
+    pub name: String,
+
+    /// The directory containing the package manifest.
+    pub root: PathBuf,
+
+    /// The kind of manifest which declared the package.
+    pub manifest: Manifest,
+}
+
+/// Detect the package which owns a particular file, by walking up from the file to find the
+/// nearest package manifest.
+///
+/// The search never goes above the `boundary` (usually the workspace root), if one is provided.
+/// This prevents packages outside of a workspace from being attributed to files inside it.
+pub fn detect_package(file: &Path, boundary: Option<&Path>) -> Option<Package> {
+    for directory in file.ancestors().skip(1) {
+        if let Some(boundary) = boundary
+            && !directory.starts_with(boundary)
+        {
+            break;
+        }
+
+        for (filename, manifest) in Manifest::FILENAMES {
+            let manifest_path = directory.join(filename);
+
+            if !manifest_path.is_file() {
+                continue;
+            }
+
+            let content = std::fs::read_to_string(&manifest_path).unwrap_or_default();
+
+            let Some(name) = get_package_name(manifest, &content, directory) else {
+                // Manifests can exist without declaring a package (i.e. a Cargo workspace root),
+                // in which case the search should carry on upwards.
+                continue;
+            };
+
+            return Some(Package {
+                name,
+                root: directory.to_path_buf(),
+                manifest,
+            });
+        }
+    }
+
+    None
+}
+
+/// Check if a file is a package manifest (i.e. `Cargo.toml`, `package.json`, etc.).
+pub fn is_manifest_file(path: &Path) -> bool {
+    path.file_name()
+        .and_then(OsStr::to_str)
+        .is_some_and(|filename| {
+            Manifest::FILENAMES
+                .iter()
+                .any(|(manifest_filename, _)| *manifest_filename == filename)
+        })
+}
+
+/// Read the name of a package out of the content of its manifest.
+///
+/// This is intentionally lenient, as only the name is needed and pulling in a full parser for
+/// every manifest format would be overkill.
+fn get_package_name(manifest: Manifest, content: &str, root: &Path) -> Option<String> {
+    let directory_name = || {
+        root.file_name()
+            .and_then(OsStr::to_str)
+            .map(ToString::to_string)
+    };
+
+    match manifest {
+        // Cargo.toml files without a `[package]` are workspace roots, rather than crates
+        Manifest::Cargo => get_toml_string(content, "package", "name"),
+        Manifest::Npm => serde_json::from_str::<serde_json::Value>(content)
+            .ok()
+            .and_then(|package| package.get("name")?.as_str().map(ToString::to_string))
+            .or_else(directory_name),
+        Manifest::GoModule => content.lines().find_map(|line| {
+            line.trim()
+                .strip_prefix("module ")
+                .map(|module| module.trim().trim_matches('"').to_string())
+        }),
+        Manifest::Python => get_toml_string(content, "project", "name")
+            .or_else(|| get_toml_string(content, "tool.poetry", "name"))
+            .or_else(|| get_setup_py_name(content))
+            .or_else(directory_name),
+        Manifest::ClojureDeps => directory_name(),
+        Manifest::Leiningen => content
+            .split_once("(defproject")
+            .and_then(|(_, rest)| rest.split_whitespace().next())
+            .map(ToString::to_string)
+            .or_else(directory_name),
+    }
+}
+
+/// Read a string value for a key in a particular TOML table (i.e. `name` in `[package]`).
+fn get_toml_string(content: &str, table: &str, key: &str) -> Option<String> {
+    let mut current_table = "";
+
+    for line in content.lines().map(str::trim) {
+        if let Some(header) = line.strip_prefix('[') {
+            current_table = header.trim_end_matches(']').trim();
+
+            continue;
+        }
+
+        if current_table != table {
+            continue;
+        }
+
+        if let Some((line_key, value)) = line.split_once('=')
+            && line_key.trim() == key
+        {
+            let value = value.trim();
+            let value = match value.chars().next() {
+                Some(quote @ ('"' | '\'')) => value[1..].split(quote).next().unwrap_or_default(),
+                _ => value,
+            };
+
+            if !value.is_empty() {
+                return Some(value.to_string());
+            }
+        }
+    }
+
+    None
+}
+
+/// Read the `name` argument out of a `setup.py` call to `setup(...)`.
+fn get_setup_py_name(content: &str) -> Option<String> {
+    content.match_indices("name").find_map(|(index, key)| {
+        let rest = content[index + key.len()..]
+            .trim_start()
+            .strip_prefix('=')?
+            .trim_start();
+
+        let quote = rest.chars().next().filter(|c| *c == '"' || *c == '\'')?;
+        let (name, _) = rest[1..].split_once(quote)?;
+
+        Some(name.to_string())
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use std::fs;
+
+    use rstest::rstest;
+    use tempfile::tempdir;
+
+    use super::Manifest;
+
+    #[rstest]
+    #[case(
+        "Cargo.toml",
+        "[package]\nname = \"my-crate\"\nversion = \"0.1.0\"",
+        "my-crate",
+        Manifest::Cargo
+    )]
+    #[case(
+        "package.json",
+        "{\"name\": \"@scope/my-package\"}",
+        "@scope/my-package",
+        Manifest::Npm
+    )]
+    #[case(
+        "go.mod",
+        "module github.com/someone/project\n\ngo 1.22",
+        "github.com/someone/project",
+        Manifest::GoModule
+    )]
+    #[case(
+        "pyproject.toml",
+        "[project]\nname = \"my_project\"",
+        "my_project",
+        Manifest::Python
+    )]
+    #[case(
+        "setup.py",
+        "setup(\n    name='legacy_project',\n)",
+        "legacy_project",
+        Manifest::Python
+    )]
+    #[case("deps.edn", "{:deps {}}", "package", Manifest::ClojureDeps)]
+    #[case(
+        "project.clj",
+        "(defproject my-org/my-app \"0.1.0\")",
+        "my-org/my-app",
+        Manifest::Leiningen
+    )]
+    pub fn test_detecting_package(
+        #[case] filename: &str,
+        #[case] content: &str,
+        #[case] expected_name: &str,
+        #[case] expected_manifest: Manifest,
+    ) {
+        let workspace = tempdir().expect("Should always be able to create a temporary workspace");
+
+        let root = workspace.path().join("package");
+        let source = root.join("src").join("nested");
+
+        fs::create_dir_all(&source).expect("Should always be able to create a test directory");
+        fs::write(root.join(filename), content).expect("Should always be able to write manifest");
+
+        let package = super::detect_package(&source.join("file.rs"), Some(workspace.path()))
+            .expect("Package should be detected");
+
+        assert_eq!(expected_name, package.name);
+        assert_eq!(root, package.root);
+        assert_eq!(expected_manifest, package.manifest);
+    }
+
+    #[test]
+    pub fn test_cargo_workspace_root_is_not_a_package() {
+        let workspace = tempdir().expect("Should always be able to create a temporary workspace");
+
+        fs::write(
+            workspace.path().join("Cargo.toml"),
+            "[workspace]\nmembers = [\"crates/*\"]",
+        )
+        .expect("Should always be able to write manifest");
+
+        assert!(
+            super::detect_package(&workspace.path().join("lib.rs"), Some(workspace.path()))
+                .is_none()
+        );
+    }
+
+    #[test]
+    pub fn test_packages_outside_of_the_boundary_are_ignored() {
+        let root = tempdir().expect("Should always be able to create a temporary directory");
+
+        let workspace = root.path().join("workspace");
+
+        fs::create_dir_all(&workspace).expect("Should always be able to create a test directory");
+        fs::write(root.path().join("package.json"), "{\"name\": \"outer\"}")
+            .expect("Should always be able to write manifest");
+
+        assert!(super::detect_package(&workspace.join("index.ts"), Some(&workspace)).is_none());
+    }
+}
@@ -0,0 +1,319 @@
+use std::{ffi::OsStr, path::Path};
+
+use crate::models::{self, parsed::SymbolKind};
+
+/// The extensions of schema files which can be read.
+pub const SCHEMA_EXTENSIONS: [&str; 4] = ["proto", "graphql", "graphqls", "gql"];
+
+/// A type (or operation) defined in a schema file, which code is commonly generated from.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct SchemaDefinition {
+    /// The full name of the definition, with nested definitions joined by a `.` (i.e.
+    /// `User.Address`).
+    pub name: String,
+
+    /// The kind of definition (i.e. a [`SymbolKind::Message`] for a Protocol Buffers message).
+    pub kind: SymbolKind,
+
+    /// The range of the name of the definition in the schema file.
+    pub range: models::parsed::Range,
+}
+
+/// The syntax of a schema file.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+enum Syntax {
+    /// Protocol Buffers (`.proto`).
+    Protobuf,
+
+    /// GraphQL (`.graphql`, `.graphqls` or `.gql`).
+    GraphQL,
+}
+
+impl Syntax {
+    fn from_path(path: &Path) -> Option<Self> {
+        match path
+            .extension()
+            .and_then(OsStr::to_str)?
+            .to_ascii_lowercase()
+            .as_str()
+        {
+            "proto" => Some(Self::Protobuf),
+            "graphql" | "graphqls" | "gql" => Some(Self::GraphQL),
+            _ => None,
+        }
+    }
+
+    /// Get the kind of definition a keyword introduces (i.e. `message` or `type`), if it
+    /// introduces one.
+    fn get_kind(self, keyword: &str) -> Option<SymbolKind> {
+        match (self, keyword) {
+            (Self::Protobuf, "message") => Some(SymbolKind::Message),
+            (Self::Protobuf | Self::GraphQL, "enum") => Some(SymbolKind::Enum),
+            (Self::Protobuf, "service") => Some(SymbolKind::Interface),
+            (Self::Protobuf, "rpc") => Some(SymbolKind::Method),
+            (Self::GraphQL, "type" | "input" | "scalar" | "fragment") => Some(SymbolKind::Type),
+            (Self::GraphQL, "interface") => Some(SymbolKind::Interface),
+            (Self::GraphQL, "union") => Some(SymbolKind::Union),
+            (Self::GraphQL, "query" | "mutation" | "subscription") => Some(SymbolKind::Function),
+            _ => None,
+        }
+    }
+}
+
+/// Check if a file is a schema file (i.e. `api/user.proto` or `schema.graphql`).
+pub fn is_schema_file(path: &Path) -> bool {
+    Syntax::from_path(path).is_some()
+}
+
+/// Read the definitions in the content of a schema file.
+///
+/// Protocol Buffers messages, enums, services and RPCs are read, with nested definitions joined
+/// to their parent (i.e. `User.Address`, or `UserService.GetUser`). For GraphQL, only top-level
+/// definitions (types, inputs, enums, interfaces, unions, scalars, fragments and named
+/// operations) are read.
+///
+/// As with locale files, this is intentionally lenient, as only the names (and their positions)
+/// are needed.
+pub fn read_schema_definitions(path: &Path, content: &str) -> Vec<SchemaDefinition> {
+    let Some(syntax) = Syntax::from_path(path) else {
+        return Vec::new();
+    };
+
+    let tokens = tokenize(syntax, content);
+
+    let mut definitions = Vec::new();
+
+    // The names of the definitions the current token is nested inside, alongside the depth of
+    // their body
+    let mut parents: Vec<(usize, String)> = Vec::new();
+    let mut depth = 0_usize;
+
+    for (index, token) in tokens.iter().enumerate() {
+        match token.text {
+            "{" => {
+                depth += 1;
+
+                continue;
+            }
+            "}" => {
+                depth = depth.saturating_sub(1);
+
+                while parents
+                    .last()
+                    .is_some_and(|(parent_depth, _)| *parent_depth > depth)
+                {
+                    parents.pop();
+                }
+
+                continue;
+            }
+            _ => {}
+        }
+
+        let Some(kind) = syntax.get_kind(token.text) else {
+            continue;
+        };
+
+        // GraphQL definitions can only be at the top-level, otherwise these are just field names
+        if syntax == Syntax::GraphQL && depth > 0 {
+            continue;
+        }
+
+        // Keywords in Protocol Buffers are only definitions inside other definitions (i.e. not
+        // inside an `option` or a field declaration)
+        if syntax == Syntax::Protobuf
+            && parents
+                .last()
+                .map_or(depth > 0, |(parent_depth, _)| *parent_depth != depth)
+        {
+            continue;
+        }
+
+        let Some(name) = tokens.get(index + 1).filter(|name| {
+            name.text
+                .starts_with(|c: char| c.is_alphabetic() || c == '_')
+        }) else {
+            continue;
+        };
+
+        let full_name = parents
+            .iter()
+            .map(|(_, parent)| parent.as_str())
+            .chain(std::iter::once(name.text))
+            .collect::<Vec<_>>()
+            .join(".");
+
+        // Only definitions with a body can contain nested definitions
+        if kind != SymbolKind::Method
+            && tokens
+                .iter()
+                .skip(index + 2)
+                .find(|token| matches!(token.text, "{" | "}" | ";"))
+                .is_some_and(|token| token.text == "{")
+        {
+            parents.push((depth + 1, full_name.clone()));
+        }
+
+        definitions.push(SchemaDefinition {
+            name: full_name,
+            kind,
+            range: models::parsed::Range::new(
+                name.line,
+                name.line,
+                name.column,
+                name.column + name.text.chars().count(),
+            ),
+        });
+    }
+
+    definitions
+}
+
+/// A token in a schema file, alongside its position (as lines and columns, starting from 1).
+#[derive(Debug)]
+struct Token<'a> {
+    text: &'a str,
+    line: usize,
+    column: usize,
+}
+
+/// Split the content of a schema file into words and punctuation (braces and semicolons),
+/// skipping over comments and strings.
+fn tokenize(syntax: Syntax, content: &str) -> Vec<Token<'_>> {
+    let mut tokens = Vec::new();
+
+    // The terminator of the block comment (or GraphQL block string) the current character is
+    // inside, which can span multiple lines
+    let mut block_terminator: Option<&str> = None;
+
+    for (index, line) in content.lines().enumerate() {
+        let mut chars = line.char_indices().peekable();
+
+        while let Some((start, c)) = chars.next() {
+            if let Some(terminator) = block_terminator {
+                if line[start..].starts_with(terminator) {
+                    // Skip over the rest of the terminator
+                    chars.nth(terminator.len() - 2);
+
+                    block_terminator = None;
+                }
+
+                continue;
+            }
+
+            match c {
+                '#' if syntax == Syntax::GraphQL => break,
+                '/' if syntax == Syntax::Protobuf && line[start..].starts_with("//") => break,
+                '/' if syntax == Syntax::Protobuf && line[start..].starts_with("/*") => {
+                    chars.next();
+
+                    block_terminator = Some("*/");
+                }
+                '"' if syntax == Syntax::GraphQL && line[start..].starts_with("\"\"\"") => {
+                    chars.nth(1);
+
+                    block_terminator = Some("\"\"\"");
+                }
+                '"' | '\'' => {
+                    // Skip over the rest of the string
+                    while let Some((_, next)) = chars.next() {
+                        match next {
+                            '\\' => {
+                                chars.next();
+                            }
+                            next if next == c => break,
+                            _ => {}
+                        }
+                    }
+                }
+                '{' | '}' | ';' => tokens.push(Token {
+                    text: &line[start..start + 1],
+                    line: index + 1,
+                    column: line[..start].chars().count() + 1,
+                }),
+                c if c.is_alphanumeric() || c == '_' => {
+                    let mut end = start + c.len_utf8();
+
+                    while let Some((next_start, next)) = chars
+                        .peek()
+                        .copied()
+                        .filter(|(_, next)| next.is_alphanumeric() || *next == '_')
+                    {
+                        end = next_start + next.len_utf8();
+
+                        chars.next();
+                    }
+
+                    tokens.push(Token {
+                        text: &line[start..end],
+                        line: index + 1,
+                        column: line[..start].chars().count() + 1,
+                    });
+                }
+                _ => {}
+            }
+        }
+    }
+
+    tokens
+}
+
+#[cfg(test)]
+mod tests {
+    use std::path::PathBuf;
+
+    use rstest::rstest;
+
+    use crate::models::{self, parsed::SymbolKind};
+
+    #[rstest]
+    #[case("api/user.proto", true)]
+    #[case("schema.graphql", true)]
+    #[case("src/queries/user.gql", true)]
+    #[case("api/user.pb.go", false)]
+    pub fn test_detecting_schema_files(#[case] path: &str, #[case] expected: bool) {
+        assert_eq!(expected, super::is_schema_file(&PathBuf::from(path)));
+    }
+
+    #[rstest]
+    #[case(
+        "api/user.proto",
+        "syntax = \"proto3\";\n\n// A user\nmessage User {\n  string name = 1;\n  message Address {\n    string line = 1;\n  }\n  Address address = 2;\n}\n\nenum Status {\n  STATUS_ACTIVE = 0;\n}\n\nservice UserService {\n  rpc GetUser(User) returns (User) {\n    option deprecated = true;\n  }\n}\n",
+        vec![
+            ("User", SymbolKind::Message, models::parsed::Range::new(4, 4, 9, 13)),
+            ("User.Address", SymbolKind::Message, models::parsed::Range::new(6, 6, 11, 18)),
+            ("Status", SymbolKind::Enum, models::parsed::Range::new(12, 12, 6, 12)),
+            ("UserService", SymbolKind::Interface, models::parsed::Range::new(16, 16, 9, 20)),
+            ("UserService.GetUser", SymbolKind::Method, models::parsed::Range::new(17, 17, 7, 14)),
+        ]
+    )]
+    #[case(
+        "schema.graphql",
+        "# The user\n\"\"\"\nA user { of the system }\n\"\"\"\ntype User {\n  id: ID!\n  type: String\n}\n\nenum Role { ADMIN }\n\nquery GetUser($id: ID!) {\n  user(id: $id) { ...UserFields }\n}\n\nfragment UserFields on User {\n  id\n}\n",
+        vec![
+            ("User", SymbolKind::Type, models::parsed::Range::new(5, 5, 6, 10)),
+            ("Role", SymbolKind::Enum, models::parsed::Range::new(10, 10, 6, 10)),
+            ("GetUser", SymbolKind::Function, models::parsed::Range::new(12, 12, 7, 14)),
+            ("UserFields", SymbolKind::Type, models::parsed::Range::new(16, 16, 10, 20)),
+        ]
+    )]
+    pub fn test_reading_schema_definitions(
+        #[case] path: &str,
+        #[case] content: &str,
+        #[case] expected_definitions: Vec<(&str, SymbolKind, models::parsed::Range)>,
+    ) {
+        let definitions = super::read_schema_definitions(&PathBuf::from(path), content);
+
+        assert_eq!(
+            expected_definitions,
+            definitions
+                .iter()
+                .map(|definition| (
+                    definition.name.as_str(),
+                    definition.kind,
+                    definition.range.clone()
+                ))
+                .collect::<Vec<_>>()
+        );
+    }
+}
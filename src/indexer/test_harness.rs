@@ -0,0 +1,160 @@
+use std::{ffi::OsStr, path::Path};
+
+/// Filename patterns (as a prefix and a suffix) which are used for test files by common test
+/// harnesses (i.e. Jest, PHP Unit, Go's `testing` package, etc.) in various programming languages.
+///
+/// Only one of the prefix or suffix is expected to carry any meaning, the other is usually the
+/// file extension.
+const TEST_FILE_PATTERNS: [(&str, &str); 33] = [
+    // JavaScript / TypeScript
+    ("", ".test.js"),
+    ("", ".spec.js"),
+    ("", ".test.jsx"),
+    ("", ".spec.jsx"),
+    ("", ".test.mjs"),
+    ("", ".test.cjs"),
+    ("", ".test.ts"),
+    ("", ".spec.ts"),
+    ("", ".test.tsx"),
+    ("", ".spec.tsx"),
+    // Python
+    ("test_", ".py"),
+    ("", "_test.py"),
+    // PHP
+    ("", "Test.php"),
+    // Java
+    ("", "Test.java"),
+    // Ruby
+    ("", "_test.rb"),
+    ("test_", ".rb"),
+    ("", "_spec.rb"),
+    // Rust
+    ("", "_test.rs"),
+    ("", "_tests.rs"),
+    // Go
+    ("", "_test.go"),
+    // Lua
+    ("", "_spec.lua"),
+    ("", "_test.lua"),
+    // Clojure
+    ("", "_test.clj"),
+    // C / C++
+    ("", "_test.c"),
+    ("", "_test.cpp"),
+    ("", "_test.cc"),
+    // C#
+    ("", "Test.cs"),
+    ("", "Tests.cs"),
+    // Kotlin
+    ("", "Test.kt"),
+    ("", "Tests.kt"),
+    // Swift
+    ("", "Tests.swift"),
+    ("", "Test.swift"),
+    // Shell (i.e. shunit2)
+    ("", "_test.sh"),
+];
+
+/// Filenames which are only ever used for tests.
+const TEST_FILENAMES: [&str; 2] = [
+    // Rust (i.e. `#[cfg(test)] mod tests;`)
+    "tests.rs",
+    // Python (pytest)
+    "conftest.py",
+];
+
+/// Directories which, by convention, only contain tests (i.e. Rust integration tests, Jest's
+/// `__tests__`, etc.).
+const TEST_DIRECTORIES: [&str; 4] = ["tests", "test", "__tests__", "spec"];
+
+/// Check if a given file (i.e. `path/to/some/file/file.test.ts`) is in what would
+/// generally be used in a test harness (i.e. Jest, PHP Unit, Rust unit/integration tests,
+/// etc.) in various programming languages.
+///
+/// This only considers the path of the file, and is used as a fallback for test code which can't
+/// be detected from the syntax of the file itself (see
+/// [`crate::models::parsed::Language::get_test_query`]).
+///
+/// Paths are expected to be relative to the workspace, so that directories _above_ the
+/// workspace (i.e. a workspace checked out inside a `tests` directory) are never considered.
+pub fn is_part_of_test_harness(path: &Path) -> bool {
+    if let Some(filename) = path.file_name().and_then(OsStr::to_str) {
+        let is_test_file = TEST_FILENAMES.contains(&filename)
+            || TEST_FILE_PATTERNS.iter().any(|(prefix, suffix)| {
+                // The pattern has to leave some of the filename unmatched, so that (for example)
+                // a file named `_test.go` isn't a match
+                filename.len() > prefix.len() + suffix.len()
+                    && filename.starts_with(prefix)
+                    && filename.ends_with(suffix)
+            });
+
+        if is_test_file {
+            return true;
+        }
+    }
+
+    // Check if any parent directory is a conventional test directory (common in Rust, PHP,
+    // Python, JavaScript, etc.)
+    path.parent().is_some_and(|parent| {
+        parent.components().any(|component| {
+            component
+                .as_os_str()
+                .to_str()
+                .is_some_and(|name| TEST_DIRECTORIES.contains(&name))
+        })
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use std::path::PathBuf;
+
+    use rstest::rstest;
+
+    #[test]
+    pub fn test_in_tests_folder() {
+        let file = PathBuf::from_iter(["some", "root", "tests", "SomeFileTest.php"]);
+
+        let is_in_test_harness = super::is_part_of_test_harness(file.as_path());
+
+        assert!(is_in_test_harness);
+    }
+
+    #[test]
+    pub fn test_in_test_harness_file_js() {
+        let file = PathBuf::from_iter(["some", "root", "some_file.test.js"]);
+
+        let is_in_test_harness = super::is_part_of_test_harness(file.as_path());
+
+        assert!(is_in_test_harness);
+    }
+
+    #[test]
+    pub fn test_not_in_test_harness() {
+        let file = PathBuf::from_iter(["some", "root", "just_a_normal_file.py"]);
+
+        let is_in_test_harness = super::is_part_of_test_harness(file.as_path());
+
+        assert!(!is_in_test_harness);
+    }
+
+    #[rstest]
+    #[case(PathBuf::from_iter(["src", "latest.rs"]))]
+    #[case(PathBuf::from_iter(["src", "contest.py"]))]
+    #[case(PathBuf::from_iter(["src", "attestation.go"]))]
+    #[case(PathBuf::from_iter(["src", "_test.go"]))]
+    #[case(PathBuf::from_iter(["contests", "lib.rs"]))]
+    pub fn test_similarly_named_files_are_not_in_test_harness(#[case] file: PathBuf) {
+        assert!(!super::is_part_of_test_harness(file.as_path()));
+    }
+
+    #[rstest]
+    #[case(PathBuf::from_iter(["src", "user_test.go"]))]
+    #[case(PathBuf::from_iter(["src", "test_service.py"]))]
+    #[case(PathBuf::from_iter(["src", "conftest.py"]))]
+    #[case(PathBuf::from_iter(["src", "components", "__tests__", "button.tsx"]))]
+    #[case(PathBuf::from_iter(["tests", "integration.rs"]))]
+    pub fn test_precise_test_harness_paths(#[case] file: PathBuf) {
+        assert!(super::is_part_of_test_harness(file.as_path()));
+    }
+}
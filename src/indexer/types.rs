@@ -2,6 +2,7 @@ use std::{
     fmt::Debug,
     path::{Path, PathBuf},
     sync::Arc,
+    time::Duration,
 };
 
 #[cfg(test)]
@@ -43,4 +44,69 @@ pub trait Indexer: Send + Sync + Debug {
     ///
     /// Returns an error if the file could not be de-indexed successfully.
     fn deindex(&self, path: &Path) -> impl Future<Output = Result<()>> + Send;
+
+    /// Index a single file on demand, even if it is outside every registered workspace.
+    ///
+    /// Files outside of a workspace (i.e. a lone script, or a file in `/tmp`) are tracked as
+    /// _ephemeral_. They are never touched by workspace-wide indexing, and are kept only until
+    /// they are evicted ([`Indexer::evict_ephemeral`]) or expire
+    /// ([`Indexer::evict_expired_ephemeral`]).
+    ///
+    /// Files which _are_ inside a registered workspace are indexed as normal.
+    ///
+    /// By default, indexers don't support ephemeral files, and index every file as normal (see
+    /// [`Indexer::index`]).
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the file could not be indexed successfully.
+    fn index_ephemeral(&self, path: &Path) -> impl Future<Output = Result<()>> + Send {
+        self.index(path)
+    }
+
+    /// Mark a previously indexed ephemeral file as accessed (i.e. when it's opened, or focused in
+    /// an editor), so that it doesn't expire while it's still being read.
+    ///
+    /// Files inside registered workspaces never expire, and are left untouched.
+    ///
+    /// By default, this does nothing.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the file could not be marked as accessed.
+    fn touch_ephemeral(&self, _path: &Path) -> impl Future<Output = Result<()>> + Send {
+        std::future::ready(Ok(()))
+    }
+
+    /// Evict a previously indexed ephemeral file, usually when it has been closed.
+    ///
+    /// Files inside registered workspaces are left untouched.
+    ///
+    /// By default, this does nothing.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the file could not be evicted successfully.
+    fn evict_ephemeral(&self, _path: &Path) -> impl Future<Output = Result<()>> + Send {
+        std::future::ready(Ok(()))
+    }
+
+    /// Evict every ephemeral file which has not been accessed within the given time-to-live.
+    ///
+    /// Returns the number of files which were evicted. By default, nothing is evicted.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the expired files could not be evicted successfully.
+    fn evict_expired_ephemeral(&self, _ttl: Duration) -> impl Future<Output = Result<u64>> + Send {
+        std::future::ready(Ok(0))
+    }
+
+    /// Get how long ephemeral files are kept for after they were last accessed, before they
+    /// expire.
+    ///
+    /// Defaults to [`indexer::constant::DEFAULT_EPHEMERAL_FILE_TTL_SECS`].
+    fn get_ephemeral_file_ttl(&self) -> Duration {
+        Duration::from_secs(indexer::constant::DEFAULT_EPHEMERAL_FILE_TTL_SECS)
+    }
 }
@@ -51,10 +51,61 @@
 //! onoma = "0.0.17"
 //! ```
 //!
+//! The simplest way to use Onoma is through [`Onoma`], which indexes a set of workspaces, watches them
+//! for changes, and resolves queries against them:
+//!
+//! ```no_run
+//! # use onoma::{Onoma, resolver::Context};
+//! # async fn run() -> Result<(), onoma::indexer::Error> {
+//! let engine = Onoma::builder()
+//!     .with_workspace("/path/to/workspace")
+//!     .build()
+//!     .await?;
+//!
+//! let symbols = engine.query("getUser", Context::default());
+//! # Ok(())
+//! # }
+//! ```
+//!
+//! Hosts which don't run a tokio runtime (i.e. GUI applications, or the plugin hosts of editors) can
+//! use the synchronous API in [`blocking`] instead.
+//!
 //! #### Documentation
 //!
 //! Full documentation is available on [docs.rs](https://docs.rs/onoma/latest/onoma/).
 //!
+//! ### 3. Model Context Protocol Server
+//!
+//! Onoma includes a [Model Context Protocol](https://modelcontextprotocol.io) server, which gives coding
+//! agents fast symbol lookup across large, polyglot workspaces, without running language servers.
+//!
+//! ```sh
+//! onoma-mcp [--storage-path <path>] [<workspace>...]
+//! ```
+//!
+//! The server communicates over stdin and stdout, and keeps the index fresh as files change. It offers the
+//! `search_symbols`, `get_definition`, `list_file_symbols` and `find_references` tools.
+//!
+//! ### 4. Language Server Proxy
+//!
+//! Where a language server is already running, Onoma can sit between it and the editor, merging its ranked
+//! results into the language server's workspace symbol and completion responses, and finding definitions
+//! the language server can't.
+//!
+//! ```sh
+//! onoma-lsp-proxy [--replace-workspace-symbols] -- <command> [<argument>...]
+//! ```
+//!
+//! ### 5. Static Code Browser
+//!
+//! Onoma can export an index as a static, cross-referenced code browser for documentation and offline
+//! review, with syntax-highlighted source pages, links between definitions and references, per-directory
+//! symbol listings, and a fuzzy symbol search which runs entirely in the browser.
+//!
+//! ```sh
+//! onoma-export [--storage-path <path>] [--no-index] --output <directory> [<workspace>...]
+//! ```
+//!
 //! ## Contributing
 //!
 //! Contributions are welcome!
@@ -81,8 +132,17 @@
 
 mod utils;
 
+pub mod blocking;
+pub mod dump;
+pub mod engine;
+pub mod export;
 pub mod indexer;
+pub mod lsp;
+pub mod mcp;
 pub mod models;
 pub mod parser;
+pub mod pin;
 pub mod resolver;
 pub mod watcher;
+
+pub use engine::Onoma;
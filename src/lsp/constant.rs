@@ -0,0 +1,17 @@
+/// The maximum number of Onoma's symbols included in a `workspace/symbol` response, whether
+/// they augment or replace the downstream server's results.
+pub const MAX_WORKSPACE_SYMBOLS: usize = 100;
+
+/// The `SymbolTag` of the Language Server Protocol for deprecated symbols.
+pub const DEPRECATED_SYMBOL_TAG: u8 = 1;
+
+/// The `CompletionItemTag` of the Language Server Protocol for deprecated symbols.
+pub const DEPRECATED_COMPLETION_ITEM_TAG: u8 = 1;
+
+/// The `TextDocumentSyncKind` of the Language Server Protocol for documents which are synced by
+/// sending only the changes to them, which Onoma asks for when serving the client directly.
+pub const INCREMENTAL_TEXT_DOCUMENT_SYNC: u8 = 2;
+
+/// The error code of the Language Server Protocol (from JSON-RPC) for requests with an unknown
+/// (or unsupported) method.
+pub const METHOD_NOT_FOUND_ERROR_CODE: i64 = -32601;
@@ -0,0 +1,1151 @@
+//! A proxy which sits between an editor and another language server, merging Onoma's results into
+//! the language server's responses.
+//!
+//! Language servers are precise, but their workspace-wide symbol search is commonly slow and
+//! limited. The proxy forwards every message between the editor (the client) and the language
+//! server (the downstream server) untouched, except:
+//!
+//! 1. `workspace/symbol` responses are augmented with Onoma's ranked results, or replaced by them
+//!    entirely (see [`WorkspaceSymbolMode`])
+//! 2. `textDocument/definition` responses fall back to Onoma's best candidate (see
+//!    [`crate::resolver::HoverResolver::get_hover`]) when the downstream server finds nothing
+//! 3. `textDocument/completion` responses are augmented with Onoma's candidates (see
+//!    [`crate::resolver::CompletionResolver::get_completions`]) for any names the downstream
+//!    server didn't complete, using the content of the document as open in the client (rather
+//!    than as saved to disk)
+//! 4. `initialize` responses always advertise support for all of the above, so any of the above
+//!    the downstream server doesn't support are answered by Onoma alone
+//!
+//! For languages (or files) without a language server at all, the proxy can instead serve the
+//! client directly (see [`Proxy::serve`]), answering with Onoma's results alone.
+//!
+//! The proxy only reads from the index, so should be run alongside a
+//! [`crate::watcher::Watcher`] which keeps the index fresh. The `onoma-lsp-proxy` binary does
+//! exactly that, spawning the downstream server as a child process (if one is given).
+
+use std::{
+    collections::{HashMap, HashSet},
+    path::{Path, PathBuf},
+    sync::Arc,
+};
+
+use serde_json::{Value, json};
+use tokio::{
+    io::{AsyncBufRead, AsyncWrite, AsyncWriteExt},
+    sync::Mutex,
+    task::JoinSet,
+};
+use tokio_stream::StreamExt;
+
+mod constant;
+mod protocol;
+mod transport;
+mod utils;
+
+pub use transport::{read_message, write_message};
+
+use crate::{
+    models::resolved::{CompletionList, Position, ResolvedSymbol},
+    resolver::{
+        CompletionResolver, Context, DatabaseBackedResolver, HoverResolver, Sort, SymbolQuery,
+    },
+};
+
+/// How Onoma's results are merged into `workspace/symbol` responses.
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq)]
+pub enum WorkspaceSymbolMode {
+    /// The downstream server's results are returned first, followed by Onoma's ranked results
+    /// for any symbols the downstream server didn't return.
+    #[default]
+    Augment,
+
+    /// Onoma's ranked results are returned, and the request is never forwarded to the
+    /// downstream server.
+    Replace,
+}
+
+/// A request from the client which is waiting for a response from the downstream server, and
+/// which the proxy may need to amend.
+#[derive(Debug)]
+enum PendingRequest {
+    Initialize,
+    WorkspaceSymbol {
+        query: String,
+    },
+    Definition {
+        path: PathBuf,
+        position: Position,
+    },
+    Completion {
+        path: PathBuf,
+        content: Option<String>,
+        position: Position,
+    },
+}
+
+/// A Language Server Protocol proxy, backed by an existing index.
+#[derive(Debug, Clone)]
+pub struct Proxy {
+    resolver: DatabaseBackedResolver,
+    hover_resolver: HoverResolver,
+    completion_resolver: CompletionResolver,
+    workspace_symbol_mode: WorkspaceSymbolMode,
+}
+
+impl Proxy {
+    /// Initialize a proxy at a given database path, for a set of workspaces.
+    ///
+    /// As with [`crate::resolver::DatabaseBackedResolver::new`], the storage path and workspaces
+    /// should match those provided to the indexer.
+    #[must_use]
+    pub fn new<'a, 'b>(
+        storage_path: &'b Path,
+        workspaces: impl IntoIterator<Item = &'a Path>,
+    ) -> Self {
+        let resolver = DatabaseBackedResolver::new(storage_path, workspaces);
+
+        // Every resolver shares the same connections to the index
+        let pool = resolver.get_pool().clone();
+
+        Self {
+            resolver,
+            hover_resolver: HoverResolver::from_pool(pool.clone()),
+            completion_resolver: CompletionResolver::from_pool(pool),
+            workspace_symbol_mode: WorkspaceSymbolMode::default(),
+        }
+    }
+
+    /// Set how Onoma's results are merged into `workspace/symbol` responses.
+    #[must_use]
+    pub const fn with_workspace_symbol_mode(mut self, mode: WorkspaceSymbolMode) -> Self {
+        self.workspace_symbol_mode = mode;
+        self
+    }
+
+    /// Proxy messages between a client and a downstream server, until both have closed their
+    /// side of the connection.
+    ///
+    /// Once the client closes its side, the downstream server's side is shut down too (which,
+    /// for a child process, closes its stdin).
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if a message couldn't be read from (or written to) either side.
+    pub async fn run<CR, CW, SR, SW>(
+        &self,
+        client_reader: CR,
+        client_writer: CW,
+        server_reader: SR,
+        server_writer: SW,
+    ) -> std::io::Result<()>
+    where
+        CR: AsyncBufRead + Unpin,
+        CW: AsyncWrite + Unpin + Send + 'static,
+        SR: AsyncBufRead + Unpin,
+        SW: AsyncWrite + Unpin,
+    {
+        // Both directions write to the client (i.e. replaced `workspace/symbol` responses), as do
+        // the tasks amending responses, so writes need to be serialised
+        let client_writer = Arc::new(Mutex::new(client_writer));
+        let pending = Mutex::new(HashMap::new());
+
+        tokio::try_join!(
+            self.forward_to_server(client_reader, server_writer, &client_writer, &pending),
+            self.forward_to_client(server_reader, &client_writer, &pending),
+        )?;
+
+        Ok(())
+    }
+
+    /// Serve a client directly, without a downstream server, until the client exits (or closes
+    /// its side of the connection).
+    ///
+    /// `workspace/symbol`, `textDocument/definition`, `textDocument/hover` and
+    /// `textDocument/completion` requests are answered with Onoma's results alone, and any other
+    /// request with a `MethodNotFound` error.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if a message couldn't be read from (or written to) the client.
+    pub async fn serve<CR, CW>(
+        &self,
+        mut client_reader: CR,
+        client_writer: CW,
+    ) -> std::io::Result<()>
+    where
+        CR: AsyncBufRead + Unpin,
+        CW: AsyncWrite + Unpin + Send + 'static,
+    {
+        let client_writer = Arc::new(Mutex::new(client_writer));
+        let mut documents = HashMap::new();
+        let mut tasks = JoinSet::new();
+
+        while let Some(message) = read_message(&mut client_reader).await? {
+            join_finished_tasks(&mut tasks)?;
+
+            // Messages without a method are responses, but requests are never sent to the client
+            let Some(method) = message.get("method").and_then(Value::as_str) else {
+                continue;
+            };
+
+            update_documents(&mut documents, method, &message["params"]);
+
+            if method == "exit" {
+                break;
+            }
+
+            // Every other notification is ignored
+            if message.get("id").is_none() {
+                continue;
+            }
+
+            // Completions are for the document as it is when they're requested
+            let content = (method == "textDocument/completion")
+                .then(|| get_text_document_position(&message["params"]))
+                .flatten()
+                .and_then(|(path, _)| documents.get(&path).cloned());
+
+            let proxy = self.clone();
+            let client_writer = Arc::clone(&client_writer);
+
+            tasks.spawn(async move {
+                let method = message["method"].as_str().unwrap_or_default();
+
+                let response = match proxy
+                    .answer_request(method, &message["params"], content)
+                    .await
+                {
+                    Some(result) => {
+                        json!({ "jsonrpc": "2.0", "id": message["id"], "result": result })
+                    }
+                    None => json!({
+                        "jsonrpc": "2.0",
+                        "id": message["id"],
+                        "error": {
+                            "code": constant::METHOD_NOT_FOUND_ERROR_CODE,
+                            "message": format!("Unsupported method: {method}"),
+                        },
+                    }),
+                };
+
+                write_message(&mut *client_writer.lock().await, &response).await
+            });
+        }
+
+        log::debug!("Client exited");
+
+        join_all_tasks(&mut tasks).await
+    }
+
+    /// Answer a request from a client being served directly (see [`Proxy::serve`]), given the
+    /// content of the document being completed (if it's open in the client).
+    ///
+    /// Returns [`None`] if the request isn't supported.
+    async fn answer_request(
+        &self,
+        method: &str,
+        params: &Value,
+        content: Option<String>,
+    ) -> Option<Value> {
+        let position = get_text_document_position(params);
+
+        let result = match method {
+            "initialize" => json!({
+                "capabilities": {
+                    "textDocumentSync": constant::INCREMENTAL_TEXT_DOCUMENT_SYNC,
+                    "workspaceSymbolProvider": true,
+                    "definitionProvider": true,
+                    "hoverProvider": true,
+                    "completionProvider": {},
+                },
+                "serverInfo": { "name": "onoma" },
+            }),
+            "shutdown" => Value::Null,
+            "workspace/symbol" => json!(
+                self.get_workspace_symbols(
+                    params["query"].as_str().unwrap_or_default().to_string()
+                )
+                .await
+            ),
+            "textDocument/definition" | "textDocument/hover" => {
+                let Some((path, position)) = position else {
+                    return Some(Value::Null);
+                };
+
+                match self.hover_resolver.get_hover(&path, position).await {
+                    Some(hover) if method == "textDocument/hover" => {
+                        json!(utils::get_hover(&hover))
+                    }
+                    Some(hover) => json!(utils::get_location(&hover.symbol)),
+                    None => Value::Null,
+                }
+            }
+            "textDocument/completion" => {
+                let Some((path, position)) = position else {
+                    return Some(Value::Null);
+                };
+
+                let completions = self.get_completions(&path, content, position).await;
+
+                json!({
+                    "isIncomplete": completions.is_incomplete,
+                    "items": completions
+                        .items
+                        .iter()
+                        .map(|item| utils::get_completion_item(item, completions.range))
+                        .collect::<Vec<_>>(),
+                })
+            }
+            _ => return None,
+        };
+
+        Some(result)
+    }
+
+    /// Forward every message from the client to the downstream server, keeping track of the
+    /// requests which may need their response amended.
+    async fn forward_to_server<CR, CW, SW>(
+        &self,
+        mut client_reader: CR,
+        mut server_writer: SW,
+        client_writer: &Arc<Mutex<CW>>,
+        pending: &Mutex<HashMap<String, PendingRequest>>,
+    ) -> std::io::Result<()>
+    where
+        CR: AsyncBufRead + Unpin,
+        CW: AsyncWrite + Unpin + Send + 'static,
+        SW: AsyncWrite + Unpin,
+    {
+        // The content of every document open in the client, which is ahead of the file on disk
+        // while it's being edited
+        let mut documents = HashMap::new();
+
+        // Requests answered by Onoma alone are answered in the background, so that they never
+        // hold up the messages behind them
+        let mut tasks = JoinSet::new();
+
+        while let Some(message) = read_message(&mut client_reader).await? {
+            join_finished_tasks(&mut tasks)?;
+
+            if let Some(method) = message.get("method").and_then(Value::as_str) {
+                update_documents(&mut documents, method, &message["params"]);
+            }
+
+            // Downstream servers aren't required to respond to cancelled requests, so they'd
+            // otherwise never stop being tracked
+            if message.get("method").and_then(Value::as_str) == Some("$/cancelRequest") {
+                pending
+                    .lock()
+                    .await
+                    .remove(&message["params"]["id"].to_string());
+            }
+
+            if let (Some(id), Some(method)) = (
+                message.get("id"),
+                message.get("method").and_then(Value::as_str),
+            ) {
+                let params = &message["params"];
+
+                let request = match method {
+                    "initialize" => Some(PendingRequest::Initialize),
+                    "workspace/symbol" => Some(PendingRequest::WorkspaceSymbol {
+                        query: params["query"].as_str().unwrap_or_default().to_string(),
+                    }),
+                    "textDocument/definition" => get_text_document_position(params)
+                        .map(|(path, position)| PendingRequest::Definition { path, position }),
+                    "textDocument/completion" => {
+                        get_text_document_position(params).map(|(path, position)| {
+                            PendingRequest::Completion {
+                                content: documents.get(&path).cloned(),
+                                path,
+                                position,
+                            }
+                        })
+                    }
+                    _ => None,
+                };
+
+                match request {
+                    Some(PendingRequest::WorkspaceSymbol { query })
+                        if self.workspace_symbol_mode == WorkspaceSymbolMode::Replace =>
+                    {
+                        let proxy = self.clone();
+                        let client_writer = Arc::clone(client_writer);
+                        let id = id.clone();
+
+                        tasks.spawn(async move {
+                            let symbols = proxy.get_workspace_symbols(query).await;
+
+                            write_message(
+                                &mut *client_writer.lock().await,
+                                &json!({ "jsonrpc": "2.0", "id": id, "result": symbols }),
+                            )
+                            .await
+                        });
+
+                        continue;
+                    }
+                    Some(request) => {
+                        pending.lock().await.insert(id.to_string(), request);
+                    }
+                    None => {}
+                }
+            }
+
+            write_message(&mut server_writer, &message).await?;
+        }
+
+        log::debug!("Client closed the connection, shutting down the downstream server");
+
+        server_writer.shutdown().await?;
+
+        join_all_tasks(&mut tasks).await
+    }
+
+    /// Forward every message from the downstream server to the client, amending the responses
+    /// to any pending requests.
+    async fn forward_to_client<SR, CW>(
+        &self,
+        mut server_reader: SR,
+        client_writer: &Arc<Mutex<CW>>,
+        pending: &Mutex<HashMap<String, PendingRequest>>,
+    ) -> std::io::Result<()>
+    where
+        SR: AsyncBufRead + Unpin,
+        CW: AsyncWrite + Unpin + Send + 'static,
+    {
+        // Responses are amended in the background, so that they never hold up the messages
+        // behind them
+        let mut tasks = JoinSet::new();
+
+        while let Some(mut message) = read_message(&mut server_reader).await? {
+            join_finished_tasks(&mut tasks)?;
+
+            // Only responses (which have an ID, but no method) can be for a pending request.
+            // Requests from the downstream server have their own IDs.
+            let request = match message.get("id") {
+                Some(id) if message.get("method").is_none() => {
+                    pending.lock().await.remove(&id.to_string())
+                }
+                _ => None,
+            };
+
+            match request {
+                // Capabilities are amended without querying the index, and nothing else from the
+                // downstream server should overtake them
+                Some(request @ PendingRequest::Initialize) => {
+                    self.amend_response(request, &mut message).await;
+                }
+                Some(request) => {
+                    let proxy = self.clone();
+                    let client_writer = Arc::clone(client_writer);
+
+                    tasks.spawn(async move {
+                        proxy.amend_response(request, &mut message).await;
+
+                        write_message(&mut *client_writer.lock().await, &message).await
+                    });
+
+                    continue;
+                }
+                None => {}
+            }
+
+            write_message(&mut *client_writer.lock().await, &message).await?;
+        }
+
+        log::debug!("Downstream server closed the connection");
+
+        join_all_tasks(&mut tasks).await
+    }
+
+    /// Amend the response from the downstream server to a pending request.
+    ///
+    /// Downstream servers respond with a `MethodNotFound` error to requests they don't support
+    /// (despite Onoma advertising support for them), which is replaced by Onoma's results alone.
+    /// Any other error response is never amended.
+    async fn amend_response(&self, request: PendingRequest, response: &mut Value) {
+        if !matches!(request, PendingRequest::Initialize)
+            && response["error"]["code"].as_i64() == Some(constant::METHOD_NOT_FOUND_ERROR_CODE)
+            && let Some(response) = response.as_object_mut()
+        {
+            log::debug!("Downstream server doesn't support the request, falling back to Onoma");
+
+            response.remove("error");
+            response.insert(String::from("result"), Value::Null);
+        }
+
+        let Some(result) = response.get_mut("result") else {
+            return;
+        };
+
+        match request {
+            PendingRequest::Initialize => {
+                if let Some(capabilities) = result
+                    .get_mut("capabilities")
+                    .and_then(Value::as_object_mut)
+                {
+                    capabilities.insert(String::from("workspaceSymbolProvider"), json!(true));
+                    capabilities.insert(String::from("definitionProvider"), json!(true));
+
+                    // The downstream server's own options (i.e. its trigger characters) are kept
+                    capabilities
+                        .entry("completionProvider")
+                        .or_insert_with(|| json!({}));
+                }
+            }
+            PendingRequest::WorkspaceSymbol { query } => {
+                let mut symbols = match result.take() {
+                    Value::Array(symbols) => symbols,
+                    _ => Vec::new(),
+                };
+
+                let returned = symbols
+                    .iter()
+                    .map(|symbol| (symbol["name"].clone(), symbol["location"]["uri"].clone()))
+                    .collect::<HashSet<_>>();
+
+                symbols.extend(self.get_workspace_symbols(query).await.into_iter().filter(
+                    |symbol| {
+                        !returned
+                            .contains(&(symbol["name"].clone(), symbol["location"]["uri"].clone()))
+                    },
+                ));
+
+                *result = Value::Array(symbols);
+            }
+            PendingRequest::Definition { path, position } => {
+                if !(result.is_null() || result.as_array().is_some_and(Vec::is_empty)) {
+                    return;
+                }
+
+                if let Some(hover) = self.hover_resolver.get_hover(&path, position).await {
+                    log::debug!(
+                        "Downstream server found no definition of {}, falling back to Onoma",
+                        hover.symbol.name
+                    );
+
+                    *result = json!(utils::get_location(&hover.symbol));
+                }
+            }
+            PendingRequest::Completion {
+                path,
+                content,
+                position,
+            } => {
+                // Downstream servers respond with either a list of items, or a `CompletionList`
+                let (mut items, is_incomplete) = match result.take() {
+                    Value::Array(items) => (items, false),
+                    Value::Object(mut list) => (
+                        match list.remove("items") {
+                            Some(Value::Array(items)) => items,
+                            _ => Vec::new(),
+                        },
+                        list.get("isIncomplete")
+                            .and_then(Value::as_bool)
+                            .unwrap_or_default(),
+                    ),
+                    _ => (Vec::new(), false),
+                };
+
+                // Completions have a latency budget of their own, after which Onoma's
+                // candidates so far are returned
+                let completions = self.get_completions(&path, content, position).await;
+
+                let returned = items
+                    .iter()
+                    .map(|item| item["label"].clone())
+                    .collect::<HashSet<_>>();
+
+                items.extend(
+                    completions
+                        .items
+                        .iter()
+                        .filter(|item| !returned.contains(&json!(item.label)))
+                        .map(|item| json!(utils::get_completion_item(item, completions.range))),
+                );
+
+                *result = json!({
+                    "isIncomplete": is_incomplete || completions.is_incomplete,
+                    "items": items,
+                });
+            }
+        }
+    }
+
+    /// Get Onoma's candidates for completing the prefix at a position in a file, given its
+    /// content as open in the client.
+    ///
+    /// Files which aren't open in the client are read from disk.
+    async fn get_completions(
+        &self,
+        path: &Path,
+        content: Option<String>,
+        position: Position,
+    ) -> CompletionList {
+        let content = match content {
+            Some(content) => content,
+            None => tokio::fs::read_to_string(path)
+                .await
+                .inspect_err(|e| {
+                    log::debug!("Unable to read {} for completion: {e}", path.display());
+                })
+                .unwrap_or_default(),
+        };
+
+        self.completion_resolver
+            .get_completions(path, &content, position, &Context::default())
+            .await
+    }
+
+    /// Get Onoma's ranked results for a `workspace/symbol` query.
+    async fn get_workspace_symbols(&self, query: String) -> Vec<Value> {
+        let symbols: Vec<ResolvedSymbol> = self
+            .resolver
+            .search(
+                SymbolQuery::fuzzy(query)
+                    .with_sort(Sort::Score)
+                    .with_limit(constant::MAX_WORKSPACE_SYMBOLS),
+                Context::default(),
+            )
+            .collect()
+            .await;
+
+        symbols
+            .iter()
+            .map(|symbol| json!(utils::get_symbol_information(symbol)))
+            .collect()
+    }
+}
+
+/// Get the path and position of the `TextDocumentPositionParams` of a request (i.e.
+/// `textDocument/definition`).
+///
+/// Returns [`None`] if the document isn't a file.
+fn get_text_document_position(params: &Value) -> Option<(PathBuf, Position)> {
+    params["textDocument"]["uri"]
+        .as_str()
+        .and_then(utils::get_path)
+        .zip(serde_json::from_value::<Position>(params["position"].clone()).ok())
+}
+
+/// Join every task writing to the client which has already finished, returning the first error
+/// writing to the client (or from a task which panicked).
+fn join_finished_tasks(tasks: &mut JoinSet<std::io::Result<()>>) -> std::io::Result<()> {
+    while let Some(result) = tasks.try_join_next() {
+        result.map_err(std::io::Error::other)??;
+    }
+
+    Ok(())
+}
+
+/// Wait for every task writing to the client to finish, returning the first error writing to the
+/// client (or from a task which panicked).
+async fn join_all_tasks(tasks: &mut JoinSet<std::io::Result<()>>) -> std::io::Result<()> {
+    while let Some(result) = tasks.join_next().await {
+        result.map_err(std::io::Error::other)??;
+    }
+
+    Ok(())
+}
+
+/// Keep track of the content of the documents open in the client, from a `textDocument/didOpen`,
+/// `textDocument/didChange` or `textDocument/didClose` notification.
+fn update_documents(documents: &mut HashMap<PathBuf, String>, method: &str, params: &Value) {
+    let Some(path) = params["textDocument"]["uri"]
+        .as_str()
+        .and_then(utils::get_path)
+    else {
+        return;
+    };
+
+    match method {
+        "textDocument/didOpen" => {
+            documents.insert(
+                path,
+                params["textDocument"]["text"]
+                    .as_str()
+                    .unwrap_or_default()
+                    .to_string(),
+            );
+        }
+        "textDocument/didChange" => {
+            if let Some(content) = documents.get_mut(&path) {
+                for change in params["contentChanges"].as_array().into_iter().flatten() {
+                    utils::apply_content_change(content, change);
+                }
+            }
+        }
+        "textDocument/didClose" => {
+            documents.remove(&path);
+        }
+        _ => {}
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use std::{collections::HashMap, sync::Arc};
+
+    use rstest::rstest;
+    use serde_json::{Value, json};
+    use tempfile::tempdir;
+    use tokio::{
+        fs,
+        io::{self, BufReader},
+        sync::Mutex,
+    };
+
+    use crate::{
+        indexer::{self, Indexer},
+        lsp::{WorkspaceSymbolMode, read_message, write_message},
+    };
+
+    /// A stub language server, which never finds any definitions, and only ever finds a single
+    /// workspace symbol (and a single completion).
+    ///
+    /// Returns the methods of every request it received.
+    async fn run_stub_server(stream: io::DuplexStream) -> Vec<String> {
+        let (reader, mut writer) = io::split(stream);
+        let mut reader = BufReader::new(reader);
+
+        let mut methods = Vec::new();
+
+        // Servers commonly send messages before they've been initialized
+        write_message(
+            &mut writer,
+            &json!({"jsonrpc": "2.0", "method": "window/logMessage", "params": {"type": 3, "message": "Starting"}}),
+        )
+        .await
+        .expect("Should never fail to write to the proxy");
+
+        while let Some(message) = read_message(&mut reader)
+            .await
+            .expect("Should never fail to read from the proxy")
+        {
+            let method = message["method"].as_str().unwrap_or_default().to_string();
+
+            let result = match method.as_str() {
+                "initialize" => json!({"capabilities": {"hoverProvider": true}}),
+                "workspace/symbol" => json!([{
+                    "name": "getUserFromCache",
+                    "kind": 12,
+                    "location": {
+                        "uri": "file:///elsewhere/cache.ts",
+                        "range": {"start": {"line": 0, "character": 0}, "end": {"line": 0, "character": 16}},
+                    },
+                }]),
+                "textDocument/completion" => json!([{"label": "getUserFromCache", "kind": 3}]),
+                _ => Value::Null,
+            };
+
+            methods.push(method);
+
+            if let Some(id) = message.get("id") {
+                write_message(
+                    &mut writer,
+                    &json!({"jsonrpc": "2.0", "id": id, "result": result}),
+                )
+                .await
+                .expect("Should never fail to write to the proxy");
+            }
+        }
+
+        methods
+    }
+
+    #[rstest]
+    #[case(WorkspaceSymbolMode::Augment)]
+    #[case(WorkspaceSymbolMode::Replace)]
+    #[tokio::test]
+    pub async fn test_proxying_language_server(#[case] mode: WorkspaceSymbolMode) {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        let definition = workspace.path().join("user.ts");
+        let current = workspace.path().join("app.ts");
+
+        fs::write(&definition, "export function getUser() {}\n")
+            .await
+            .expect("Should never fail to write a file into the workspace");
+        fs::write(&current, "getUser();\n")
+            .await
+            .expect("Should never fail to write a file into the workspace");
+
+        let workspaces = vec![workspace.path()];
+
+        let indexer = indexer::DatabaseBackedIndexer::new(storage_path.path(), workspaces.clone())
+            .await
+            .expect("Should be able to create the empty index");
+
+        assert!(indexer.index_workspaces().await.is_ok());
+
+        let proxy =
+            super::Proxy::new(storage_path.path(), workspaces).with_workspace_symbol_mode(mode);
+
+        let (client, proxy_client) = io::duplex(64 * 1024);
+        let (proxy_server, server) = io::duplex(64 * 1024);
+
+        let (client_reader, client_writer) = io::split(proxy_client);
+        let (server_reader, server_writer) = io::split(proxy_server);
+
+        let run_client = async move {
+            let (reader, mut writer) = io::split(client);
+            let mut reader = BufReader::new(reader);
+
+            let requests = [
+                json!({"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"capabilities": {}}}),
+                json!({"jsonrpc": "2.0", "id": 2, "method": "workspace/symbol", "params": {"query": "getUser"}}),
+                json!({"jsonrpc": "2.0", "id": 3, "method": "textDocument/definition", "params": {
+                    "textDocument": {"uri": super::utils::get_uri(&current)},
+                    "position": {"line": 0, "character": 2},
+                }}),
+                json!({"jsonrpc": "2.0", "method": "textDocument/didOpen", "params": {
+                    "textDocument": {"uri": super::utils::get_uri(&current), "languageId": "typescript", "version": 1, "text": "getUser();\n"},
+                }}),
+                // The new line is never saved to disk
+                json!({"jsonrpc": "2.0", "method": "textDocument/didChange", "params": {
+                    "textDocument": {"uri": super::utils::get_uri(&current), "version": 2},
+                    "contentChanges": [{
+                        "range": {"start": {"line": 1, "character": 0}, "end": {"line": 1, "character": 0}},
+                        "text": "getU",
+                    }],
+                }}),
+                json!({"jsonrpc": "2.0", "id": 4, "method": "textDocument/completion", "params": {
+                    "textDocument": {"uri": super::utils::get_uri(&current)},
+                    "position": {"line": 1, "character": 4},
+                }}),
+                json!({"jsonrpc": "2.0", "id": 5, "method": "shutdown"}),
+            ];
+
+            let mut messages = Vec::new();
+
+            for request in requests {
+                write_message(&mut writer, &request)
+                    .await
+                    .expect("Should never fail to write to the proxy");
+
+                // Notifications are never responded to
+                if request.get("id").is_none() {
+                    continue;
+                }
+
+                // Wait for the response, collecting any other messages along the way
+                loop {
+                    let message = read_message(&mut reader)
+                        .await
+                        .expect("Should never fail to read from the proxy")
+                        .expect("Proxy should respond to every request");
+
+                    let is_response = message.get("id") == request.get("id");
+
+                    messages.push(message);
+
+                    if is_response {
+                        break;
+                    }
+                }
+            }
+
+            write_message(&mut writer, &json!({"jsonrpc": "2.0", "method": "exit"}))
+                .await
+                .expect("Should never fail to write to the proxy");
+
+            messages
+        };
+
+        let (result, messages, methods) = tokio::join!(
+            proxy.run(
+                BufReader::new(client_reader),
+                client_writer,
+                BufReader::new(server_reader),
+                server_writer,
+            ),
+            run_client,
+            run_stub_server(server),
+        );
+
+        assert!(result.is_ok());
+
+        // Messages from the downstream server are forwarded untouched
+        assert_eq!(json!("window/logMessage"), messages[0]["method"]);
+
+        // Capabilities are always advertised
+        assert_eq!(
+            json!({
+                "hoverProvider": true,
+                "workspaceSymbolProvider": true,
+                "definitionProvider": true,
+                "completionProvider": {},
+            }),
+            messages[1]["result"]["capabilities"]
+        );
+
+        let symbols = messages[2]["result"]
+            .as_array()
+            .expect("Workspace symbols should be returned")
+            .iter()
+            .map(|symbol| symbol["name"].as_str().unwrap_or_default())
+            .collect::<Vec<_>>();
+
+        match mode {
+            WorkspaceSymbolMode::Augment => {
+                assert_eq!(vec!["getUserFromCache", "getUser"], symbols);
+            }
+            WorkspaceSymbolMode::Replace => {
+                assert_eq!(vec!["getUser"], symbols);
+            }
+        }
+
+        // The downstream server found nothing, so the definition falls back to Onoma
+        assert_eq!(
+            json!({
+                "uri": super::utils::get_uri(&definition),
+                "range": {"start": {"line": 0, "character": 16}, "end": {"line": 0, "character": 23}},
+            }),
+            messages[3]["result"]
+        );
+
+        // Onoma's candidates are added after the downstream server's, replacing the prefix as
+        // it was typed in the client
+        let completions = messages[4]["result"]["items"]
+            .as_array()
+            .expect("Completions should be returned");
+
+        assert_eq!(
+            vec!["getUserFromCache", "getUser"],
+            completions
+                .iter()
+                .map(|item| item["label"].as_str().unwrap_or_default())
+                .collect::<Vec<_>>()
+        );
+        assert_eq!(
+            json!({
+                "range": {"start": {"line": 1, "character": 0}, "end": {"line": 1, "character": 4}},
+                "newText": "getUser",
+            }),
+            completions[1]["textEdit"]
+        );
+
+        assert_eq!(Value::Null, messages[5]["result"]);
+
+        let expected_methods = match mode {
+            WorkspaceSymbolMode::Augment => vec![
+                "initialize",
+                "workspace/symbol",
+                "textDocument/definition",
+                "textDocument/didOpen",
+                "textDocument/didChange",
+                "textDocument/completion",
+                "shutdown",
+                "exit",
+            ],
+            WorkspaceSymbolMode::Replace => vec![
+                "initialize",
+                "textDocument/definition",
+                "textDocument/didOpen",
+                "textDocument/didChange",
+                "textDocument/completion",
+                "shutdown",
+                "exit",
+            ],
+        };
+
+        assert_eq!(expected_methods, methods);
+    }
+
+    #[tokio::test]
+    pub async fn test_forgetting_cancelled_requests() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        let proxy = super::Proxy::new(storage_path.path(), [workspace.path()]);
+
+        let mut client = Vec::new();
+
+        for message in [
+            json!({"jsonrpc": "2.0", "id": 1, "method": "workspace/symbol", "params": {"query": "getUser"}}),
+            json!({"jsonrpc": "2.0", "id": 2, "method": "workspace/symbol", "params": {"query": "getSession"}}),
+            json!({"jsonrpc": "2.0", "method": "$/cancelRequest", "params": {"id": 1}}),
+        ] {
+            write_message(&mut client, &message)
+                .await
+                .expect("Should never fail to write to a buffer");
+        }
+
+        let pending = Mutex::new(HashMap::new());
+        let mut server = Vec::new();
+
+        proxy
+            .forward_to_server(
+                client.as_slice(),
+                &mut server,
+                &Arc::new(Mutex::new(io::sink())),
+                &pending,
+            )
+            .await
+            .expect("Every message should be forwarded to the server");
+
+        // Notice, the cancellation is still forwarded, as the server may still be working on
+        // the request
+        let mut server = server.as_slice();
+        let mut methods = Vec::new();
+
+        while let Some(message) = read_message(&mut server)
+            .await
+            .expect("Should never fail to read from a buffer")
+        {
+            methods.push(message["method"].as_str().unwrap_or_default().to_string());
+        }
+
+        assert_eq!(
+            vec!["workspace/symbol", "workspace/symbol", "$/cancelRequest"],
+            methods
+        );
+
+        assert_eq!(
+            vec![json!(2).to_string()],
+            pending.lock().await.keys().cloned().collect::<Vec<_>>()
+        );
+    }
+
+    #[tokio::test]
+    pub async fn test_serving_client_directly() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        let current = workspace.path().join("app.ts");
+
+        fs::write(
+            workspace.path().join("user.ts"),
+            "export function getUser() {}\n",
+        )
+        .await
+        .expect("Should never fail to write a file into the workspace");
+        fs::write(&current, "getUser();\n")
+            .await
+            .expect("Should never fail to write a file into the workspace");
+
+        let workspaces = vec![workspace.path()];
+
+        let indexer = indexer::DatabaseBackedIndexer::new(storage_path.path(), workspaces.clone())
+            .await
+            .expect("Should be able to create the empty index");
+
+        assert!(indexer.index_workspaces().await.is_ok());
+
+        let proxy = super::Proxy::new(storage_path.path(), workspaces);
+
+        let mut client = Vec::new();
+
+        for message in [
+            json!({"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"capabilities": {}}}),
+            json!({"jsonrpc": "2.0", "method": "initialized", "params": {}}),
+            json!({"jsonrpc": "2.0", "method": "textDocument/didOpen", "params": {
+                "textDocument": {"uri": super::utils::get_uri(&current), "languageId": "typescript", "version": 1, "text": "getUser();\ngetU\n"},
+            }}),
+            json!({"jsonrpc": "2.0", "id": 2, "method": "textDocument/completion", "params": {
+                "textDocument": {"uri": super::utils::get_uri(&current)},
+                "position": {"line": 1, "character": 4},
+            }}),
+            json!({"jsonrpc": "2.0", "id": 3, "method": "textDocument/hover", "params": {
+                "textDocument": {"uri": super::utils::get_uri(&current)},
+                "position": {"line": 0, "character": 2},
+            }}),
+            json!({"jsonrpc": "2.0", "id": 4, "method": "textDocument/formatting", "params": {
+                "textDocument": {"uri": super::utils::get_uri(&current)},
+                "options": {"tabSize": 4, "insertSpaces": true},
+            }}),
+            json!({"jsonrpc": "2.0", "id": 5, "method": "shutdown"}),
+            json!({"jsonrpc": "2.0", "method": "exit"}),
+            // Nothing after the client exits is read
+            json!({"jsonrpc": "2.0", "id": 6, "method": "shutdown"}),
+        ] {
+            write_message(&mut client, &message)
+                .await
+                .expect("Should never fail to write to a buffer");
+        }
+
+        let (output, proxy_output) = io::duplex(64 * 1024);
+
+        let read_output = async move {
+            let mut output = BufReader::new(output);
+            let mut messages = Vec::new();
+
+            while let Some(message) = read_message(&mut output)
+                .await
+                .expect("Should never fail to read from the proxy")
+            {
+                messages.push(message);
+            }
+
+            messages
+        };
+
+        let (result, mut messages) =
+            tokio::join!(proxy.serve(client.as_slice(), proxy_output), read_output);
+
+        assert!(result.is_ok());
+
+        // Requests are answered concurrently, so responses can be in any order
+        messages.sort_by_key(|message| message["id"].as_u64());
+
+        // Only requests are answered
+        assert_eq!(
+            vec![json!(1), json!(2), json!(3), json!(4), json!(5)],
+            messages
+                .iter()
+                .map(|message| message["id"].clone())
+                .collect::<Vec<_>>()
+        );
+
+        assert_eq!(
+            json!({
+                "textDocumentSync": 2,
+                "workspaceSymbolProvider": true,
+                "definitionProvider": true,
+                "hoverProvider": true,
+                "completionProvider": {},
+            }),
+            messages[0]["result"]["capabilities"]
+        );
+
+        // Completions are for the document as open in the client
+        let completions = messages[1]["result"]["items"]
+            .as_array()
+            .expect("Completions should be returned");
+
+        assert_eq!(
+            vec!["getUser"],
+            completions
+                .iter()
+                .map(|item| item["label"].as_str().unwrap_or_default())
+                .collect::<Vec<_>>()
+        );
+        assert_eq!(
+            json!({
+                "range": {"start": {"line": 1, "character": 0}, "end": {"line": 1, "character": 4}},
+                "newText": "getUser",
+            }),
+            completions[0]["textEdit"]
+        );
+
+        assert_eq!(json!("markdown"), messages[2]["result"]["contents"]["kind"]);
+        assert_eq!(
+            json!({"start": {"line": 0, "character": 0}, "end": {"line": 0, "character": 7}}),
+            messages[2]["result"]["range"]
+        );
+
+        assert_eq!(json!(-32601), messages[3]["error"]["code"]);
+        assert_eq!(Value::Null, messages[4]["result"]);
+    }
+}
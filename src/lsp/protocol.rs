@@ -0,0 +1,101 @@
+use serde::Serialize;
+
+use crate::models::resolved::TextRange;
+
+/// A location in a file, as a URI and range.
+///
+/// Matches the `Location` of the Language Server Protocol.
+#[derive(Debug, Serialize)]
+pub struct Location {
+    /// The `file://` URI of the file.
+    pub uri: String,
+
+    /// The range of the location in the file.
+    pub range: TextRange,
+}
+
+/// A symbol returned from a `workspace/symbol` request.
+///
+/// Matches the `SymbolInformation` of the Language Server Protocol.
+#[derive(Debug, Serialize)]
+#[serde(rename_all = "camelCase")]
+pub struct SymbolInformation {
+    /// The name of the symbol.
+    pub name: String,
+
+    /// The `SymbolKind` of the Language Server Protocol, which is a number.
+    pub kind: u8,
+
+    /// The `SymbolTag`s of the symbol (i.e. deprecated).
+    #[serde(skip_serializing_if = "Vec::is_empty")]
+    pub tags: Vec<u8>,
+
+    /// Where the symbol is defined.
+    pub location: Location,
+
+    /// The name of the package the symbol is defined in, if any.
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub container_name: Option<String>,
+}
+
+/// A textual edit to the file a completion is requested in.
+///
+/// Matches the `TextEdit` of the Language Server Protocol.
+#[derive(Debug, Serialize)]
+#[serde(rename_all = "camelCase")]
+pub struct TextEdit {
+    /// The range of text being replaced.
+    pub range: TextRange,
+
+    /// The text to replace the range with.
+    pub new_text: String,
+}
+
+/// A candidate returned from a `textDocument/completion` request.
+///
+/// Matches the `CompletionItem` of the Language Server Protocol.
+#[derive(Debug, Serialize)]
+#[serde(rename_all = "camelCase")]
+pub struct CompletionItem {
+    /// The name of the symbol being completed.
+    pub label: String,
+
+    /// The `CompletionItemKind` of the Language Server Protocol, which is a number.
+    pub kind: u8,
+
+    /// The `CompletionItemTag`s of the candidate (i.e. deprecated).
+    #[serde(skip_serializing_if = "Vec::is_empty")]
+    pub tags: Vec<u8>,
+
+    /// A human-readable description of the symbol (i.e. its signature, and the file it's defined
+    /// in).
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub detail: Option<String>,
+
+    /// The edit replacing the prefix being completed with the name of the symbol.
+    pub text_edit: TextEdit,
+}
+
+/// Formatted content, shown by the client (i.e. in a hover).
+///
+/// Matches the `MarkupContent` of the Language Server Protocol.
+#[derive(Debug, Serialize)]
+pub struct MarkupContent {
+    /// The format of the content, which is always `markdown`.
+    pub kind: &'static str,
+
+    /// The content itself.
+    pub value: String,
+}
+
+/// The information returned from a `textDocument/hover` request.
+///
+/// Matches the `Hover` of the Language Server Protocol.
+#[derive(Debug, Serialize)]
+pub struct Hover {
+    /// The information about the identifier being hovered.
+    pub contents: MarkupContent,
+
+    /// The range of the identifier being hovered.
+    pub range: TextRange,
+}
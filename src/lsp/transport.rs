@@ -0,0 +1,114 @@
+use serde_json::Value;
+use tokio::io::{AsyncBufRead, AsyncBufReadExt, AsyncReadExt, AsyncWrite, AsyncWriteExt};
+
+/// Read a single message, framed by a `Content-Length` header, as used by the Language Server
+/// Protocol.
+///
+/// Returns [`None`] once the reader has been closed.
+///
+/// # Errors
+///
+/// Returns an error if the message couldn't be read, or isn't valid JSON.
+pub async fn read_message<R>(reader: &mut R) -> std::io::Result<Option<Value>>
+where
+    R: AsyncBufRead + Unpin,
+{
+    let mut content_length = None;
+    let mut line = String::new();
+
+    loop {
+        line.clear();
+
+        if reader.read_line(&mut line).await? == 0 {
+            return Ok(None);
+        }
+
+        let header = line.trim_end();
+
+        if header.is_empty() {
+            // The headers end with an empty line, but some clients send stray newlines between
+            // messages
+            if content_length.is_some() {
+                break;
+            }
+
+            continue;
+        }
+
+        if let Some((name, value)) = header.split_once(':')
+            && name.eq_ignore_ascii_case("content-length")
+        {
+            content_length = Some(
+                value
+                    .trim()
+                    .parse::<usize>()
+                    .map_err(|e| std::io::Error::new(std::io::ErrorKind::InvalidData, e))?,
+            );
+        }
+    }
+
+    let mut content = vec![0; content_length.unwrap_or_default()];
+
+    reader.read_exact(&mut content).await?;
+
+    Ok(Some(serde_json::from_slice(&content)?))
+}
+
+/// Write a single message, framed by a `Content-Length` header, as used by the Language Server
+/// Protocol.
+///
+/// # Errors
+///
+/// Returns an error if the message couldn't be written.
+pub async fn write_message<W>(writer: &mut W, message: &Value) -> std::io::Result<()>
+where
+    W: AsyncWrite + Unpin,
+{
+    let content = serde_json::to_vec(message)?;
+
+    writer
+        .write_all(format!("Content-Length: {}\r\n\r\n", content.len()).as_bytes())
+        .await?;
+    writer.write_all(&content).await?;
+    writer.flush().await
+}
+
+#[cfg(test)]
+mod tests {
+    use serde_json::json;
+
+    #[tokio::test]
+    pub async fn test_reading_and_writing_messages() {
+        let mut output = Vec::new();
+
+        for message in [json!({"id": 1, "method": "initialize"}), json!({"id": "é"})] {
+            super::write_message(&mut output, &message)
+                .await
+                .expect("Should never fail when writing to memory");
+        }
+
+        // Other headers are ignored, and header names are case-insensitive
+        output.extend_from_slice(
+            b"\r\ncontent-length: 2\r\nContent-Type: application/vscode-jsonrpc; charset=utf-8\r\n\r\n{}",
+        );
+
+        let mut reader = output.as_slice();
+        let mut messages = Vec::new();
+
+        while let Some(message) = super::read_message(&mut reader)
+            .await
+            .expect("Messages should always be valid")
+        {
+            messages.push(message);
+        }
+
+        assert_eq!(
+            vec![
+                json!({"id": 1, "method": "initialize"}),
+                json!({"id": "é"}),
+                json!({})
+            ],
+            messages
+        );
+    }
+}
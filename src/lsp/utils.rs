@@ -0,0 +1,324 @@
+use std::path::{Path, PathBuf};
+
+use serde_json::Value;
+
+use crate::{
+    lsp::{
+        constant,
+        protocol::{CompletionItem, Hover, Location, MarkupContent, SymbolInformation, TextEdit},
+    },
+    models::{
+        self,
+        parsed::SymbolKind,
+        resolved::{Position, ResolvedSymbol, TextRange},
+    },
+};
+
+/// Check if a byte can appear in a URI path without being percent-encoded.
+const fn is_unreserved(byte: u8) -> bool {
+    byte.is_ascii_alphanumeric() || matches!(byte, b'-' | b'.' | b'_' | b'~' | b'/')
+}
+
+/// Get the `file://` URI of a path.
+pub fn get_uri(path: &Path) -> String {
+    let mut uri = String::from("file://");
+
+    for byte in path.to_string_lossy().bytes() {
+        if is_unreserved(byte) {
+            uri.push(char::from(byte));
+        } else {
+            uri.push_str(&format!("%{byte:02X}"));
+        }
+    }
+
+    uri
+}
+
+/// Get the path of a `file://` URI.
+///
+/// Returns [`None`] if the URI isn't a file URI.
+pub fn get_path(uri: &str) -> Option<PathBuf> {
+    let encoded = uri.strip_prefix("file://")?.as_bytes();
+
+    let mut bytes = Vec::with_capacity(encoded.len());
+    let mut index = 0;
+
+    while index < encoded.len() {
+        let decoded = (encoded[index] == b'%')
+            .then(|| encoded.get(index + 1..index + 3))
+            .flatten()
+            .and_then(|hex| u8::from_str_radix(std::str::from_utf8(hex).ok()?, 16).ok());
+
+        if let Some(byte) = decoded {
+            bytes.push(byte);
+            index += 3;
+        } else {
+            bytes.push(encoded[index]);
+            index += 1;
+        }
+    }
+
+    Some(PathBuf::from(String::from_utf8_lossy(&bytes).to_string()))
+}
+
+/// Get the `SymbolKind` of the Language Server Protocol which most closely matches the kind of a
+/// symbol.
+const fn get_symbol_kind(kind: SymbolKind) -> u8 {
+    match kind {
+        SymbolKind::File => 1,
+        SymbolKind::Module | SymbolKind::Library => 2,
+        SymbolKind::Namespace => 3,
+        SymbolKind::Package | SymbolKind::PackageObject => 4,
+        SymbolKind::Class | SymbolKind::SingletonClass | SymbolKind::Contract => 5,
+        SymbolKind::Method
+        | SymbolKind::StaticMethod
+        | SymbolKind::MethodSpecification
+        | SymbolKind::TraitMethod
+        | SymbolKind::ProtocolMethod
+        | SymbolKind::TypeClassMethod
+        | SymbolKind::AbstractMethod
+        | SymbolKind::PureVirtualMethod
+        | SymbolKind::MethodAlias
+        | SymbolKind::SingletonMethod
+        | SymbolKind::Getter
+        | SymbolKind::Setter
+        | SymbolKind::Accessor => 6,
+        SymbolKind::Property | SymbolKind::StaticProperty => 7,
+        SymbolKind::Field | SymbolKind::StaticField | SymbolKind::StaticDataMember => 8,
+        SymbolKind::Constructor => 9,
+        SymbolKind::Enum => 10,
+        SymbolKind::Interface
+        | SymbolKind::Protocol
+        | SymbolKind::Trait
+        | SymbolKind::TypeClass
+        | SymbolKind::Mixin => 11,
+        SymbolKind::Function | SymbolKind::Macro | SymbolKind::Delegate => 12,
+        SymbolKind::Constant => 14,
+        SymbolKind::String | SymbolKind::TranslationKey => 15,
+        SymbolKind::Number => 16,
+        SymbolKind::Boolean => 17,
+        SymbolKind::Array => 18,
+        SymbolKind::Object | SymbolKind::Instance => 19,
+        SymbolKind::Key => 20,
+        SymbolKind::Null => 21,
+        SymbolKind::EnumMember => 22,
+        SymbolKind::Struct | SymbolKind::Union | SymbolKind::Message => 23,
+        SymbolKind::Event | SymbolKind::StaticEvent => 24,
+        SymbolKind::Operator => 25,
+        SymbolKind::TypeParameter | SymbolKind::AssociatedType => 26,
+        _ => 13,
+    }
+}
+
+/// Get the `CompletionItemKind` of the Language Server Protocol which most closely matches the
+/// kind of a symbol.
+const fn get_completion_item_kind(kind: SymbolKind) -> u8 {
+    match kind {
+        SymbolKind::Method
+        | SymbolKind::StaticMethod
+        | SymbolKind::MethodSpecification
+        | SymbolKind::TraitMethod
+        | SymbolKind::ProtocolMethod
+        | SymbolKind::TypeClassMethod
+        | SymbolKind::AbstractMethod
+        | SymbolKind::PureVirtualMethod
+        | SymbolKind::MethodAlias
+        | SymbolKind::SingletonMethod
+        | SymbolKind::Getter
+        | SymbolKind::Setter
+        | SymbolKind::Accessor => 2,
+        SymbolKind::Function | SymbolKind::Macro | SymbolKind::Delegate => 3,
+        SymbolKind::Constructor => 4,
+        SymbolKind::Field | SymbolKind::StaticField | SymbolKind::StaticDataMember => 5,
+        SymbolKind::Class | SymbolKind::SingletonClass | SymbolKind::Contract => 7,
+        SymbolKind::Interface
+        | SymbolKind::Protocol
+        | SymbolKind::Trait
+        | SymbolKind::TypeClass
+        | SymbolKind::Mixin => 8,
+        SymbolKind::Module
+        | SymbolKind::Library
+        | SymbolKind::Namespace
+        | SymbolKind::Package
+        | SymbolKind::PackageObject => 9,
+        SymbolKind::Property | SymbolKind::StaticProperty => 10,
+        SymbolKind::Enum => 13,
+        SymbolKind::File => 17,
+        SymbolKind::EnumMember => 20,
+        SymbolKind::Constant => 21,
+        SymbolKind::Struct | SymbolKind::Union | SymbolKind::Message => 22,
+        SymbolKind::Event | SymbolKind::StaticEvent => 23,
+        SymbolKind::Operator => 24,
+        SymbolKind::TypeParameter | SymbolKind::AssociatedType => 25,
+        _ => 6,
+    }
+}
+
+/// Get the location of a symbol's name.
+///
+/// The index stores columns as bytes, so columns are only exact for lines which are ASCII up to
+/// the symbol.
+pub fn get_location(symbol: &ResolvedSymbol) -> Location {
+    let get_position = |line: i64, column: i64| Position {
+        line: u32::try_from(line - 1).unwrap_or_default(),
+        character: u32::try_from(column - 1).unwrap_or_default(),
+    };
+
+    Location {
+        uri: get_uri(&symbol.path),
+        range: TextRange {
+            start: get_position(symbol.start_line, symbol.start_column),
+            end: get_position(symbol.end_line, symbol.end_column),
+        },
+    }
+}
+
+/// Get the information about a symbol returned from a `workspace/symbol` request.
+pub fn get_symbol_information(symbol: &ResolvedSymbol) -> SymbolInformation {
+    SymbolInformation {
+        name: symbol.name.clone(),
+        kind: get_symbol_kind(symbol.kind),
+        tags: if symbol.deprecated {
+            vec![constant::DEPRECATED_SYMBOL_TAG]
+        } else {
+            Vec::new()
+        },
+        location: get_location(symbol),
+        container_name: symbol.package.clone(),
+    }
+}
+
+/// Get a candidate returned from a `textDocument/completion` request, which replaces the prefix
+/// being completed (at a range) with the name of the symbol.
+pub fn get_completion_item(
+    item: &models::resolved::CompletionItem,
+    range: TextRange,
+) -> CompletionItem {
+    CompletionItem {
+        label: item.label.clone(),
+        kind: get_completion_item_kind(item.kind),
+        tags: if item.symbol.deprecated {
+            vec![constant::DEPRECATED_COMPLETION_ITEM_TAG]
+        } else {
+            Vec::new()
+        },
+        detail: item.detail.clone(),
+        text_edit: TextEdit {
+            range,
+            new_text: item.label.clone(),
+        },
+    }
+}
+
+/// Get the information returned from a `textDocument/hover` request.
+pub fn get_hover(hover: &models::resolved::Hover) -> Hover {
+    Hover {
+        contents: MarkupContent {
+            kind: "markdown",
+            value: hover.contents.clone(),
+        },
+        range: hover.range,
+    }
+}
+
+/// Get the byte offset of a position in some content.
+///
+/// Positions past the end of a line are clamped to the end of that line (before its line
+/// break), and positions past the last line are clamped to the end of the content.
+fn get_offset(content: &str, position: Position) -> usize {
+    let Some((start, line)) = content
+        .split_inclusive('\n')
+        .scan(0, |start, line| {
+            let line_start = *start;
+            *start += line.len();
+
+            Some((line_start, line))
+        })
+        .nth(usize::try_from(position.line).unwrap_or(usize::MAX))
+    else {
+        return content.len();
+    };
+
+    let line = line.trim_end_matches(['\n', '\r']);
+
+    // Positions are counted in UTF-16 code units, so must be converted into a byte offset
+    let mut characters = 0;
+    let offset = line
+        .char_indices()
+        .find(|(_, c)| {
+            let is_past = characters >= position.character;
+
+            characters += u32::try_from(c.len_utf16()).unwrap_or(1);
+
+            is_past
+        })
+        .map_or(line.len(), |(offset, _)| offset);
+
+    start + offset
+}
+
+/// Apply a `TextDocumentContentChangeEvent` (from a `textDocument/didChange` notification) to
+/// the content of an open document.
+///
+/// Changes without a range replace the whole document.
+pub fn apply_content_change(content: &mut String, change: &Value) {
+    let Some(text) = change["text"].as_str() else {
+        return;
+    };
+
+    match serde_json::from_value::<TextRange>(change["range"].clone()) {
+        Ok(range) => {
+            let start = get_offset(content, range.start);
+            let end = get_offset(content, range.end).max(start);
+
+            content.replace_range(start..end, text);
+        }
+        Err(_) => text.clone_into(content),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use std::path::Path;
+
+    use rstest::rstest;
+    use serde_json::json;
+
+    #[rstest]
+    #[case("/workspace/src/main.rs", "file:///workspace/src/main.rs")]
+    #[case("/my workspace/naïve.ts", "file:///my%20workspace/na%C3%AFve.ts")]
+    pub fn test_converting_paths_to_uris(#[case] path: &str, #[case] uri: &str) {
+        assert_eq!(uri, super::get_uri(Path::new(path)));
+        assert_eq!(Some(Path::new(path).to_path_buf()), super::get_path(uri));
+    }
+
+    #[test]
+    pub fn test_only_file_uris_have_paths() {
+        assert_eq!(None, super::get_path("untitled:Untitled-1"));
+    }
+
+    #[rstest]
+    #[case(json!({"text": "getUser();\n"}), "getUser();\n")]
+    #[case(
+        json!({"range": {"start": {"line": 1, "character": 0}, "end": {"line": 1, "character": 0}}, "text": "getUs"}),
+        "let user;\ngetUs"
+    )]
+    #[case(
+        json!({"range": {"start": {"line": 0, "character": 4}, "end": {"line": 0, "character": 8}}, "text": "naïve"}),
+        "let naïve;\n"
+    )]
+    #[case(
+        json!({"range": {"start": {"line": 0, "character": 8}, "end": {"line": 5, "character": 0}}, "text": ""}),
+        "let user"
+    )]
+    pub fn test_applying_content_changes(
+        #[case] change: serde_json::Value,
+        #[case] expected: &str,
+    ) {
+        let mut content = String::from("let user;\n");
+
+        super::apply_content_change(&mut content, &change);
+
+        assert_eq!(expected, content);
+    }
+}
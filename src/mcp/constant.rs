@@ -0,0 +1,28 @@
+/// The versions of the Model Context Protocol the server supports, from the latest.
+///
+/// Clients requesting any other version (or none at all) are offered the latest version, which
+/// they can choose to disconnect from.
+pub const SUPPORTED_PROTOCOL_VERSIONS: &[&str] = &["2025-06-18", "2025-03-26", "2024-11-05"];
+
+/// The JSON-RPC error code for a message which isn't valid JSON.
+pub const PARSE_ERROR: i64 = -32700;
+
+/// The JSON-RPC error code for a message which is valid JSON, but not a valid request.
+pub const INVALID_REQUEST: i64 = -32600;
+
+/// The JSON-RPC error code for a request to a method the server doesn't implement.
+pub const METHOD_NOT_FOUND: i64 = -32601;
+
+/// The JSON-RPC error code for a request with invalid parameters (i.e. an unknown tool).
+pub const INVALID_PARAMS: i64 = -32602;
+
+/// The number of symbols returned by `search_symbols` when the client doesn't provide a limit.
+pub const DEFAULT_SEARCH_LIMIT: usize = 20;
+
+/// The maximum number of symbols returned by `search_symbols`, regardless of the limit the
+/// client provides.
+pub const MAX_SEARCH_LIMIT: usize = 200;
+
+/// The number of references returned by `find_references` when the client doesn't provide a
+/// limit.
+pub const DEFAULT_REFERENCE_LIMIT: usize = 100;
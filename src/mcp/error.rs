@@ -0,0 +1,42 @@
+use thiserror::Error;
+
+use crate::mcp::constant;
+
+/// Errors that can occur when handling a request from a Model Context Protocol client.
+///
+/// Errors from calling a tool (i.e. invalid arguments) are reported to the client as part of the
+/// tool's result, so that agents can correct themselves. All others are reported as JSON-RPC
+/// errors.
+#[derive(Error, Debug)]
+pub enum Error {
+    /// The client requested a method which the server doesn't implement.
+    #[error("Method not found: {0}")]
+    MethodNotFound(String),
+
+    /// The client called a tool which the server doesn't offer.
+    #[error("Unknown tool: {0}")]
+    UnknownTool(String),
+
+    /// The parameters of a request (or the arguments of a tool) were missing or invalid.
+    #[error("Invalid parameters: {0}")]
+    InvalidParams(serde_json::Error),
+
+    /// A symbol kind provided as a filter isn't a known [`crate::models::parsed::SymbolKind`].
+    #[error("Unknown symbol kind: {0}")]
+    UnknownSymbolKind(String),
+
+    /// No definition of a symbol with the requested name has been indexed.
+    #[error("No definition of \"{0}\" is indexed")]
+    DefinitionNotFound(String),
+}
+
+impl Error {
+    /// Get the JSON-RPC error code for the error, when it's reported as a JSON-RPC error.
+    #[must_use]
+    pub const fn code(&self) -> i64 {
+        match self {
+            Self::MethodNotFound(_) => constant::METHOD_NOT_FOUND,
+            _ => constant::INVALID_PARAMS,
+        }
+    }
+}
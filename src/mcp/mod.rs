@@ -0,0 +1,562 @@
+//! A [Model Context Protocol](https://modelcontextprotocol.io) server, which exposes symbol search
+//! to coding agents as tools, without the need for language servers.
+//!
+//! The server speaks newline-delimited JSON-RPC 2.0 (the `stdio` transport of the protocol), and
+//! offers the following tools:
+//!
+//! 1. `search_symbols` - fuzzy search for symbols by name (see [`crate::resolver::Resolver::query`])
+//! 2. `get_definition` - find the definition of a symbol by its exact name
+//! 3. `list_file_symbols` - list every symbol defined in a file
+//! 4. `find_references` - find every occurrence of a symbol's name (see
+//!    [`crate::resolver::RenameResolver::get_references`])
+//!
+//! The server only reads from the index, so should be run alongside a
+//! [`crate::watcher::Watcher`] which keeps the index fresh. The `onoma-mcp` binary does exactly
+//! that, serving over stdin and stdout.
+
+use std::path::{Path, PathBuf};
+
+use itertools::Itertools;
+use serde::de::DeserializeOwned;
+use serde_json::{Value, json};
+use tokio::io::{AsyncBufRead, AsyncBufReadExt, AsyncWrite, AsyncWriteExt};
+use tokio_stream::StreamExt;
+
+mod constant;
+mod error;
+mod protocol;
+mod tools;
+mod types;
+
+pub use error::Error;
+pub use types::Result;
+
+use crate::{
+    mcp::{
+        protocol::{CallToolParams, Request, Response},
+        tools::{
+            CompactReference, CompactSymbol, ListFileSymbolsArguments, NameArguments,
+            SearchSymbolsArguments,
+        },
+    },
+    models::{parsed::SymbolKind, resolved::ResolvedSymbol},
+    resolver::{Context, DatabaseBackedResolver, RenameResolver, Resolver, SymbolKindFilter},
+};
+
+/// Parse the arguments of a tool call.
+fn parse_arguments<T: DeserializeOwned>(arguments: Value) -> Result<T> {
+    // Clients commonly omit the arguments entirely for tools which don't need any
+    let arguments = if arguments.is_null() {
+        json!({})
+    } else {
+        arguments
+    };
+
+    serde_json::from_value(arguments).map_err(Error::InvalidParams)
+}
+
+/// A Model Context Protocol server, backed by an existing index.
+#[derive(Debug, Clone)]
+pub struct Server {
+    workspaces: Vec<PathBuf>,
+    resolver: DatabaseBackedResolver,
+    rename_resolver: RenameResolver,
+}
+
+impl Server {
+    /// Initialize a server at a given database path, for a set of workspaces.
+    ///
+    /// As with [`crate::resolver::DatabaseBackedResolver::new`], the storage path and workspaces
+    /// should match those provided to the indexer.
+    #[must_use]
+    pub fn new<'a, 'b>(
+        storage_path: &'b Path,
+        workspaces: impl IntoIterator<Item = &'a Path>,
+    ) -> Self {
+        let workspaces = workspaces.into_iter().collect::<Vec<_>>();
+
+        Self {
+            resolver: DatabaseBackedResolver::new(storage_path, workspaces.iter().copied()),
+            rename_resolver: RenameResolver::new(storage_path, workspaces.iter().copied()),
+            workspaces: workspaces.into_iter().map(Path::to_path_buf).collect(),
+        }
+    }
+
+    /// Serve requests read (one per line) from a reader, writing each response (one per line)
+    /// to a writer, until the reader is closed.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if reading a request, or writing a response, fails.
+    pub async fn serve<R, W>(&self, reader: R, mut writer: W) -> std::io::Result<()>
+    where
+        R: AsyncBufRead + Unpin,
+        W: AsyncWrite + Unpin,
+    {
+        let mut lines = reader.lines();
+
+        while let Some(line) = lines.next_line().await? {
+            if line.trim().is_empty() {
+                continue;
+            }
+
+            let Some(response) = self.handle_message(&line).await else {
+                continue;
+            };
+
+            let mut message = serde_json::to_vec(&response)?;
+            message.push(b'\n');
+
+            writer.write_all(&message).await?;
+            writer.flush().await?;
+        }
+
+        Ok(())
+    }
+
+    /// Handle a single message from the client, returning the response to send (if any).
+    async fn handle_message(&self, message: &str) -> Option<Response> {
+        let request = match serde_json::from_str::<Value>(message) {
+            Ok(request) => request,
+            Err(e) => {
+                return Some(Response::error(
+                    Value::Null,
+                    constant::PARSE_ERROR,
+                    e.to_string(),
+                ));
+            }
+        };
+
+        let request = match serde_json::from_value::<Request>(request) {
+            Ok(request) => request,
+            Err(e) => {
+                return Some(Response::error(
+                    Value::Null,
+                    constant::INVALID_REQUEST,
+                    e.to_string(),
+                ));
+            }
+        };
+
+        log::debug!("Handling MCP request: {}", request.method);
+
+        let result = self.handle_request(&request.method, request.params).await;
+
+        // Notifications (i.e. `notifications/initialized`) never receive a response
+        let id = request.id?;
+
+        Some(match result {
+            Ok(result) => Response::result(id, result),
+            Err(e) => Response::error(id, e.code(), e.to_string()),
+        })
+    }
+
+    /// Handle a request (or notification) for a particular method.
+    async fn handle_request(&self, method: &str, params: Value) -> Result<Value> {
+        match method {
+            "initialize" => Ok(json!({
+                "protocolVersion": get_protocol_version(&params),
+                "capabilities": {
+                    "tools": {},
+                },
+                "serverInfo": {
+                    "name": env!("CARGO_PKG_NAME"),
+                    "version": env!("CARGO_PKG_VERSION"),
+                },
+            })),
+            "ping" => Ok(json!({})),
+            "tools/list" => Ok(json!({
+                "tools": tools::get_tool_definitions(),
+            })),
+            "tools/call" => {
+                let params = serde_json::from_value::<CallToolParams>(params)
+                    .map_err(Error::InvalidParams)?;
+
+                // Errors from the tool itself are returned to the client as part of the result,
+                // so that agents can see (and correct) them
+                let (text, is_error) = match self.call_tool(&params.name, params.arguments).await {
+                    Ok(result) => (result.to_string(), false),
+                    Err(Error::UnknownTool(name)) => return Err(Error::UnknownTool(name)),
+                    Err(e) => (e.to_string(), true),
+                };
+
+                Ok(json!({
+                    "content": [{ "type": "text", "text": text }],
+                    "isError": is_error,
+                }))
+            }
+            method if method.starts_with("notifications/") => Ok(Value::Null),
+            method => Err(Error::MethodNotFound(method.to_string())),
+        }
+    }
+
+    /// Call a tool with a set of arguments, returning its (compact) result.
+    async fn call_tool(&self, name: &str, arguments: Value) -> Result<Value> {
+        match name {
+            "search_symbols" => self.search_symbols(parse_arguments(arguments)?).await,
+            "get_definition" => self.get_definition(parse_arguments(arguments)?).await,
+            "list_file_symbols" => self.list_file_symbols(parse_arguments(arguments)?).await,
+            "find_references" => self.find_references(parse_arguments(arguments)?).await,
+            name => Err(Error::UnknownTool(name.to_string())),
+        }
+    }
+
+    /// Fuzzy search for symbols by name, returning the most relevant first.
+    async fn search_symbols(&self, arguments: SearchSymbolsArguments) -> Result<Value> {
+        let kinds = arguments
+            .kinds
+            .iter()
+            .map(|kind| {
+                kind.parse::<SymbolKind>()
+                    .map_err(|_| Error::UnknownSymbolKind(kind.clone()))
+            })
+            .collect::<Result<Vec<_>>>()?;
+
+        let mut ctx = Context::default();
+
+        if let Some(current_file) = &arguments.current_file {
+            ctx = ctx.with_current_file(self.resolve_path(current_file));
+        }
+
+        if !kinds.is_empty() {
+            ctx = ctx.with_symbol_kinds(SymbolKindFilter::Global(kinds));
+        }
+
+        let limit = arguments
+            .limit
+            .unwrap_or(constant::DEFAULT_SEARCH_LIMIT)
+            .clamp(1, constant::MAX_SEARCH_LIMIT);
+
+        // Symbols are streamed in the order they're resolved, rather than by relevance, so every
+        // match needs to be collected before the best can be picked
+        let symbols: Vec<ResolvedSymbol> =
+            self.resolver.query(arguments.query, ctx).collect().await;
+
+        let symbols = symbols
+            .iter()
+            .sorted_by(|a, b| b.score.cmp(&a.score).then_with(|| a.cmp(b)))
+            .take(limit)
+            .map(CompactSymbol::from)
+            .collect::<Vec<_>>();
+
+        Ok(json!(symbols))
+    }
+
+    /// Find the most likely definition of a symbol by its exact name.
+    async fn get_definition(&self, arguments: NameArguments) -> Result<Value> {
+        let current_file = arguments
+            .current_file
+            .as_deref()
+            .map(|path| self.resolve_path(path));
+
+        let definitions = self
+            .resolver
+            .get_definitions(&arguments.name, current_file.as_deref())
+            .await;
+
+        let Some((definition, alternatives)) = definitions.split_first() else {
+            return Err(Error::DefinitionNotFound(arguments.name));
+        };
+
+        Ok(json!({
+            "definition": CompactSymbol::from(definition),
+            "alternatives": alternatives.iter().map(CompactSymbol::from).collect::<Vec<_>>(),
+        }))
+    }
+
+    /// List every symbol defined in a file.
+    async fn list_file_symbols(&self, arguments: ListFileSymbolsArguments) -> Result<Value> {
+        let symbols = self
+            .resolver
+            .get_symbols_in_file(&self.resolve_path(&arguments.path))
+            .await;
+
+        Ok(json!(
+            symbols.iter().map(CompactSymbol::from).collect::<Vec<_>>()
+        ))
+    }
+
+    /// Find every plausible reference to the most likely definition of a symbol.
+    async fn find_references(&self, arguments: NameArguments) -> Result<Value> {
+        let current_file = arguments
+            .current_file
+            .as_deref()
+            .map(|path| self.resolve_path(path));
+
+        let definitions = self
+            .resolver
+            .get_definitions(&arguments.name, current_file.as_deref())
+            .await;
+
+        let Some(definition) = definitions.first() else {
+            return Err(Error::DefinitionNotFound(arguments.name));
+        };
+
+        let references = self.rename_resolver.get_references(definition).await;
+
+        let limit = arguments
+            .limit
+            .unwrap_or(constant::DEFAULT_REFERENCE_LIMIT)
+            .max(1);
+
+        Ok(json!({
+            "definition": CompactSymbol::from(definition),
+            "total": references.len(),
+            "references": references
+                .iter()
+                .sorted_by_key(|reference| std::cmp::Reverse(reference.confidence))
+                .take(limit)
+                .map(CompactReference::from)
+                .collect::<Vec<_>>(),
+        }))
+    }
+
+    /// Resolve a path provided by the client, which may be relative to one of the workspaces.
+    fn resolve_path(&self, path: &Path) -> PathBuf {
+        if path.is_absolute() {
+            return path.to_path_buf();
+        }
+
+        self.workspaces
+            .iter()
+            .map(|workspace| workspace.join(path))
+            .find(|path| path.exists())
+            .unwrap_or_else(|| path.to_path_buf())
+    }
+}
+
+/// Get the version of the protocol to respond to an `initialize` request with, which is the
+/// version the client requested (if the server supports it), or otherwise the latest version the
+/// server supports.
+fn get_protocol_version(params: &Value) -> &'static str {
+    let requested = params.get("protocolVersion").and_then(Value::as_str);
+
+    constant::SUPPORTED_PROTOCOL_VERSIONS
+        .iter()
+        .copied()
+        .find(|version| Some(*version) == requested)
+        .unwrap_or(constant::SUPPORTED_PROTOCOL_VERSIONS[0])
+}
+
+#[cfg(test)]
+mod tests {
+    use rstest::rstest;
+    use serde_json::{Value, json};
+    use tempfile::tempdir;
+    use tokio::fs;
+
+    use crate::indexer::{self, Indexer};
+
+    /// Send a set of messages to a server, returning every response it writes.
+    async fn send(server: &super::Server, messages: &[Value]) -> Vec<Value> {
+        let input = messages
+            .iter()
+            .map(|message| format!("{message}\n"))
+            .collect::<String>();
+
+        let mut output = Vec::new();
+
+        server
+            .serve(input.as_bytes(), &mut output)
+            .await
+            .expect("Should never fail when serving from memory");
+
+        String::from_utf8(output)
+            .expect("Responses should always be UTF-8")
+            .lines()
+            .map(|line| serde_json::from_str(line).expect("Responses should always be JSON"))
+            .collect()
+    }
+
+    /// Get the (parsed) text content of a `tools/call` response.
+    fn get_tool_result(response: &Value) -> (Value, bool) {
+        let text = response["result"]["content"][0]["text"]
+            .as_str()
+            .expect("Tool results should always have text content");
+
+        (
+            serde_json::from_str(text).unwrap_or_else(|_| Value::String(text.to_string())),
+            response["result"]["isError"] == json!(true),
+        )
+    }
+
+    #[rstest]
+    #[case(json!({"protocolVersion": "2025-03-26"}), "2025-03-26")]
+    #[case(json!({"protocolVersion": "2025-06-18"}), "2025-06-18")]
+    #[case(json!({"protocolVersion": "1999-01-01"}), "2025-06-18")]
+    #[case(json!({}), "2025-06-18")]
+    pub fn test_negotiating_protocol_version(#[case] params: Value, #[case] expected: &str) {
+        assert_eq!(expected, super::get_protocol_version(&params));
+    }
+
+    #[tokio::test]
+    pub async fn test_serving_protocol_messages() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        let workspaces = vec![workspace.path()];
+
+        indexer::DatabaseBackedIndexer::new(storage_path.path(), workspaces.clone())
+            .await
+            .expect("Should be able to create the empty index");
+
+        let server = super::Server::new(storage_path.path(), workspaces);
+
+        let responses = send(
+            &server,
+            &[
+                json!({"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2025-03-26"}}),
+                json!({"jsonrpc": "2.0", "method": "notifications/initialized"}),
+                json!({"jsonrpc": "2.0", "id": 2, "method": "tools/list"}),
+                json!({"jsonrpc": "2.0", "id": 3, "method": "resources/list"}),
+                json!({"jsonrpc": "2.0", "id": 4, "method": "tools/call", "params": {"name": "unknown"}}),
+                json!("not a request"),
+            ],
+        )
+        .await;
+
+        // The notification never receives a response
+        assert_eq!(5, responses.len());
+
+        assert_eq!(json!(1), responses[0]["id"]);
+        assert_eq!(
+            json!("2025-03-26"),
+            responses[0]["result"]["protocolVersion"]
+        );
+        assert_eq!(json!("onoma"), responses[0]["result"]["serverInfo"]["name"]);
+
+        assert_eq!(
+            vec![
+                "search_symbols",
+                "get_definition",
+                "list_file_symbols",
+                "find_references"
+            ],
+            responses[1]["result"]["tools"]
+                .as_array()
+                .expect("Tools should be listed")
+                .iter()
+                .map(|tool| tool["name"].as_str().unwrap_or_default())
+                .collect::<Vec<_>>()
+        );
+
+        assert_eq!(json!(-32601), responses[2]["error"]["code"]);
+        assert_eq!(json!(-32602), responses[3]["error"]["code"]);
+        assert_eq!(Value::Null, responses[4]["id"]);
+        assert_eq!(json!(-32600), responses[4]["error"]["code"]);
+    }
+
+    #[tokio::test]
+    pub async fn test_calling_tools() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        let definition = workspace.path().join("user.ts");
+        let importer = workspace.path().join("app.ts");
+
+        fs::write(
+            &definition,
+            "export class User {}\n\nexport function getUser(): User {\n  return new User();\n}\n",
+        )
+        .await
+        .expect("Should never fail to write a file into the workspace");
+        fs::write(
+            &importer,
+            "import { getUser } from \"./user\";\n\nconst user = getUser();\n",
+        )
+        .await
+        .expect("Should never fail to write a file into the workspace");
+
+        let workspaces = vec![workspace.path()];
+
+        let indexer = indexer::DatabaseBackedIndexer::new(storage_path.path(), workspaces.clone())
+            .await
+            .expect("Should be able to create the empty index");
+
+        assert!(indexer.index_workspaces().await.is_ok());
+
+        let server = super::Server::new(storage_path.path(), workspaces);
+
+        let call = |id: u32, name: &str, arguments: Value| json!({"jsonrpc": "2.0", "id": id, "method": "tools/call", "params": {"name": name, "arguments": arguments}});
+
+        let responses = send(
+            &server,
+            &[
+                call(
+                    1,
+                    "search_symbols",
+                    json!({"query": "getUser", "kinds": ["Function"]}),
+                ),
+                call(
+                    2,
+                    "get_definition",
+                    json!({"name": "getUser", "current_file": "app.ts"}),
+                ),
+                call(3, "list_file_symbols", json!({"path": "user.ts"})),
+                call(4, "find_references", json!({"name": "getUser"})),
+                call(5, "get_definition", json!({"name": "missing"})),
+                call(
+                    6,
+                    "search_symbols",
+                    json!({"query": "getUser", "kinds": ["Gadget"]}),
+                ),
+            ],
+        )
+        .await;
+
+        let (symbols, is_error) = get_tool_result(&responses[0]);
+
+        assert!(!is_error);
+        assert_eq!(json!("getUser"), symbols[0]["name"]);
+        assert_eq!(json!("Function"), symbols[0]["kind"]);
+        assert_eq!(json!(definition), symbols[0]["path"]);
+        assert_eq!(json!([3, 17, 3, 24]), symbols[0]["range"]);
+
+        // The import in the current file is never the definition
+        let (result, _) = get_tool_result(&responses[1]);
+
+        assert_eq!(json!(definition), result["definition"]["path"]);
+        assert_eq!(json!([]), result["alternatives"]);
+
+        let (symbols, _) = get_tool_result(&responses[2]);
+
+        let names = symbols
+            .as_array()
+            .expect("Symbols should be listed")
+            .iter()
+            .map(|symbol| symbol["name"].as_str().unwrap_or_default())
+            .collect::<Vec<_>>();
+
+        // Symbols are listed in the order they're defined
+        assert_eq!(Some(&"User"), names.first());
+        assert!(names.contains(&"getUser"));
+
+        let (result, _) = get_tool_result(&responses[3]);
+
+        // The definition, its import, and its call
+        assert_eq!(json!(3), result["total"]);
+        assert!(
+            result["references"]
+                .as_array()
+                .expect("References should be listed")
+                .iter()
+                .all(|reference| reference["confidence"] == json!("High"))
+        );
+
+        let (message, is_error) = get_tool_result(&responses[4]);
+
+        assert!(is_error);
+        assert_eq!(json!("No definition of \"missing\" is indexed"), message);
+
+        let (message, is_error) = get_tool_result(&responses[5]);
+
+        assert!(is_error);
+        assert_eq!(json!("Unknown symbol kind: Gadget"), message);
+    }
+}
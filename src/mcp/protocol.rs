@@ -0,0 +1,79 @@
+use serde::{Deserialize, Serialize};
+use serde_json::Value;
+
+/// A JSON-RPC 2.0 request (or notification, when it has no ID) sent by the client.
+#[derive(Debug, Deserialize)]
+pub struct Request {
+    /// The ID of the request, which is echoed in its response. Notifications have no ID, and
+    /// never receive a response.
+    #[serde(default)]
+    pub id: Option<Value>,
+
+    /// The method being called (i.e. `tools/call`).
+    pub method: String,
+
+    /// The parameters of the method, if any.
+    #[serde(default)]
+    pub params: Value,
+}
+
+/// A JSON-RPC 2.0 response, sent by the server for every request.
+#[derive(Debug, Serialize)]
+pub struct Response {
+    /// The version of JSON-RPC, which is always `2.0`.
+    jsonrpc: &'static str,
+
+    /// The ID of the request being responded to, or `null` if it couldn't be read.
+    id: Value,
+
+    /// The result of a successful request.
+    #[serde(skip_serializing_if = "Option::is_none")]
+    result: Option<Value>,
+
+    /// The error of an unsuccessful request.
+    #[serde(skip_serializing_if = "Option::is_none")]
+    error: Option<ResponseError>,
+}
+
+/// The error of an unsuccessful JSON-RPC request.
+#[derive(Debug, Serialize)]
+struct ResponseError {
+    /// The JSON-RPC error code (see [`crate::mcp::constant`]).
+    code: i64,
+
+    /// A human-readable description of the error.
+    message: String,
+}
+
+impl Response {
+    /// Respond to a request successfully.
+    pub const fn result(id: Value, result: Value) -> Self {
+        Self {
+            jsonrpc: "2.0",
+            id,
+            result: Some(result),
+            error: None,
+        }
+    }
+
+    /// Respond to a request with an error.
+    pub const fn error(id: Value, code: i64, message: String) -> Self {
+        Self {
+            jsonrpc: "2.0",
+            id,
+            result: None,
+            error: Some(ResponseError { code, message }),
+        }
+    }
+}
+
+/// The parameters of a `tools/call` request.
+#[derive(Debug, Deserialize)]
+pub struct CallToolParams {
+    /// The name of the tool being called.
+    pub name: String,
+
+    /// The arguments to call the tool with.
+    #[serde(default)]
+    pub arguments: Value,
+}
@@ -0,0 +1,211 @@
+use std::path::{Path, PathBuf};
+
+use serde::{Deserialize, Serialize};
+use serde_json::{Value, json};
+
+use crate::models::{
+    parsed::{Language, SymbolKind},
+    resolved::{Confidence, Reference, ResolvedSymbol, TextRange},
+};
+
+/// The arguments of the `search_symbols` tool.
+#[derive(Debug, Deserialize)]
+#[serde(deny_unknown_fields)]
+pub struct SearchSymbolsArguments {
+    /// The (fuzzy) query to match symbol names against.
+    pub query: String,
+
+    /// The kinds of symbol to include (i.e. `Function`), or every kind if empty.
+    #[serde(default)]
+    pub kinds: Vec<String>,
+
+    /// The file the agent is working in, which symbols nearby are preferred to.
+    #[serde(default)]
+    pub current_file: Option<PathBuf>,
+
+    /// The maximum number of symbols to return.
+    #[serde(default)]
+    pub limit: Option<usize>,
+}
+
+/// The arguments of the `get_definition` and `find_references` tools.
+#[derive(Debug, Deserialize)]
+#[serde(deny_unknown_fields)]
+pub struct NameArguments {
+    /// The exact name of the symbol.
+    pub name: String,
+
+    /// The file the agent is working in, which definitions nearby are preferred to.
+    #[serde(default)]
+    pub current_file: Option<PathBuf>,
+
+    /// The maximum number of results to return (only used by `find_references`).
+    #[serde(default)]
+    pub limit: Option<usize>,
+}
+
+/// The arguments of the `list_file_symbols` tool.
+#[derive(Debug, Deserialize)]
+#[serde(deny_unknown_fields)]
+pub struct ListFileSymbolsArguments {
+    /// The path of the file, either absolute or relative to a workspace.
+    pub path: PathBuf,
+}
+
+/// A symbol, in the compact form returned to clients.
+///
+/// Ranges are `[start_line, start_column, end_line, end_column]`, where lines and columns start
+/// from 1, and cover only the name of the symbol.
+#[derive(Debug, Serialize)]
+pub struct CompactSymbol<'a> {
+    name: &'a str,
+    kind: SymbolKind,
+    language: Language,
+    path: &'a Path,
+    range: [i64; 4],
+    #[serde(skip_serializing_if = "Option::is_none")]
+    signature: Option<&'a str>,
+    #[serde(skip_serializing_if = "std::ops::Not::not")]
+    deprecated: bool,
+    #[serde(skip_serializing_if = "std::ops::Not::not")]
+    test: bool,
+    #[serde(skip_serializing_if = "std::ops::Not::not")]
+    generated: bool,
+}
+
+impl<'a> From<&'a ResolvedSymbol> for CompactSymbol<'a> {
+    fn from(symbol: &'a ResolvedSymbol) -> Self {
+        Self {
+            name: &symbol.name,
+            kind: symbol.kind,
+            language: symbol.language,
+            path: &symbol.path,
+            range: [
+                symbol.start_line,
+                symbol.start_column,
+                symbol.end_line,
+                symbol.end_column,
+            ],
+            signature: symbol.signature.as_deref(),
+            deprecated: symbol.deprecated,
+            test: symbol.test,
+            generated: symbol.generated,
+        }
+    }
+}
+
+/// A reference to a symbol, in the compact form returned to clients.
+///
+/// Ranges are in the same form as [`CompactSymbol`].
+#[derive(Debug, Serialize)]
+pub struct CompactReference<'a> {
+    path: &'a Path,
+    range: [u32; 4],
+    confidence: Confidence,
+}
+
+impl<'a> From<&'a Reference> for CompactReference<'a> {
+    fn from(reference: &'a Reference) -> Self {
+        let TextRange { start, end } = reference.range;
+
+        Self {
+            path: &reference.path,
+            range: [
+                start.line + 1,
+                start.character + 1,
+                end.line + 1,
+                end.character + 1,
+            ],
+            confidence: reference.confidence,
+        }
+    }
+}
+
+/// Get the definitions of every tool offered by the server, as returned by `tools/list`.
+pub fn get_tool_definitions() -> Value {
+    let current_file = json!({
+        "type": "string",
+        "description": "The file currently being worked on, used to prefer nearby symbols.",
+    });
+
+    json!([
+        {
+            "name": "search_symbols",
+            "title": "Search symbols",
+            "description": "Fuzzy search for symbols (functions, types, variables, etc.) by name across every indexed workspace, in any language. Results are ordered by relevance. Ranges are [start_line, start_column, end_line, end_column], starting from 1, and cover only the symbol's name.",
+            "inputSchema": {
+                "type": "object",
+                "properties": {
+                    "query": {
+                        "type": "string",
+                        "description": "The name (or part of the name) to search for. Typos are tolerated.",
+                    },
+                    "kinds": {
+                        "type": "array",
+                        "items": { "type": "string" },
+                        "description": "Only include symbols of these kinds (i.e. \"Function\", \"Method\", \"Struct\", \"Class\").",
+                    },
+                    "current_file": current_file,
+                    "limit": {
+                        "type": "integer",
+                        "minimum": 1,
+                        "description": "The maximum number of symbols to return (defaults to 20).",
+                    },
+                },
+                "required": ["query"],
+            },
+        },
+        {
+            "name": "get_definition",
+            "title": "Get definition",
+            "description": "Find where a symbol is defined, by its exact name. Returns the most likely definition, alongside any other symbols with the same name.",
+            "inputSchema": {
+                "type": "object",
+                "properties": {
+                    "name": {
+                        "type": "string",
+                        "description": "The exact name of the symbol.",
+                    },
+                    "current_file": current_file,
+                },
+                "required": ["name"],
+            },
+        },
+        {
+            "name": "list_file_symbols",
+            "title": "List file symbols",
+            "description": "List every symbol defined in a file, in the order they're defined (a quick outline of the file).",
+            "inputSchema": {
+                "type": "object",
+                "properties": {
+                    "path": {
+                        "type": "string",
+                        "description": "The path of the file, either absolute or relative to a workspace.",
+                    },
+                },
+                "required": ["path"],
+            },
+        },
+        {
+            "name": "find_references",
+            "title": "Find references",
+            "description": "Find every occurrence of a symbol's name across the indexed workspaces, including its definition. References are found by name, so each has a confidence (High, Medium or Low) that it refers to the same symbol.",
+            "inputSchema": {
+                "type": "object",
+                "properties": {
+                    "name": {
+                        "type": "string",
+                        "description": "The exact name of the symbol.",
+                    },
+                    "current_file": current_file,
+                    "limit": {
+                        "type": "integer",
+                        "minimum": 1,
+                        "description": "The maximum number of references to return, most confident first (defaults to 100).",
+                    },
+                },
+                "required": ["name"],
+            },
+        },
+    ])
+}
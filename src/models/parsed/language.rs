@@ -60,6 +60,10 @@ pub enum Language {
     /// Python
     #[strum(ascii_case_insensitive)]
     Python,
+
+    /// Shell (i.e. Bash), including dotenv files
+    #[strum(ascii_case_insensitive)]
+    Shell,
 }
 
 /// A particular file extension for a supported language ([`Language`]).
@@ -88,6 +92,7 @@ impl TryFrom<&FileExtension<'_>> for Language {
             "jsx" => Ok(Self::JavascriptJsx),
             "clj" => Ok(Self::Clojure),
             "py" => Ok(Self::Python),
+            "sh" => Ok(Self::Shell),
             _ => Err(parser::Error::InvalidUri(value.0.to_string())),
         }
     }
@@ -105,6 +110,7 @@ impl From<Language> for FileExtension<'_> {
             Language::JavascriptJsx => "jsx",
             Language::Clojure => "clj",
             Language::Python => "py",
+            Language::Shell => "sh",
         })
     }
 }
@@ -113,6 +119,12 @@ impl TryFrom<&Path> for Language {
     type Error = parser::Error;
 
     fn try_from(path: &Path) -> Result<Self, Self::Error> {
+        if is_dotenv_file(path) {
+            // Dotenv files (i.e. `.env` or `.env.local`) are named by convention, rather than by
+            // their extension, but are written in (a subset of) shell syntax
+            return Ok(Self::Shell);
+        }
+
         let ext = match path.extension().and_then(|ext| ext.to_str()) {
             Some(e) => e.trim_start_matches('.').to_ascii_lowercase(),
             None => {
@@ -137,6 +149,7 @@ impl From<Language> for LanguageFn {
             Language::Javascript | Language::JavascriptJsx => tree_sitter_javascript::LANGUAGE,
             Language::Clojure => tree_sitter_clojure_orchard::LANGUAGE,
             Language::Python => tree_sitter_python::LANGUAGE,
+            Language::Shell => tree_sitter_bash::LANGUAGE,
         }
     }
 }
@@ -164,8 +177,107 @@ impl Language {
             }
             Self::Clojure => include_str!("./../../parser/treesitter/scm/clojure_symbols.scm"),
             Self::Python => include_str!("./../../parser/treesitter/scm/python_symbols.scm"),
+            Self::Shell => include_str!("./../../parser/treesitter/scm/shell_symbols.scm"),
+        }
+    }
+
+    /// Get the language-specific Treesitter test query, in order to find the parts of a
+    /// particular source file which are test code (i.e. test modules, test functions, or calls
+    /// to a test framework).
+    ///
+    /// Every `@Test` capture marks a node which, along with everything inside it, is test code.
+    #[must_use]
+    pub const fn get_test_query(&self) -> &'static str {
+        match self {
+            Self::Go => include_str!("./../../parser/treesitter/scm/golang_tests.scm"),
+            Self::Rust => include_str!("./../../parser/treesitter/scm/rust_tests.scm"),
+            Self::Lua => include_str!("./../../parser/treesitter/scm/lua_tests.scm"),
+            Self::TypeScript | Self::TypeScriptJsx | Self::Javascript | Self::JavascriptJsx => {
+                include_str!("./../../parser/treesitter/scm/javascript_tests.scm")
+            }
+            Self::Clojure => include_str!("./../../parser/treesitter/scm/clojure_tests.scm"),
+            Self::Python => include_str!("./../../parser/treesitter/scm/python_tests.scm"),
+            Self::Shell => include_str!("./../../parser/treesitter/scm/shell_tests.scm"),
+        }
+    }
+
+    /// Get the language-specific Treesitter deprecation query, in order to find the symbols in a
+    /// particular source file which have been marked as deprecated (i.e. `#[deprecated]` in
+    /// Rust, or `@deprecated` in JSDoc).
+    ///
+    /// Every `@Deprecated` capture marks the name of a deprecated symbol, and the `@Deprecation`
+    /// capture in the same match marks the node the deprecation message can be read from.
+    #[must_use]
+    pub const fn get_deprecation_query(&self) -> &'static str {
+        match self {
+            Self::Go => include_str!("./../../parser/treesitter/scm/golang_deprecations.scm"),
+            Self::Rust => include_str!("./../../parser/treesitter/scm/rust_deprecations.scm"),
+            Self::Lua => include_str!("./../../parser/treesitter/scm/lua_deprecations.scm"),
+            Self::TypeScript | Self::TypeScriptJsx => {
+                include_str!("./../../parser/treesitter/scm/typescript_deprecations.scm")
+            }
+            Self::Javascript | Self::JavascriptJsx => {
+                include_str!("./../../parser/treesitter/scm/javascript_deprecations.scm")
+            }
+            Self::Clojure => {
+                include_str!("./../../parser/treesitter/scm/clojure_deprecations.scm")
+            }
+            Self::Python => include_str!("./../../parser/treesitter/scm/python_deprecations.scm"),
+            Self::Shell => include_str!("./../../parser/treesitter/scm/shell_deprecations.scm"),
+        }
+    }
+
+    /// Get the language-specific Treesitter environment query, in order to find the environment
+    /// variables read or written by a particular source file (i.e. `std::env::var("X")` in Rust,
+    /// or `process.env.X` in JavaScript).
+    ///
+    /// Every `@Read` or `@Write` capture marks the node naming the environment variable (either
+    /// a string literal, or an identifier).
+    #[must_use]
+    pub const fn get_environment_query(&self) -> &'static str {
+        match self {
+            Self::Go => include_str!("./../../parser/treesitter/scm/golang_environment.scm"),
+            Self::Rust => include_str!("./../../parser/treesitter/scm/rust_environment.scm"),
+            Self::Lua => include_str!("./../../parser/treesitter/scm/lua_environment.scm"),
+            Self::TypeScript | Self::TypeScriptJsx | Self::Javascript | Self::JavascriptJsx => {
+                include_str!("./../../parser/treesitter/scm/javascript_environment.scm")
+            }
+            Self::Clojure => include_str!("./../../parser/treesitter/scm/clojure_environment.scm"),
+            Self::Python => include_str!("./../../parser/treesitter/scm/python_environment.scm"),
+            Self::Shell => include_str!("./../../parser/treesitter/scm/shell_environment.scm"),
         }
     }
+
+    /// Get the language-specific Treesitter translation query, in order to find the translation
+    /// keys used by a particular source file (i.e. `t("checkout.payment.title")`).
+    ///
+    /// Every `@Key` capture marks the string literal naming the translation key, and the
+    /// `@Function` capture in the same match marks the function being called, which is compared
+    /// against the configured translation functions (see
+    /// [`crate::parser::treesitter::Context::with_translation_functions`]).
+    ///
+    /// Returns [`None`] for languages where translation keys are not supported.
+    #[must_use]
+    pub const fn get_translation_query(&self) -> Option<&'static str> {
+        match self {
+            Self::TypeScript | Self::TypeScriptJsx | Self::Javascript | Self::JavascriptJsx => {
+                Some(include_str!(
+                    "./../../parser/treesitter/scm/javascript_translations.scm"
+                ))
+            }
+            Self::Python => Some(include_str!(
+                "./../../parser/treesitter/scm/python_translations.scm"
+            )),
+            Self::Go | Self::Rust | Self::Lua | Self::Clojure | Self::Shell => None,
+        }
+    }
+}
+
+/// Check if a file is a dotenv file (i.e. `.env`, or `.env.production`).
+pub fn is_dotenv_file(path: &Path) -> bool {
+    path.file_name()
+        .and_then(|filename| filename.to_str())
+        .is_some_and(|filename| filename == ".env" || filename.starts_with(".env."))
 }
 
 impl From<&Language> for sea_query::Value {
@@ -5,15 +5,19 @@ mod index;
 
 mod language;
 mod symbol;
+mod symbol_category;
 mod symbol_kind;
 mod symbol_occurrence;
 mod symbol_range;
 mod symbol_role;
+mod symbol_signature;
 
 pub use index::*;
 pub use language::*;
 pub use symbol::*;
+pub use symbol_category::*;
 pub use symbol_kind::*;
 pub use symbol_occurrence::*;
 pub use symbol_range::*;
 pub use symbol_role::*;
+pub use symbol_signature::*;
@@ -21,6 +21,23 @@ pub struct Symbol {
 
     /// The occurrences in different source files of this symbol.
     pub occurrences: Vec<models::parsed::Occurrence>,
+
+    /// Whether the symbol is part of test code (i.e. it's defined inside a test module, test
+    /// function, or a call to a test framework).
+    pub test: bool,
+
+    /// Whether the symbol has been marked as deprecated (i.e. `#[deprecated]` in Rust, or
+    /// `@deprecated` in JSDoc).
+    pub deprecated: bool,
+
+    /// The message explaining the deprecation (i.e. what to use instead), if one was given.
+    pub deprecation_message: Option<String>,
+
+    /// The normalised signature of the symbol (i.e. `(Path, Context) -> Result<Vec<u8>>`), if it's
+    /// a function-like symbol.
+    ///
+    /// See [`models::parsed::Signature`].
+    pub signature: Option<String>,
 }
 
 impl Symbol {
@@ -34,6 +51,10 @@ impl Symbol {
             name: name.to_string(),
             occurrences: Vec::default(),
             definition: None,
+            test: false,
+            deprecated: false,
+            deprecation_message: None,
+            signature: None,
         }
     }
 
@@ -0,0 +1,179 @@
+use serde::{Deserialize, Serialize};
+use strum::IntoEnumIterator;
+
+use crate::models::parsed::SymbolKind;
+
+/// A broad grouping of [`SymbolKind`]s (i.e. classes, structs and traits are all types), for
+/// filtering symbols without listing every kind a language might use for them.
+#[derive(
+    Debug,
+    Clone,
+    Copy,
+    Hash,
+    Eq,
+    PartialEq,
+    strum_macros::Display,
+    strum_macros::EnumString,
+    strum_macros::EnumIter,
+    Serialize,
+    Deserialize,
+)]
+pub enum SymbolCategory {
+    /// Files, packages, modules and namespaces.
+    Module,
+
+    /// Types, and type-like definitions (i.e. classes, structs, enums, interfaces and traits).
+    Type,
+
+    /// Functions, methods, and anything else which can be called (i.e. constructors, getters and
+    /// macros).
+    Function,
+
+    /// Variables, constants, fields, properties and enum members.
+    Variable,
+
+    /// Parameters of functions and types (including receivers, and `self`).
+    Parameter,
+
+    /// Literal values (i.e. strings and numbers) and the keys of objects.
+    Literal,
+
+    /// Environment variables and translation keys, which are only ever referenced by name.
+    Key,
+
+    /// Anything else (i.e. attributes, logical constructs such as lemmas and theorems, or an
+    /// unknown kind).
+    Other,
+}
+
+impl SymbolCategory {
+    /// Get every kind of symbol in the category.
+    #[must_use]
+    pub fn kinds(self) -> Vec<SymbolKind> {
+        SymbolKind::iter()
+            .filter(|kind| kind.category() == self)
+            .collect()
+    }
+}
+
+impl SymbolKind {
+    /// Get the category the kind of symbol is grouped into.
+    #[must_use]
+    pub const fn category(self) -> SymbolCategory {
+        match self {
+            Self::File
+            | Self::Package
+            | Self::PackageObject
+            | Self::Module
+            | Self::Namespace
+            | Self::Library => SymbolCategory::Module,
+            Self::Type
+            | Self::TypeAlias
+            | Self::TypeFamily
+            | Self::DataFamily
+            | Self::TypeClass
+            | Self::Class
+            | Self::Struct
+            | Self::Enum
+            | Self::Error
+            | Self::Interface
+            | Self::Protocol
+            | Self::Trait
+            | Self::Mixin
+            | Self::Extension
+            | Self::Contract
+            | Self::Message
+            | Self::Delegate
+            | Self::Signature
+            | Self::SingletonClass
+            | Self::Instance
+            | Self::AssociatedType
+            | Self::Union
+            | Self::Concept => SymbolCategory::Type,
+            Self::Macro
+            | Self::Operator
+            | Self::Subscript
+            | Self::Accessor
+            | Self::Getter
+            | Self::Setter
+            | Self::Function
+            | Self::Method
+            | Self::StaticMethod
+            | Self::Constructor
+            | Self::MethodSpecification
+            | Self::TraitMethod
+            | Self::ProtocolMethod
+            | Self::TypeClassMethod
+            | Self::AbstractMethod
+            | Self::PureVirtualMethod
+            | Self::MethodAlias
+            | Self::SingletonMethod
+            | Self::Quasiquoter => SymbolCategory::Function,
+            Self::Value
+            | Self::Variable
+            | Self::Constant
+            | Self::Field
+            | Self::StaticField
+            | Self::StaticVariable
+            | Self::StaticDataMember
+            | Self::Property
+            | Self::StaticProperty
+            | Self::EnumMember
+            | Self::Event
+            | Self::StaticEvent => SymbolCategory::Variable,
+            Self::Parameter
+            | Self::ParameterLabel
+            | Self::SelfParameter
+            | Self::ThisParameter
+            | Self::MethodReceiver
+            | Self::TypeParameter => SymbolCategory::Parameter,
+            Self::Number
+            | Self::String
+            | Self::Boolean
+            | Self::Null
+            | Self::Array
+            | Self::Object
+            | Self::Key => SymbolCategory::Literal,
+            Self::EnvironmentVariable | Self::TranslationKey => SymbolCategory::Key,
+            Self::Unknown
+            | Self::Lang
+            | Self::Grammar
+            | Self::Attribute
+            | Self::Pattern
+            | Self::Modifier
+            | Self::Predicate
+            | Self::Assertion
+            | Self::Fact
+            | Self::Axiom
+            | Self::Lemma
+            | Self::Theorem
+            | Self::Tactic => SymbolCategory::Other,
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use rstest::rstest;
+
+    use super::SymbolCategory;
+    use crate::models::parsed::SymbolKind;
+
+    #[rstest]
+    #[case(SymbolKind::Struct, SymbolCategory::Type)]
+    #[case(SymbolKind::Trait, SymbolCategory::Type)]
+    #[case(SymbolKind::Method, SymbolCategory::Function)]
+    #[case(SymbolKind::Getter, SymbolCategory::Function)]
+    #[case(SymbolKind::EnumMember, SymbolCategory::Variable)]
+    #[case(SymbolKind::SelfParameter, SymbolCategory::Parameter)]
+    #[case(SymbolKind::Namespace, SymbolCategory::Module)]
+    #[case(SymbolKind::EnvironmentVariable, SymbolCategory::Key)]
+    #[case(SymbolKind::Unknown, SymbolCategory::Other)]
+    pub fn test_categorising_symbol_kinds(
+        #[case] kind: SymbolKind,
+        #[case] expected_category: SymbolCategory,
+    ) {
+        assert_eq!(expected_category, kind.category());
+        assert!(expected_category.kinds().contains(&kind));
+    }
+}
@@ -401,6 +401,26 @@ pub enum SymbolKind {
     /// ```
     Key,
 
+    /// An environment variable, which is read or written by the program.
+    ///
+    /// Unlike most symbols, each read or write of an environment variable is a separate symbol, so
+    /// that every usage of a variable can be found.
+    ///
+    /// ```rust,ignore
+    /// let url = std::env::var("DATABASE_URL");
+    /// ```
+    EnvironmentVariable,
+
+    /// A translation key, which is either used by the program or defined in a locale file.
+    ///
+    /// As with [`SymbolKind::EnvironmentVariable`], each usage of a translation key is a
+    /// separate symbol.
+    ///
+    /// ```javascript
+    /// const title = t("checkout.payment.title");
+    /// ```
+    TranslationKey,
+
     /// An operator symbol.
     ///
     /// ```rust,ignore
@@ -10,6 +10,12 @@ pub enum SymbolRole {
     /// The occurrence is where the Symbol was defined.
     Definition,
 
+    /// The occurrence reads the value of the symbol (i.e. reading an environment variable).
+    ReadAccess,
+
+    /// The occurrence writes the value of the symbol (i.e. setting an environment variable).
+    WriteAccess,
+
     /// A catch-all for any roles not yet promoted to first-class roles.
     Other(String),
 }
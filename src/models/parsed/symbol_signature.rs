@@ -0,0 +1,231 @@
+use std::{fmt::Display, str::FromStr};
+
+/// The placeholder used for parameters which have no declared type (i.e. in dynamically typed
+/// languages).
+pub const UNKNOWN_TYPE: &str = "_";
+
+/// Keywords and sigils which are stripped from the start of types, as they rarely matter when
+/// searching by signature (i.e. `&mut Vec<u8>` and `Vec<u8>` are treated the same).
+const TYPE_PREFIXES: [&str; 9] = [
+    "&",
+    "*",
+    "...",
+    ":",
+    "mut ",
+    "dyn ",
+    "impl ",
+    "const ",
+    "readonly ",
+];
+
+/// The normalised signature of a function-like symbol (i.e. a function, method or
+/// constructor), made up of the types of its parameters and its return type.
+///
+/// Signatures are language-agnostic, and are written as `(Path, Context) -> Result<Vec<u8>>`.
+/// Receivers (i.e. `self` in Rust and Python) are not included as parameters.
+#[derive(Debug, Clone, Default, Hash, Eq, PartialEq, PartialOrd, Ord)]
+pub struct Signature {
+    /// The normalised types of each parameter, in order.
+    ///
+    /// Parameters without a declared type are [`UNKNOWN_TYPE`].
+    pub parameters: Vec<String>,
+
+    /// The normalised return type, if one was declared.
+    pub return_type: Option<String>,
+}
+
+impl Signature {
+    /// Create a new signature, from the (raw) types of each parameter and the (raw) return type,
+    /// as written in the source code.
+    #[must_use]
+    pub fn new<'a>(
+        parameters: impl IntoIterator<Item = Option<&'a str>>,
+        return_type: Option<&str>,
+    ) -> Self {
+        Self {
+            parameters: parameters
+                .into_iter()
+                .map(|parameter| parameter.map_or_else(|| UNKNOWN_TYPE.to_string(), normalise_type))
+                .collect(),
+            return_type: return_type
+                .map(normalise_type)
+                .filter(|return_type| !return_type.is_empty()),
+        }
+    }
+}
+
+impl Display for Signature {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        write!(f, "({})", self.parameters.join(", "))?;
+
+        if let Some(return_type) = &self.return_type {
+            write!(f, " -> {return_type}")?;
+        }
+
+        Ok(())
+    }
+}
+
+impl FromStr for Signature {
+    type Err = ();
+
+    /// Parse a signature from its normalised form (i.e. `(Path, Context) -> Result<Vec<u8>>`).
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        let (parameters, return_type) = split_parameters(s.trim()).ok_or(())?;
+
+        let return_type = return_type.trim();
+
+        Ok(Self {
+            parameters: split_top_level(parameters)
+                .into_iter()
+                .map(ToString::to_string)
+                .collect(),
+            return_type: return_type
+                .strip_prefix("->")
+                .map(|return_type| return_type.trim().to_string()),
+        })
+    }
+}
+
+/// Normalise a type as written in the source code, so that types can be compared across
+/// languages (i.e. `&'a mut Vec<u8>` is normalised to `Vec<u8>`, and `*testing.T` to `testing.T`).
+#[must_use]
+pub fn normalise_type(text: &str) -> String {
+    let mut text = text.trim();
+
+    loop {
+        let stripped = TYPE_PREFIXES
+            .iter()
+            .find_map(|prefix| text.strip_prefix(prefix))
+            .or_else(|| {
+                // Lifetimes (i.e. `&'a str`)
+                text.strip_prefix('\'').map(|rest| {
+                    rest.split_once(char::is_whitespace)
+                        .map_or("", |(_, rest)| rest)
+                })
+            });
+
+        match stripped {
+            Some(stripped) => text = stripped.trim_start(),
+            None => break,
+        }
+    }
+
+    // Whitespace is only kept where it separates two words (i.e. `chan int`)
+    let mut normalised = String::with_capacity(text.len());
+    let mut pending_whitespace = false;
+
+    for c in text.chars() {
+        if c.is_whitespace() {
+            pending_whitespace = true;
+
+            continue;
+        }
+
+        if pending_whitespace
+            && is_word_character(c)
+            && normalised.chars().last().is_some_and(is_word_character)
+        {
+            normalised.push(' ');
+        }
+
+        pending_whitespace = false;
+        normalised.push(c);
+    }
+
+    normalised
+}
+
+/// Split a signature (or signature pattern) into the content of its parameter list, and
+/// everything which follows it.
+///
+/// Returns [`None`] if the signature does not start with a balanced parameter list.
+pub(crate) fn split_parameters(signature: &str) -> Option<(&str, &str)> {
+    let rest = signature.strip_prefix('(')?;
+
+    let mut depth = 0_usize;
+
+    for (index, c) in rest.char_indices() {
+        match c {
+            '(' | '<' | '[' | '{' => depth += 1,
+            ')' if depth == 0 => return Some((&rest[..index], &rest[index + 1..])),
+            '>' if is_arrow(rest, index) => {}
+            ')' | '>' | ']' | '}' => depth = depth.saturating_sub(1),
+            _ => {}
+        }
+    }
+
+    None
+}
+
+/// Split a comma separated list (i.e. parameters, or generic arguments) at the top level only,
+/// so that nested lists (i.e. `HashMap<String, u8>`) are kept intact.
+pub(crate) fn split_top_level(list: &str) -> Vec<&str> {
+    let mut items = Vec::new();
+    let mut depth = 0_usize;
+    let mut start = 0;
+
+    for (index, c) in list.char_indices() {
+        match c {
+            '(' | '<' | '[' | '{' => depth += 1,
+            '>' if is_arrow(list, index) => {}
+            ')' | '>' | ']' | '}' => depth = depth.saturating_sub(1),
+            ',' if depth == 0 => {
+                items.push(list[start..index].trim());
+                start = index + 1;
+            }
+            _ => {}
+        }
+    }
+
+    items.push(list[start..].trim());
+    items.retain(|item| !item.is_empty());
+
+    items
+}
+
+/// Check if the `>` at a particular index is part of an arrow (i.e. `Fn(u8) -> u8` or
+/// `(a: number) => void`), rather than closing a list of generic arguments.
+fn is_arrow(text: &str, index: usize) -> bool {
+    text[..index].ends_with(['-', '='])
+}
+
+const fn is_word_character(c: char) -> bool {
+    c.is_alphanumeric() || c == '_'
+}
+
+#[cfg(test)]
+mod tests {
+    use rstest::rstest;
+
+    use super::Signature;
+
+    #[rstest]
+    #[case("&Path", "Path")]
+    #[case("&'a mut Vec<u8>", "Vec<u8>")]
+    #[case("Result<Vec<u8>, Error>", "Result<Vec<u8>,Error>")]
+    #[case("*testing.T", "testing.T")]
+    #[case("...string", "string")]
+    #[case(": Promise<User>", "Promise<User>")]
+    #[case("chan   int", "chan int")]
+    #[case("impl Iterator<Item = &'a Path>", "Iterator<Item=&'a Path>")]
+    pub fn test_normalising_types(#[case] text: &str, #[case] expected_type: &str) {
+        assert_eq!(expected_type, super::normalise_type(text));
+    }
+
+    #[rstest]
+    #[case(vec![Some("&Path"), Some("&mut Context")], Some("Result<()>"), "(Path, Context) -> Result<()>")]
+    #[case(vec![None, Some("str")], None, "(_, str)")]
+    #[case(vec![], Some("bool"), "() -> bool")]
+    #[case(vec![Some("Box<dyn Fn(u8) -> u8>"), Some("u8")], None, "(Box<dyn Fn(u8)->u8>, u8)")]
+    pub fn test_signature_round_trip(
+        #[case] parameters: Vec<Option<&str>>,
+        #[case] return_type: Option<&str>,
+        #[case] expected_signature: &str,
+    ) {
+        let signature = Signature::new(parameters, return_type);
+
+        assert_eq!(expected_signature, signature.to_string());
+        assert_eq!(Ok(signature), expected_signature.parse());
+    }
+}
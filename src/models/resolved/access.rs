@@ -0,0 +1,24 @@
+use serde::{Deserialize, Serialize};
+
+/// The way in which a symbol accesses a value (i.e. reading or writing an environment
+/// variable).
+#[derive(
+    Debug,
+    Clone,
+    Copy,
+    Hash,
+    Eq,
+    PartialEq,
+    sqlx::Type,
+    strum_macros::Display,
+    strum_macros::EnumString,
+    Serialize,
+    Deserialize,
+)]
+pub enum Access {
+    /// The symbol reads the value (i.e. `std::env::var("X")`).
+    Read,
+
+    /// The symbol writes the value (i.e. `std::env::set_var("X", …)`).
+    Write,
+}
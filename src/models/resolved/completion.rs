@@ -0,0 +1,41 @@
+use serde::{Deserialize, Serialize};
+
+use crate::models;
+
+/// A candidate for completing the identifier being typed.
+///
+/// Matches the `CompletionItem` of the Language Server Protocol, alongside the symbol being
+/// completed.
+#[derive(Debug, PartialEq, Eq, Serialize, Deserialize)]
+#[serde(rename_all = "camelCase")]
+pub struct CompletionItem {
+    /// The name of the symbol, which is also the text inserted when the completion is accepted.
+    pub label: String,
+
+    /// The kind of symbol being completed.
+    pub kind: models::parsed::SymbolKind,
+
+    /// A human-readable description of the symbol (i.e. its signature, and the file it's defined
+    /// in).
+    pub detail: Option<String>,
+
+    /// The symbol being completed.
+    pub symbol: models::resolved::ResolvedSymbol,
+}
+
+/// The candidates for completing the identifier being typed, in order of relevance.
+///
+/// Matches the `CompletionList` of the Language Server Protocol.
+#[derive(Debug, PartialEq, Eq, Serialize, Deserialize)]
+#[serde(rename_all = "camelCase")]
+pub struct CompletionList {
+    /// Whether the list is incomplete (i.e. it was truncated, or ran out of time), and so should
+    /// be requested again as typing continues.
+    pub is_incomplete: bool,
+
+    /// The range of the prefix being completed, which is replaced when a completion is accepted.
+    pub range: models::resolved::TextRange,
+
+    /// The candidates, in order of relevance.
+    pub items: Vec<CompletionItem>,
+}
@@ -0,0 +1,90 @@
+use std::{collections::BTreeMap, path::PathBuf};
+
+use serde::{Deserialize, Serialize};
+
+/// A position in a file, in the form the Language Server Protocol expects.
+///
+/// Unlike the rest of the index, lines and characters are zero-based, and characters are counted
+/// in UTF-16 code units.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Hash, Serialize, Deserialize)]
+pub struct Position {
+    /// The line of the position, starting from 0.
+    pub line: u32,
+
+    /// The character offset of the position on its line, starting from 0.
+    pub character: u32,
+}
+
+/// A start and end position in a file, in the form the Language Server Protocol expects.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Hash, Serialize, Deserialize)]
+pub struct TextRange {
+    /// The start of the range (inclusive).
+    pub start: Position,
+
+    /// The end of the range (exclusive).
+    pub end: Position,
+}
+
+/// How confident Onoma is that an edit is correct.
+///
+/// Edits are grouped by confidence when proposed, so that editors can ask for confirmation of
+/// the edits which are less likely to be correct.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Hash, Serialize, Deserialize)]
+pub enum Confidence {
+    /// The edit is likely to be wrong, but may still be correct (i.e. the same name in an
+    /// unrelated file).
+    Low,
+
+    /// The edit is likely to be correct (i.e. the same name in a file of the same package).
+    Medium,
+
+    /// The edit is very likely to be correct (i.e. the same name in the file the symbol is
+    /// defined in).
+    High,
+}
+
+/// A description of a group of edits, which editors can show when previewing the edits.
+///
+/// Matches the `ChangeAnnotation` of the Language Server Protocol.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+#[serde(rename_all = "camelCase")]
+pub struct ChangeAnnotation {
+    /// A human-readable label for the group of edits.
+    pub label: String,
+
+    /// Whether the user should confirm the edits before they are applied.
+    pub needs_confirmation: bool,
+
+    /// A human-readable description of why edits are in the group.
+    pub description: String,
+}
+
+/// A textual edit to a file.
+///
+/// Matches the `AnnotatedTextEdit` of the Language Server Protocol.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+#[serde(rename_all = "camelCase")]
+pub struct TextEdit {
+    /// The range of text being replaced.
+    pub range: TextRange,
+
+    /// The text to replace the range with.
+    pub new_text: String,
+
+    /// The group (in [`WorkspaceEdit::change_annotations`]) the edit is part of.
+    pub annotation_id: Confidence,
+}
+
+/// A set of edits across the workspace, which an editor can preview and apply.
+///
+/// Matches the `WorkspaceEdit` of the Language Server Protocol, except that files are identified
+/// by their path, rather than a URI. Onoma never applies edits itself.
+#[derive(Debug, Default, Clone, PartialEq, Eq, Serialize, Deserialize)]
+#[serde(rename_all = "camelCase")]
+pub struct WorkspaceEdit {
+    /// The edits to each file, in the order they appear in the file.
+    pub changes: BTreeMap<PathBuf, Vec<TextEdit>>,
+
+    /// The description of each group of edits, by their confidence.
+    pub change_annotations: BTreeMap<Confidence, ChangeAnnotation>,
+}
@@ -0,0 +1,14 @@
+use serde::{Deserialize, Serialize};
+
+/// An expansion of a term in a query to one of its synonyms or abbreviations (i.e. `cfg` to
+/// `config`), through which a symbol was matched.
+///
+/// See [`crate::resolver::Synonyms`].
+#[derive(Debug, Clone, Hash, Eq, PartialEq, Serialize, Deserialize)]
+pub struct QueryExpansion {
+    /// The term in the query which was expanded (i.e. `cfg`).
+    pub term: String,
+
+    /// The synonym the term was expanded to (i.e. `config`).
+    pub synonym: String,
+}
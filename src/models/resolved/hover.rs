@@ -0,0 +1,30 @@
+use serde::{Deserialize, Serialize};
+
+use crate::models;
+
+/// Information about the identifier at a position in a file (i.e. under the cursor), which
+/// editors can show on hover.
+#[derive(Debug, PartialEq, Eq, Serialize, Deserialize)]
+pub struct Hover {
+    /// The information, formatted as Markdown, in the form the Language Server Protocol expects
+    /// for `textDocument/hover`.
+    pub contents: String,
+
+    /// The range of the identifier being hovered.
+    pub range: models::resolved::TextRange,
+
+    /// The best candidate for the definition of the identifier.
+    pub symbol: models::resolved::ResolvedSymbol,
+
+    /// The names of the symbols the definition is nested inside, from the outermost to the
+    /// innermost (i.e. `["api", "Client"]` for a method on a `Client` class in an `api`
+    /// module).
+    pub containers: Vec<String>,
+
+    /// The doc comment (or docstring) of the definition, without any comment markers.
+    pub documentation: Option<String>,
+
+    /// The number of other candidates for the definition of the identifier, which share the
+    /// same name.
+    pub alternatives: usize,
+}
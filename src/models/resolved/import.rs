@@ -0,0 +1,64 @@
+use serde::{Deserialize, Serialize};
+use thiserror::Error;
+
+use crate::models;
+
+/// An import statement which brings a symbol into scope in another file, alongside where to
+/// insert it.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct ImportEdit {
+    /// The import statement (i.e. `use crate::models::User;`, or `from api.user import User`).
+    pub statement: String,
+
+    /// The position to insert the import at, in the form the Language Server Protocol expects.
+    pub position: models::resolved::Position,
+
+    /// The text to insert at the position, which contains the statement alongside any
+    /// surrounding whitespace (or, for Go import blocks, only the quoted import path).
+    pub new_text: String,
+}
+
+/// The reasons a symbol cannot be imported into a file.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Error, Serialize, Deserialize)]
+pub enum NotImportable {
+    /// The symbol is defined in the file it would be imported into.
+    #[error("The symbol is defined in the same file")]
+    SameFile,
+
+    /// The symbol is defined in the same module (i.e. Go package) as the file, and so is
+    /// already in scope.
+    #[error("The symbol is defined in the same module, so is already in scope")]
+    SameModule,
+
+    /// The symbol is already imported into the file.
+    #[error("The symbol is already imported")]
+    AlreadyImported,
+
+    /// The symbol is defined in a language which can't be imported into the file's language.
+    #[error("The symbol is defined in a different language")]
+    DifferentLanguage,
+
+    /// Imports can't be generated for the language of the symbol (i.e. Clojure).
+    #[error("Imports are not supported for the symbol's language")]
+    UnsupportedLanguage,
+
+    /// The symbol is private to the module (or package) it's defined in (i.e. a Rust item
+    /// without `pub`, or a Go identifier starting with a lowercase letter).
+    #[error("The symbol is private")]
+    Private,
+
+    /// The symbol is local to another definition (i.e. a parameter, or a variable inside a
+    /// function).
+    #[error("The symbol is local to another definition")]
+    Local,
+
+    /// The symbol is a member of another definition (i.e. a method or field), so its parent
+    /// should be imported instead.
+    #[error("The symbol is a member of another definition")]
+    Member,
+
+    /// The module the symbol is defined in couldn't be determined (i.e. the file isn't part of
+    /// a package).
+    #[error("The module path of the symbol could not be determined")]
+    UnknownModule,
+}
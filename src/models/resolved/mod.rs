@@ -1,8 +1,34 @@
 //! A language-agnostic set of models to represent a symbol, which has previously been indexed, and now has been
 //! resolved as part of a query.
 
+mod access;
+mod completion;
+mod edit;
+mod expansion;
+mod hover;
+mod import;
+mod owners;
+mod pin;
+mod reference;
+mod related;
 mod resolved_symbol;
+mod schema;
 mod score;
+mod symbol_change;
+mod translation;
 
+pub use access::*;
+pub use completion::*;
+pub use edit::*;
+pub use expansion::*;
+pub use hover::*;
+pub use import::*;
+pub use owners::*;
+pub use pin::*;
+pub use reference::*;
+pub use related::*;
 pub use resolved_symbol::*;
+pub use schema::*;
 pub use score::*;
+pub use symbol_change::*;
+pub use translation::*;
@@ -0,0 +1,29 @@
+use std::ops::Deref;
+
+use serde::{Deserialize, Serialize};
+
+/// The owners of a symbol (i.e. `@user`, `@org/team`, or an email address), as declared by the
+/// `CODEOWNERS` file of the workspace the symbol is defined in.
+#[derive(Debug, Default, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct Owners(Vec<String>);
+
+impl Deref for Owners {
+    type Target = [String];
+
+    fn deref(&self) -> &Self::Target {
+        &self.0
+    }
+}
+
+impl From<Vec<String>> for Owners {
+    fn from(value: Vec<String>) -> Self {
+        Self(value)
+    }
+}
+
+impl From<String> for Owners {
+    /// Owners are stored as a single, whitespace separated, string when resolved from the index.
+    fn from(value: String) -> Self {
+        Self(value.split_whitespace().map(ToString::to_string).collect())
+    }
+}
@@ -0,0 +1,79 @@
+use std::path::PathBuf;
+
+use serde::{Deserialize, Serialize};
+
+use crate::models;
+
+/// The kind of a [`Pin`].
+#[derive(
+    Debug,
+    Clone,
+    Copy,
+    Hash,
+    Eq,
+    PartialEq,
+    sqlx::Type,
+    strum_macros::Display,
+    strum_macros::EnumString,
+    Serialize,
+    Deserialize,
+)]
+pub enum PinKind {
+    /// A key symbol (i.e. an entry point, or core type), which is boosted to the top of every
+    /// query it matches.
+    Pin,
+
+    /// A symbol saved for later, which is listed alongside pins but doesn't influence scoring.
+    Bookmark,
+}
+
+/// Whether a [`Pin`] could be re-attached to a symbol in the index, since it was pinned.
+#[derive(Debug, Clone, Copy, Hash, Eq, PartialEq, Serialize, Deserialize)]
+pub enum PinStatus {
+    /// The symbol is still in the same file and container, with the same name and kind.
+    Attached,
+
+    /// The symbol has moved to a different file or container, and the pin has been re-attached
+    /// to it there.
+    Moved,
+
+    /// The symbol can't be found anywhere in the index (i.e. it was renamed or removed).
+    Missing,
+}
+
+/// A symbol which has been pinned (or bookmarked).
+///
+/// See [`crate::pin::PinStore`].
+#[derive(Debug, PartialEq, Eq, Serialize, Deserialize)]
+pub struct Pin {
+    /// The ID of the pin.
+    pub id: i64,
+
+    /// Whether the symbol was pinned or bookmarked.
+    pub kind: PinKind,
+
+    /// The label given to the pin, if one was given.
+    pub label: Option<String>,
+
+    /// The group the pin is part of (i.e. `auth flow`), if it's part of one.
+    pub group: Option<String>,
+
+    /// The path to the file the symbol was last seen in.
+    pub path: PathBuf,
+
+    /// The names of the symbols the pinned symbol was last seen nested inside, from the
+    /// outermost to the innermost.
+    pub containers: Vec<String>,
+
+    /// The name of the pinned symbol.
+    pub name: String,
+
+    /// The kind of the pinned symbol.
+    pub symbol_kind: models::parsed::SymbolKind,
+
+    /// Whether the pin could be re-attached to a symbol in the index.
+    pub status: PinStatus,
+
+    /// The symbol the pin is attached to, unless it's [`PinStatus::Missing`].
+    pub symbol: Option<models::resolved::ResolvedSymbol>,
+}
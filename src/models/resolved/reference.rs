@@ -0,0 +1,21 @@
+use std::path::PathBuf;
+
+use serde::{Deserialize, Serialize};
+
+use crate::models;
+
+/// A plausible reference to a symbol (i.e. an occurrence of its name in an indexed file).
+///
+/// See [`crate::resolver::RenameResolver::get_references`].
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct Reference {
+    /// The path to the file which contains the reference.
+    pub path: PathBuf,
+
+    /// The range of the reference, in the form the Language Server Protocol expects.
+    pub range: models::resolved::TextRange,
+
+    /// How confident Onoma is that the reference is to the symbol, rather than to a different
+    /// symbol with the same name.
+    pub confidence: models::resolved::Confidence,
+}
@@ -0,0 +1,37 @@
+use std::path::PathBuf;
+
+use serde::{Deserialize, Serialize};
+
+use crate::models;
+
+/// The relationship a related file (or symbol) has to the file (or symbol) it was resolved for.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash, Serialize, Deserialize)]
+pub enum Relation {
+    /// The related file (or symbol) tests the original.
+    ///
+    /// This also includes files which exercise the original in other ways, like Storybook stories.
+    Test,
+
+    /// The related file (or symbol) is the implementation which the original tests.
+    Implementation,
+}
+
+/// A file which is related to another file (i.e. its tests, or the implementation it tests).
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct RelatedFile {
+    /// The path to the related file.
+    pub path: PathBuf,
+
+    /// How the file is related.
+    pub relation: Relation,
+}
+
+/// A symbol which is related to another symbol (i.e. its tests, or the implementation it tests).
+#[derive(Debug, PartialEq, Eq, Serialize, Deserialize)]
+pub struct RelatedSymbol {
+    /// The related symbol.
+    pub symbol: models::resolved::ResolvedSymbol,
+
+    /// How the symbol is related.
+    pub relation: Relation,
+}
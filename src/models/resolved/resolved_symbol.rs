@@ -40,6 +40,79 @@ pub struct ResolvedSymbol {
     #[sqlx[try_from = "String"]]
     pub path: PathBuf,
 
+    /// The name of the package (i.e. crate, npm package, Go module, etc.) which owns the file the
+    /// symbol is defined in, if one could be detected.
+    ///
+    /// See [`crate::indexer::detect_package`].
+    #[sqlx(default)]
+    pub package: Option<String>,
+
+    /// The owners of the file the symbol is defined in, as declared by the `CODEOWNERS` file of
+    /// its workspace.
+    ///
+    /// Symbols in files which are not matched by any `CODEOWNERS` rule have no owners.
+    #[sqlx(default)]
+    #[sqlx[try_from = "String"]]
+    pub owners: models::resolved::Owners,
+
+    /// Whether the symbol is part of test code.
+    ///
+    /// This is detected from the syntax of the file the symbol is defined in (i.e. a test module
+    /// or test function), falling back to the path of the file (i.e. `user_test.go`).
+    #[sqlx(default)]
+    pub test: bool,
+
+    /// Whether the symbol has been marked as deprecated (i.e. `#[deprecated]` in Rust, or
+    /// `@deprecated` in JSDoc).
+    ///
+    /// Deprecated symbols are penalised when scoring, and UIs will commonly render them with a
+    /// strikethrough.
+    #[sqlx(default)]
+    pub deprecated: bool,
+
+    /// The message explaining why the symbol was deprecated (i.e. what to use instead), if one
+    /// was given.
+    #[sqlx(default)]
+    pub deprecation_message: Option<String>,
+
+    /// The normalised signature of the symbol (i.e. `(Path, Context) -> Result<Vec<u8>>`), if it's
+    /// a function-like symbol.
+    ///
+    /// See [`models::parsed::Signature`].
+    #[sqlx(default)]
+    pub signature: Option<String>,
+
+    /// How the symbol accesses its value, for symbols which are usages rather than declarations
+    /// (i.e. reads and writes of a [`models::parsed::SymbolKind::EnvironmentVariable`]).
+    #[sqlx(default)]
+    pub access: Option<models::resolved::Access>,
+
+    /// Whether the symbol is defined in generated code (i.e. `user.pb.go`), which should never be
+    /// edited by hand.
+    ///
+    /// See [`crate::resolver::SchemaResolver`] for finding the schema definitions generated code
+    /// originates from.
+    #[sqlx(default)]
+    pub generated: bool,
+
+    /// The expansion of the query (i.e. `cfg` to `config`) through which the symbol was matched,
+    /// if it didn't match the query directly.
+    ///
+    /// Symbols matched through an expansion are scored slightly below direct matches. See
+    /// [`crate::resolver::Synonyms`].
+    #[sqlx(skip)]
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub expansion: Option<models::resolved::QueryExpansion>,
+
+    /// When the content of the file the symbol is defined in last changed, if it's known.
+    ///
+    /// This is the time of the last commit which changed the file (when the indexer reads the git
+    /// history, and the file has no uncommitted changes), falling back to the modification time
+    /// of the file.
+    #[sqlx(default)]
+    #[serde(skip)]
+    pub changed_at: Option<chrono::DateTime<chrono::Utc>>,
+
     /// The score is calculated just-in-time by the Resolver and represents a numerical value how
     /// good a match the resolved symbol is for query.
     ///
@@ -74,6 +147,44 @@ pub struct ResolvedSymbol {
     pub end_column: i64,
 }
 
+#[cfg(test)]
+impl ResolvedSymbol {
+    /// Create a symbol for use in tests, defined on the first line of a file, with every other
+    /// field left empty.
+    ///
+    /// Tests should only set the fields they depend on, with the rest taken from here, i.e.
+    /// `ResolvedSymbol { test: true, ..ResolvedSymbol::for_test(...) }`.
+    pub(crate) fn for_test(
+        name: &str,
+        kind: models::parsed::SymbolKind,
+        language: models::parsed::Language,
+        path: impl Into<PathBuf>,
+    ) -> Self {
+        Self {
+            id: 1,
+            name: name.to_string(),
+            kind,
+            language,
+            path: path.into(),
+            package: None,
+            owners: models::resolved::Owners::default(),
+            test: false,
+            deprecated: false,
+            deprecation_message: None,
+            signature: None,
+            access: None,
+            generated: false,
+            expansion: None,
+            changed_at: None,
+            score: models::resolved::Score::default(),
+            start_line: 1,
+            end_line: 1,
+            start_column: 1,
+            end_column: 1 + i64::try_from(name.len()).unwrap_or_default(),
+        }
+    }
+}
+
 impl PartialOrd for ResolvedSymbol {
     fn partial_cmp(&self, other: &Self) -> Option<std::cmp::Ordering> {
         Some(self.cmp(other))
@@ -0,0 +1,42 @@
+use std::path::PathBuf;
+
+use serde::{Deserialize, Serialize};
+
+use crate::models;
+
+/// A type (or operation) defined in a schema file (i.e. a Protocol Buffers message, or a GraphQL
+/// type), which code is commonly generated from.
+#[derive(Debug, Clone, sqlx::FromRow, PartialEq, Eq, Serialize, Deserialize)]
+pub struct SchemaDefinition {
+    /// The full name of the definition, with nested definitions joined by a `.` (i.e.
+    /// `User.Address`).
+    pub name: String,
+
+    /// The kind of definition (i.e. a [`models::parsed::SymbolKind::Message`] for a Protocol
+    /// Buffers message).
+    pub kind: models::parsed::SymbolKind,
+
+    /// The path to the schema file which contains the definition.
+    #[sqlx[try_from = "String"]]
+    pub path: PathBuf,
+
+    /// The start line for the definition.
+    ///
+    /// This matches how editors generally refer to lines, and so starts from 1.
+    pub start_line: i64,
+
+    /// The end line for the definition.
+    ///
+    /// This matches how editors generally refer to lines, and so starts from 1.
+    pub end_line: i64,
+
+    /// The start column (character) for the definition.
+    ///
+    /// This matches how editors generally refer to columns (characters), and so starts from 1.
+    pub start_column: i64,
+
+    /// The end column (character) for the definition.
+    ///
+    /// This matches how editors generally refer to columns (characters), and so starts from 1.
+    pub end_column: i64,
+}
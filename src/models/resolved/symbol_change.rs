@@ -0,0 +1,66 @@
+use std::path::PathBuf;
+
+use serde::{Deserialize, Serialize};
+
+use crate::models;
+
+/// Whether a symbol was added to, or removed from, a file.
+#[derive(
+    Debug,
+    Clone,
+    Copy,
+    Hash,
+    Eq,
+    PartialEq,
+    sqlx::Type,
+    strum_macros::Display,
+    strum_macros::EnumString,
+    Serialize,
+    Deserialize,
+)]
+pub enum SymbolChangeKind {
+    /// The symbol wasn't in the file when it was last indexed.
+    Added,
+
+    /// The symbol was in the file when it was last indexed, but no longer is (or the file itself
+    /// was removed).
+    Removed,
+}
+
+/// A change to the symbols in a file, as recorded in the change log when the file was re-indexed.
+///
+/// Renaming a symbol is recorded as the removal of its old name, and the addition of its new name.
+///
+/// See [`crate::resolver::HistoryResolver`].
+#[derive(Debug, Clone, sqlx::FromRow, PartialEq, Eq)]
+pub struct SymbolChange {
+    /// The ID of the change, which increases with every change recorded.
+    pub id: i64,
+
+    /// Whether the symbol was added or removed.
+    pub change: SymbolChangeKind,
+
+    /// The path to the file the symbol was added to (or removed from).
+    #[sqlx[try_from = "String"]]
+    pub path: PathBuf,
+
+    /// The name of the symbol.
+    pub name: String,
+
+    /// The kind of symbol.
+    pub kind: models::parsed::SymbolKind,
+
+    /// The language the symbol is defined in.
+    pub language: models::parsed::Language,
+
+    /// The line the symbol was defined on, when it was added (or before it was removed).
+    ///
+    /// This matches how editors generally refer to lines, and so starts from 1.
+    pub line: i64,
+
+    /// The hash of the commit which made the change, if it's known.
+    pub commit_hash: Option<String>,
+
+    /// When the change was recorded.
+    pub changed_at: chrono::DateTime<chrono::Utc>,
+}
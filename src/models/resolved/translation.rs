@@ -0,0 +1,60 @@
+use std::path::PathBuf;
+
+use serde::{Deserialize, Serialize};
+
+use crate::models;
+
+/// A translation key defined in a locale file (i.e. `locales/en.json`).
+#[derive(Debug, Clone, sqlx::FromRow, PartialEq, Eq, Serialize, Deserialize)]
+pub struct Translation {
+    /// The full path of the translation key (i.e. `checkout.payment.title`).
+    pub key: String,
+
+    /// The locale the translation is for (i.e. `en`).
+    pub locale: String,
+
+    /// The path to the locale file which defines the translation.
+    #[sqlx[try_from = "String"]]
+    pub path: PathBuf,
+
+    /// The start line for the definition of the translation key.
+    ///
+    /// This matches how editors generally refer to lines, and so starts from 1.
+    pub start_line: i64,
+
+    /// The end line for the definition of the translation key.
+    ///
+    /// This matches how editors generally refer to lines, and so starts from 1.
+    pub end_line: i64,
+
+    /// The start column (character) for the definition of the translation key.
+    ///
+    /// This matches how editors generally refer to columns (characters), and so starts from 1.
+    pub start_column: i64,
+
+    /// The end column (character) for the definition of the translation key.
+    ///
+    /// This matches how editors generally refer to columns (characters), and so starts from 1.
+    pub end_column: i64,
+}
+
+/// Every location a translation key is defined (in locale files) or used (in source code).
+#[derive(Debug, PartialEq, Eq, Serialize, Deserialize)]
+pub struct TranslationLocations {
+    /// The translations of the key, in each locale file.
+    pub definitions: Vec<Translation>,
+
+    /// The usages of the key, which are
+    /// [`models::parsed::SymbolKind::TranslationKey`] symbols.
+    pub usages: Vec<models::resolved::ResolvedSymbol>,
+}
+
+/// A report of the translation keys which are out of sync between source code and locale files.
+#[derive(Debug, PartialEq, Eq, Serialize, Deserialize)]
+pub struct TranslationReport {
+    /// The usages of translation keys which are not defined in any locale file.
+    pub undefined: Vec<models::resolved::ResolvedSymbol>,
+
+    /// The translations (in locale files) of keys which are never used.
+    pub unused: Vec<Translation>,
+}
@@ -1,5 +1,12 @@
+use std::sync::Arc;
+
 use crate::models;
 
+/// The functions which are treated as translation functions by default (i.e.
+/// `t("checkout.payment.title")`), as used by common i18n libraries (i.e. i18next, vue-i18n and
+/// python-i18n).
+pub const DEFAULT_TRANSLATION_FUNCTIONS: [&str; 5] = ["t", "i18n.t", "i18next.t", "$t", "this.$t"];
+
 /// The context in which parsing has begun in.
 ///
 /// Fields defined in here generally help to better inform the parsing behavior, in order
@@ -7,6 +14,7 @@ use crate::models;
 #[derive(Debug, Default)]
 pub struct Context {
     pub(crate) existing_tree: Option<tree_sitter::Tree>,
+    pub(crate) translation_functions: Option<Arc<Vec<String>>>,
 }
 
 impl Context {
@@ -18,6 +26,25 @@ impl Context {
 
         self
     }
+
+    /// Set the functions which translation keys are passed to (i.e. `t` or `i18n.t`), replacing
+    /// the [`DEFAULT_TRANSLATION_FUNCTIONS`].
+    ///
+    /// Functions are matched against the callee exactly as it's written in the source code.
+    #[must_use]
+    pub fn with_translation_functions(mut self, functions: Arc<Vec<String>>) -> Self {
+        self.translation_functions = Some(functions);
+
+        self
+    }
+
+    /// Check if a function (as written in the source code) is a translation function.
+    pub(crate) fn is_translation_function(&self, function: &str) -> bool {
+        self.translation_functions.as_ref().map_or_else(
+            || DEFAULT_TRANSLATION_FUNCTIONS.contains(&function),
+            |functions| functions.iter().any(|f| f == function),
+        )
+    }
 }
 
 /// The output of the parsed source file.
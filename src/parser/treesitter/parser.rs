@@ -1,4 +1,9 @@
-use std::{collections::HashSet, path::Path, str::FromStr};
+use std::{
+    collections::{HashMap, HashSet},
+    ops::Range,
+    path::Path,
+    str::FromStr,
+};
 
 use tokio::{fs::File, io::AsyncReadExt};
 use tree_sitter::StreamingIterator;
@@ -45,12 +50,40 @@ impl parser::Parser for Parser {
         let (tree, file_content) =
             Self::parse_file_into_tree(file, &parser_language, ctx.existing_tree.as_ref()).await?;
 
-        let symbols =
-            Self::extract_symbols(file, &file_content, &tree, language, &parser_language)?;
+        let test_scopes =
+            Self::extract_test_scopes(&file_content, &tree, language, &parser_language)?;
+
+        let symbols = Self::extract_symbols(
+            file,
+            &file_content,
+            &tree,
+            language,
+            &parser_language,
+            &test_scopes,
+        )?;
+
+        let environment_variables = Self::extract_environment_variables(
+            file,
+            &file_content,
+            &tree,
+            language,
+            &parser_language,
+            &test_scopes,
+        )?;
+
+        let translation_keys = Self::extract_translation_keys(
+            file,
+            &file_content,
+            &tree,
+            language,
+            &parser_language,
+            &test_scopes,
+            ctx,
+        )?;
 
         let mut index = models::parsed::Index::new(models::parsed::Type::TreeSitter);
 
-        for symbol in symbols {
+        for symbol in symbols.chain(environment_variables).chain(translation_keys) {
             index.append_symbol(symbol);
         }
 
@@ -101,6 +134,7 @@ impl Parser {
         tree: &tree_sitter::Tree,
         language: models::parsed::Language,
         parser_language: &tree_sitter::Language,
+        test_scopes: &[Range<usize>],
     ) -> parser::Result<impl Iterator<Item = models::parsed::Symbol>> {
         let query = tree_sitter::Query::new(parser_language, language.get_symbol_query())
             .map_err(parser::Error::InvalidQuery)?;
@@ -110,6 +144,9 @@ impl Parser {
 
         let capture_names = query.capture_names();
 
+        let deprecations =
+            Self::extract_deprecations(file_content, tree, language, parser_language)?;
+
         let mut symbols: HashSet<Symbol> = HashSet::new();
 
         while let Some(m) = matches.next() {
@@ -132,18 +169,20 @@ impl Parser {
 
                 let mut symbol = models::parsed::Symbol::new(kind, &name);
 
-                let start_position = c.node.start_position();
-                let end_position = c.node.end_position();
+                symbol.test = is_in_scope(test_scopes, c.node);
+
+                if let Some(message) = deprecations.get(&c.node.byte_range()) {
+                    symbol.deprecated = true;
+                    symbol.deprecation_message.clone_from(message);
+                }
+
+                symbol.signature = Self::extract_signature(c.node, file_content)
+                    .map(|signature| signature.to_string());
 
                 let occurrence = models::parsed::Occurrence::new(
                     language,
                     file,
-                    models::parsed::Range::new(
-                        start_position.row + 1,
-                        end_position.row + 1,
-                        start_position.column + 1,
-                        end_position.column + 1,
-                    ),
+                    get_range(c.node),
                     models::parsed::Roles(vec![models::parsed::SymbolRole::Definition]),
                 );
                 symbol.add_occurrence(occurrence);
@@ -178,16 +217,428 @@ impl Parser {
 
         Ok(symbols.into_iter())
     }
+
+    /// Extract the byte ranges of any test code (i.e. test modules, test functions, or calls to
+    /// a test framework) from the Treesitter tree.
+    ///
+    /// Any symbols which fall inside these ranges are considered to be part of the tests.
+    ///
+    /// See [`models::parsed::Language::get_test_query`] for the underlying Treesitter queries
+    /// for supported languages.
+    fn extract_test_scopes(
+        file_content: &[u8],
+        tree: &tree_sitter::Tree,
+        language: models::parsed::Language,
+        parser_language: &tree_sitter::Language,
+    ) -> parser::Result<Vec<Range<usize>>> {
+        let query = tree_sitter::Query::new(parser_language, language.get_test_query())
+            .map_err(parser::Error::InvalidQuery)?;
+
+        let Some(test_capture_index) = query.capture_index_for_name("Test") else {
+            return Ok(Vec::new());
+        };
+
+        let mut cursor = tree_sitter::QueryCursor::new();
+        let mut matches = cursor.matches(&query, tree.root_node(), file_content);
+
+        let mut test_scopes = Vec::new();
+
+        while let Some(m) = matches.next() {
+            test_scopes.extend(
+                m.captures
+                    .iter()
+                    .filter(|c| c.index == test_capture_index)
+                    .map(|c| c.node.byte_range()),
+            );
+        }
+
+        Ok(test_scopes)
+    }
+
+    /// Extract the byte ranges of the names of any deprecated symbols from the Treesitter tree,
+    /// alongside the deprecation message (if one was given).
+    ///
+    /// See [`models::parsed::Language::get_deprecation_query`] for the underlying Treesitter
+    /// queries for supported languages.
+    fn extract_deprecations(
+        file_content: &[u8],
+        tree: &tree_sitter::Tree,
+        language: models::parsed::Language,
+        parser_language: &tree_sitter::Language,
+    ) -> parser::Result<HashMap<Range<usize>, Option<String>>> {
+        let query = tree_sitter::Query::new(parser_language, language.get_deprecation_query())
+            .map_err(parser::Error::InvalidQuery)?;
+
+        let (Some(deprecated_capture_index), Some(deprecation_capture_index)) = (
+            query.capture_index_for_name("Deprecated"),
+            query.capture_index_for_name("Deprecation"),
+        ) else {
+            return Ok(HashMap::new());
+        };
+
+        let mut cursor = tree_sitter::QueryCursor::new();
+        let mut matches = cursor.matches(&query, tree.root_node(), file_content);
+
+        let mut deprecations = HashMap::new();
+
+        while let Some(m) = matches.next() {
+            let message = m
+                .captures
+                .iter()
+                .find(|c| c.index == deprecation_capture_index)
+                .and_then(|c| c.node.utf8_text(file_content).ok())
+                .and_then(|marker| get_deprecation_message(language, marker));
+
+            for c in m
+                .captures
+                .iter()
+                .filter(|c| c.index == deprecated_capture_index)
+            {
+                deprecations.insert(c.node.byte_range(), message.clone());
+            }
+        }
+
+        Ok(deprecations)
+    }
+
+    /// Extract the environment variables which are read or written (i.e. `std::env::var("X")`
+    /// or `process.env.X`) from the Treesitter tree.
+    ///
+    /// Each read or write is a separate [`models::parsed::SymbolKind::EnvironmentVariable`]
+    /// symbol, so that every usage of a variable can be found across a workspace. Writes are
+    /// treated as definitions of the variable, whereas reads are only references.
+    ///
+    /// See [`models::parsed::Language::get_environment_query`] for the underlying Treesitter
+    /// queries for supported languages.
+    fn extract_environment_variables(
+        file: &Path,
+        file_content: &[u8],
+        tree: &tree_sitter::Tree,
+        language: models::parsed::Language,
+        parser_language: &tree_sitter::Language,
+        test_scopes: &[Range<usize>],
+    ) -> parser::Result<Vec<models::parsed::Symbol>> {
+        let query = tree_sitter::Query::new(parser_language, language.get_environment_query())
+            .map_err(parser::Error::InvalidQuery)?;
+
+        let read_capture_index = query.capture_index_for_name("Read");
+        let write_capture_index = query.capture_index_for_name("Write");
+
+        let mut cursor = tree_sitter::QueryCursor::new();
+        let mut matches = cursor.matches(&query, tree.root_node(), file_content);
+
+        // The nodes naming each environment variable, and whether they're written to. Writes can
+        // also match the queries for reads (i.e. `os.environ["X"] = …` in Python), so writes
+        // always take precedence.
+        let mut accesses: HashMap<Range<usize>, (tree_sitter::Node<'_>, bool)> = HashMap::new();
+
+        while let Some(m) = matches.next() {
+            for c in m.captures {
+                let is_write = if Some(c.index) == write_capture_index {
+                    true
+                } else if Some(c.index) == read_capture_index {
+                    false
+                } else {
+                    continue;
+                };
+
+                accesses
+                    .entry(c.node.byte_range())
+                    .and_modify(|(_, existing_is_write)| *existing_is_write |= is_write)
+                    .or_insert((c.node, is_write));
+            }
+        }
+
+        let mut environment_variables = Vec::new();
+
+        for (node, is_write) in accesses.into_values() {
+            let Ok(text) = node.utf8_text(file_content) else {
+                continue;
+            };
+
+            // Variables are named by either a string literal (i.e. `os.Getenv("X")`), or an
+            // identifier (i.e. `process.env.X`)
+            let name = get_quoted_string(text).unwrap_or(text).trim();
+
+            if name.is_empty() || name.contains(char::is_whitespace) {
+                // Variables can't be reliably named from interpolated strings (i.e. `${X}`)
+                continue;
+            }
+
+            let mut symbol =
+                models::parsed::Symbol::new(models::parsed::SymbolKind::EnvironmentVariable, name);
+
+            symbol.test = is_in_scope(test_scopes, node);
+
+            let roles = if is_write {
+                vec![
+                    models::parsed::SymbolRole::Definition,
+                    models::parsed::SymbolRole::WriteAccess,
+                ]
+            } else {
+                vec![models::parsed::SymbolRole::ReadAccess]
+            };
+
+            symbol.add_occurrence(models::parsed::Occurrence::new(
+                language,
+                file,
+                get_range(node),
+                models::parsed::Roles(roles),
+            ));
+
+            environment_variables.push(symbol);
+        }
+
+        Ok(environment_variables)
+    }
+
+    /// Extract the translation keys which are used (i.e. `t("checkout.payment.title")`) from the
+    /// Treesitter tree.
+    ///
+    /// As with environment variables, each usage is a separate
+    /// [`models::parsed::SymbolKind::TranslationKey`] symbol. Translation keys are only ever
+    /// defined in locale files, which are read by the indexer instead.
+    ///
+    /// See [`models::parsed::Language::get_translation_query`] for the underlying Treesitter
+    /// queries for supported languages.
+    fn extract_translation_keys(
+        file: &Path,
+        file_content: &[u8],
+        tree: &tree_sitter::Tree,
+        language: models::parsed::Language,
+        parser_language: &tree_sitter::Language,
+        test_scopes: &[Range<usize>],
+        ctx: &super::Context,
+    ) -> parser::Result<Vec<models::parsed::Symbol>> {
+        let Some(query) = language.get_translation_query() else {
+            return Ok(Vec::new());
+        };
+
+        let query =
+            tree_sitter::Query::new(parser_language, query).map_err(parser::Error::InvalidQuery)?;
+
+        let function_capture_index = query.capture_index_for_name("Function");
+        let key_capture_index = query.capture_index_for_name("Key");
+
+        let mut cursor = tree_sitter::QueryCursor::new();
+        let mut matches = cursor.matches(&query, tree.root_node(), file_content);
+
+        let mut translation_keys = Vec::new();
+
+        while let Some(m) = matches.next() {
+            let is_translation_function = m
+                .captures
+                .iter()
+                .filter(|c| Some(c.index) == function_capture_index)
+                .filter_map(|c| c.node.utf8_text(file_content).ok())
+                .any(|function| ctx.is_translation_function(function));
+
+            if !is_translation_function {
+                continue;
+            }
+
+            for c in m
+                .captures
+                .iter()
+                .filter(|c| Some(c.index) == key_capture_index)
+            {
+                let Some(name) = c
+                    .node
+                    .utf8_text(file_content)
+                    .ok()
+                    .and_then(get_quoted_string)
+                    .map(str::trim)
+                    .filter(|name| !name.is_empty())
+                else {
+                    continue;
+                };
+
+                let mut symbol =
+                    models::parsed::Symbol::new(models::parsed::SymbolKind::TranslationKey, name);
+
+                symbol.test = is_in_scope(test_scopes, c.node);
+
+                symbol.add_occurrence(models::parsed::Occurrence::new(
+                    language,
+                    file,
+                    get_range(c.node),
+                    models::parsed::Roles(vec![models::parsed::SymbolRole::ReadAccess]),
+                ));
+
+                translation_keys.push(symbol);
+            }
+        }
+
+        Ok(translation_keys)
+    }
+
+    /// Extract the signature of a function-like symbol (i.e. a function, method, or a function
+    /// assigned to a variable), from the node which names it.
+    ///
+    /// This relies on the field names which are common to most Treesitter grammars (i.e.
+    /// `name`, `parameters`, `return_type` and `result`), rather than on language-specific
+    /// queries.
+    fn extract_signature(
+        name: tree_sitter::Node<'_>,
+        file_content: &[u8],
+    ) -> Option<models::parsed::Signature> {
+        let mut name = name;
+        let mut declaration = name.parent()?;
+
+        // Methods in Lua are named by an index expression (i.e. `function M:method() end`)
+        if matches!(
+            declaration.kind(),
+            "method_index_expression" | "dot_index_expression"
+        ) {
+            name = declaration;
+            declaration = declaration.parent()?;
+        }
+
+        if declaration.child_by_field_name("name")? != name {
+            // The symbol is only part of the declaration (i.e. a parameter), rather than naming
+            // it
+            return None;
+        }
+
+        // Functions assigned to variables (i.e. `const render = () => {}`)
+        if declaration.kind() == "variable_declarator" {
+            declaration = declaration.child_by_field_name("value")?;
+        }
+
+        let text = |node: tree_sitter::Node<'_>| node.utf8_text(file_content).ok();
+
+        let parameters = declaration
+            .child_by_field_name("parameters")
+            .or_else(|| declaration.child_by_field_name("parameter"))?;
+
+        let parameter_types = if parameters.kind() == "identifier" {
+            // Arrow functions with a single parameter can omit the parentheses (i.e. `x => x`)
+            vec![None]
+        } else {
+            let mut cursor = parameters.walk();
+
+            parameters
+                .named_children(&mut cursor)
+                .filter(|parameter| {
+                    !parameter.is_extra()
+                        && !matches!(
+                            parameter.kind(),
+                            "self_parameter"
+                                | "attribute_item"
+                                | "keyword_separator"
+                                | "positional_separator"
+                        )
+                        // Receivers in Python are (by convention) untyped, and named `self` or
+                        // `cls`
+                        && !(parameter.kind() == "identifier"
+                            && matches!(text(*parameter), Some("self" | "cls")))
+                })
+                .flat_map(|parameter| {
+                    // Go allows multiple parameters to share a type (i.e. `a, b int`)
+                    let mut cursor = parameter.walk();
+                    let names = parameter
+                        .children_by_field_name("name", &mut cursor)
+                        .count()
+                        .max(1);
+
+                    std::iter::repeat_n(parameter.child_by_field_name("type").and_then(text), names)
+                })
+                .collect()
+        };
+
+        let return_type = declaration
+            .child_by_field_name("return_type")
+            .or_else(|| declaration.child_by_field_name("result"))
+            .and_then(text);
+
+        Some(models::parsed::Signature::new(parameter_types, return_type))
+    }
+}
+
+/// Get the range of a node, in the form editors generally refer to (i.e. lines and columns
+/// starting from 1).
+fn get_range(node: tree_sitter::Node<'_>) -> models::parsed::Range {
+    let start_position = node.start_position();
+    let end_position = node.end_position();
+
+    models::parsed::Range::new(
+        start_position.row + 1,
+        end_position.row + 1,
+        start_position.column + 1,
+        end_position.column + 1,
+    )
+}
+
+/// Check if a node falls entirely inside any of a set of scopes (i.e. test scopes).
+fn is_in_scope(scopes: &[Range<usize>], node: tree_sitter::Node<'_>) -> bool {
+    scopes
+        .iter()
+        .any(|scope| scope.start <= node.start_byte() && node.end_byte() <= scope.end)
+}
+
+/// Read the deprecation message out of a deprecation marker (i.e. `note = "…"` in a Rust
+/// `#[deprecated]` attribute, or the text after a JSDoc `@deprecated` tag).
+fn get_deprecation_message(language: models::parsed::Language, marker: &str) -> Option<String> {
+    use models::parsed::Language;
+
+    let message = match language {
+        Language::Rust => marker
+            .split_once("note")
+            .and_then(|(_, rest)| rest.trim_start().strip_prefix('='))
+            .or_else(|| {
+                marker
+                    .split_once("deprecated")
+                    .and_then(|(_, rest)| rest.trim_start().strip_prefix('='))
+            })
+            .and_then(get_quoted_string),
+        Language::Go => marker
+            .split_once("Deprecated:")
+            .map(|(_, message)| message.trim()),
+        Language::Python => get_quoted_string(marker),
+        Language::Clojure => marker
+            .split_once(":deprecated")
+            .and_then(|(_, rest)| get_quoted_string(rest)),
+        Language::TypeScript
+        | Language::TypeScriptJsx
+        | Language::Javascript
+        | Language::JavascriptJsx
+        | Language::Lua => marker.split_once("@deprecated").map(|(_, rest)| {
+            rest.lines()
+                .next()
+                .unwrap_or_default()
+                .trim()
+                .trim_end_matches("*/")
+                .trim()
+        }),
+        Language::Shell => None,
+    }?;
+
+    (!message.is_empty()).then(|| message.to_string())
+}
+
+/// Get the content of the first quoted string (using single or double quotes) in some text.
+fn get_quoted_string(text: &str) -> Option<&str> {
+    let start = text.find(['"', '\''])?;
+    let quote = text[start..].chars().next()?;
+
+    text[start + 1..]
+        .split_once(quote)
+        .map(|(content, _)| content)
 }
 
 #[cfg(test)]
 mod tests {
-    use std::path::PathBuf;
+    use std::{path::PathBuf, sync::Arc};
 
     use insta::assert_debug_snapshot;
     use itertools::Itertools;
+    use rstest::rstest;
+    use tempfile::tempdir;
 
-    use crate::parser::{Parser, treesitter::Context};
+    use crate::{
+        models::parsed::{Language, SymbolKind, SymbolRole},
+        parser::{Parser, treesitter::Context},
+    };
 
     #[tokio::test]
     pub async fn test_parsing_rust() {
@@ -335,4 +786,405 @@ mod tests {
 
         assert_debug_snapshot!(index.index.symbols.iter().sorted());
     }
+
+    #[rstest]
+    #[case(
+        "lib.rs",
+        "fn parse() {}\n\n#[cfg(test)]\nmod tests {\n    #[test]\n    fn test_parse() {}\n}\n",
+        vec!["tests", "test_parse"],
+        vec!["parse"]
+    )]
+    #[case(
+        "latest.rs",
+        "fn latest() {}\n\n#[tokio::test]\nasync fn check_latest() {}\n",
+        vec!["check_latest"],
+        vec!["latest"]
+    )]
+    #[case(
+        "user.go",
+        "package user\n\nfunc GetUser() {}\n\nfunc ExampleGetUser() {}\n\nfunc Testify() {}\n",
+        vec!["ExampleGetUser"],
+        vec!["GetUser", "Testify"]
+    )]
+    #[case(
+        "service.py",
+        "import unittest\n\ndef serve():\n    pass\n\nclass ServiceCase(unittest.TestCase):\n    def check(self):\n        pass\n",
+        vec!["ServiceCase", "check"],
+        vec!["serve"]
+    )]
+    #[case(
+        "report.py",
+        "def testimonial():\n    pass\n\nclass TestReport:\n    def test_render(self):\n        pass\n",
+        vec!["TestReport", "test_render"],
+        vec!["testimonial"]
+    )]
+    #[case(
+        "button.ts",
+        "function render() {}\n\ndescribe('button', () => {\n    function mount() {}\n});\n",
+        vec!["mount"],
+        vec!["render"]
+    )]
+    #[tokio::test]
+    pub async fn test_detecting_test_symbols(
+        #[case] filename: &str,
+        #[case] content: &str,
+        #[case] expected_tests: Vec<&str>,
+        #[case] expected_non_tests: Vec<&str>,
+    ) {
+        let directory = tempdir().expect("Should always be able to create a temporary directory");
+
+        let file = directory.path().join(filename);
+
+        tokio::fs::write(&file, content)
+            .await
+            .expect("Should always be able to write a test file");
+
+        let output = super::Parser::default()
+            .parse(&file, &Context::default())
+            .await
+            .expect("Index should always be available");
+
+        let is_test = |name: &str| {
+            output
+                .index
+                .symbols
+                .iter()
+                .find(|symbol| symbol.name == name)
+                .unwrap_or_else(|| panic!("{name} should have been parsed"))
+                .test
+        };
+
+        for name in expected_tests {
+            assert!(is_test(name), "{name} should be a test symbol");
+        }
+
+        for name in expected_non_tests {
+            assert!(!is_test(name), "{name} should not be a test symbol");
+        }
+    }
+
+    #[rstest]
+    #[case(
+        "lib.rs",
+        "#[deprecated(since = \"1.2.0\", note = \"Use `parse` instead\")]\n#[must_use]\nfn old_parse() {}\n\nfn parse() {}\n",
+        vec![("old_parse", Some("Use `parse` instead"))],
+        vec!["parse"]
+    )]
+    #[case(
+        "lib.rs",
+        "#[deprecated]\nstruct Legacy {\n    value: u8,\n}\n",
+        vec![("Legacy", None)],
+        vec!["value"]
+    )]
+    #[case(
+        "user.go",
+        "package user\n\n// GetUser returns a user.\n//\n// Deprecated: Use FindUser instead.\nfunc GetUser() {}\n\nfunc FindUser() {}\n",
+        vec![("GetUser", Some("Use FindUser instead."))],
+        vec!["FindUser"]
+    )]
+    #[case(
+        "service.py",
+        "from warnings import deprecated\n\n@deprecated(\"Use serve instead\")\ndef run():\n    pass\n\ndef serve():\n    pass\n",
+        vec![("run", Some("Use serve instead"))],
+        vec!["serve"]
+    )]
+    #[case(
+        "button.ts",
+        "/**\n * Render a button.\n *\n * @deprecated Use `Button` instead.\n */\nexport function renderButton() {}\n\nexport function Button() {}\n",
+        vec![("renderButton", Some("Use `Button` instead."))],
+        vec!["Button"]
+    )]
+    #[case(
+        "button.js",
+        "/** @deprecated */\nfunction renderButton() {}\n\nfunction Button() {}\n",
+        vec![("renderButton", None)],
+        vec!["Button"]
+    )]
+    #[case(
+        "util.lua",
+        "---@deprecated Use format instead\nfunction stringify() end\n\nfunction format() end\n",
+        vec![("stringify", Some("Use format instead"))],
+        vec!["format"]
+    )]
+    #[tokio::test]
+    pub async fn test_detecting_deprecated_symbols(
+        #[case] filename: &str,
+        #[case] content: &str,
+        #[case] expected_deprecations: Vec<(&str, Option<&str>)>,
+        #[case] expected_non_deprecations: Vec<&str>,
+    ) {
+        let directory = tempdir().expect("Should always be able to create a temporary directory");
+
+        let file = directory.path().join(filename);
+
+        tokio::fs::write(&file, content)
+            .await
+            .expect("Should always be able to write a test file");
+
+        let output = super::Parser::default()
+            .parse(&file, &Context::default())
+            .await
+            .expect("Index should always be available");
+
+        let get_symbol = |name: &str| {
+            output
+                .index
+                .symbols
+                .iter()
+                .find(|symbol| symbol.name == name)
+                .unwrap_or_else(|| panic!("{name} should have been parsed"))
+        };
+
+        for (name, expected_message) in expected_deprecations {
+            let symbol = get_symbol(name);
+
+            assert!(symbol.deprecated, "{name} should be deprecated");
+            assert_eq!(expected_message, symbol.deprecation_message.as_deref());
+        }
+
+        for name in expected_non_deprecations {
+            assert!(
+                !get_symbol(name).deprecated,
+                "{name} should not be deprecated"
+            );
+        }
+    }
+
+    #[rstest]
+    #[case(
+        "lib.rs",
+        "impl Loader {\n    fn load(&self, path: &Path, ctx: &mut Context) -> Result<Vec<u8>, Error> {}\n}\n",
+        "load",
+        Some("(Path, Context) -> Result<Vec<u8>,Error>")
+    )]
+    #[case(
+        "user.go",
+        "package user\n\nfunc (s *Store) Find(id, version int, opts ...Option) (*User, error) {}\n",
+        "Find",
+        Some("(int, int, Option) -> (*User,error)")
+    )]
+    #[case(
+        "service.py",
+        "class Service:\n    def serve(self, port: int, host=None) -> bool:\n        pass\n",
+        "serve",
+        Some("(int, _) -> bool")
+    )]
+    #[case(
+        "button.ts",
+        "const render = (label: string, disabled?: boolean): Element => {};\n",
+        "render",
+        Some("(string, boolean) -> Element")
+    )]
+    #[case(
+        "util.lua",
+        "local M = {}\n\nfunction M:format(value) end\n",
+        "format",
+        Some("(_)")
+    )]
+    #[case("lib.rs", "struct Loader {\n    path: PathBuf,\n}\n", "path", None)]
+    #[tokio::test]
+    pub async fn test_capturing_signatures(
+        #[case] filename: &str,
+        #[case] content: &str,
+        #[case] name: &str,
+        #[case] expected_signature: Option<&str>,
+    ) {
+        let directory = tempdir().expect("Should always be able to create a temporary directory");
+
+        let file = directory.path().join(filename);
+
+        tokio::fs::write(&file, content)
+            .await
+            .expect("Should always be able to write a test file");
+
+        let output = super::Parser::default()
+            .parse(&file, &Context::default())
+            .await
+            .expect("Index should always be available");
+
+        let symbol = output
+            .index
+            .symbols
+            .iter()
+            .find(|symbol| symbol.name == name)
+            .unwrap_or_else(|| panic!("{name} should have been parsed"));
+
+        assert_eq!(expected_signature, symbol.signature.as_deref());
+    }
+
+    #[rstest]
+    #[case(
+        "main.rs",
+        "fn main() {\n    let home = std::env::var(\"HOME\");\n    let path = env!(\"CARGO_PKG_NAME\");\n    std::env::set_var(\"DEBUG\", \"1\");\n}\n",
+        vec![("HOME", false), ("CARGO_PKG_NAME", false), ("DEBUG", true)]
+    )]
+    #[case(
+        "main.go",
+        "package main\n\nfunc main() {\n\thome := os.Getenv(\"HOME\")\n\tos.Setenv(\"DEBUG\", \"1\")\n}\n",
+        vec![("HOME", false), ("DEBUG", true)]
+    )]
+    #[case(
+        "main.py",
+        "import os\n\nhome = os.environ[\"HOME\"]\nuser = os.getenv('USER')\nos.environ[\"DEBUG\"] = \"1\"\n",
+        vec![("HOME", false), ("USER", false), ("DEBUG", true)]
+    )]
+    #[case(
+        "index.ts",
+        "const home = process.env.HOME;\nconst user = process.env[\"USER\"];\nprocess.env.DEBUG = \"1\";\n",
+        vec![("HOME", false), ("USER", false), ("DEBUG", true)]
+    )]
+    #[case(
+        "deploy.sh",
+        "#!/bin/sh\nexport DEBUG=1\necho \"${HOME}\" $USER $count\nunset TOKEN\n",
+        vec![("DEBUG", true), ("HOME", false), ("USER", false), ("TOKEN", true)]
+    )]
+    #[case(
+        ".env",
+        "DATABASE_URL=postgres://localhost\nexport PORT=8080\n",
+        vec![("DATABASE_URL", true), ("PORT", true)]
+    )]
+    #[tokio::test]
+    pub async fn test_detecting_environment_variables(
+        #[case] filename: &str,
+        #[case] content: &str,
+        #[case] expected_variables: Vec<(&str, bool)>,
+    ) {
+        let directory = tempdir().expect("Should always be able to create a temporary directory");
+
+        let file = directory.path().join(filename);
+
+        tokio::fs::write(&file, content)
+            .await
+            .expect("Should always be able to write a test file");
+
+        let output = super::Parser::default()
+            .parse(&file, &Context::default())
+            .await
+            .expect("Index should always be available");
+
+        let variables = output
+            .index
+            .symbols
+            .iter()
+            .filter(|symbol| symbol.kind == SymbolKind::EnvironmentVariable)
+            .collect::<Vec<_>>();
+
+        assert_eq!(expected_variables.len(), variables.len());
+
+        for (name, is_write) in expected_variables {
+            let variable = variables
+                .iter()
+                .find(|symbol| symbol.name == name)
+                .unwrap_or_else(|| panic!("{name} should have been parsed"));
+
+            // Writes are the definition of a variable, whereas reads are only references to it
+            if is_write {
+                assert!(
+                    variable.definition.as_ref().is_some_and(|definition| {
+                        definition.roles.contains(&SymbolRole::WriteAccess)
+                    }),
+                    "{name} should be written to"
+                );
+            } else {
+                assert!(
+                    variable.definition.is_none(),
+                    "{name} should not be defined"
+                );
+                assert!(
+                    variable
+                        .occurrences
+                        .iter()
+                        .all(|occurrence| occurrence.roles.contains(&SymbolRole::ReadAccess)),
+                    "{name} should be read from"
+                );
+            }
+        }
+    }
+
+    #[rstest]
+    #[case(
+        "checkout.ts",
+        "const title = t(\"checkout.payment.title\");\nconst label = i18n.t('checkout.payment.submit', { count: 1 });\nconst other = translate(\"checkout.other\");\n",
+        None,
+        vec!["checkout.payment.title", "checkout.payment.submit"]
+    )]
+    #[case(
+        "checkout.py",
+        "title = t(\"checkout.payment.title\")\nother = gettext(\"checkout.other\")\n",
+        None,
+        vec!["checkout.payment.title"]
+    )]
+    #[case(
+        "checkout.js",
+        "const title = t(\"checkout.payment.title\");\nconst other = translate(\"checkout.other\");\n",
+        Some(vec!["translate"]),
+        vec!["checkout.other"]
+    )]
+    #[case("main.go", "package main\n\nfunc main() {\n\tt(\"checkout.title\")\n}\n", None, vec![])]
+    #[tokio::test]
+    pub async fn test_detecting_translation_keys(
+        #[case] filename: &str,
+        #[case] content: &str,
+        #[case] translation_functions: Option<Vec<&str>>,
+        #[case] expected_keys: Vec<&str>,
+    ) {
+        let directory = tempdir().expect("Should always be able to create a temporary directory");
+
+        let file = directory.path().join(filename);
+
+        tokio::fs::write(&file, content)
+            .await
+            .expect("Should always be able to write a test file");
+
+        let mut ctx = Context::default();
+
+        if let Some(translation_functions) = translation_functions {
+            ctx = ctx.with_translation_functions(Arc::new(
+                translation_functions
+                    .into_iter()
+                    .map(ToString::to_string)
+                    .collect(),
+            ));
+        }
+
+        let output = super::Parser::default()
+            .parse(&file, &ctx)
+            .await
+            .expect("Index should always be available");
+
+        let keys = output
+            .index
+            .symbols
+            .iter()
+            .filter(|symbol| symbol.kind == SymbolKind::TranslationKey)
+            .map(|symbol| symbol.name.as_str())
+            .sorted()
+            .collect::<Vec<_>>();
+
+        assert_eq!(expected_keys.into_iter().sorted().collect::<Vec<_>>(), keys);
+    }
+
+    #[rstest]
+    #[case(Language::Rust, "#[deprecated = \"Use bar\"]", Some("Use bar"))]
+    #[case(Language::Rust, "#[deprecated(since = \"1.0\")]", None)]
+    #[case(Language::Go, "// Deprecated: Use Bar.", Some("Use Bar."))]
+    #[case(
+        Language::Python,
+        "@typing_extensions.deprecated('Use bar')",
+        Some("Use bar")
+    )]
+    #[case(Language::Clojure, "^{:deprecated \"1.2\"}", Some("1.2"))]
+    #[case(Language::Clojure, "^:deprecated", None)]
+    #[case(Language::TypeScript, "/** @deprecated Use bar */", Some("Use bar"))]
+    #[case(Language::Lua, "---@deprecated", None)]
+    pub fn test_reading_deprecation_messages(
+        #[case] language: Language,
+        #[case] marker: &str,
+        #[case] expected_message: Option<&str>,
+    ) {
+        assert_eq!(
+            expected_message.map(ToString::to_string),
+            super::get_deprecation_message(language, marker)
+        );
+    }
 }
@@ -0,0 +1,29 @@
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Deprecated definitions: (defn ^:deprecated NAME …) and (defn ^{:deprecated "…"} NAME …)
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (list_lit
+    .
+    (sym_lit) @_head
+    .
+    (sym_lit
+      (meta_lit) @Deprecation) @Deprecated)
+  (#match? @_head "(^|/)def")
+  (#match? @Deprecation ":deprecated\\b")
+)
+
+;; Attribute maps: (defn NAME "docstring" {:deprecated "…"} …)
+(
+  (list_lit
+    .
+    (sym_lit) @_head
+    .
+    (sym_lit) @Deprecated
+    .
+    (str_lit)?
+    .
+    (map_lit) @Deprecation)
+  (#match? @_head "(^|/)def")
+  (#match? @Deprecation ":deprecated\\b")
+)
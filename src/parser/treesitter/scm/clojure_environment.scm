@@ -0,0 +1,14 @@
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Reads: (System/getenv "X")
+;;
+;; The JVM has no standard way of writing environment variables.
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (list_lit
+    .
+    (sym_lit) @_function
+    .
+    (str_lit) @Read)
+  (#eq? @_function "System/getenv")
+)
@@ -0,0 +1,10 @@
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Test definitions: (deftest NAME …), (defspec NAME …) and (use-fixtures …)
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (list_lit
+    .
+    (sym_lit) @_head) @Test
+  (#match? @_head "(^|/)(deftest|deftest-|defspec|use-fixtures)$")
+)
@@ -0,0 +1,50 @@
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Deprecated declarations: a "Deprecated:" paragraph in the doc comment
+;;
+;; The paragraph doesn't have to be the last in the doc comment, so any comments between the
+;; marker and the declaration are skipped.
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (comment) @Deprecation
+  .
+  (comment)*
+  .
+  [
+    (function_declaration
+      name: (identifier) @Deprecated)
+    (method_declaration
+      name: (field_identifier) @Deprecated)
+    (type_declaration
+      (type_spec
+        name: (type_identifier) @Deprecated))
+    (const_declaration
+      (const_spec
+        name: (identifier) @Deprecated))
+    (var_declaration
+      (var_spec
+        name: (identifier) @Deprecated))
+  ]
+  (#match? @Deprecation "^//\\s*Deprecated:")
+)
+
+;; Grouped declarations (i.e. const ( … )) and struct fields carry their own doc comments
+(
+  (comment) @Deprecation
+  .
+  (comment)*
+  .
+  [
+    (type_spec
+      name: (type_identifier) @Deprecated)
+    (const_spec
+      name: (identifier) @Deprecated)
+    (var_spec
+      name: (identifier) @Deprecated)
+    (field_declaration
+      name: (field_identifier) @Deprecated)
+    (method_elem
+      name: (field_identifier) @Deprecated)
+  ]
+  (#match? @Deprecation "^//\\s*Deprecated:")
+)
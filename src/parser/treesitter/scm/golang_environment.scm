@@ -0,0 +1,37 @@
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Reads: os.Getenv("X") and os.LookupEnv("X")
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (call_expression
+    function: (selector_expression
+      operand: (identifier) @_package
+      field: (field_identifier) @_function)
+    arguments: (argument_list
+      .
+      [
+        (interpreted_string_literal)
+        (raw_string_literal)
+      ] @Read))
+  (#eq? @_package "os")
+  (#match? @_function "^(Getenv|LookupEnv)$")
+)
+
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Writes: os.Setenv("X", …) and os.Unsetenv("X")
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (call_expression
+    function: (selector_expression
+      operand: (identifier) @_package
+      field: (field_identifier) @_function)
+    arguments: (argument_list
+      .
+      [
+        (interpreted_string_literal)
+        (raw_string_literal)
+      ] @Write))
+  (#eq? @_package "os")
+  (#match? @_function "^(Setenv|Unsetenv)$")
+)
@@ -0,0 +1,9 @@
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Tests, benchmarks, examples and fuzz tests: func TestXxx(t *testing.T) { … }
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (function_declaration
+    name: (identifier) @_name) @Test
+  (#match? @_name "^(Test|Benchmark|Example|Fuzz)([^a-z]|$)")
+)
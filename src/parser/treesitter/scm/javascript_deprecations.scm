@@ -0,0 +1,43 @@
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Deprecated declarations: a JSDoc comment containing an @deprecated tag
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (comment) @Deprecation
+  .
+  [
+    (function_declaration
+      name: (identifier) @Deprecated)
+    (class_declaration
+      name: (identifier) @Deprecated)
+    (method_definition
+      name: (property_identifier) @Deprecated)
+    (lexical_declaration
+      (variable_declarator
+        name: (identifier) @Deprecated))
+    (variable_declaration
+      (variable_declarator
+        name: (identifier) @Deprecated))
+  ]
+  (#match? @Deprecation "(?s)^/\\*\\*.*@deprecated")
+)
+
+;; Exported declarations (i.e. export function …)
+(
+  (comment) @Deprecation
+  .
+  (export_statement
+    declaration: [
+      (function_declaration
+        name: (identifier) @Deprecated)
+      (class_declaration
+        name: (identifier) @Deprecated)
+      (lexical_declaration
+        (variable_declarator
+          name: (identifier) @Deprecated))
+      (variable_declaration
+        (variable_declarator
+          name: (identifier) @Deprecated))
+    ])
+  (#match? @Deprecation "(?s)^/\\*\\*.*@deprecated")
+)
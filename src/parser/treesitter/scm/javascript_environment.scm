@@ -0,0 +1,51 @@
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Reads: process.env.X and process.env["X"]
+;;
+;; This is shared between JavaScript, TypeScript and their JSX variants.
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (member_expression
+    object: (member_expression
+      object: (identifier) @_object
+      property: (property_identifier) @_property)
+    property: (property_identifier) @Read)
+  (#eq? @_object "process")
+  (#eq? @_property "env")
+)
+
+(
+  (subscript_expression
+    object: (member_expression
+      object: (identifier) @_object
+      property: (property_identifier) @_property)
+    index: (string) @Read)
+  (#eq? @_object "process")
+  (#eq? @_property "env")
+)
+
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Writes: process.env.X = … and process.env["X"] = …
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (assignment_expression
+    left: (member_expression
+      object: (member_expression
+        object: (identifier) @_object
+        property: (property_identifier) @_property)
+      property: (property_identifier) @Write))
+  (#eq? @_object "process")
+  (#eq? @_property "env")
+)
+
+(
+  (assignment_expression
+    left: (subscript_expression
+      object: (member_expression
+        object: (identifier) @_object
+        property: (property_identifier) @_property)
+      index: (string) @Write))
+  (#eq? @_object "process")
+  (#eq? @_property "env")
+)
@@ -0,0 +1,28 @@
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Test framework calls (Jest, Vitest, Mocha, etc.): describe(…), it(…), test(…), etc.
+;;
+;; This is shared between JavaScript, TypeScript and their JSX variants.
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (call_expression
+    function: (identifier) @_function) @Test
+  (#match? @_function "^(describe|suite|it|test|beforeEach|afterEach|beforeAll|afterAll)$")
+)
+
+;; describe.only(…), it.skip(…), etc.
+(
+  (call_expression
+    function: (member_expression
+      object: (identifier) @_function)) @Test
+  (#match? @_function "^(describe|suite|it|test|beforeEach|afterEach|beforeAll|afterAll)$")
+)
+
+;; describe.each(…)(…), test.each(…)(…), etc.
+(
+  (call_expression
+    function: (call_expression
+      function: (member_expression
+        object: (identifier) @_function))) @Test
+  (#match? @_function "^(describe|suite|it|test)$")
+)
@@ -0,0 +1,17 @@
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Usages: t("X"), i18n.t("X"), this.$t("X"), etc.
+;;
+;; This is shared between JavaScript, TypeScript and their JSX variants. Which functions are
+;; actually translation functions is decided by the parser, as it's configurable.
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (call_expression
+    function: [
+      (identifier)
+      (member_expression)
+    ] @Function
+    arguments: (arguments
+      .
+      (string) @Key))
+)
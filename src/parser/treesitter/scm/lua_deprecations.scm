@@ -0,0 +1,19 @@
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Deprecated functions: a ---@deprecated LuaLS annotation
+;;
+;; Other annotations (i.e. ---@param) can sit between the marker and the function.
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (comment) @Deprecation
+  .
+  (comment)*
+  .
+  (function_declaration
+    name: [
+      (identifier) @Deprecated
+      (method_index_expression
+        method: (identifier) @Deprecated)
+    ])
+  (#match? @Deprecation "^---\\s*@deprecated")
+)
@@ -0,0 +1,17 @@
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Reads: os.getenv("X")
+;;
+;; Lua has no standard way of writing environment variables.
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (function_call
+    name: (dot_index_expression
+      table: (identifier) @_table
+      field: (identifier) @_function)
+    arguments: (arguments
+      .
+      (string) @Read))
+  (#eq? @_table "os")
+  (#eq? @_function "getenv")
+)
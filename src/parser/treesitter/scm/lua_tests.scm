@@ -0,0 +1,9 @@
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Test framework calls (busted, plenary, etc.): describe(…), it(…), etc.
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (function_call
+    name: (identifier) @_function) @Test
+  (#match? @_function "^(describe|it|pending|before_each|after_each)$")
+)
@@ -0,0 +1,15 @@
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Deprecated definitions: @deprecated, @warnings.deprecated, @typing_extensions.deprecated, etc.
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (decorated_definition
+    (decorator) @Deprecation
+    definition: [
+      (function_definition
+        name: (identifier) @Deprecated)
+      (class_definition
+        name: (identifier) @Deprecated)
+    ])
+  (#match? @Deprecation "^@(\\w+\\.)*deprecated\\b")
+)
@@ -0,0 +1,83 @@
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Reads: os.environ["X"], os.environ.get("X"), os.getenv("X")
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (subscript
+    value: (attribute
+      object: (identifier) @_module
+      attribute: (identifier) @_attribute)
+    subscript: (string) @Read)
+  (#eq? @_module "os")
+  (#eq? @_attribute "environ")
+)
+
+(
+  (call
+    function: (attribute
+      object: (attribute
+        object: (identifier) @_module
+        attribute: (identifier) @_attribute)
+      attribute: (identifier) @_function)
+    arguments: (argument_list
+      .
+      (string) @Read))
+  (#eq? @_module "os")
+  (#eq? @_attribute "environ")
+  (#eq? @_function "get")
+)
+
+(
+  (call
+    function: (attribute
+      object: (identifier) @_module
+      attribute: (identifier) @_function)
+    arguments: (argument_list
+      .
+      (string) @Read))
+  (#eq? @_module "os")
+  (#eq? @_function "getenv")
+)
+
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Writes: os.environ["X"] = …, os.environ.setdefault("X", …), os.environ.pop("X") and
+;; os.putenv("X", …)
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (assignment
+    left: (subscript
+      value: (attribute
+        object: (identifier) @_module
+        attribute: (identifier) @_attribute)
+      subscript: (string) @Write))
+  (#eq? @_module "os")
+  (#eq? @_attribute "environ")
+)
+
+(
+  (call
+    function: (attribute
+      object: (attribute
+        object: (identifier) @_module
+        attribute: (identifier) @_attribute)
+      attribute: (identifier) @_function)
+    arguments: (argument_list
+      .
+      (string) @Write))
+  (#eq? @_module "os")
+  (#eq? @_attribute "environ")
+  (#match? @_function "^(setdefault|pop)$")
+)
+
+(
+  (call
+    function: (attribute
+      object: (identifier) @_module
+      attribute: (identifier) @_function)
+    arguments: (argument_list
+      .
+      (string) @Write))
+  (#eq? @_module "os")
+  (#match? @_function "^(putenv|unsetenv)$")
+)
@@ -0,0 +1,33 @@
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Test classes: class TestSomething: … and class Something(unittest.TestCase): …
+;;
+;; Test functions (def test_something(): …) are only tests inside a test class, or a test module
+;; (i.e. test_something.py), which is recognised by its path instead. Elsewhere, a function named
+;; test… is just as likely to be application code.
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (class_definition
+    name: (identifier) @_name) @Test
+  (#match? @_name "^Test")
+)
+
+(
+  (class_definition
+    superclasses: (argument_list
+      [
+        (identifier)
+        (attribute)
+      ] @_superclass)) @Test
+  (#match? @_superclass "TestCase$")
+)
+
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Fixtures and marked tests: @pytest.fixture, @pytest.mark.parametrize(…), etc.
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (decorated_definition
+    (decorator) @_decorator) @Test
+  (#match? @_decorator "^@pytest\\.")
+)
@@ -0,0 +1,17 @@
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Usages: t("X"), i18n.t("X"), etc.
+;;
+;; Which functions are actually translation functions is decided by the parser, as it's
+;; configurable.
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (call
+    function: [
+      (identifier)
+      (attribute)
+    ] @Function
+    arguments: (argument_list
+      .
+      (string) @Key))
+)
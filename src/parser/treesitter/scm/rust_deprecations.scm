@@ -0,0 +1,47 @@
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Deprecated items: #[deprecated], #[deprecated = "…"] and #[deprecated(note = "…")]
+;;
+;; Other attributes and comments can sit between the marker and the item.
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (attribute_item
+    (attribute
+      (identifier) @_attribute)) @Deprecation
+  .
+  [
+    (attribute_item)
+    (line_comment)
+    (block_comment)
+  ]*
+  .
+  [
+    (function_item
+      name: (identifier) @Deprecated)
+    (function_signature_item
+      name: (identifier) @Deprecated)
+    (struct_item
+      name: (type_identifier) @Deprecated)
+    (enum_item
+      name: (type_identifier) @Deprecated)
+    (union_item
+      name: (type_identifier) @Deprecated)
+    (trait_item
+      name: (type_identifier) @Deprecated)
+    (type_item
+      name: (type_identifier) @Deprecated)
+    (const_item
+      name: (identifier) @Deprecated)
+    (static_item
+      name: (identifier) @Deprecated)
+    (mod_item
+      name: (identifier) @Deprecated)
+    (macro_definition
+      name: (identifier) @Deprecated)
+    (field_declaration
+      name: (field_identifier) @Deprecated)
+    (enum_variant
+      name: (identifier) @Deprecated)
+  ]
+  (#eq? @_attribute "deprecated")
+)
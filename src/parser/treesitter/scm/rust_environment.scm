@@ -0,0 +1,34 @@
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Reads: std::env::var("X"), env::var_os("X"), env!("X") and option_env!("X")
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (call_expression
+    function: (scoped_identifier) @_function
+    arguments: (arguments
+      .
+      (string_literal) @Read))
+  (#match? @_function "(^|::)env::(var|var_os)$")
+)
+
+(
+  (macro_invocation
+    macro: (identifier) @_macro
+    (token_tree
+      .
+      (string_literal) @Read))
+  (#match? @_macro "^(env|option_env)$")
+)
+
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Writes: std::env::set_var("X", …) and env::remove_var("X")
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (call_expression
+    function: (scoped_identifier) @_function
+    arguments: (arguments
+      .
+      (string_literal) @Write))
+  (#match? @_function "(^|::)env::(set_var|remove_var)$")
+)
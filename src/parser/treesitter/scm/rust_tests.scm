@@ -0,0 +1,32 @@
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Test modules: #[cfg(test)] mod tests { … }
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (attribute_item
+    (attribute
+      (identifier) @_attribute
+      arguments: (token_tree
+        (identifier) @_argument)))
+  .
+  (mod_item) @Test
+  (#eq? @_attribute "cfg")
+  (#eq? @_argument "test")
+)
+
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Test functions: #[test], #[tokio::test], #[rstest], etc.
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (attribute_item
+    (attribute
+      [
+        (identifier) @_attribute
+        (scoped_identifier
+          name: (identifier) @_attribute)
+      ]))
+  .
+  (function_item) @Test
+  (#match? @_attribute "^(test|rstest|bench)$")
+)
@@ -0,0 +1,45 @@
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Reads: ${X}, ${X:-default} and $X
+;;
+;; Shell variables and environment variables share the same syntax, so only upper case names
+;; (the convention for environment variables) are considered.
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (expansion
+    (variable_name) @Read)
+  (#match? @Read "^[A-Z_][A-Z0-9_]*$")
+)
+
+(
+  (simple_expansion
+    (variable_name) @Read)
+  (#match? @Read "^[A-Z_][A-Z0-9_]*$")
+)
+
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Writes: export X=…, export X and unset X
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(declaration_command
+  "export"
+  [
+    (variable_assignment
+      name: (variable_name) @Write)
+    (variable_name) @Write
+  ])
+
+(unset_command
+  (variable_name) @Write)
+
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Config keys: X=… at the top level of a file, which is how dotenv files (i.e. `.env`) define
+;; each variable, and how scripts commonly set their defaults
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (program
+    (variable_assignment
+      name: (variable_name) @Write))
+  (#match? @Write "^[A-Z_][A-Z0-9_]*$")
+)
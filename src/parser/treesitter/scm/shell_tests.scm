@@ -0,0 +1,4 @@
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Shell test frameworks (i.e. shunit2) don't have any syntax of their own, so test code is only
+;; recognised by the path of the file it's in (i.e. `some_test.sh`).
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
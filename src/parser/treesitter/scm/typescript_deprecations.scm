@@ -0,0 +1,59 @@
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+;; Deprecated declarations: a TSDoc comment containing an @deprecated tag
+;;
+;; This is shared between TypeScript and TSX.
+;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;;
+
+(
+  (comment) @Deprecation
+  .
+  [
+    (function_declaration
+      name: (identifier) @Deprecated)
+    (class_declaration
+      name: (type_identifier) @Deprecated)
+    (abstract_class_declaration
+      name: (type_identifier) @Deprecated)
+    (interface_declaration
+      name: (type_identifier) @Deprecated)
+    (type_alias_declaration
+      name: (type_identifier) @Deprecated)
+    (enum_declaration
+      name: (identifier) @Deprecated)
+    (method_definition
+      name: (property_identifier) @Deprecated)
+    (method_signature
+      name: (property_identifier) @Deprecated)
+    (property_signature
+      name: (property_identifier) @Deprecated)
+    (lexical_declaration
+      (variable_declarator
+        name: (identifier) @Deprecated))
+  ]
+  (#match? @Deprecation "(?s)^/\\*\\*.*@deprecated")
+)
+
+;; Exported declarations (i.e. export function …)
+(
+  (comment) @Deprecation
+  .
+  (export_statement
+    declaration: [
+      (function_declaration
+        name: (identifier) @Deprecated)
+      (class_declaration
+        name: (type_identifier) @Deprecated)
+      (abstract_class_declaration
+        name: (type_identifier) @Deprecated)
+      (interface_declaration
+        name: (type_identifier) @Deprecated)
+      (type_alias_declaration
+        name: (type_identifier) @Deprecated)
+      (enum_declaration
+        name: (identifier) @Deprecated)
+      (lexical_declaration
+        (variable_declarator
+          name: (identifier) @Deprecated))
+    ])
+  (#match? @Deprecation "(?s)^/\\*\\*.*@deprecated")
+)
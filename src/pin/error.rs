@@ -0,0 +1,13 @@
+use thiserror::Error;
+
+/// Errors that can occur when pinning (or bookmarking) symbols, or resolving existing pins.
+#[derive(Error, Debug)]
+pub enum Error {
+    /// The query for reading (or writing) pins was invalid.
+    #[error("Invalid query for pins: {0}")]
+    InvalidQuerySyntax(#[from] sea_query::error::Error),
+
+    /// The query for reading (or writing) pins failed.
+    #[error("Query error for pins: {0}")]
+    QueryFailed(#[from] sqlx::Error),
+}
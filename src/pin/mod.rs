@@ -0,0 +1,551 @@
+//! Pins and bookmarks of key symbols (i.e. entry points, or core types), which persist across
+//! sessions and re-indexing.
+//!
+//! Pins are stored in the index database, but aren't tied to the indexed symbols themselves
+//! (which are replaced whenever a file is re-indexed). Instead, pins are re-attached to symbols by
+//! their identity: the path, container, name and kind of the symbol. When a symbol moves to
+//! another file or container its pin follows it ([`PinStatus::Moved`]), and when it disappears the
+//! pin is flagged ([`PinStatus::Missing`]) until the symbol reappears, or the pin is removed.
+//!
+//! Pinned symbols ([`PinKind::Pin`]) are boosted to the top of every query they match (see
+//! [`crate::resolver::DatabaseBackedResolver`]), while bookmarks ([`PinKind::Bookmark`]) are
+//! only listed.
+
+use std::path::{Path, PathBuf};
+
+use itertools::Itertools;
+use sea_query::{
+    Cond, Expr, ExprTrait, OnConflict, Order, Query, SelectStatement, SqliteQueryBuilder,
+};
+use sea_query_sqlx::SqlxBinder;
+
+use crate::{
+    models::{
+        parsed::SymbolKind,
+        resolved::{Pin, PinKind, PinStatus, ResolvedSymbol},
+    },
+    resolver::{hover, utils},
+};
+
+mod error;
+mod types;
+
+pub use error::Error;
+pub use types::Result;
+
+/// The separator between the names of the containers of a symbol, when they're stored as part of
+/// a pin's identity.
+const CONTAINER_SEPARATOR: &str = "::";
+
+/// A pin, as it's stored in the index.
+#[derive(Debug, sqlx::FromRow)]
+struct PinRecord {
+    id: i64,
+    kind: PinKind,
+    label: Option<String>,
+    group_name: Option<String>,
+    #[sqlx(try_from = "String")]
+    path: PathBuf,
+    container: String,
+    name: String,
+    symbol_kind: SymbolKind,
+    line: i64,
+}
+
+impl PinRecord {
+    /// Convert the record into a pin, attached to a particular symbol.
+    fn into_pin(self, status: PinStatus, symbol: Option<ResolvedSymbol>) -> Pin {
+        Pin {
+            id: self.id,
+            kind: self.kind,
+            label: self.label,
+            group: self.group_name,
+            path: self.path,
+            containers: self
+                .container
+                .split(CONTAINER_SEPARATOR)
+                .filter(|container| !container.is_empty())
+                .map(str::to_string)
+                .collect(),
+            name: self.name,
+            symbol_kind: self.symbol_kind,
+            status,
+            symbol,
+        }
+    }
+}
+
+/// A store of pinned (and bookmarked) symbols, in an existing index.
+#[derive(Debug, Clone)]
+pub struct PinStore {
+    pool: sqlx::Pool<sqlx::Sqlite>,
+}
+
+impl PinStore {
+    /// Initialize a pin store at a given database path, for a set of workspaces.
+    ///
+    /// As with [`crate::resolver::DatabaseBackedResolver::new`], the storage path and workspaces
+    /// should match those provided to the indexer.
+    #[must_use]
+    pub fn new<'a, 'b>(
+        storage_path: &'b Path,
+        workspaces: impl IntoIterator<Item = &'a Path>,
+    ) -> Self {
+        Self {
+            pool: utils::get_connection_pool(storage_path, workspaces),
+        }
+    }
+
+    /// Pin (or bookmark) a symbol, with an optional label and group.
+    ///
+    /// Pinning a symbol which is already pinned (as the same kind of pin) replaces its label and
+    /// group.
+    ///
+    /// Returns the ID of the pin.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the pin couldn't be stored.
+    pub async fn pin(
+        &self,
+        symbol: &ResolvedSymbol,
+        kind: PinKind,
+        label: Option<String>,
+        group: Option<String>,
+    ) -> Result<i64> {
+        let (content, file_symbols) = self.read_file(&symbol.path).await;
+
+        let container = get_container(&content, symbol, &file_symbols);
+
+        let (sql, values) = Query::insert()
+            .into_table("pin")
+            .columns([
+                "kind",
+                "label",
+                "group_name",
+                "path",
+                "container",
+                "name",
+                "symbol_kind",
+                "line",
+                "created_at",
+            ])
+            .values([
+                kind.to_string().into(),
+                label.clone().into(),
+                group.clone().into(),
+                symbol.path.to_string_lossy().to_string().into(),
+                container.into(),
+                symbol.name.clone().into(),
+                symbol.kind.to_string().into(),
+                symbol.start_line.into(),
+                chrono::Utc::now().into(),
+            ])?
+            .on_conflict(
+                OnConflict::columns(["kind", "path", "container", "name", "symbol_kind"])
+                    .value("label", label)
+                    .value("group_name", group)
+                    .value("line", symbol.start_line)
+                    .to_owned(),
+            )
+            .returning(Query::returning().column(("pin", "id")))
+            .build_sqlx(SqliteQueryBuilder);
+
+        Ok(sqlx::query_scalar_with::<_, i64, _>(&sql, values)
+            .fetch_one(&self.pool)
+            .await?)
+    }
+
+    /// Remove a pin (or bookmark).
+    ///
+    /// Returns whether the pin existed.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the pin couldn't be removed.
+    pub async fn unpin(&self, id: i64) -> Result<bool> {
+        let (sql, values) = Query::delete()
+            .from_table("pin")
+            .and_where(Expr::col(("pin", "id")).eq(id))
+            .build_sqlx(SqliteQueryBuilder);
+
+        let result = sqlx::query_with(&sql, values).execute(&self.pool).await?;
+
+        Ok(result.rows_affected() > 0)
+    }
+
+    /// Get every pin, optionally of only one kind or in only one group, in the order they were
+    /// pinned.
+    ///
+    /// Every pin is re-attached to the symbol it identifies, preferring the same file and
+    /// container. Pins for symbols which have moved are updated to follow them, and pins for
+    /// symbols which can't be found are flagged as [`PinStatus::Missing`].
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the pins couldn't be read.
+    pub async fn get_pins(&self, kind: Option<PinKind>, group: Option<&str>) -> Result<Vec<Pin>> {
+        let mut query = select_pins();
+
+        if let Some(kind) = kind {
+            query.and_where(Expr::col(("pin", "kind")).eq(kind.to_string()));
+        }
+
+        if let Some(group) = group {
+            query.and_where(Expr::col(("pin", "group_name")).eq(group));
+        }
+
+        let (sql, values) = query
+            .order_by(("pin", "id"), Order::Asc)
+            .build_sqlx(SqliteQueryBuilder);
+
+        let records = sqlx::query_as_with::<_, PinRecord, _>(&sql, values)
+            .fetch_all(&self.pool)
+            .await?;
+
+        let mut pins = Vec::with_capacity(records.len());
+
+        for record in records {
+            pins.push(self.reattach(record).await?);
+        }
+
+        Ok(pins)
+    }
+
+    /// Get the name of every group which has pins in it, in alphabetical order.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the pins couldn't be read.
+    pub async fn get_groups(&self) -> Result<Vec<String>> {
+        let (sql, values) = Query::select()
+            .distinct()
+            .column(("pin", "group_name"))
+            .from("pin")
+            .and_where(Expr::col(("pin", "group_name")).is_not_null())
+            .order_by(("pin", "group_name"), Order::Asc)
+            .build_sqlx(SqliteQueryBuilder);
+
+        Ok(sqlx::query_scalar_with::<_, String, _>(&sql, values)
+            .fetch_all(&self.pool)
+            .await?)
+    }
+
+    /// Re-attach a pin to the symbol it identifies, updating the pin if the symbol has moved.
+    ///
+    /// Symbols with the same name and kind are candidates when they're in the same file (even if
+    /// their container has changed), or in the same container (even if their file has changed).
+    async fn reattach(&self, mut record: PinRecord) -> Result<Pin> {
+        let (sql, values) = utils::get_symbols_by_name_sql(&record.name);
+
+        let symbols = sqlx::query_as_with::<_, ResolvedSymbol, _>(&sql, values)
+            .fetch_all(&self.pool)
+            .await?
+            .into_iter()
+            .filter(|symbol| symbol.kind == record.symbol_kind)
+            .into_group_map_by(|symbol| symbol.path.clone());
+
+        let mut candidates = Vec::new();
+
+        for (path, symbols) in symbols {
+            let (content, file_symbols) = self.read_file(&path).await;
+
+            for symbol in symbols {
+                let container = get_container(&content, &symbol, &file_symbols);
+
+                candidates.push((symbol, container));
+            }
+        }
+
+        let best = candidates
+            .iter()
+            .enumerate()
+            .filter(|(_, (symbol, container))| {
+                symbol.path == record.path || *container == record.container
+            })
+            .min_by_key(|(_, (symbol, container))| {
+                (
+                    symbol.path != record.path,
+                    *container != record.container,
+                    utils::get_path_distance(&record.path, &symbol.path),
+                    (symbol.start_line - record.line).abs(),
+                    symbol.path.clone(),
+                )
+            })
+            .map(|(index, _)| index);
+
+        let Some(index) = best else {
+            return Ok(record.into_pin(PinStatus::Missing, None));
+        };
+
+        let (symbol, container) = candidates.swap_remove(index);
+
+        let status = if symbol.path == record.path && container == record.container {
+            PinStatus::Attached
+        } else {
+            PinStatus::Moved
+        };
+
+        if status == PinStatus::Moved || symbol.start_line != record.line {
+            let (sql, values) = Query::update()
+                .table("pin")
+                .values([
+                    ("path", symbol.path.to_string_lossy().to_string().into()),
+                    ("container", container.clone().into()),
+                    ("line", symbol.start_line.into()),
+                ])
+                .and_where(Expr::col(("pin", "id")).eq(record.id))
+                .build_sqlx(SqliteQueryBuilder);
+
+            // The symbol could have moved on top of another pin for the same symbol, in which
+            // case this pin is left where it was, and re-attached again next time
+            if let Err(e) = sqlx::query_with(&sql, values).execute(&self.pool).await {
+                log::warn!(
+                    "Unable to update pin {} after re-attaching it: {e}",
+                    record.id
+                );
+            }
+        }
+
+        record.path.clone_from(&symbol.path);
+        record.container = container;
+        record.line = symbol.start_line;
+
+        Ok(record.into_pin(status, Some(symbol)))
+    }
+
+    /// Read the content of a file, and every symbol indexed in it.
+    ///
+    /// Files which can't be read have no content, so every symbol in them has no containers.
+    async fn read_file(&self, path: &Path) -> (String, Vec<ResolvedSymbol>) {
+        let content = tokio::fs::read_to_string(path).await.unwrap_or_else(|e| {
+            log::debug!("Unable to read {} for pins: {e}", path.display());
+
+            String::new()
+        });
+
+        let (sql, values) = utils::get_symbols_in_files_sql([path]);
+
+        let file_symbols = sqlx::query_as_with::<_, ResolvedSymbol, _>(&sql, values)
+            .fetch_all(&self.pool)
+            .await
+            .unwrap_or_else(|e| {
+                log::error!("Error returned from query listing symbols in a file: {e}");
+
+                Vec::new()
+            });
+
+        (content, file_symbols)
+    }
+}
+
+/// Get a query which selects all of the columns needed for a pin.
+fn select_pins() -> SelectStatement {
+    Query::select()
+        .columns([
+            ("pin", "id"),
+            ("pin", "kind"),
+            ("pin", "label"),
+            ("pin", "group_name"),
+            ("pin", "path"),
+            ("pin", "container"),
+            ("pin", "name"),
+            ("pin", "symbol_kind"),
+            ("pin", "line"),
+        ])
+        .from("pin")
+        .take()
+}
+
+/// Get the containers of a symbol (see [`hover::get_containers`]), in the form they're stored as
+/// part of a pin's identity.
+fn get_container(
+    content: &str,
+    symbol: &ResolvedSymbol,
+    file_symbols: &[ResolvedSymbol],
+) -> String {
+    let lines = content.lines().collect::<Vec<_>>();
+
+    hover::get_containers(&lines, symbol, file_symbols).join(CONTAINER_SEPARATOR)
+}
+
+/// Get the IDs of the indexed symbols which have been pinned ([`PinKind::Pin`]), so that they can
+/// be boosted when scoring.
+///
+/// To keep queries fast, pins are matched by the path, name and kind of the symbol (preferring the
+/// symbol nearest the line it was last seen on), without reading any files. Pins for symbols which
+/// have moved to another file are matched once they've been re-attached by
+/// [`PinStore::get_pins`].
+pub(crate) async fn get_pinned_symbol_ids(pool: &sqlx::Pool<sqlx::Sqlite>) -> Vec<i64> {
+    let (sql, values) = Query::select()
+        .columns([
+            ("pin", "id"),
+            ("pin", "line"),
+            ("symbol", "id"),
+            ("symbol", "start_line"),
+        ])
+        .from("pin")
+        .join(
+            sea_query::JoinType::InnerJoin,
+            "file",
+            Expr::col(("file", "path")).equals(("pin", "path")),
+        )
+        .join(
+            sea_query::JoinType::InnerJoin,
+            "symbol",
+            Cond::all()
+                .add(Expr::col(("symbol", "file_id")).equals(("file", "id")))
+                .add(Expr::col(("symbol", "name")).equals(("pin", "name")))
+                .add(Expr::col(("symbol", "kind")).equals(("pin", "symbol_kind"))),
+        )
+        .and_where(Expr::col(("pin", "kind")).eq(PinKind::Pin.to_string()))
+        .build_sqlx(SqliteQueryBuilder);
+
+    sqlx::query_as_with::<_, (i64, i64, i64, i64), _>(&sql, values)
+        .fetch_all(pool)
+        .await
+        .unwrap_or_else(|e| {
+            log::error!("Error returned from query listing pinned symbols: {e}");
+
+            Vec::new()
+        })
+        .into_iter()
+        .into_group_map_by(|(pin_id, ..)| *pin_id)
+        .into_values()
+        .filter_map(|candidates| {
+            candidates
+                .into_iter()
+                .min_by_key(|(_, line, _, start_line)| (start_line - line).abs())
+                .map(|(_, _, symbol_id, _)| symbol_id)
+        })
+        .collect()
+}
+
+#[cfg(test)]
+mod tests {
+    use tempfile::tempdir;
+    use tokio::fs;
+
+    use crate::{
+        indexer::{self, Indexer},
+        models::resolved::{PinKind, PinStatus},
+        resolver::DatabaseBackedResolver,
+    };
+
+    #[tokio::test]
+    pub async fn test_pins_survive_reindexing() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        let user = workspace.path().join("user.ts");
+        let service = workspace.path().join("service.ts");
+
+        fs::write(&user, "class UserService {\n  getUser() {}\n}\n")
+            .await
+            .expect("Should never fail to write a file into the workspace");
+
+        let workspaces = vec![workspace.path()];
+
+        let indexer = indexer::DatabaseBackedIndexer::new(storage_path.path(), workspaces.clone())
+            .await
+            .expect("Should be able to create the empty index");
+
+        assert!(indexer.index_workspaces().await.is_ok());
+
+        let resolver = DatabaseBackedResolver::new(storage_path.path(), workspaces.clone());
+        let store = super::PinStore::new(storage_path.path(), workspaces);
+
+        let symbol = resolver
+            .get_symbols_in_file(&user)
+            .await
+            .into_iter()
+            .find(|symbol| symbol.name == "getUser")
+            .expect("Method should have been indexed");
+
+        let id = store
+            .pin(
+                &symbol,
+                PinKind::Pin,
+                Some("Entry point".to_string()),
+                Some("users".to_string()),
+            )
+            .await
+            .expect("Symbol should be pinned");
+
+        // Moving the symbol within its file keeps the pin attached
+        fs::write(
+            &user,
+            "// Users\n\nclass UserService {\n  getUser() {}\n}\n",
+        )
+        .await
+        .expect("Should never fail to write a file into the workspace");
+
+        assert!(indexer.index(&user).await.is_ok());
+
+        let pins = store
+            .get_pins(None, Some("users"))
+            .await
+            .expect("Pins should be listed");
+
+        assert_eq!(1, pins.len());
+        assert_eq!(id, pins[0].id);
+        assert_eq!(PinStatus::Attached, pins[0].status);
+        assert_eq!(vec!["UserService"], pins[0].containers);
+        assert_eq!(Some("Entry point"), pins[0].label.as_deref());
+        assert_eq!(
+            Some(4),
+            pins[0].symbol.as_ref().map(|symbol| symbol.start_line)
+        );
+
+        // Moving the symbol to another file re-attaches the pin there
+        fs::remove_file(&user)
+            .await
+            .expect("Should never fail to remove a file from the workspace");
+        fs::write(&service, "class UserService {\n  getUser() {}\n}\n")
+            .await
+            .expect("Should never fail to write a file into the workspace");
+
+        assert!(indexer.deindex(&user).await.is_ok());
+        assert!(indexer.index(&service).await.is_ok());
+
+        let pins = store
+            .get_pins(Some(PinKind::Pin), None)
+            .await
+            .expect("Pins should be listed");
+
+        assert_eq!(PinStatus::Moved, pins[0].status);
+        assert_eq!(service, pins[0].path);
+
+        // Removing the symbol flags the pin
+        fs::write(&service, "class UserService {\n  findUser() {}\n}\n")
+            .await
+            .expect("Should never fail to write a file into the workspace");
+
+        assert!(indexer.index(&service).await.is_ok());
+
+        let pins = store
+            .get_pins(None, None)
+            .await
+            .expect("Pins should be listed");
+
+        assert_eq!(PinStatus::Missing, pins[0].status);
+        assert!(pins[0].symbol.is_none());
+
+        assert_eq!(
+            vec!["users"],
+            store.get_groups().await.expect("Groups should be listed")
+        );
+
+        assert!(store.unpin(id).await.expect("Pin should be removed"));
+        assert!(
+            store
+                .get_pins(None, None)
+                .await
+                .expect("Pins should be listed")
+                .is_empty()
+        );
+    }
+}
@@ -0,0 +1,5 @@
+use crate::pin;
+
+#[allow(missing_docs)]
+#[doc(hidden)]
+pub type Result<T> = std::result::Result<T, pin::Error>;
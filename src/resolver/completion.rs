@@ -0,0 +1,372 @@
+use std::{collections::HashMap, path::Path, time::Duration};
+
+use itertools::Itertools;
+use tokio_stream::StreamExt;
+
+use crate::{
+    models::{
+        parsed::{Language, SymbolKind},
+        resolved::{CompletionItem, CompletionList, Position, ResolvedSymbol, TextRange},
+    },
+    resolver::{Context, constant, scoring, utils, weight},
+};
+
+/// Get the prefix being typed at a position in some content (i.e. `getU` in `getU|`), alongside
+/// its range.
+///
+/// Unlike [`utils::get_identifier_at`], only the part of the identifier _before_ the position is
+/// included.
+fn get_prefix_at(content: &str, position: Position) -> Option<(String, TextRange)> {
+    let (identifier, range) = utils::get_identifier_at(content, position)?;
+
+    let length = usize::try_from(position.character.checked_sub(range.start.character)?).ok()?;
+
+    let prefix =
+        String::from_utf16_lossy(&identifier.encode_utf16().take(length).collect::<Vec<_>>());
+
+    (!prefix.is_empty()).then(|| {
+        (
+            prefix,
+            TextRange {
+                start: range.start,
+                end: position,
+            },
+        )
+    })
+}
+
+/// Get a human-readable description of a completion candidate (i.e. its signature, and the file
+/// it's defined in).
+fn get_detail(symbol: &ResolvedSymbol) -> Option<String> {
+    let filename = symbol.path.file_name()?.to_string_lossy();
+
+    Some(match &symbol.signature {
+        Some(signature) => format!("{signature} · {filename}"),
+        None => filename.to_string(),
+    })
+}
+
+/// Completion resolver, which offers every symbol in the workspace as a candidate for completing
+/// the identifier being typed.
+///
+/// This is intended as a fallback completion source for languages (or files) without a language
+/// server, rather than a replacement for one.
+#[derive(Debug, Clone)]
+pub struct CompletionResolver {
+    pool: sqlx::Pool<sqlx::Sqlite>,
+}
+
+impl CompletionResolver {
+    /// Initialize a completion resolver at a given database path, for a set of workspaces.
+    ///
+    /// As with [`crate::resolver::DatabaseBackedResolver::new`], the storage path and workspaces
+    /// should match those provided to the indexer.
+    #[must_use]
+    pub fn new<'a, 'b>(
+        storage_path: &'b Path,
+        workspaces: impl IntoIterator<Item = &'a Path>,
+    ) -> Self {
+        Self {
+            pool: utils::get_connection_pool(storage_path, workspaces),
+        }
+    }
+
+    /// Initialize a completion resolver from an existing connection pool, usually one shared with
+    /// another resolver (see [`crate::lsp::Proxy`]).
+    pub(crate) const fn from_pool(pool: sqlx::Pool<sqlx::Sqlite>) -> Self {
+        Self { pool }
+    }
+
+    /// Get the candidates for completing the prefix at a position in a file, given the file's
+    /// current content (which, while the file is being edited, is usually ahead of the file on
+    /// disk).
+    ///
+    /// Candidates are symbols whose name starts with the prefix (case-insensitively), filtered by
+    /// the context in the same way as [`crate::resolver::Resolver::query`]. They're scored in the
+    /// same way as query results, with a bonus for symbols in the same language as the file,
+    /// and for symbols in modules the file already imports.
+    ///
+    /// Completions have a strict latency budget
+    /// ([`constant::COMPLETION_TIMEOUT_MILLIS`]), after which the candidates resolved so far are
+    /// returned as an incomplete list.
+    pub async fn get_completions(
+        &self,
+        path: &Path,
+        content: &str,
+        position: Position,
+        ctx: &Context,
+    ) -> CompletionList {
+        let deadline = tokio::time::Instant::now()
+            + Duration::from_millis(constant::COMPLETION_TIMEOUT_MILLIS);
+
+        let Some((prefix, range)) = get_prefix_at(content, position) else {
+            return CompletionList {
+                is_incomplete: false,
+                range: TextRange {
+                    start: position,
+                    end: position,
+                },
+                items: Vec::new(),
+            };
+        };
+
+        let (sql, values) = utils::get_file_package_sql(path);
+
+        let current_package = sqlx::query_scalar_with::<_, Option<String>, _>(&sql, values)
+            .fetch_optional(&self.pool)
+            .await
+            .unwrap_or_else(|e| {
+                log::error!("Error returned from query finding current package: {e}");
+
+                None
+            })
+            .flatten();
+
+        let scoring_ctx = scoring::ScoringContext {
+            current_file: Some(path),
+            current_package: current_package.as_deref(),
+            current_owners: ctx.current_owners.as_deref().unwrap_or_default(),
+            deprecated_symbol_penalty: *ctx.deprecated_symbol_penalty,
+            ..Default::default()
+        };
+
+        let language = Language::try_from(path).ok();
+        let import_lines = content
+            .lines()
+            .filter(|line| utils::is_import_line(line))
+            .collect::<Vec<_>>();
+
+        let config = scoring::get_fuzzy_config(&prefix);
+
+        let (sql, values) = utils::get_completion_candidates_sql(&prefix, ctx);
+
+        let mut results =
+            sqlx::query_as_with::<_, ResolvedSymbol, _>(&sql, values).fetch(&self.pool);
+
+        // The best scoring symbol for each name, as completions only ever insert the name
+        let mut candidates: HashMap<String, ResolvedSymbol> = HashMap::new();
+        let mut count: u64 = 0;
+        let mut is_incomplete = false;
+
+        loop {
+            let result = match tokio::time::timeout_at(deadline, results.next()).await {
+                Ok(Some(result)) => result,
+                Ok(None) => break,
+                Err(_) => {
+                    log::warn!(
+                        "Completion for \"{prefix}\" ran out of time after {count} candidates, returning an incomplete list."
+                    );
+
+                    is_incomplete = true;
+
+                    break;
+                }
+            };
+
+            let mut symbol = match result {
+                Ok(symbol) => symbol,
+                Err(e) => {
+                    log::error!("Error returned from query listing completion candidates: {e}");
+
+                    continue;
+                }
+            };
+
+            count += 1;
+
+            // Parameters can only be used inside the function which defines them
+            if matches!(
+                symbol.kind,
+                SymbolKind::Parameter | SymbolKind::SelfParameter | SymbolKind::ThisParameter
+            ) && symbol.path != path
+            {
+                continue;
+            }
+
+            if let Some(pattern) = &*ctx.signature
+                && !symbol
+                    .signature
+                    .as_deref()
+                    .is_some_and(|signature| pattern.matches(signature))
+            {
+                continue;
+            }
+
+            let fuzzy_matches = scoring::fuzzy_match(&prefix, &symbol, &config);
+
+            let same_language_bonus = if Some(symbol.language) == language {
+                weight::SAME_LANGUAGE_COMPLETION_SCORE_BONUS
+            } else {
+                0
+            };
+
+            let imported_file_bonus = if symbol.path != path
+                && utils::get_module_name(&symbol.path).is_some_and(|module_name| {
+                    import_lines
+                        .iter()
+                        .any(|line| utils::contains_identifier(line, module_name))
+                }) {
+                weight::IMPORTED_FILE_COMPLETION_SCORE_BONUS
+            } else {
+                0
+            };
+
+            symbol.score =
+                scoring::calculate_score(&prefix, &symbol, fuzzy_matches.iter(), &scoring_ctx)
+                    .saturating_add(same_language_bonus)
+                    .saturating_add(imported_file_bonus)
+                    .into();
+
+            if candidates
+                .get(&symbol.name)
+                .is_none_or(|candidate| candidate.score < symbol.score)
+            {
+                candidates.insert(symbol.name.clone(), symbol);
+            }
+        }
+
+        if count >= constant::MAX_COMPLETION_CANDIDATES
+            || candidates.len() > constant::MAX_COMPLETION_ITEMS
+        {
+            is_incomplete = true;
+        }
+
+        let items = candidates
+            .into_values()
+            .sorted_by(|a, b| b.score.cmp(&a.score).then_with(|| a.name.cmp(&b.name)))
+            .take(constant::MAX_COMPLETION_ITEMS)
+            .map(|symbol| CompletionItem {
+                label: symbol.name.clone(),
+                kind: symbol.kind,
+                detail: get_detail(&symbol),
+                symbol,
+            })
+            .collect();
+
+        CompletionList {
+            is_incomplete,
+            range,
+            items,
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use rstest::rstest;
+    use tempfile::tempdir;
+    use tokio::fs;
+
+    use crate::{
+        indexer::{self, Indexer},
+        models::resolved::Position,
+        resolver::Context,
+    };
+
+    #[rstest]
+    #[case("getU", 4, Some(("getU", 0, 4)))]
+    #[case("getUser", 4, Some(("getU", 0, 4)))]
+    #[case("  user.fetc", 11, Some(("fetc", 7, 11)))]
+    #[case("getUser(", 8, None)]
+    pub fn test_getting_prefix_at_position(
+        #[case] line: &str,
+        #[case] character: u32,
+        #[case] expected: Option<(&str, u32, u32)>,
+    ) {
+        let prefix = super::get_prefix_at(line, Position { line: 0, character });
+
+        assert_eq!(
+            expected,
+            prefix.as_ref().map(|(prefix, range)| (
+                prefix.as_str(),
+                range.start.character,
+                range.end.character
+            ))
+        );
+    }
+
+    #[tokio::test]
+    pub async fn test_resolving_completions() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        let imported = workspace.path().join("user.lua");
+        let unrelated = workspace.path().join("other.py");
+        let current = workspace.path().join("app.lua");
+
+        fs::write(&imported, "function get_user_by_id(id) end\n")
+            .await
+            .expect("Should never fail to write a file into the workspace");
+        fs::write(&unrelated, "def get_user_name():\n    pass\n")
+            .await
+            .expect("Should never fail to write a file into the workspace");
+        fs::write(&current, "local user = require(\"user\")\n")
+            .await
+            .expect("Should never fail to write a file into the workspace");
+
+        let workspaces = vec![workspace.path()];
+
+        let indexer = indexer::DatabaseBackedIndexer::new(storage_path.path(), workspaces.clone())
+            .await
+            .expect("Should be able to create the empty index");
+
+        assert!(indexer.index_workspaces().await.is_ok());
+
+        let completion_resolver = super::CompletionResolver::new(storage_path.path(), workspaces);
+
+        // The file is being edited, so its content is ahead of the file on disk
+        let content = "local user = require(\"user\")\n\nprint(get_us)\n";
+
+        let completions = completion_resolver
+            .get_completions(
+                &current,
+                content,
+                Position {
+                    line: 2,
+                    character: 12,
+                },
+                &Context::default(),
+            )
+            .await;
+
+        assert!(!completions.is_incomplete);
+        assert_eq!(
+            (2, 6),
+            (
+                completions.range.start.line,
+                completions.range.start.character
+            )
+        );
+        assert_eq!(12, completions.range.end.character);
+
+        let labels = completions
+            .items
+            .iter()
+            .map(|item| item.label.as_str())
+            .collect::<Vec<_>>();
+
+        // Symbols in the same language, from an imported module, are preferred
+        assert_eq!(vec!["get_user_by_id", "get_user_name"], labels);
+        assert_eq!(imported, completions.items[0].symbol.path);
+
+        // Positions which aren't after an identifier never have any completions
+        assert!(
+            completion_resolver
+                .get_completions(
+                    &current,
+                    content,
+                    Position {
+                        line: 1,
+                        character: 0,
+                    },
+                    &Context::default(),
+                )
+                .await
+                .items
+                .is_empty()
+        );
+    }
+}
@@ -18,3 +18,53 @@ pub const RESOLVER_SEND_TIMEOUT_SECS: u64 = 2;
 /// The minimum length a query must be for the clear intent scoring will be
 /// applied: [`scoring::calculate_clear_intent_bonus`]
 pub const MIN_CLEAR_INTENT_QUERY_LENGTH: u8 = 3;
+
+/// The minimum length a (normalised) symbol name must be before symbols referencing it by name
+/// are considered related: [`relation::RelationResolver::get_related_symbols`]
+pub const MIN_RELATED_SYMBOL_NAME_LENGTH: usize = 3;
+
+/// The number of milliseconds a completion request can take, before the candidates resolved so
+/// far are returned (as an incomplete list): [`completion::CompletionResolver::get_completions`]
+pub const COMPLETION_TIMEOUT_MILLIS: u64 = 50;
+
+/// The maximum number of symbols considered as candidates for a completion, with the shortest
+/// names (i.e. the closest to the prefix) considered first.
+pub const MAX_COMPLETION_CANDIDATES: u64 = 2000;
+
+/// The maximum number of completions returned for a completion request.
+pub const MAX_COMPLETION_ITEMS: usize = 50;
+
+/// The built-in groups of synonyms and abbreviations which are used interchangeably in code,
+/// used to expand queries when no others are configured: [`synonym::Synonyms::default`]
+///
+/// Ambiguous abbreviations (i.e. `res` for `response` or `result`) are deliberately left out, as
+/// they would broaden queries more than they help.
+pub const DEFAULT_SYNONYMS: &[&[&str]] = &[
+    &["cfg", "conf", "config", "configuration"],
+    &["repo", "repository"],
+    &["ctx", "context"],
+    &["msg", "message"],
+    &["req", "request"],
+    &["resp", "response"],
+    &["err", "error"],
+    &["env", "environment"],
+    &["db", "database"],
+    &["auth", "authentication"],
+    &["auth", "authorization"],
+    &["btn", "button"],
+    &["idx", "index"],
+    &["dir", "directory"],
+    &["pkg", "package"],
+    &["lib", "library"],
+    &["args", "arguments"],
+    &["params", "parameters"],
+    &["prev", "previous"],
+    &["cur", "curr", "current"],
+    &["len", "length"],
+    &["tmp", "temp", "temporary"],
+    &["util", "utils", "utility", "utilities"],
+    &["impl", "implementation"],
+    &["info", "information"],
+    &["src", "source"],
+    &["dst", "dest", "destination"],
+];
@@ -1,18 +1,29 @@
-use std::{path::Path, time::Duration};
+use std::{
+    path::{Path, PathBuf},
+    time::Duration,
+};
 
-use sqlx::sqlite::{SqliteConnectOptions, SqlitePoolOptions};
-use tokio::sync::mpsc::{self, error::SendTimeoutError};
+use itertools::Itertools;
+use tokio::{
+    io::{AsyncWrite, AsyncWriteExt},
+    sync::mpsc::{self, error::SendTimeoutError},
+};
 use tokio_stream::StreamExt;
 use tokio_stream::wrappers::ReceiverStream;
 
 use crate::{
-    models::resolved::ResolvedSymbol,
+    dump,
+    models::{
+        parsed::Language,
+        resolved::{ResolvedSymbol, SchemaDefinition, Translation},
+    },
+    pin,
     resolver::{
-        Context, Resolver, constant,
-        scoring::{self, fuzzy_match},
+        Context, NameMatcher, Resolver, SymbolQuery, Synonyms, constant,
+        query::SourceCache,
+        scoring,
         utils::{self},
     },
-    utils::get_database_path,
 };
 
 /// Resolver is a wrapper around an existing index, which allows for querying
@@ -20,6 +31,7 @@ use crate::{
 #[derive(Debug, Clone)]
 pub struct DatabaseBackedResolver {
     pool: sqlx::Pool<sqlx::Sqlite>,
+    workspaces: Vec<PathBuf>,
 }
 
 impl DatabaseBackedResolver {
@@ -34,82 +46,367 @@ impl DatabaseBackedResolver {
         storage_path: &'b Path,
         workspaces: impl IntoIterator<Item = &'a Path>,
     ) -> Self {
-        let database_path = get_database_path(storage_path, workspaces);
+        let workspaces = workspaces
+            .into_iter()
+            .map(Path::to_path_buf)
+            .collect::<Vec<_>>();
 
-        if let Err(e) = std::fs::create_dir_all(storage_path) {
-            log::error!(
-                "Failed to create storage directory for resolver at {}: {e:?}",
-                storage_path.display()
-            );
-        }
+        let pool =
+            utils::get_connection_pool(storage_path, workspaces.iter().map(PathBuf::as_path));
 
-        log::info!(
-            "Initializing database for resolver at path: {:?}",
-            &database_path
-        );
+        Self { pool, workspaces }
+    }
 
-        let options = SqliteConnectOptions::new()
-            .create_if_missing(false)
-            .filename(database_path)
-            .journal_mode(sqlx::sqlite::SqliteJournalMode::Wal)
-            .synchronous(sqlx::sqlite::SqliteSynchronous::Normal);
+    /// Initialize a resolver from an existing connection pool, usually one shared with an
+    /// indexer (see [`crate::engine::Onoma`]).
+    ///
+    /// The database behind the pool must already be migrated.
+    pub(crate) const fn from_pool(
+        pool: sqlx::Pool<sqlx::Sqlite>,
+        workspaces: Vec<PathBuf>,
+    ) -> Self {
+        Self { pool, workspaces }
+    }
 
-        let pool = SqlitePoolOptions::new().connect_lazy_with(options);
+    /// Get the connection pool of the resolver, which other resolvers for the same index can
+    /// share.
+    pub(crate) const fn get_pool(&self) -> &sqlx::Pool<sqlx::Sqlite> {
+        &self.pool
+    }
+
+    /// Get the path of every indexed file (including ephemeral files), in order.
+    pub async fn get_indexed_files(&self) -> Vec<PathBuf> {
+        let (sql, values) = utils::get_indexed_files_sql();
+
+        let mut files = sqlx::query_as_with::<_, (String, Option<String>, bool), _>(&sql, values)
+            .fetch_all(&self.pool)
+            .await
+            .unwrap_or_else(|e| {
+                log::error!("Error returned from query listing indexed files: {e}");
+
+                Vec::new()
+            })
+            .into_iter()
+            .map(|(path, _, _)| PathBuf::from(path))
+            .collect::<Vec<_>>();
 
-        Self { pool }
+        files.sort_unstable();
+
+        files
     }
-}
 
-impl Resolver for DatabaseBackedResolver {
-    type QueryContext = Context;
+    /// Get every symbol defined in a file, in the order they're defined.
+    ///
+    /// Unlike [`Resolver::query`], the symbols are not scored, and the file must already be
+    /// indexed.
+    pub async fn get_symbols_in_file(&self, path: &Path) -> Vec<ResolvedSymbol> {
+        let (sql, values) = utils::get_symbols_in_files_sql([path]);
 
-    type QueryResult = ReceiverStream<ResolvedSymbol>;
+        let mut symbols = sqlx::query_as_with::<_, ResolvedSymbol, _>(&sql, values)
+            .fetch_all(&self.pool)
+            .await
+            .unwrap_or_else(|e| {
+                log::error!("Error returned from query listing symbols in file: {e}");
 
-    /// Run a query against the indexed Symbols.
+                Vec::new()
+            });
+
+        symbols.sort_unstable_by_key(|symbol| (symbol.start_line, symbol.start_column));
+
+        symbols
+    }
+
+    /// Get the candidate definitions of an identifier (i.e. every symbol with exactly the given
+    /// name), from the most to the least likely.
     ///
-    /// The query will immediately yield a stream, consisting of resolved symbols
-    /// streamed from the index just-in-time.
+    /// Imports of the identifier are never candidates. When a current file is given, candidates
+    /// in the same file, then the same language, then the nearest directories, are preferred.
+    pub async fn get_definitions(
+        &self,
+        name: &str,
+        current_file: Option<&Path>,
+    ) -> Vec<ResolvedSymbol> {
+        let (sql, values) = utils::get_symbols_by_name_sql(name);
+
+        let symbols = sqlx::query_as_with::<_, ResolvedSymbol, _>(&sql, values)
+            .fetch_all(&self.pool)
+            .await
+            .unwrap_or_else(|e| {
+                log::error!("Error returned from query listing symbols by name: {e}");
+
+                Vec::new()
+            });
+
+        let mut definitions = Vec::with_capacity(symbols.len());
+
+        for symbol in symbols {
+            if !utils::is_import(&symbol).await {
+                definitions.push(symbol);
+            }
+        }
+
+        let current_language = current_file.and_then(|path| Language::try_from(path).ok());
+
+        definitions.sort_by_cached_key(|symbol| {
+            (
+                current_file.is_some_and(|path| symbol.path != path),
+                current_language.is_some_and(|language| symbol.language != language),
+                current_file.map_or(0, |path| utils::get_path_distance(path, &symbol.path)),
+                symbol.path.clone(),
+                symbol.start_line,
+            )
+        });
+
+        definitions
+    }
+
+    /// Write a dump of the full index as JSON Lines (see [`crate::dump`]).
     ///
-    /// The stream can be dropped at any time, and the resolver will safely cancel
-    /// and shut down the query, even if not all symbols have been returned.
-    fn query(&self, query: String, ctx: Self::QueryContext) -> Self::QueryResult {
+    /// Records are always written in the same order (files by path, and everything in a file by
+    /// position), so that dumps of the same index can be diffed. For the same reason, the IDs of
+    /// symbols (which are only unique to a single database) are always written as `0`.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the index couldn't be read, or the dump couldn't be written.
+    pub async fn dump(
+        &self,
+        mut writer: impl AsyncWrite + Unpin,
+    ) -> dump::Result<dump::DumpSummary> {
+        let (sql, values) = utils::get_dumped_files_sql();
+
+        let mut files = sqlx::query_as_with::<_, dump::File, _>(&sql, values)
+            .fetch_all(&self.pool)
+            .await?;
+
+        files.sort_unstable_by(|a, b| a.path.cmp(&b.path));
+
+        let (sql, values) = utils::get_all_symbols_sql();
+
+        let mut symbols = sqlx::query_as_with::<_, ResolvedSymbol, _>(&sql, values)
+            .fetch_all(&self.pool)
+            .await?
+            .into_iter()
+            .into_group_map_by(|symbol| symbol.path.clone());
+
+        let (sql, values) = utils::get_translations_sql(None);
+
+        let mut translations = sqlx::query_as_with::<_, Translation, _>(&sql, values)
+            .fetch_all(&self.pool)
+            .await?
+            .into_iter()
+            .into_group_map_by(|translation| translation.path.clone());
+
+        let (sql, values) = utils::get_all_schema_definitions_sql();
+
+        let mut schema_definitions = sqlx::query_as_with::<_, SchemaDefinition, _>(&sql, values)
+            .fetch_all(&self.pool)
+            .await?
+            .into_iter()
+            .into_group_map_by(|definition| definition.path.clone());
+
+        let mut summary = dump::DumpSummary::default();
+        let mut line = 1;
+
+        dump::write_record(
+            &mut writer,
+            line,
+            &dump::Record::Header {
+                version: dump::VERSION,
+            },
+        )
+        .await?;
+
+        for file in files {
+            let mut file_symbols = symbols.remove(&file.path).unwrap_or_default();
+
+            file_symbols.sort_by(|a, b| {
+                (
+                    a.start_line,
+                    a.start_column,
+                    a.end_line,
+                    a.end_column,
+                    &a.name,
+                    a.kind,
+                )
+                    .cmp(&(
+                        b.start_line,
+                        b.start_column,
+                        b.end_line,
+                        b.end_column,
+                        &b.name,
+                        b.kind,
+                    ))
+            });
+
+            let mut file_translations = translations.remove(&file.path).unwrap_or_default();
+
+            file_translations.sort_by(|a, b| {
+                (a.start_line, a.start_column, &a.key).cmp(&(b.start_line, b.start_column, &b.key))
+            });
+
+            let mut file_schema_definitions =
+                schema_definitions.remove(&file.path).unwrap_or_default();
+
+            file_schema_definitions.sort_by(|a, b| {
+                (a.start_line, a.start_column, &a.name).cmp(&(
+                    b.start_line,
+                    b.start_column,
+                    &b.name,
+                ))
+            });
+
+            let records = std::iter::once(dump::Record::File(file))
+                .chain(file_symbols.into_iter().map(|mut symbol| {
+                    symbol.id = 0;
+
+                    dump::Record::Symbol(symbol)
+                }))
+                .chain(file_translations.into_iter().map(dump::Record::Translation))
+                .chain(
+                    file_schema_definitions
+                        .into_iter()
+                        .map(dump::Record::SchemaDefinition),
+                );
+
+            for record in records {
+                line += 1;
+
+                dump::write_record(&mut writer, line, &record).await?;
+
+                summary.add(&record);
+            }
+        }
+
+        writer.flush().await.map_err(dump::Error::WriteFailed)?;
+
+        Ok(summary)
+    }
+
+    /// Run a structured query (see [`SymbolQuery`]) against the indexed symbols, in the same way
+    /// as [`Resolver::query`].
+    ///
+    /// Queries without a sort order stream symbols just-in-time, whereas sorted queries return
+    /// symbols once every symbol has been matched (unless sorting can be done by SQLite).
+    pub fn search(&self, query: SymbolQuery, ctx: Context) -> ReceiverStream<ResolvedSymbol> {
         let (tx, rx) = mpsc::channel::<ResolvedSymbol>(100);
 
         let pool = self.pool.clone();
 
+        // Paths in the query must match the paths of files as they're stored in the index
+        let query = query.normalise_paths(&self.workspaces);
+
         tokio::spawn(async move {
             log::info!(
-                "Executing query: \"{query}\" (from current file: {:?})",
+                "Executing query: {query:?} (from current file: {:?})",
                 ctx.current_file
             );
 
-            let (sql, values) = utils::get_resolver_query_sql(&ctx);
+            let current_package = match ctx.current_file.as_deref() {
+                Some(current_file) => {
+                    let (sql, values) = utils::get_file_package_sql(current_file);
+
+                    sqlx::query_scalar_with::<_, Option<String>, _>(&sql, values)
+                        .fetch_optional(&pool)
+                        .await
+                        .unwrap_or_else(|e| {
+                            log::error!("Error returned from query finding current package: {e}");
+
+                            None
+                        })
+                        .flatten()
+                }
+                None => None,
+            };
+
+            let pinned_symbols = pin::get_pinned_symbol_ids(&pool).await;
+
+            let scoring_ctx = scoring::ScoringContext {
+                current_file: ctx.current_file.as_deref(),
+                current_package: current_package.as_deref(),
+                current_owners: ctx.current_owners.as_deref().unwrap_or_default(),
+                deprecated_symbol_penalty: *ctx.deprecated_symbol_penalty,
+                pinned_symbols: &pinned_symbols,
+                recency: ctx
+                    .recency_half_life
+                    .map(|half_life| (chrono::Utc::now(), half_life)),
+            };
+
+            let (sql, values) = utils::get_symbol_query_sql(&query, &ctx);
 
             let mut results =
                 sqlx::query_as_with::<_, ResolvedSymbol, _>(&sql, values).fetch(&pool);
 
+            let mut sources = SourceCache::new(&pool);
+            let mut sorted_symbols = Vec::new();
             let mut count = 0;
-            let config = scoring::get_fuzzy_config(&query);
+
+            // Symbols matched by name exactly (or not by name at all) are still scored, but
+            // without fuzzy matching the query, or expanding it
+            let fuzzy_query = query.get_fuzzy_query();
+            let scoring_query = match &query.name {
+                Some(NameMatcher::Fuzzy(name) | NameMatcher::Exact(name)) => name.as_str(),
+                Some(NameMatcher::Regex(_)) | None => "",
+            };
+
+            let config = scoring::get_fuzzy_config(scoring_query);
+
+            // Queries are expanded with the built-in synonyms, unless the context opts out (with
+            // `Synonyms::empty`) or provides synonyms of its own
+            let expansions = fuzzy_query
+                .map(|fuzzy_query| {
+                    ctx.synonyms
+                        .as_ref()
+                        .as_ref()
+                        .map_or_else(
+                            || Synonyms::default().expand(fuzzy_query),
+                            |synonyms| synonyms.expand(fuzzy_query),
+                        )
+                        .into_iter()
+                        .map(|expansion| {
+                            let config = scoring::get_fuzzy_config(&expansion.query);
+
+                            (expansion, config)
+                        })
+                        .collect::<Vec<_>>()
+                })
+                .unwrap_or_default();
 
             while let Some(result) = results.next().await {
                 match result {
                     Ok(mut symbol) => {
-                        let fuzzy_matches = fuzzy_match(&query, &symbol, &config);
+                        if let Some(pattern) = &*ctx.signature
+                            && !symbol
+                                .signature
+                                .as_deref()
+                                .is_some_and(|signature| pattern.matches(signature))
+                        {
+                            // The symbol's signature didn't match the pattern, meaning we can
+                            // stop here.
+                            continue;
+                        }
 
-                        if !query.is_empty() && fuzzy_matches.is_empty() {
-                            // The symbol didn't fuzzy match the query, meaning we can stop here.
+                        if !query.matches(&symbol, &mut sources).await {
+                            // The symbol didn't satisfy the parts of the query which couldn't be
+                            // evaluated in SQL, meaning we can stop here.
                             continue;
                         }
 
-                        symbol.score = scoring::calculate_score(
-                            &query,
-                            &symbol,
-                            fuzzy_matches.iter(),
-                            ctx.current_file.as_deref(),
-                        )
-                        .into();
+                        let Some(score) = scoring::calculate_best_score(
+                            scoring_query,
+                            &config,
+                            &expansions,
+                            &mut symbol,
+                            &scoring_ctx,
+                        ) else {
+                            // The symbol didn't fuzzy match the query (or any of its expansions),
+                            // meaning we can stop here.
+                            continue;
+                        };
 
-                        if *symbol.score < constant::DEFAULT_SCORE {
+                        symbol.score = score.into();
+
+                        if fuzzy_query.is_some() && *symbol.score < constant::DEFAULT_SCORE {
                             // The symbol's score is less than the score it started with. This
                             // indicates that it incurred more penalties than it did bonuses. As
                             // such, it's likely not a good match.
@@ -121,38 +418,24 @@ impl Resolver for DatabaseBackedResolver {
                             continue;
                         }
 
-                        // Maintaining a timeout here allows for channels to naturally be closed
-                        // fairly quickly in times of congestion (when many queries are started
-                        // in quick succession). This is important for sqlx, as it has only a small
-                        // number of open connections in its pool, and needlessly waiting for a
-                        // send to complete here can _easily_ exhaust the available connections, and
-                        // starve newer queries.
-                        if let Err(e) = tx
-                            .send_timeout(
-                                symbol,
-                                Duration::from_secs(constant::RESOLVER_SEND_TIMEOUT_SECS),
-                            )
-                            .await
-                        {
-                            match e {
-                                SendTimeoutError::Closed(_) => {
-                                    log::warn!(
-                                        "Receiving side of the stream is closed (i.e. no longer waiting for additional symbols), stopping task.",
-                                    );
-                                }
-                                SendTimeoutError::Timeout(e) => {
-                                    log::error!(
-                                        "Receiving side of the stream was full and sender timed out before delivering symbol: {e:?}"
-                                    );
-                                }
-                            }
+                        if query.sort.is_some() {
+                            // Sorted symbols can only be returned once every symbol is matched
+                            sorted_symbols.push(symbol);
 
+                            continue;
+                        }
+
+                        if !send(&tx, symbol).await {
                             break;
                         }
 
                         // Symbol returned and the send was successful - we're good to continue
                         // on.
                         count += 1;
+
+                        if query.limit.is_some_and(|limit| count >= limit) {
+                            break;
+                        }
                     }
                     Err(e) => {
                         log::error!("Error returned from query listing matching symbols: {e}",);
@@ -160,6 +443,16 @@ impl Resolver for DatabaseBackedResolver {
                 }
             }
 
+            query.sort(&mut sorted_symbols);
+
+            for symbol in sorted_symbols {
+                if !send(&tx, symbol).await {
+                    break;
+                }
+
+                count += 1;
+            }
+
             log::info!(
                 "Returned {count} symbols (until no other symbols left, or stream no longer open)."
             );
@@ -169,6 +462,57 @@ impl Resolver for DatabaseBackedResolver {
     }
 }
 
+impl Resolver for DatabaseBackedResolver {
+    type QueryContext = Context;
+
+    type QueryResult = ReceiverStream<ResolvedSymbol>;
+
+    /// Run a query against the indexed Symbols.
+    ///
+    /// The query will immediately yield a stream, consisting of resolved symbols
+    /// streamed from the index just-in-time.
+    ///
+    /// The stream can be dropped at any time, and the resolver will safely cancel
+    /// and shut down the query, even if not all symbols have been returned.
+    fn query(&self, query: String, ctx: Self::QueryContext) -> Self::QueryResult {
+        self.search(SymbolQuery::fuzzy(query), ctx)
+    }
+}
+
+/// Send a symbol to the receiving side of a query's stream, returning whether the stream is still
+/// open for more symbols.
+async fn send(tx: &mpsc::Sender<ResolvedSymbol>, symbol: ResolvedSymbol) -> bool {
+    // Maintaining a timeout here allows for channels to naturally be closed
+    // fairly quickly in times of congestion (when many queries are started
+    // in quick succession). This is important for sqlx, as it has only a small
+    // number of open connections in its pool, and needlessly waiting for a
+    // send to complete here can _easily_ exhaust the available connections, and
+    // starve newer queries.
+    match tx
+        .send_timeout(
+            symbol,
+            Duration::from_secs(constant::RESOLVER_SEND_TIMEOUT_SECS),
+        )
+        .await
+    {
+        Ok(()) => true,
+        Err(SendTimeoutError::Closed(_)) => {
+            log::warn!(
+                "Receiving side of the stream is closed (i.e. no longer waiting for additional symbols), stopping task.",
+            );
+
+            false
+        }
+        Err(SendTimeoutError::Timeout(e)) => {
+            log::error!(
+                "Receiving side of the stream was full and sender timed out before delivering symbol: {e:?}"
+            );
+
+            false
+        }
+    }
+}
+
 #[cfg(test)]
 mod tests {
     use std::{collections::HashMap, path::PathBuf};
@@ -183,7 +527,7 @@ mod tests {
             self,
             parsed::{Language, SymbolKind},
         },
-        resolver::{Resolver, SymbolKindFilter},
+        resolver::{Resolver, SignaturePattern, SymbolKindFilter, Synonyms},
     };
 
     #[tokio::test]
@@ -298,4 +642,99 @@ mod tests {
             {"[].id" => 0} // IDs are non-deterministic, so just blank them out
         );
     }
+
+    #[tokio::test]
+    pub async fn test_resolving_symbols_by_signature() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let fixtures = PathBuf::from("tests/fixtures/");
+
+        let workspaces = vec![fixtures.as_path()];
+
+        let indexer = indexer::DatabaseBackedIndexer::new(storage_path.path(), workspaces.clone())
+            .await
+            .expect("Should be able to create the empty index");
+
+        let resolver = super::DatabaseBackedResolver::new(storage_path.path(), workspaces.clone());
+
+        assert!(indexer.index_workspaces().await.is_ok());
+
+        let mut resolved_symbols: Vec<models::resolved::ResolvedSymbol> = resolver
+            .query(
+                String::new(),
+                super::Context::default().with_signature(
+                    SignaturePattern::parse("func(int, int) int")
+                        .expect("Signature pattern should be valid"),
+                ),
+            )
+            .collect()
+            .await;
+
+        resolved_symbols.sort_unstable();
+
+        assert_eq!(
+            vec!["Add", "Multiply"],
+            resolved_symbols
+                .iter()
+                .map(|symbol| symbol.name.as_str())
+                .collect::<Vec<_>>()
+        );
+    }
+
+    #[tokio::test]
+    pub async fn test_expanding_queries_unless_opted_out() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        tokio::fs::write(
+            workspace.path().join("config.ts"),
+            "export const cfg = {};\n",
+        )
+        .await
+        .expect("Should never fail to write config.ts");
+
+        let workspaces = vec![workspace.path()];
+
+        let indexer = indexer::DatabaseBackedIndexer::new(storage_path.path(), workspaces.clone())
+            .await
+            .expect("Should be able to create the empty index");
+
+        let resolver = super::DatabaseBackedResolver::new(storage_path.path(), workspaces.clone());
+
+        assert!(indexer.index_workspaces().await.is_ok());
+
+        // Notice, the query is expanded with the built-in synonyms by default
+        let resolved_symbols: Vec<models::resolved::ResolvedSymbol> = resolver
+            .query(String::from("configuration"), super::Context::default())
+            .collect()
+            .await;
+
+        assert_eq!(
+            vec![("cfg", Some("configuration"))],
+            resolved_symbols
+                .iter()
+                .map(|symbol| (
+                    symbol.name.as_str(),
+                    symbol
+                        .expansion
+                        .as_ref()
+                        .map(|expansion| expansion.term.as_str())
+                ))
+                .collect::<Vec<_>>()
+        );
+
+        let resolved_symbols: Vec<models::resolved::ResolvedSymbol> = resolver
+            .query(
+                String::from("configuration"),
+                super::Context::default().with_synonyms(Synonyms::empty()),
+            )
+            .collect()
+            .await;
+
+        assert!(resolved_symbols.is_empty());
+    }
 }
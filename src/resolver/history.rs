@@ -0,0 +1,248 @@
+use std::{path::Path, time::Duration};
+
+use sea_query::{Expr, ExprTrait, Order, Query, SelectStatement, SqliteQueryBuilder};
+use sea_query_sqlx::SqlxBinder;
+
+use crate::{
+    models::resolved::{SymbolChange, SymbolChangeKind},
+    resolver::utils,
+};
+
+/// History resolver, which answers questions about how the symbols in an existing index have
+/// changed over time (i.e. which symbols were added this week, or when a symbol first appeared),
+/// from the change log recorded as files are re-indexed.
+///
+/// The change log only starts once a workspace has been indexed, so the symbols which were already
+/// in a workspace when it was first indexed have no history. Changes are also pruned from the log
+/// once they're too old (see
+/// [`crate::indexer::DatabaseBackedIndexer::with_change_log_retention`]).
+#[derive(Debug, Clone)]
+pub struct HistoryResolver {
+    pool: sqlx::Pool<sqlx::Sqlite>,
+}
+
+impl HistoryResolver {
+    /// Initialize a history resolver at a given database path, for a set of workspaces.
+    ///
+    /// As with [`crate::resolver::DatabaseBackedResolver::new`], the storage path and workspaces
+    /// should match those provided to the indexer.
+    #[must_use]
+    pub fn new<'a, 'b>(
+        storage_path: &'b Path,
+        workspaces: impl IntoIterator<Item = &'a Path>,
+    ) -> Self {
+        Self {
+            pool: utils::get_connection_pool(storage_path, workspaces),
+        }
+    }
+
+    /// Get the symbols which were added within a period of time (i.e. the last 7 days), from the
+    /// most recently added.
+    ///
+    /// Symbols which were added and then removed again within the period are still included.
+    pub async fn get_added_within(&self, period: Duration) -> Vec<SymbolChange> {
+        let since = chrono::TimeDelta::from_std(period)
+            .ok()
+            .and_then(|period| chrono::Utc::now().checked_sub_signed(period))
+            .unwrap_or(chrono::DateTime::<chrono::Utc>::MIN_UTC);
+
+        let mut query = select_symbol_changes();
+
+        query
+            .and_where(
+                Expr::col(("symbol_change", "change")).eq(SymbolChangeKind::Added.to_string()),
+            )
+            .and_where(Expr::col(("symbol_change", "changed_at")).gte(since))
+            .order_by(("symbol_change", "id"), Order::Desc);
+
+        self.get_symbol_changes(&query).await
+    }
+
+    /// Get the symbols which have been removed from a file (including when the file itself was
+    /// removed), from the most recently removed.
+    pub async fn get_removed_from(&self, path: &Path) -> Vec<SymbolChange> {
+        let mut query = select_symbol_changes();
+
+        query
+            .and_where(
+                Expr::col(("symbol_change", "change")).eq(SymbolChangeKind::Removed.to_string()),
+            )
+            .and_where(Expr::col(("symbol_change", "path")).eq(path.to_string_lossy().to_string()))
+            .order_by(("symbol_change", "id"), Order::Desc);
+
+        self.get_symbol_changes(&query).await
+    }
+
+    /// Get when a symbol (by its exact name) first appeared in any file, if it was added since
+    /// the change log started.
+    pub async fn get_first_appearance(&self, name: &str) -> Option<SymbolChange> {
+        let mut query = select_symbol_changes();
+
+        query
+            .and_where(
+                Expr::col(("symbol_change", "change")).eq(SymbolChangeKind::Added.to_string()),
+            )
+            .and_where(Expr::col(("symbol_change", "name")).eq(name))
+            .order_by(("symbol_change", "id"), Order::Asc)
+            .limit(1);
+
+        self.get_symbol_changes(&query).await.into_iter().next()
+    }
+
+    /// Get every change to a symbol (by its exact name) across every file, in the order they
+    /// happened.
+    pub async fn get_history(&self, name: &str) -> Vec<SymbolChange> {
+        let mut query = select_symbol_changes();
+
+        query
+            .and_where(Expr::col(("symbol_change", "name")).eq(name))
+            .order_by(("symbol_change", "id"), Order::Asc);
+
+        self.get_symbol_changes(&query).await
+    }
+
+    /// Get the changes to symbols selected by a query.
+    async fn get_symbol_changes(&self, query: &SelectStatement) -> Vec<SymbolChange> {
+        let (sql, values) = query.build_sqlx(SqliteQueryBuilder);
+
+        sqlx::query_as_with::<_, SymbolChange, _>(&sql, values)
+            .fetch_all(&self.pool)
+            .await
+            .unwrap_or_else(|e| {
+                log::error!("Error returned from query listing symbol changes: {e}");
+
+                Vec::new()
+            })
+    }
+}
+
+/// Get the query selecting every change to symbols (as [`SymbolChange`]) in the change log.
+fn select_symbol_changes() -> SelectStatement {
+    Query::select()
+        .columns([
+            ("symbol_change", "id"),
+            ("symbol_change", "change"),
+            ("symbol_change", "path"),
+            ("symbol_change", "name"),
+            ("symbol_change", "kind"),
+            ("symbol_change", "language"),
+            ("symbol_change", "line"),
+            ("symbol_change", "commit_hash"),
+            ("symbol_change", "changed_at"),
+        ])
+        .from("symbol_change")
+        .to_owned()
+}
+
+#[cfg(test)]
+mod tests {
+    use std::time::Duration;
+
+    use tempfile::tempdir;
+    use tokio::fs;
+
+    use crate::{
+        indexer::{self, Indexer},
+        models::resolved::SymbolChangeKind,
+    };
+
+    #[tokio::test]
+    pub async fn test_resolving_symbol_changes() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        let user = workspace.path().join("user.ts");
+        let session = workspace.path().join("session.ts");
+
+        fs::write(
+            &user,
+            "export function getUser() {}\nexport function legacyLogin() {}\n",
+        )
+        .await
+        .expect("Should never fail to write user.ts");
+
+        let indexer = indexer::DatabaseBackedIndexer::new(storage_path.path(), [workspace.path()])
+            .await
+            .expect("Indexer should be created successfully");
+
+        indexer
+            .index_workspaces()
+            .await
+            .expect("Workspace should be indexed successfully");
+
+        let resolver = super::HistoryResolver::new(storage_path.path(), [workspace.path()]);
+
+        // Notice, symbols already in the workspace when it's first indexed have no history
+        assert!(
+            resolver
+                .get_added_within(Duration::from_secs(60))
+                .await
+                .is_empty()
+        );
+
+        fs::write(
+            &user,
+            "export function getUser() {}\nexport function login() {}\n",
+        )
+        .await
+        .expect("Should never fail to update user.ts");
+
+        fs::write(&session, "export class Session {}\n")
+            .await
+            .expect("Should never fail to write session.ts");
+
+        indexer
+            .index(&user)
+            .await
+            .expect("user.ts should be re-indexed successfully");
+
+        indexer
+            .index(&session)
+            .await
+            .expect("session.ts should be indexed successfully");
+
+        let added = resolver.get_added_within(Duration::from_secs(60)).await;
+
+        assert_eq!(
+            vec!["Session", "login"],
+            added
+                .iter()
+                .map(|change| change.name.as_str())
+                .collect::<Vec<_>>()
+        );
+
+        let removed = resolver.get_removed_from(&user).await;
+
+        assert_eq!(1, removed.len());
+        assert_eq!("legacyLogin", removed[0].name);
+        assert_eq!(SymbolChangeKind::Removed, removed[0].change);
+
+        let first_appearance = resolver
+            .get_first_appearance("Session")
+            .await
+            .expect("Session should have been added");
+
+        assert_eq!(session, first_appearance.path);
+        assert_eq!(1, first_appearance.line);
+
+        indexer
+            .deindex(&session)
+            .await
+            .expect("session.ts should be de-indexed successfully");
+
+        assert_eq!(
+            vec![SymbolChangeKind::Added, SymbolChangeKind::Removed],
+            resolver
+                .get_history("Session")
+                .await
+                .iter()
+                .map(|change| change.change)
+                .collect::<Vec<_>>()
+        );
+
+        assert!(resolver.get_first_appearance("getUser").await.is_none());
+    }
+}
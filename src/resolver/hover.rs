@@ -0,0 +1,571 @@
+use std::path::Path;
+
+use itertools::Itertools;
+
+use crate::{
+    models::{
+        parsed::{Language, SymbolKind},
+        resolved::{Hover, Position, ResolvedSymbol},
+    },
+    resolver::utils,
+};
+
+/// The markers which start a comment line, in any supported language.
+///
+/// More specific markers come first, as only the first matching marker is stripped.
+const COMMENT_MARKERS: [&str; 12] = [
+    "///", "//!", "//", "/**", "*/", "/*", "---", "--", ";;;", ";;", "#", "*",
+];
+
+/// The markers which start (and end) a Python docstring.
+const DOCSTRING_MARKERS: [&str; 2] = ["\"\"\"", "'''"];
+
+/// Get the language identifier used for fenced code blocks in Markdown.
+const fn get_markdown_language(language: Language) -> &'static str {
+    match language {
+        Language::Go => "go",
+        Language::Rust => "rust",
+        Language::Lua => "lua",
+        Language::TypeScript => "typescript",
+        Language::TypeScriptJsx => "tsx",
+        Language::Javascript => "javascript",
+        Language::JavascriptJsx => "jsx",
+        Language::Clojure => "clojure",
+        Language::Python => "python",
+        Language::Shell => "sh",
+    }
+}
+
+/// Check if a line (without its indentation) is an attribute or decorator (i.e. `#[must_use]` or
+/// `@staticmethod`), which commonly sits between a doc comment and its definition.
+fn is_attribute(line: &str) -> bool {
+    line.starts_with("#[") || line.starts_with("#!") || line.starts_with('@')
+}
+
+/// Check if a line (without its indentation) only closes a block (i.e. `}` or Lua's `end`),
+/// rather than opening one.
+fn is_closing_line(line: &str) -> bool {
+    line.starts_with(['}', ')', ']'])
+        || line.split(|c: char| !utils::is_identifier_char(c)).next() == Some("end")
+}
+
+/// Get the names of the symbols a definition is nested inside, from the outermost to the
+/// innermost.
+///
+/// As only the names of symbols are indexed (not their bodies), nesting is based on the
+/// indentation of the lines above the definition, which holds for the vast majority of
+/// formatted code.
+pub fn get_containers(
+    lines: &[&str],
+    symbol: &ResolvedSymbol,
+    file_symbols: &[ResolvedSymbol],
+) -> Vec<String> {
+    let Some(index) = usize::try_from(symbol.start_line - 1)
+        .ok()
+        .filter(|index| *index < lines.len())
+    else {
+        return Vec::new();
+    };
+
+    let get_indentation = |line: &str| line.len() - line.trim_start().len();
+
+    let mut indentation = get_indentation(lines[index]);
+    let mut containers = Vec::new();
+
+    for (index, line) in lines[..index].iter().enumerate().rev() {
+        if indentation == 0 {
+            break;
+        }
+
+        let trimmed = line.trim_start();
+
+        if trimmed.is_empty() || is_closing_line(trimmed) || get_indentation(line) >= indentation {
+            continue;
+        }
+
+        indentation = get_indentation(line);
+
+        let container = file_symbols
+            .iter()
+            .filter(|container| {
+                usize::try_from(container.start_line).ok() == Some(index + 1)
+                    && !matches!(
+                        container.kind,
+                        SymbolKind::Parameter
+                            | SymbolKind::SelfParameter
+                            | SymbolKind::ThisParameter
+                            | SymbolKind::Variable
+                    )
+            })
+            .min_by_key(|container| container.start_column);
+
+        if let Some(container) = container {
+            containers.push(container.name.clone());
+        }
+    }
+
+    containers.reverse();
+
+    containers
+}
+
+/// Get the doc comment of a definition, without any comment markers.
+///
+/// Doc comments are the comment lines directly above the definition (skipping over any
+/// attributes or decorators), or in Python, the docstring directly below it.
+fn get_documentation(lines: &[&str], symbol: &ResolvedSymbol) -> Option<String> {
+    let index = usize::try_from(symbol.start_line - 1)
+        .ok()
+        .filter(|index| *index < lines.len())?;
+
+    let mut comment = lines[..index]
+        .iter()
+        .rev()
+        .map(|line| line.trim())
+        .skip_while(|line| is_attribute(line))
+        .map_while(|line| {
+            let marker = COMMENT_MARKERS
+                .iter()
+                .find(|marker| line.starts_with(*marker))?;
+
+            Some(line[marker.len()..].trim_end_matches("*/").trim())
+        })
+        .collect::<Vec<_>>();
+
+    comment.reverse();
+
+    if comment.is_empty() && symbol.language == Language::Python {
+        comment = get_docstring(&lines[index..]);
+    }
+
+    let documentation = comment
+        .into_iter()
+        .skip_while(|line| line.is_empty())
+        .join("\n")
+        .trim_end()
+        .to_string();
+
+    (!documentation.is_empty()).then_some(documentation)
+}
+
+/// Get the lines of the docstring of a Python definition, from the lines starting at the
+/// definition.
+fn get_docstring<'a>(lines: &[&'a str]) -> Vec<&'a str> {
+    // Definitions can span multiple lines (i.e. long parameter lists), so the docstring is only
+    // after the line which ends the definition's header
+    let Some(header_end) = lines.iter().position(|line| line.trim_end().ends_with(':')) else {
+        return Vec::new();
+    };
+
+    let Some(first_line) = lines.get(header_end + 1).map(|line| line.trim()) else {
+        return Vec::new();
+    };
+
+    let Some(marker) = DOCSTRING_MARKERS
+        .iter()
+        .find(|marker| first_line.starts_with(*marker))
+    else {
+        return Vec::new();
+    };
+
+    let first_line = &first_line[marker.len()..];
+
+    // Single line docstrings (i.e. `"""Load a user."""`)
+    if let Some(docstring) = first_line.strip_suffix(marker) {
+        return vec![docstring.trim()];
+    }
+
+    let mut docstring = vec![first_line.trim()];
+
+    for line in &lines[header_end + 2..] {
+        let line = line.trim();
+
+        if let Some(end) = line.find(marker) {
+            docstring.push(line[..end].trim());
+
+            break;
+        }
+
+        docstring.push(line);
+    }
+
+    docstring
+}
+
+/// Format the hover information of a definition as Markdown.
+fn get_contents(
+    symbol: &ResolvedSymbol,
+    containers: &[String],
+    documentation: Option<&str>,
+    alternatives: usize,
+) -> String {
+    let mut sections = vec![format!(
+        "```{}\n{}{}\n```",
+        get_markdown_language(symbol.language),
+        symbol.name,
+        symbol.signature.as_deref().unwrap_or_default()
+    )];
+
+    if containers.is_empty() {
+        sections.push(format!("*{}*", symbol.kind));
+    } else {
+        sections.push(format!("*{}* in `{}`", symbol.kind, containers.join(".")));
+    }
+
+    if symbol.deprecated {
+        sections.push(symbol.deprecation_message.as_ref().map_or_else(
+            || String::from("**Deprecated**"),
+            |message| format!("**Deprecated**: {message}"),
+        ));
+    }
+
+    if let Some(documentation) = documentation {
+        sections.push(documentation.to_string());
+    }
+
+    let location = format!(
+        "Defined in `{}:{}:{}`",
+        symbol.path.display(),
+        symbol.start_line,
+        symbol.start_column
+    );
+
+    sections.push(match alternatives {
+        0 => format!("---\n\n{location}"),
+        1 => format!("---\n\n{location} (1 other candidate)"),
+        alternatives => format!("---\n\n{location} ({alternatives} other candidates)"),
+    });
+
+    sections.join("\n\n")
+}
+
+/// Hover resolver, which describes the identifier at a position in a file (i.e. under the
+/// cursor), for editors to show on hover (i.e. `textDocument/hover`).
+///
+/// As with renames, hovers are based on names alone: the identifier under the cursor is matched
+/// against every symbol with the same name, and the most likely definition is described.
+#[derive(Debug, Clone)]
+pub struct HoverResolver {
+    pool: sqlx::Pool<sqlx::Sqlite>,
+}
+
+impl HoverResolver {
+    /// Initialize a hover resolver at a given database path, for a set of workspaces.
+    ///
+    /// As with [`crate::resolver::DatabaseBackedResolver::new`], the storage path and workspaces
+    /// should match those provided to the indexer.
+    #[must_use]
+    pub fn new<'a, 'b>(
+        storage_path: &'b Path,
+        workspaces: impl IntoIterator<Item = &'a Path>,
+    ) -> Self {
+        Self {
+            pool: utils::get_connection_pool(storage_path, workspaces),
+        }
+    }
+
+    /// Initialize a hover resolver from an existing connection pool, usually one shared with
+    /// another resolver (see [`crate::lsp::Proxy`]).
+    pub(crate) const fn from_pool(pool: sqlx::Pool<sqlx::Sqlite>) -> Self {
+        Self { pool }
+    }
+
+    /// Get the hover information for the identifier at a position in a file.
+    ///
+    /// Candidate definitions are ranked by whether they're in the same file (and then by how
+    /// close they are), the same language, a module the file imports, and the same package,
+    /// with generated, test and deprecated definitions last.
+    ///
+    /// Returns [`None`] if there is no identifier at the position, or it has no definitions.
+    pub async fn get_hover(&self, path: &Path, position: Position) -> Option<Hover> {
+        let content = tokio::fs::read_to_string(path)
+            .await
+            .inspect_err(|e| log::debug!("Unable to read {} for hover: {e}", path.display()))
+            .ok()?;
+
+        let (name, range) = utils::get_identifier_at(&content, position)?;
+
+        let (sql, values) = utils::get_symbols_by_name_sql(&name);
+
+        let symbols = sqlx::query_as_with::<_, ResolvedSymbol, _>(&sql, values)
+            .fetch_all(&self.pool)
+            .await
+            .unwrap_or_else(|e| {
+                log::error!("Error returned from query listing symbols with the same name: {e}");
+
+                Vec::new()
+            });
+
+        let mut candidates = Vec::with_capacity(symbols.len());
+
+        for symbol in symbols {
+            if !utils::is_import(&symbol).await {
+                candidates.push(symbol);
+            }
+        }
+
+        let (sql, values) = utils::get_file_package_sql(path);
+
+        let package = sqlx::query_scalar_with::<_, Option<String>, _>(&sql, values)
+            .fetch_optional(&self.pool)
+            .await
+            .unwrap_or_else(|e| {
+                log::error!("Error returned from query finding the package of a file: {e}");
+
+                None
+            })
+            .flatten();
+
+        let language = Language::try_from(path).ok();
+        let line = i64::from(position.line) + 1;
+
+        let alternatives = candidates.len().saturating_sub(1);
+
+        let symbol = candidates.into_iter().min_by_key(|candidate| {
+            let same_file = candidate.path == path;
+
+            (
+                !same_file,
+                if same_file {
+                    (candidate.start_line - line).abs()
+                } else {
+                    0
+                },
+                Some(candidate.language) != language,
+                !utils::get_module_name(&candidate.path)
+                    .is_some_and(|module_name| utils::imports_module(&content, module_name)),
+                package.is_none() || candidate.package != package,
+                candidate.generated,
+                candidate.test,
+                candidate.deprecated,
+                candidate.path.clone(),
+                candidate.start_line,
+            )
+        })?;
+
+        let (containers, documentation) = self.describe_definition(&symbol).await;
+
+        Some(Hover {
+            contents: get_contents(&symbol, &containers, documentation.as_deref(), alternatives),
+            range,
+            symbol,
+            containers,
+            documentation,
+            alternatives,
+        })
+    }
+
+    /// Get the containers and doc comment of a definition, from the file it's defined in.
+    async fn describe_definition(&self, symbol: &ResolvedSymbol) -> (Vec<String>, Option<String>) {
+        let Ok(content) = tokio::fs::read_to_string(&symbol.path).await else {
+            log::debug!("Unable to read {} for hover", symbol.path.display());
+
+            return (Vec::new(), None);
+        };
+
+        let (sql, values) = utils::get_symbols_in_files_sql([symbol.path.as_path()]);
+
+        let file_symbols = sqlx::query_as_with::<_, ResolvedSymbol, _>(&sql, values)
+            .fetch_all(&self.pool)
+            .await
+            .unwrap_or_else(|e| {
+                log::error!("Error returned from query listing symbols in a file: {e}");
+
+                Vec::new()
+            });
+
+        let lines = content.lines().collect::<Vec<_>>();
+
+        (
+            get_containers(&lines, symbol, &file_symbols),
+            get_documentation(&lines, symbol),
+        )
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use rstest::rstest;
+    use tempfile::tempdir;
+    use tokio::fs;
+
+    use crate::{
+        indexer::{self, Indexer},
+        models::{
+            parsed::{Language, SymbolKind},
+            resolved::{Position, ResolvedSymbol},
+        },
+    };
+
+    #[rstest]
+    #[case(
+        "/**\n * Load a user.\n *\n * @param id The ID\n */\n@cached\nfunction getUser(id) {}\n",
+        Language::TypeScript,
+        7,
+        Some("Load a user.\n\n@param id The ID")
+    )]
+    #[case(
+        "/// Parse a file.\n#[must_use]\nfn parse() {}\n",
+        Language::Rust,
+        3,
+        Some("Parse a file.")
+    )]
+    #[case(
+        "-- Load a user\nlocal function get_user() end\n",
+        Language::Lua,
+        2,
+        Some("Load a user")
+    )]
+    #[case(
+        "def get_user(\n    id,\n):\n    \"\"\"\n    Load a user.\n    \"\"\"\n",
+        Language::Python,
+        1,
+        Some("Load a user.")
+    )]
+    #[case(
+        "def get_user():\n    \"\"\"Load a user.\"\"\"\n",
+        Language::Python,
+        1,
+        Some("Load a user.")
+    )]
+    #[case("fn parse() {}\n\n// Unrelated\n", Language::Rust, 1, None)]
+    pub fn test_reading_documentation(
+        #[case] content: &str,
+        #[case] language: Language,
+        #[case] line: i64,
+        #[case] expected_documentation: Option<&str>,
+    ) {
+        let symbol = ResolvedSymbol {
+            language,
+            ..get_symbol("example", SymbolKind::Function, line, 1)
+        };
+
+        let lines = content.lines().collect::<Vec<_>>();
+
+        assert_eq!(
+            expected_documentation.map(str::to_string),
+            super::get_documentation(&lines, &symbol)
+        );
+    }
+
+    #[test]
+    pub fn test_reading_containers() {
+        let content = "mod api {\n    struct Client {}\n\n    impl Client {\n        fn fetch(&self) {}\n    }\n}\n\nfn fetch() {}\n";
+        let lines = content.lines().collect::<Vec<_>>();
+
+        let file_symbols = vec![
+            get_symbol("api", SymbolKind::Module, 1, 5),
+            get_symbol("Client", SymbolKind::Struct, 2, 12),
+            get_symbol("Client", SymbolKind::Type, 4, 10),
+            get_symbol("self", SymbolKind::SelfParameter, 5, 19),
+        ];
+
+        assert_eq!(
+            vec!["api", "Client"],
+            super::get_containers(
+                &lines,
+                &get_symbol("fetch", SymbolKind::Method, 5, 12),
+                &file_symbols
+            )
+        );
+        assert!(
+            super::get_containers(
+                &lines,
+                &get_symbol("fetch", SymbolKind::Function, 9, 4),
+                &file_symbols
+            )
+            .is_empty()
+        );
+    }
+
+    fn get_symbol(name: &str, kind: SymbolKind, line: i64, column: i64) -> ResolvedSymbol {
+        ResolvedSymbol {
+            id: line * 100 + column,
+            start_line: line,
+            end_line: line,
+            start_column: column,
+            end_column: column + i64::try_from(name.len()).unwrap_or_default(),
+            ..ResolvedSymbol::for_test(name, kind, Language::Rust, "lib.rs")
+        }
+    }
+
+    #[tokio::test]
+    pub async fn test_resolving_hovers() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        let definition = workspace.path().join("user.ts");
+        let importer = workspace.path().join("app.ts");
+        let unrelated = workspace.path().join("other.py");
+
+        fs::write(
+            &definition,
+            "/**\n * Load a user by their ID.\n */\nexport function getUser(id: string): string {}\n",
+        )
+        .await
+        .expect("Should never fail to write a file into the workspace");
+        fs::write(
+            &importer,
+            "import { getUser } from \"./user\";\n\ngetUser(\"1\");\n",
+        )
+        .await
+        .expect("Should never fail to write a file into the workspace");
+        fs::write(&unrelated, "def getUser():\n    pass\n")
+            .await
+            .expect("Should never fail to write a file into the workspace");
+
+        let workspaces = vec![workspace.path()];
+
+        let indexer = indexer::DatabaseBackedIndexer::new(storage_path.path(), workspaces.clone())
+            .await
+            .expect("Should be able to create the empty index");
+
+        assert!(indexer.index_workspaces().await.is_ok());
+
+        let hover_resolver = super::HoverResolver::new(storage_path.path(), workspaces);
+
+        let hover = hover_resolver
+            .get_hover(
+                &importer,
+                Position {
+                    line: 2,
+                    character: 3,
+                },
+            )
+            .await
+            .expect("Identifier should have a hover");
+
+        assert_eq!(definition, hover.symbol.path);
+        assert_eq!(SymbolKind::Function, hover.symbol.kind);
+        assert_eq!(
+            Some("Load a user by their ID."),
+            hover.documentation.as_deref()
+        );
+        assert_eq!(1, hover.alternatives);
+        assert_eq!(
+            (2, 0),
+            (hover.range.start.line, hover.range.start.character)
+        );
+        assert_eq!(7, hover.range.end.character);
+        assert!(hover.contents.starts_with("```typescript\ngetUser"));
+        assert!(hover.contents.contains("Load a user by their ID."));
+        assert!(hover.contents.contains("(1 other candidate)"));
+
+        // Positions which aren't on an identifier never have a hover
+        assert!(
+            hover_resolver
+                .get_hover(
+                    &importer,
+                    Position {
+                        line: 1,
+                        character: 0,
+                    },
+                )
+                .await
+                .is_none()
+        );
+    }
+}
@@ -0,0 +1,932 @@
+use std::{
+    ffi::OsStr,
+    path::{Path, PathBuf},
+};
+
+use itertools::Itertools;
+
+use crate::{
+    indexer::{self, Manifest},
+    models::{
+        parsed::{Language, SymbolKind},
+        resolved::{ImportEdit, NotImportable, Position, ResolvedSymbol},
+    },
+    resolver::utils,
+};
+
+/// The names of the TypeScript (and JavaScript) configuration files, which can declare aliases
+/// for import paths (i.e. `@/*` for `src/*`).
+const TS_CONFIG_FILENAMES: [&str; 2] = ["tsconfig.json", "jsconfig.json"];
+
+/// The markers which start a comment line, in any supported language.
+const COMMENT_MARKERS: [&str; 6] = ["//", "#", "--", ";", "/*", "*"];
+
+/// An import statement for a symbol, before its position has been decided.
+#[derive(Debug)]
+struct Import {
+    /// The module being imported (i.e. `crate::models`, or `./api/user`).
+    module: String,
+
+    /// The full import statement.
+    statement: String,
+
+    /// The entry to add to an existing import block (i.e. Go's `import (…)`), if the language
+    /// has them.
+    block_entry: Option<String>,
+}
+
+/// Check if a file in one language can import symbols from a file in another language.
+fn can_import(from: Language, to: Language) -> bool {
+    let is_javascript = |language: Language| {
+        matches!(
+            language,
+            Language::TypeScript
+                | Language::TypeScriptJsx
+                | Language::Javascript
+                | Language::JavascriptJsx
+        )
+    };
+
+    from == to || (is_javascript(from) && is_javascript(to))
+}
+
+/// Check if a kind of symbol is a member of another definition (i.e. a method or field), rather
+/// than something which can be imported by itself.
+const fn is_member(kind: SymbolKind) -> bool {
+    matches!(
+        kind,
+        SymbolKind::Method
+            | SymbolKind::StaticMethod
+            | SymbolKind::AbstractMethod
+            | SymbolKind::TraitMethod
+            | SymbolKind::Constructor
+            | SymbolKind::Getter
+            | SymbolKind::Setter
+            | SymbolKind::Accessor
+            | SymbolKind::Field
+            | SymbolKind::StaticField
+            | SymbolKind::Property
+            | SymbolKind::StaticProperty
+            | SymbolKind::EnumMember
+    )
+}
+
+/// Check if a kind of symbol is always local to another definition (i.e. a parameter).
+const fn is_local(kind: SymbolKind) -> bool {
+    matches!(
+        kind,
+        SymbolKind::Parameter
+            | SymbolKind::ParameterLabel
+            | SymbolKind::SelfParameter
+            | SymbolKind::ThisParameter
+            | SymbolKind::TypeParameter
+            | SymbolKind::MethodReceiver
+    )
+}
+
+/// Check if a line is code, rather than blank or a comment.
+fn is_code(line: &str) -> bool {
+    let line = line.trim();
+
+    !line.is_empty()
+        && !COMMENT_MARKERS
+            .iter()
+            .any(|marker| line.starts_with(marker))
+}
+
+/// Get the segments of a module path, from a file path relative to the root of its package (i.e.
+/// `["models", "user"]` for `models/user.rs`).
+///
+/// Entrypoint files (i.e. `mod.rs` or `__init__.py`) are named after their directory, and so
+/// don't add a segment of their own.
+fn get_module_segments(relative_path: &Path, entrypoint_stems: &[&str]) -> Vec<String> {
+    let mut segments = relative_path
+        .parent()
+        .into_iter()
+        .flat_map(Path::components)
+        .filter_map(|component| component.as_os_str().to_str())
+        .map(ToString::to_string)
+        .collect::<Vec<_>>();
+
+    if let Some(stem) = relative_path.file_stem().and_then(OsStr::to_str)
+        && !entrypoint_stems.contains(&stem)
+    {
+        segments.push(stem.to_string());
+    }
+
+    segments
+}
+
+/// Join a relative path, as written in a configuration file (i.e. `./src/*`), onto a directory.
+fn join_relative(directory: &Path, relative_path: &str) -> PathBuf {
+    relative_path
+        .split('/')
+        .filter(|segment| !segment.is_empty() && *segment != ".")
+        .fold(directory.to_path_buf(), |path, segment| {
+            if segment == ".." {
+                path.parent().map_or(path.clone(), Path::to_path_buf)
+            } else {
+                path.join(segment)
+            }
+        })
+}
+
+/// Get the relative import path from a directory to a TypeScript (or JavaScript) module (i.e.
+/// `./api/user`, or `../user`).
+fn get_relative_specifier(directory: &Path, module: &Path) -> String {
+    let directory = directory.components().collect::<Vec<_>>();
+    let module = module.components().collect::<Vec<_>>();
+
+    let common = directory
+        .iter()
+        .zip(&module)
+        .take_while(|(a, b)| a == b)
+        .count();
+
+    let specifier = std::iter::repeat_n("..", directory.len() - common)
+        .chain(
+            module[common..]
+                .iter()
+                .filter_map(|component| component.as_os_str().to_str()),
+        )
+        .join("/");
+
+    if specifier.is_empty() {
+        String::from(".")
+    } else if specifier.starts_with("..") {
+        specifier
+    } else {
+        format!("./{specifier}")
+    }
+}
+
+/// Get the aliased import path to a TypeScript (or JavaScript) module, using the `paths` declared
+/// in the nearest `tsconfig.json` (or `jsconfig.json`) to the current file.
+fn get_aliased_specifier(current_file: &Path, module: &Path) -> Option<String> {
+    let (directory, content) = current_file.ancestors().skip(1).find_map(|directory| {
+        TS_CONFIG_FILENAMES.iter().find_map(|filename| {
+            std::fs::read_to_string(directory.join(filename))
+                .ok()
+                .map(|content| (directory, content))
+        })
+    })?;
+
+    // Configuration files commonly contain comments, which aren't valid JSON
+    let content = content
+        .lines()
+        .filter(|line| !line.trim_start().starts_with("//"))
+        .join("\n");
+
+    let config = serde_json::from_str::<serde_json::Value>(&content).ok()?;
+    let options = config.get("compilerOptions")?;
+
+    let base = join_relative(
+        directory,
+        options
+            .get("baseUrl")
+            .and_then(serde_json::Value::as_str)
+            .unwrap_or("."),
+    );
+
+    options
+        .get("paths")?
+        .as_object()?
+        .iter()
+        .find_map(|(alias, targets)| {
+            targets
+                .as_array()?
+                .iter()
+                .filter_map(serde_json::Value::as_str)
+                .find_map(|target| {
+                    let Some((prefix, _)) = target.split_once('*') else {
+                        return (join_relative(&base, target) == module).then(|| alias.clone());
+                    };
+
+                    let rest = module
+                        .strip_prefix(join_relative(&base, prefix))
+                        .ok()?
+                        .components()
+                        .filter_map(|component| component.as_os_str().to_str())
+                        .join("/");
+
+                    Some(alias.replacen('*', &rest, 1))
+                })
+        })
+}
+
+/// Get the import for a Rust item (i.e. `use crate::models::User;`).
+fn get_rust_import(
+    symbol: &ResolvedSymbol,
+    prefix: &str,
+    current_file: &Path,
+) -> Result<Import, NotImportable> {
+    let package = indexer::detect_package(&symbol.path, None)
+        .filter(|package| package.manifest == Manifest::Cargo)
+        .ok_or(NotImportable::UnknownModule)?;
+
+    let is_same_crate = indexer::detect_package(current_file, None)
+        .is_some_and(|current_package| current_package.root == package.root);
+
+    // Restricted visibility (i.e. `pub(crate)`) is only visible inside the same crate
+    match prefix
+        .split_whitespace()
+        .find(|word| word.starts_with("pub"))
+    {
+        Some("pub") => {}
+        Some(_) if is_same_crate => {}
+        _ => return Err(NotImportable::Private),
+    }
+
+    let relative_path = symbol
+        .path
+        .strip_prefix(package.root.join("src"))
+        .map_err(|_| NotImportable::UnknownModule)?;
+
+    let crate_name = if is_same_crate {
+        String::from("crate")
+    } else {
+        package.name.replace('-', "_")
+    };
+
+    let module = std::iter::once(crate_name)
+        .chain(get_module_segments(relative_path, &["mod", "lib", "main"]))
+        .join("::");
+
+    Ok(Import {
+        statement: format!("use {module}::{};", symbol.name),
+        module,
+        block_entry: None,
+    })
+}
+
+/// Get the import for a TypeScript (or JavaScript) export (i.e. `import { getUser } from
+/// "./user";`).
+fn get_javascript_import(
+    symbol: &ResolvedSymbol,
+    prefix: &str,
+    definition: &str,
+    current_file: &Path,
+    content: &str,
+) -> Result<Import, NotImportable> {
+    let prefix = prefix.trim_start();
+
+    let is_exported = prefix.starts_with("export")
+        || definition.lines().any(|line| {
+            line.trim_start().starts_with("export")
+                && line.contains('{')
+                && utils::contains_identifier(line, &symbol.name)
+        });
+
+    if !is_exported {
+        return Err(NotImportable::Private);
+    }
+
+    let directory = current_file.parent().ok_or(NotImportable::UnknownModule)?;
+
+    let mut module_path = symbol.path.with_extension("");
+
+    if module_path.file_name().and_then(OsStr::to_str) == Some("index") {
+        module_path.pop();
+    }
+
+    let relative_specifier = get_relative_specifier(directory, &module_path);
+
+    // Aliases are only preferred over relative paths which reach outside the current directory
+    let module = if relative_specifier.starts_with("..") {
+        get_aliased_specifier(current_file, &module_path).unwrap_or(relative_specifier)
+    } else {
+        relative_specifier
+    };
+
+    // Match the existing style of the file's imports
+    let semicolon = if content
+        .lines()
+        .filter(|line| utils::is_import_line(line))
+        .any(|line| !line.trim_end().ends_with(';'))
+    {
+        ""
+    } else {
+        ";"
+    };
+
+    let statement = if prefix.starts_with("export default") {
+        format!("import {} from \"{module}\"{semicolon}", symbol.name)
+    } else {
+        format!("import {{ {} }} from \"{module}\"{semicolon}", symbol.name)
+    };
+
+    Ok(Import {
+        module: format!("\"{module}\""),
+        statement,
+        block_entry: None,
+    })
+}
+
+/// Get the import for a Python definition (i.e. `from api.user import User`).
+fn get_python_import(
+    symbol: &ResolvedSymbol,
+    current_file: &Path,
+) -> Result<Import, NotImportable> {
+    // By convention, names starting with an underscore are private to their module
+    if symbol.name.starts_with('_') {
+        return Err(NotImportable::Private);
+    }
+
+    let root = indexer::detect_package(&symbol.path, None)
+        .filter(|package| package.manifest == Manifest::Python)
+        .map(|package| package.root)
+        .or_else(|| get_fallback_root(&symbol.path, current_file))
+        .ok_or(NotImportable::UnknownModule)?;
+
+    let relative_path = symbol
+        .path
+        .strip_prefix(root)
+        .map_err(|_| NotImportable::UnknownModule)?;
+
+    let mut segments = get_module_segments(relative_path, &["__init__"]);
+
+    // Packages using the `src` layout are imported from inside `src`
+    if segments.first().is_some_and(|segment| segment == "src") {
+        segments.remove(0);
+    }
+
+    if segments.is_empty() {
+        return Err(NotImportable::UnknownModule);
+    }
+
+    let module = segments.join(".");
+
+    Ok(Import {
+        statement: format!("from {module} import {}", symbol.name),
+        module,
+        block_entry: None,
+    })
+}
+
+/// Get the import for a Go identifier (i.e. `import "example.com/app/api"`).
+fn get_go_import(symbol: &ResolvedSymbol, current_file: &Path) -> Result<Import, NotImportable> {
+    // Only identifiers starting with an uppercase letter are exported from their package
+    if !symbol.name.starts_with(char::is_uppercase) {
+        return Err(NotImportable::Private);
+    }
+
+    let directory = symbol.path.parent().ok_or(NotImportable::UnknownModule)?;
+
+    if current_file.parent() == Some(directory) {
+        return Err(NotImportable::SameModule);
+    }
+
+    let package = indexer::detect_package(&symbol.path, None)
+        .filter(|package| package.manifest == Manifest::GoModule)
+        .ok_or(NotImportable::UnknownModule)?;
+
+    let relative_path = directory
+        .strip_prefix(&package.root)
+        .map_err(|_| NotImportable::UnknownModule)?;
+
+    let module = std::iter::once(package.name.as_str())
+        .chain(
+            relative_path
+                .components()
+                .filter_map(|component| component.as_os_str().to_str()),
+        )
+        .join("/");
+
+    Ok(Import {
+        statement: format!("import \"{module}\""),
+        block_entry: Some(format!("\t\"{module}\"")),
+        module: format!("\"{module}\""),
+    })
+}
+
+/// Get the import for the Lua module a definition is in (i.e. `local user = require("user")`).
+fn get_lua_import(
+    symbol: &ResolvedSymbol,
+    prefix: &str,
+    current_file: &Path,
+) -> Result<Import, NotImportable> {
+    if prefix.trim_start().starts_with("local") {
+        return Err(NotImportable::Private);
+    }
+
+    // Modules are commonly required from a `lua` directory (i.e. in Neovim plugins), otherwise
+    // from alongside the current file
+    let root = symbol
+        .path
+        .ancestors()
+        .find(|directory| directory.file_name() == Some(OsStr::new("lua")))
+        .map(Path::to_path_buf)
+        .or_else(|| get_fallback_root(&symbol.path, current_file))
+        .ok_or(NotImportable::UnknownModule)?;
+
+    let relative_path = symbol
+        .path
+        .strip_prefix(root)
+        .map_err(|_| NotImportable::UnknownModule)?;
+
+    let segments = get_module_segments(relative_path, &["init"]);
+
+    let (Some(variable), module) = (segments.last(), segments.join(".")) else {
+        return Err(NotImportable::UnknownModule);
+    };
+
+    Ok(Import {
+        statement: format!("local {variable} = require(\"{module}\")"),
+        module: format!("\"{module}\""),
+        block_entry: None,
+    })
+}
+
+/// Get the directory modules can be imported relative to, when a symbol isn't part of a package
+/// (i.e. scripts alongside the current file).
+fn get_fallback_root(path: &Path, current_file: &Path) -> Option<PathBuf> {
+    current_file
+        .parent()
+        .filter(|directory| path.starts_with(directory))
+        .map(Path::to_path_buf)
+}
+
+/// Decide where to insert an import into some content.
+///
+/// Imports are inserted after the last existing import (or inside Go's `import (…)` block), or
+/// otherwise before the first line of code (after any leading comments and package clauses).
+fn get_import_edit_for_content(content: &str, import: Import) -> ImportEdit {
+    let lines = content.lines().collect::<Vec<_>>();
+
+    let get_position = |index: usize| Position {
+        line: u32::try_from(index).unwrap_or(u32::MAX),
+        character: 0,
+    };
+
+    if let Some(block_entry) = &import.block_entry
+        && let Some(start) = lines.iter().position(|line| line.trim() == "import (")
+        && let Some(end) = lines[start..].iter().position(|line| line.trim() == ")")
+    {
+        return ImportEdit {
+            statement: import.statement,
+            position: get_position(start + end),
+            new_text: format!("{block_entry}\n"),
+        };
+    }
+
+    // Imports can span multiple lines (i.e. `import {\n  a,\n  b,\n} from "x"`), so the end of
+    // each import is where its brackets are balanced again
+    let mut depth = 0_i64;
+    let mut last_import = None;
+
+    for (index, line) in lines.iter().enumerate() {
+        if depth == 0 && !utils::is_import_line(line) {
+            if last_import.is_some() && is_code(line) {
+                break;
+            }
+
+            continue;
+        }
+
+        depth += line.chars().fold(0, |depth, c| match c {
+            '{' | '(' => depth + 1,
+            '}' | ')' => depth - 1,
+            _ => depth,
+        });
+
+        if depth <= 0 {
+            depth = 0;
+            last_import = Some(index);
+        }
+    }
+
+    if let Some(last_import) = last_import {
+        return ImportEdit {
+            new_text: format!("{}\n", import.statement),
+            statement: import.statement,
+            position: get_position(last_import + 1),
+        };
+    }
+
+    if let Some(package) = lines
+        .iter()
+        .position(|line| line.trim_start().starts_with("package "))
+    {
+        return ImportEdit {
+            new_text: format!("\n{}\n", import.statement),
+            statement: import.statement,
+            position: get_position(package + 1),
+        };
+    }
+
+    let Some(mut first_code) = lines.iter().position(|line| is_code(line)) else {
+        return ImportEdit {
+            new_text: format!("{}\n", import.statement),
+            statement: import.statement,
+            position: get_position(lines.len()),
+        };
+    };
+
+    // Comments directly above the first line of code document it (i.e. `///` in Rust), so the
+    // import shouldn't separate them, unless they document the whole file
+    while first_code > 0
+        && !is_code(lines[first_code - 1])
+        && !lines[first_code - 1].trim().is_empty()
+        && !lines[first_code - 1].starts_with("//!")
+        && !lines[first_code - 1].starts_with("#!")
+    {
+        first_code -= 1;
+    }
+
+    ImportEdit {
+        new_text: format!("{}\n\n", import.statement),
+        statement: import.statement,
+        position: get_position(first_code),
+    }
+}
+
+/// Get the import statement which brings a symbol into scope in the current file, alongside where
+/// to insert it.
+///
+/// Import paths are derived from the package the symbol is defined in (i.e. its crate, Python
+/// project, or Go module), and for TypeScript and JavaScript, the aliases declared in the
+/// nearest `tsconfig.json` (or `jsconfig.json`). For Lua, the module containing the symbol is
+/// required.
+///
+/// Symbols which can't be imported (i.e. because they're private, local to another definition,
+/// or already imported) return the reason they can't be.
+pub async fn get_import_edit(
+    symbol: &ResolvedSymbol,
+    current_file: &Path,
+) -> Result<ImportEdit, NotImportable> {
+    if symbol.path == current_file {
+        return Err(NotImportable::SameFile);
+    }
+
+    let language =
+        Language::try_from(current_file).map_err(|_| NotImportable::DifferentLanguage)?;
+
+    if !can_import(language, symbol.language) {
+        return Err(NotImportable::DifferentLanguage);
+    }
+
+    // Lua functions are commonly members of the table a module returns (i.e. `M.get_user`),
+    // which is what's imported
+    if symbol.language != Language::Lua && is_member(symbol.kind) {
+        return Err(NotImportable::Member);
+    }
+
+    if is_local(symbol.kind) {
+        return Err(NotImportable::Local);
+    }
+
+    let definition = tokio::fs::read_to_string(&symbol.path)
+        .await
+        .map_err(|_| NotImportable::UnknownModule)?;
+
+    let line = usize::try_from(symbol.start_line - 1)
+        .ok()
+        .and_then(|index| definition.lines().nth(index))
+        .ok_or(NotImportable::UnknownModule)?;
+
+    // Only top-level definitions can be imported, anything nested is inside another definition
+    if line.starts_with(char::is_whitespace) {
+        return Err(NotImportable::Local);
+    }
+
+    let prefix = usize::try_from(symbol.start_column - 1)
+        .ok()
+        .and_then(|column| line.get(..column))
+        .unwrap_or_default();
+
+    let content = tokio::fs::read_to_string(current_file)
+        .await
+        .unwrap_or_default();
+
+    let import = match symbol.language {
+        Language::Rust => get_rust_import(symbol, prefix, current_file)?,
+        Language::TypeScript
+        | Language::TypeScriptJsx
+        | Language::Javascript
+        | Language::JavascriptJsx => {
+            get_javascript_import(symbol, prefix, &definition, current_file, &content)?
+        }
+        Language::Python => get_python_import(symbol, current_file)?,
+        Language::Go => get_go_import(symbol, current_file)?,
+        Language::Lua => get_lua_import(symbol, prefix, current_file)?,
+        Language::Clojure | Language::Shell => return Err(NotImportable::UnsupportedLanguage),
+    };
+
+    let is_imported = content.lines().any(|line| {
+        utils::is_import_line(line)
+            && line.contains(&import.module)
+            // Go and Lua import the whole module, rather than the symbol
+            && (matches!(symbol.language, Language::Go | Language::Lua)
+                || utils::contains_identifier(line, &symbol.name))
+    });
+
+    if is_imported {
+        return Err(NotImportable::AlreadyImported);
+    }
+
+    Ok(get_import_edit_for_content(&content, import))
+}
+
+#[cfg(test)]
+mod tests {
+    use std::path::{Path, PathBuf};
+
+    use rstest::rstest;
+    use tempfile::tempdir;
+    use tokio::fs;
+
+    use crate::models::{
+        parsed::{Language, SymbolKind},
+        resolved::{NotImportable, ResolvedSymbol},
+    };
+
+    fn get_symbol(
+        name: &str,
+        kind: SymbolKind,
+        language: Language,
+        path: &Path,
+        line: i64,
+        column: i64,
+    ) -> ResolvedSymbol {
+        ResolvedSymbol {
+            start_line: line,
+            end_line: line,
+            start_column: column,
+            end_column: column + i64::try_from(name.len()).unwrap_or_default(),
+            ..ResolvedSymbol::for_test(name, kind, language, path)
+        }
+    }
+
+    #[rstest]
+    #[case("/app/src", "/app/src/api/user", "./api/user")]
+    #[case("/app/src/pages", "/app/src/api/user", "../api/user")]
+    #[case("/app/src/api", "/app/src/api", ".")]
+    pub fn test_getting_relative_specifiers(
+        #[case] directory: &str,
+        #[case] module: &str,
+        #[case] expected: &str,
+    ) {
+        assert_eq!(
+            expected,
+            super::get_relative_specifier(&PathBuf::from(directory), &PathBuf::from(module))
+        );
+    }
+
+    #[rstest]
+    #[case(
+        "use std::path::Path;\n\nfn main() {}\n",
+        None,
+        (1, "use crate::user::User;\n")
+    )]
+    #[case(
+        "//! The app.\n\n/// Run the app.\nfn main() {}\n",
+        None,
+        (2, "use crate::user::User;\n\n")
+    )]
+    #[case(
+        "import {\n  a,\n} from \"./a\";\nimport b from \"./b\";\n\nrun();\n",
+        None,
+        (4, "use crate::user::User;\n")
+    )]
+    #[case(
+        "package main\n\nimport (\n\t\"fmt\"\n)\n",
+        Some("\t\"example.com/app/api\""),
+        (4, "\t\"example.com/app/api\"\n")
+    )]
+    #[case(
+        "package main\n\nfunc main() {}\n",
+        None,
+        (1, "\nuse crate::user::User;\n")
+    )]
+    pub fn test_inserting_imports(
+        #[case] content: &str,
+        #[case] block_entry: Option<&str>,
+        #[case] expected: (u32, &str),
+    ) {
+        let edit = super::get_import_edit_for_content(
+            content,
+            super::Import {
+                module: String::from("crate::user"),
+                statement: String::from("use crate::user::User;"),
+                block_entry: block_entry.map(ToString::to_string),
+            },
+        );
+
+        assert_eq!(expected, (edit.position.line, edit.new_text.as_str()));
+    }
+
+    #[tokio::test]
+    pub async fn test_generating_import_edits() {
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        let root = workspace.path();
+
+        for (path, content) in [
+            ("Cargo.toml", "[package]\nname = \"my-app\"\n"),
+            (
+                "src/models/user.rs",
+                "pub struct User {}\n\nfn helper() {}\n\nimpl User {\n    pub fn load() {}\n}\n",
+            ),
+            (
+                "src/lib.rs",
+                "use std::path::Path;\n\nuse crate::models::user::User;\n",
+            ),
+            ("src/main.rs", "fn main() {}\n"),
+            (
+                "web/tsconfig.json",
+                "{\n  // Aliases\n  \"compilerOptions\": {\n    \"baseUrl\": \".\",\n    \"paths\": { \"@/*\": [\"./src/*\"] }\n  }\n}\n",
+            ),
+            (
+                "web/src/api/user.ts",
+                "export function getUser() {}\n\nfunction internal() {}\n",
+            ),
+            (
+                "web/src/pages/home.ts",
+                "import { a } from \"./a\"\n\nrun();\n",
+            ),
+            ("web/src/app.ts", ""),
+            ("py/pyproject.toml", "[project]\nname = \"app\"\n"),
+            ("py/src/app/api/user.py", "class User:\n    pass\n"),
+            ("py/src/app/main.py", "import os\n"),
+            ("go/go.mod", "module example.com/app\n"),
+            (
+                "go/api/user.go",
+                "package api\n\nfunc GetUser() {}\n\nfunc load() {}\n",
+            ),
+            ("go/main.go", "package main\n\nimport (\n\t\"fmt\"\n)\n"),
+            (
+                "lua/user.lua",
+                "local M = {}\n\nfunction M.get_user() end\n\nreturn M\n",
+            ),
+            ("lua/app.lua", "print(\"hello\")\n"),
+        ] {
+            let path = root.join(path);
+
+            fs::create_dir_all(path.parent().expect("Should always have a parent"))
+                .await
+                .expect("Should never fail to create a directory in the workspace");
+            fs::write(&path, content)
+                .await
+                .expect("Should never fail to write a file into the workspace");
+        }
+
+        for (symbol, current_file, expected) in [
+            (
+                get_symbol(
+                    "User",
+                    SymbolKind::Struct,
+                    Language::Rust,
+                    &root.join("src/models/user.rs"),
+                    1,
+                    12,
+                ),
+                "src/main.rs",
+                Ok((0, "use crate::models::user::User;\n\n")),
+            ),
+            (
+                get_symbol(
+                    "User",
+                    SymbolKind::Struct,
+                    Language::Rust,
+                    &root.join("src/models/user.rs"),
+                    1,
+                    12,
+                ),
+                "src/lib.rs",
+                Err(NotImportable::AlreadyImported),
+            ),
+            (
+                get_symbol(
+                    "helper",
+                    SymbolKind::Function,
+                    Language::Rust,
+                    &root.join("src/models/user.rs"),
+                    3,
+                    4,
+                ),
+                "src/main.rs",
+                Err(NotImportable::Private),
+            ),
+            (
+                get_symbol(
+                    "load",
+                    SymbolKind::Method,
+                    Language::Rust,
+                    &root.join("src/models/user.rs"),
+                    6,
+                    12,
+                ),
+                "src/main.rs",
+                Err(NotImportable::Member),
+            ),
+            (
+                get_symbol(
+                    "getUser",
+                    SymbolKind::Function,
+                    Language::TypeScript,
+                    &root.join("web/src/api/user.ts"),
+                    1,
+                    17,
+                ),
+                "web/src/app.ts",
+                Ok((0, "import { getUser } from \"./api/user\";\n")),
+            ),
+            (
+                get_symbol(
+                    "getUser",
+                    SymbolKind::Function,
+                    Language::TypeScript,
+                    &root.join("web/src/api/user.ts"),
+                    1,
+                    17,
+                ),
+                "web/src/pages/home.ts",
+                Ok((1, "import { getUser } from \"@/api/user\"\n")),
+            ),
+            (
+                get_symbol(
+                    "internal",
+                    SymbolKind::Function,
+                    Language::TypeScript,
+                    &root.join("web/src/api/user.ts"),
+                    3,
+                    10,
+                ),
+                "web/src/app.ts",
+                Err(NotImportable::Private),
+            ),
+            (
+                get_symbol(
+                    "User",
+                    SymbolKind::Class,
+                    Language::Python,
+                    &root.join("py/src/app/api/user.py"),
+                    1,
+                    7,
+                ),
+                "py/src/app/main.py",
+                Ok((1, "from app.api.user import User\n")),
+            ),
+            (
+                get_symbol(
+                    "GetUser",
+                    SymbolKind::Function,
+                    Language::Go,
+                    &root.join("go/api/user.go"),
+                    3,
+                    6,
+                ),
+                "go/main.go",
+                Ok((4, "\t\"example.com/app/api\"\n")),
+            ),
+            (
+                get_symbol(
+                    "load",
+                    SymbolKind::Function,
+                    Language::Go,
+                    &root.join("go/api/user.go"),
+                    5,
+                    6,
+                ),
+                "go/main.go",
+                Err(NotImportable::Private),
+            ),
+            (
+                get_symbol(
+                    "get_user",
+                    SymbolKind::Property,
+                    Language::Lua,
+                    &root.join("lua/user.lua"),
+                    3,
+                    12,
+                ),
+                "lua/app.lua",
+                Ok((0, "local user = require(\"user\")\n\n")),
+            ),
+            (
+                get_symbol(
+                    "get_user",
+                    SymbolKind::Property,
+                    Language::Lua,
+                    &root.join("lua/user.lua"),
+                    3,
+                    12,
+                ),
+                "src/main.rs",
+                Err(NotImportable::DifferentLanguage),
+            ),
+        ] {
+            let edit = super::get_import_edit(&symbol, &root.join(current_file)).await;
+
+            assert_eq!(
+                expected,
+                edit.as_ref()
+                    .map(|edit| (edit.position.line, edit.new_text.as_str()))
+                    .map_err(|e| *e),
+                "Importing {} into {current_file}",
+                symbol.name
+            );
+        }
+    }
+}
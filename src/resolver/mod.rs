@@ -1,12 +1,34 @@
 //! Tooling for fuzzy matching and scoring symbols from indexes in real-time.
 
+mod completion;
 pub(crate) mod constant;
 mod database_backed_resolver;
+mod history;
+pub(crate) mod hover;
+mod import;
+mod query;
+mod relation;
+mod rename;
+mod schema;
 mod scoring;
+mod signature;
+mod synonym;
+mod translation;
 mod types;
-mod utils;
+pub(crate) mod utils;
 mod weight;
 
+pub use completion::CompletionResolver;
 pub use database_backed_resolver::DatabaseBackedResolver;
+pub use history::HistoryResolver;
+pub use hover::HoverResolver;
+pub use import::get_import_edit;
+pub use query::{NameMatcher, Predicate, Sort, SymbolQuery};
+pub use relation::{PairingRule, RelationResolver};
+pub use rename::RenameResolver;
+pub use schema::SchemaResolver;
+pub use signature::SignaturePattern;
+pub use synonym::Synonyms;
+pub use translation::TranslationResolver;
 
 pub use types::{Context, Resolver, SymbolKindFilter};
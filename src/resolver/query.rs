@@ -0,0 +1,1132 @@
+use std::{
+    collections::HashMap,
+    path::{Component, MAIN_SEPARATOR, Path, PathBuf},
+    sync::Arc,
+};
+
+use sea_query::{Cond, Condition, Expr, ExprTrait, LikeExpr, Order, SelectStatement};
+
+use crate::{
+    models::{
+        parsed::{Language, SymbolCategory, SymbolKind},
+        resolved::ResolvedSymbol,
+    },
+    resolver::{hover, scoring, utils},
+};
+
+/// How the name of a symbol (or a container it's nested in) is matched.
+#[derive(Debug, Clone)]
+pub enum NameMatcher {
+    /// Match names fuzzily (tolerating typos), in the same way as [`crate::resolver::Resolver::query`].
+    ///
+    /// When matching the name of a symbol, fuzzy matches are also scored by how well they match.
+    Fuzzy(String),
+
+    /// Match names exactly, including their case.
+    Exact(String),
+
+    /// Match names against a regular expression (i.e. `^get[A-Z]`).
+    ///
+    /// SQLite can't match regular expressions itself, so symbols are matched once they've been
+    /// read from the index. Where possible, combine a regular expression with other predicates
+    /// which narrow down the symbols which need to be read.
+    Regex(regex::Regex),
+}
+
+impl NameMatcher {
+    /// Create a matcher for a regular expression.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the regular expression is invalid.
+    pub fn regex(pattern: &str) -> Result<Self, regex::Error> {
+        Ok(Self::Regex(regex::Regex::new(pattern)?))
+    }
+
+    /// Check if a name matches.
+    #[must_use]
+    pub fn matches(&self, name: &str) -> bool {
+        match self {
+            Self::Fuzzy(query) => {
+                query.is_empty()
+                    || !frizbee::match_list(
+                        query,
+                        &[name, name.to_lowercase().as_str()],
+                        &scoring::get_fuzzy_config(query),
+                    )
+                    .is_empty()
+            }
+            Self::Exact(expected) => name == expected,
+            Self::Regex(regex) => regex.is_match(name),
+        }
+    }
+
+    /// Get the condition for the matcher in SQL, if it can be evaluated in SQL.
+    fn to_sql(&self, column: (&'static str, &'static str)) -> Option<Condition> {
+        match self {
+            Self::Exact(name) => Some(Cond::all().add(Expr::col(column).eq(name.as_str()))),
+            Self::Fuzzy(_) | Self::Regex(_) => None,
+        }
+    }
+}
+
+/// A predicate which symbols must satisfy to be returned by a [`SymbolQuery`].
+///
+/// Predicates are combined with [`Predicate::and`], [`Predicate::or`] and `!` (negation), and are
+/// compiled into SQL wherever possible. Predicates which can't be evaluated by SQLite (namely,
+/// fuzzy and regular expression matches, containers, and visibility) are evaluated once symbols
+/// have been read from the index, after as many symbols as possible have been filtered out in SQL.
+#[derive(Debug, Clone)]
+pub enum Predicate {
+    /// The name of the symbol matches.
+    Name(NameMatcher),
+
+    /// The symbol is one of a set of kinds.
+    Kind(Vec<SymbolKind>),
+
+    /// The kind of the symbol is in a category (i.e. [`SymbolCategory::Type`]).
+    Category(SymbolCategory),
+
+    /// The symbol is defined in one of a set of languages.
+    Language(Vec<Language>),
+
+    /// The symbol is defined in a file inside a directory (or is the file itself).
+    ///
+    /// Absolute paths are matched from the root of the filesystem, while relative paths are
+    /// matched at any depth (i.e. `src` matches both `src/lib.rs` and `crates/api/src/lib.rs`).
+    ///
+    /// Absolute paths inside a workspace which was given as a relative path (i.e.
+    /// `tests/fixtures`) are matched against the workspace as it was given.
+    Path(PathBuf),
+
+    /// The symbol is defined in one of a set of packages (see [`crate::indexer::detect_package`]).
+    Package(Vec<String>),
+
+    /// The symbol is nested inside a container (i.e. a class, or a module) whose name matches.
+    ///
+    /// Containers are found from the source of the file the symbol is defined in, in the same
+    /// way as hover information ([`crate::resolver::HoverResolver`]).
+    Container(NameMatcher),
+
+    /// The symbol is part of test code.
+    Test,
+
+    /// The symbol has been marked as deprecated.
+    Deprecated,
+
+    /// The symbol is defined in generated code.
+    Generated,
+
+    /// The symbol is visible outside the module it's defined in (i.e. `pub` in Rust, `export` in
+    /// TypeScript, or capitalised in Go).
+    ///
+    /// Visibility is detected from the source of the line the symbol is defined on, and so is a
+    /// best effort for each language.
+    Public,
+
+    /// Every one of the predicates is satisfied.
+    All(Vec<Predicate>),
+
+    /// At least one of the predicates is satisfied.
+    Any(Vec<Predicate>),
+
+    /// The predicate isn't satisfied.
+    Not(Box<Predicate>),
+}
+
+impl Predicate {
+    /// Combine the predicate with another, where both must be satisfied.
+    #[must_use]
+    pub fn and(self, other: Self) -> Self {
+        match self {
+            Self::All(mut predicates) => {
+                predicates.push(other);
+
+                Self::All(predicates)
+            }
+            predicate => Self::All(vec![predicate, other]),
+        }
+    }
+
+    /// Combine the predicate with another, where either can be satisfied.
+    #[must_use]
+    pub fn or(self, other: Self) -> Self {
+        match self {
+            Self::Any(mut predicates) => {
+                predicates.push(other);
+
+                Self::Any(predicates)
+            }
+            predicate => Self::Any(vec![predicate, other]),
+        }
+    }
+
+    /// Get the condition for the predicate in SQL, if it can be evaluated entirely in SQL.
+    fn to_sql(&self) -> Option<Condition> {
+        match self {
+            Self::Name(matcher) => matcher.to_sql(("symbol", "name")),
+            Self::Kind(kinds) => {
+                Some(Cond::all().add(
+                    Expr::col(("symbol", "kind")).is_in(kinds.iter().map(ToString::to_string)),
+                ))
+            }
+            Self::Category(category) => Some(
+                Cond::all().add(
+                    Expr::col(("symbol", "kind"))
+                        .is_in(category.kinds().iter().map(ToString::to_string)),
+                ),
+            ),
+            Self::Language(languages) => Some(Cond::all().add(
+                Expr::col(("symbol", "language")).is_in(languages.iter().map(ToString::to_string)),
+            )),
+            Self::Path(path) => Some(get_path_condition(path)),
+            Self::Package(packages) => Some(
+                Cond::all()
+                    .add(Expr::col(("file", "package")).is_in(packages.iter().map(String::as_str))),
+            ),
+            Self::Test => Some(Cond::all().add(Expr::col(("symbol", "test")).eq(true))),
+            Self::Deprecated => Some(Cond::all().add(Expr::col(("symbol", "deprecated")).eq(true))),
+            Self::Generated => Some(Cond::all().add(Expr::col(("file", "generated")).eq(true))),
+            Self::Container(_) | Self::Public => None,
+            Self::All(predicates) => predicates.iter().try_fold(Cond::all(), |cond, predicate| {
+                Some(cond.add(predicate.to_sql()?))
+            }),
+            Self::Any(predicates) => predicates.iter().try_fold(Cond::any(), |cond, predicate| {
+                Some(cond.add(predicate.to_sql()?))
+            }),
+            Self::Not(predicate) => predicate.to_sql().map(Condition::not),
+        }
+    }
+
+    /// Get a condition in SQL which every symbol satisfying the predicate also satisfies, to
+    /// narrow down the symbols which need to be read from the index when the predicate can't be
+    /// evaluated entirely in SQL.
+    ///
+    /// Symbols which satisfy the condition don't necessarily satisfy the predicate.
+    fn to_sql_prefilter(&self) -> Option<Condition> {
+        match self {
+            Self::All(predicates) => {
+                let conditions = predicates
+                    .iter()
+                    .filter_map(Self::to_sql_prefilter)
+                    .collect::<Vec<_>>();
+
+                (!conditions.is_empty())
+                    .then(|| conditions.into_iter().fold(Cond::all(), Condition::add))
+            }
+            Self::Any(predicates) => predicates.iter().try_fold(Cond::any(), |cond, predicate| {
+                Some(cond.add(predicate.to_sql_prefilter()?))
+            }),
+            predicate => predicate.to_sql(),
+        }
+    }
+
+    /// Check if the predicate needs the source of the file a symbol is defined in.
+    fn needs_source(&self) -> bool {
+        match self {
+            Self::Container(_) | Self::Public => true,
+            Self::All(predicates) | Self::Any(predicates) => {
+                predicates.iter().any(Self::needs_source)
+            }
+            Self::Not(predicate) => predicate.needs_source(),
+            _ => false,
+        }
+    }
+
+    /// Check if a symbol satisfies the predicate.
+    ///
+    /// The source of the file the symbol is defined in is only needed when
+    /// [`Predicate::needs_source`], and predicates which need it are never satisfied without it.
+    fn matches(&self, symbol: &ResolvedSymbol, source: Option<&Source>) -> bool {
+        match self {
+            Self::Name(matcher) => matcher.matches(&symbol.name),
+            Self::Kind(kinds) => kinds.contains(&symbol.kind),
+            Self::Category(category) => symbol.kind.category() == *category,
+            Self::Language(languages) => languages.contains(&symbol.language),
+            Self::Path(path) => is_within(&symbol.path, path),
+            Self::Package(packages) => symbol
+                .package
+                .as_ref()
+                .is_some_and(|package| packages.contains(package)),
+            Self::Container(matcher) => source.is_some_and(|source| {
+                hover::get_containers(&source.lines(), symbol, &source.symbols)
+                    .iter()
+                    .any(|container| matcher.matches(container))
+            }),
+            Self::Test => symbol.test,
+            Self::Deprecated => symbol.deprecated,
+            Self::Generated => symbol.generated,
+            Self::Public => source.is_some_and(|source| is_public(symbol, &source.lines())),
+            Self::All(predicates) => predicates
+                .iter()
+                .all(|predicate| predicate.matches(symbol, source)),
+            Self::Any(predicates) => predicates
+                .iter()
+                .any(|predicate| predicate.matches(symbol, source)),
+            Self::Not(predicate) => !predicate.matches(symbol, source),
+        }
+    }
+}
+
+impl Predicate {
+    /// Normalise the paths in the predicate against the workspaces they're inside, so that they
+    /// match the paths of files as they're stored in the index (which are relative when the
+    /// workspace was given as a relative path).
+    fn normalise_paths(self, workspaces: &[PathBuf]) -> Self {
+        match self {
+            Self::Path(path) => Self::Path(normalise_path(&path, workspaces)),
+            Self::All(predicates) => Self::All(
+                predicates
+                    .into_iter()
+                    .map(|predicate| predicate.normalise_paths(workspaces))
+                    .collect(),
+            ),
+            Self::Any(predicates) => Self::Any(
+                predicates
+                    .into_iter()
+                    .map(|predicate| predicate.normalise_paths(workspaces))
+                    .collect(),
+            ),
+            Self::Not(predicate) => Self::Not(Box::new(predicate.normalise_paths(workspaces))),
+            predicate => predicate,
+        }
+    }
+}
+
+impl std::ops::Not for Predicate {
+    type Output = Self;
+
+    fn not(self) -> Self::Output {
+        match self {
+            Self::Not(predicate) => *predicate,
+            predicate => Self::Not(Box::new(predicate)),
+        }
+    }
+}
+
+/// The order symbols are returned from a [`SymbolQuery`] in.
+#[derive(Debug, Clone, Copy, Hash, Eq, PartialEq)]
+pub enum Sort {
+    /// From the highest to the lowest score.
+    Score,
+
+    /// By name (and then by path and position), alphabetically.
+    Name,
+
+    /// By path, and then by position in the file.
+    Path,
+
+    /// From the most to the least recently changed file (see
+    /// [`crate::resolver::Context::with_recency_half_life`]), and then by path and position.
+    RecentlyChanged,
+}
+
+/// A structured query for symbols, built from a name matcher, predicates, a sort order and a
+/// limit.
+///
+/// Queries are compiled into SQL, with anything SQLite can't evaluate (see [`Predicate`])
+/// evaluated once symbols have been read from the index. When nothing needs to be evaluated
+/// outside of SQL, sorting (other than by score) and limits are applied by SQLite as well.
+///
+/// ```no_run
+/// # use onoma::models::parsed::{Language, SymbolKind};
+/// # use onoma::resolver::{NameMatcher, Predicate, SymbolQuery};
+/// // Public methods in a container matching `Repo`, in `src`, in TypeScript or Go, but not in tests
+/// let query = SymbolQuery::new().with_filter(
+///     Predicate::Kind(vec![SymbolKind::Method])
+///         .and(Predicate::Container(NameMatcher::Fuzzy("Repo".into())))
+///         .and(Predicate::Path("src".into()))
+///         .and(Predicate::Language(vec![Language::TypeScript, Language::Go]))
+///         .and(!Predicate::Test)
+///         .and(Predicate::Public),
+/// );
+/// ```
+#[derive(Debug, Clone, Default)]
+pub struct SymbolQuery {
+    /// The matcher for the names of symbols, which (when fuzzy) also scores them.
+    pub name: Option<NameMatcher>,
+
+    /// The predicate symbols must satisfy.
+    pub filter: Option<Predicate>,
+
+    /// The order symbols are returned in.
+    ///
+    /// Queries without a sort order stream symbols as soon as they're matched, in no particular
+    /// order.
+    pub sort: Option<Sort>,
+
+    /// The maximum number of symbols returned.
+    pub limit: Option<usize>,
+}
+
+impl SymbolQuery {
+    /// Create a query which matches every symbol.
+    #[must_use]
+    pub fn new() -> Self {
+        Self::default()
+    }
+
+    /// Create a query which fuzzy matches the names of symbols, in the same way as
+    /// [`crate::resolver::Resolver::query`].
+    #[must_use]
+    pub fn fuzzy(query: impl Into<String>) -> Self {
+        Self::new().with_name(NameMatcher::Fuzzy(query.into()))
+    }
+
+    /// Set the name matcher.
+    #[must_use]
+    pub fn with_name(mut self, name: NameMatcher) -> Self {
+        self.name = Some(name);
+
+        self
+    }
+
+    /// Add a predicate, which must be satisfied alongside any predicates already added.
+    #[must_use]
+    pub fn with_filter(mut self, predicate: Predicate) -> Self {
+        self.filter = Some(match self.filter.take() {
+            Some(filter) => filter.and(predicate),
+            None => predicate,
+        });
+
+        self
+    }
+
+    /// Set the sort order.
+    #[must_use]
+    pub fn with_sort(mut self, sort: Sort) -> Self {
+        self.sort = Some(sort);
+
+        self
+    }
+
+    /// Set the limit.
+    #[must_use]
+    pub fn with_limit(mut self, limit: usize) -> Self {
+        self.limit = Some(limit);
+
+        self
+    }
+
+    /// Get the fuzzy query symbols are scored against, if the names of symbols are fuzzy
+    /// matched.
+    pub(crate) fn get_fuzzy_query(&self) -> Option<&str> {
+        match &self.name {
+            Some(NameMatcher::Fuzzy(query)) => Some(query),
+            _ => None,
+        }
+    }
+
+    /// Normalise the paths in the query's predicates against the workspaces of the index (see
+    /// [`Predicate::Path`]).
+    #[must_use]
+    pub(crate) fn normalise_paths(mut self, workspaces: &[PathBuf]) -> Self {
+        self.filter = self.filter.map(|filter| filter.normalise_paths(workspaces));
+
+        self
+    }
+
+    /// Check if any part of the query needs to be evaluated once symbols have been read from the
+    /// index (apart from scoring).
+    pub(crate) fn needs_post_filter(&self) -> bool {
+        matches!(self.name, Some(NameMatcher::Regex(_)))
+            || self
+                .filter
+                .as_ref()
+                .is_some_and(|filter| filter.to_sql().is_none())
+    }
+
+    /// Restrict (and, where possible, sort and limit) a query for symbols in SQL.
+    ///
+    /// When `push_down` is set, sorting and the limit are applied in SQL, which is only valid
+    /// when no symbols are filtered out after they've been read from the index.
+    pub(crate) fn apply_sql(&self, query: &mut SelectStatement, push_down: bool) {
+        if let Some(condition) = self
+            .name
+            .as_ref()
+            .and_then(|name| name.to_sql(("symbol", "name")))
+        {
+            query.cond_where(condition);
+        }
+
+        if let Some(condition) = self.filter.as_ref().and_then(Predicate::to_sql_prefilter) {
+            query.cond_where(condition);
+        }
+
+        if !push_down {
+            return;
+        }
+
+        match self.sort {
+            Some(Sort::Name) => {
+                query.order_by(("symbol", "name"), Order::Asc);
+            }
+            Some(Sort::RecentlyChanged) => {
+                query.order_by_expr(
+                    Expr::cust("COALESCE(file.committed_at, file.modified_at)"),
+                    Order::Desc,
+                );
+            }
+            Some(Sort::Path | Sort::Score) | None => {}
+        }
+
+        if self.sort.is_some() {
+            query
+                .order_by(("file", "path"), Order::Asc)
+                .order_by(("symbol", "start_line"), Order::Asc)
+                .order_by(("symbol", "start_column"), Order::Asc);
+        }
+
+        if let Some(limit) = self.limit {
+            query.limit(u64::try_from(limit).unwrap_or(u64::MAX));
+        }
+    }
+
+    /// Check if a symbol (read from the index) satisfies the parts of the query which can't be
+    /// evaluated in SQL.
+    pub(crate) async fn matches(
+        &self,
+        symbol: &ResolvedSymbol,
+        sources: &mut SourceCache<'_>,
+    ) -> bool {
+        if let Some(NameMatcher::Regex(regex)) = &self.name
+            && !regex.is_match(&symbol.name)
+        {
+            return false;
+        }
+
+        let Some(filter) = &self.filter else {
+            return true;
+        };
+
+        if filter.to_sql().is_some() {
+            // The predicate was already evaluated in full, as part of the query
+            return true;
+        }
+
+        let source = if filter.needs_source() {
+            sources.get(&symbol.path).await
+        } else {
+            None
+        };
+
+        filter.matches(symbol, source.as_deref())
+    }
+
+    /// Sort a set of (scored) symbols into the order of the query, and apply its limit.
+    pub(crate) fn sort(&self, symbols: &mut Vec<ResolvedSymbol>) {
+        let by_position = |a: &ResolvedSymbol, b: &ResolvedSymbol| {
+            (&a.path, a.start_line, a.start_column).cmp(&(&b.path, b.start_line, b.start_column))
+        };
+
+        match self.sort {
+            Some(Sort::Score) => {
+                symbols.sort_by(|a, b| b.score.cmp(&a.score).then_with(|| by_position(a, b)))
+            }
+            Some(Sort::Name) => {
+                symbols.sort_by(|a, b| a.name.cmp(&b.name).then_with(|| by_position(a, b)));
+            }
+            Some(Sort::Path) => symbols.sort_by(by_position),
+            Some(Sort::RecentlyChanged) => symbols.sort_by(|a, b| {
+                b.changed_at
+                    .cmp(&a.changed_at)
+                    .then_with(|| by_position(a, b))
+            }),
+            None => {}
+        }
+
+        if let Some(limit) = self.limit {
+            symbols.truncate(limit);
+        }
+    }
+}
+
+/// The source of a file, and the symbols defined in it, for evaluating predicates which need more
+/// than the index has stored about a symbol.
+#[derive(Debug)]
+pub(crate) struct Source {
+    content: String,
+    symbols: Vec<ResolvedSymbol>,
+}
+
+impl Source {
+    /// Get the lines of the file.
+    fn lines(&self) -> Vec<&str> {
+        self.content.lines().collect()
+    }
+}
+
+/// A cache of the sources of files, so that each file is read at most once for a query.
+#[derive(Debug)]
+pub(crate) struct SourceCache<'a> {
+    pool: &'a sqlx::Pool<sqlx::Sqlite>,
+    sources: HashMap<PathBuf, Option<Arc<Source>>>,
+}
+
+impl<'a> SourceCache<'a> {
+    /// Create an empty cache, reading the symbols of files from an index.
+    pub(crate) fn new(pool: &'a sqlx::Pool<sqlx::Sqlite>) -> Self {
+        Self {
+            pool,
+            sources: HashMap::new(),
+        }
+    }
+
+    /// Get the source of a file, if it can be read.
+    async fn get(&mut self, path: &Path) -> Option<Arc<Source>> {
+        if let Some(source) = self.sources.get(path) {
+            return source.clone();
+        }
+
+        let source = match tokio::fs::read_to_string(path).await {
+            Ok(content) => {
+                let (sql, values) = utils::get_symbols_in_files_sql([path]);
+
+                let symbols = sqlx::query_as_with::<_, ResolvedSymbol, _>(&sql, values)
+                    .fetch_all(self.pool)
+                    .await
+                    .unwrap_or_else(|e| {
+                        log::error!("Error returned from query listing symbols in file: {e}");
+
+                        Vec::new()
+                    });
+
+                Some(Arc::new(Source { content, symbols }))
+            }
+            Err(e) => {
+                log::debug!("Unable to read {} for query: {e}", path.display());
+
+                None
+            }
+        };
+
+        self.sources.insert(path.to_path_buf(), source.clone());
+
+        source
+    }
+}
+
+/// Get the condition (in SQL) for symbols defined inside a path.
+fn get_path_condition(path: &Path) -> Condition {
+    let path = path.to_string_lossy();
+    let path = path.trim_end_matches(MAIN_SEPARATOR);
+
+    // Paths can contain underscores, which would otherwise act as wildcards
+    let escaped_path = path
+        .replace('!', "!!")
+        .replace('%', "!%")
+        .replace('_', "!_");
+
+    let column = || Expr::col(("file", "path"));
+
+    if Path::new(path).is_absolute() {
+        Cond::any().add(column().eq(path)).add(
+            column().like(LikeExpr::new(format!("{escaped_path}{MAIN_SEPARATOR}%")).escape('!')),
+        )
+    } else {
+        // Relative paths match at any depth, including at the start of paths which are stored
+        // relative to a relative workspace (i.e. `tests/fixtures/lib.rs`)
+        Cond::any()
+            .add(column().eq(path))
+            .add(
+                column()
+                    .like(LikeExpr::new(format!("{escaped_path}{MAIN_SEPARATOR}%")).escape('!')),
+            )
+            .add(
+                column()
+                    .like(LikeExpr::new(format!("%{MAIN_SEPARATOR}{escaped_path}")).escape('!')),
+            )
+            .add(
+                column().like(
+                    LikeExpr::new(format!("%{MAIN_SEPARATOR}{escaped_path}{MAIN_SEPARATOR}%"))
+                        .escape('!'),
+                ),
+            )
+    }
+}
+
+/// Normalise a path against the workspaces of the index, removing any `.` components, and
+/// rewriting absolute paths inside a relative workspace to be relative to where the workspace is
+/// (as the paths of its files are stored).
+fn normalise_path(path: &Path, workspaces: &[PathBuf]) -> PathBuf {
+    let path = path
+        .components()
+        .filter(|component| *component != Component::CurDir)
+        .collect::<PathBuf>();
+
+    if !path.is_absolute() {
+        return path;
+    }
+
+    workspaces
+        .iter()
+        .filter(|workspace| workspace.is_relative())
+        .find_map(|workspace| {
+            let absolute_workspace = std::path::absolute(workspace).ok()?;
+            let relative_path = path.strip_prefix(absolute_workspace).ok()?;
+
+            Some(
+                workspace
+                    .components()
+                    .filter(|component| *component != Component::CurDir)
+                    .collect::<PathBuf>()
+                    .join(relative_path),
+            )
+        })
+        .unwrap_or(path)
+}
+
+/// Check if a file is inside a path (or is the path itself), matching relative paths at any
+/// depth.
+fn is_within(file: &Path, path: &Path) -> bool {
+    if path.is_absolute() {
+        return file.starts_with(path);
+    }
+
+    let components = path.components().collect::<Vec<_>>();
+
+    components.is_empty()
+        || file
+            .components()
+            .collect::<Vec<_>>()
+            .windows(components.len())
+            .any(|window| window == components.as_slice())
+}
+
+/// Check if a symbol is visible outside the module it's defined in, from the line it's defined on.
+fn is_public(symbol: &ResolvedSymbol, lines: &[&str]) -> bool {
+    let line = usize::try_from(symbol.start_line - 1)
+        .ok()
+        .and_then(|index| lines.get(index))
+        .copied()
+        .unwrap_or_default();
+
+    // Everything before the name of the symbol (i.e. `pub fn`, or `export const`)
+    let prefix = line
+        .find(symbol.name.as_str())
+        .map_or(line, |index| &line[..index])
+        .trim_start();
+
+    let has_word = |word: &str| prefix.split_whitespace().any(|w| w == word);
+
+    match symbol.language {
+        // Only identifiers starting with an uppercase letter are exported from their package
+        Language::Go => symbol.name.starts_with(char::is_uppercase),
+        // By convention, names starting with an underscore are private to their module
+        Language::Python => !symbol.name.starts_with('_'),
+        // Enum members (and the methods of traits) share the visibility of their container
+        Language::Rust => {
+            has_word("pub")
+                || matches!(
+                    symbol.kind,
+                    SymbolKind::EnumMember | SymbolKind::TraitMethod
+                )
+        }
+        Language::TypeScript
+        | Language::TypeScriptJsx
+        | Language::Javascript
+        | Language::JavascriptJsx => match symbol.kind {
+            // Members of classes (and interfaces) are public unless they're marked otherwise
+            SymbolKind::Method
+            | SymbolKind::StaticMethod
+            | SymbolKind::AbstractMethod
+            | SymbolKind::Constructor
+            | SymbolKind::Getter
+            | SymbolKind::Setter
+            | SymbolKind::Field
+            | SymbolKind::Property
+            | SymbolKind::StaticProperty
+            | SymbolKind::EnumMember => {
+                !has_word("private") && !has_word("protected") && !symbol.name.starts_with('#')
+            }
+            _ => prefix.starts_with("export"),
+        },
+        Language::Lua | Language::Shell => !has_word("local"),
+        Language::Clojure => !has_word("(defn-") && !prefix.contains("^:private"),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use std::path::{Path, PathBuf};
+
+    use rstest::rstest;
+    use sea_query::SqliteQueryBuilder;
+    use tempfile::tempdir;
+    use tokio::fs;
+    use tokio_stream::StreamExt;
+
+    use crate::{
+        indexer::{self, Indexer},
+        models::{
+            parsed::{Language, SymbolCategory, SymbolKind},
+            resolved::ResolvedSymbol,
+        },
+        resolver::{Context, DatabaseBackedResolver},
+    };
+
+    use super::{NameMatcher, Predicate, Sort, SymbolQuery};
+
+    fn get_symbol(name: &str, kind: SymbolKind, path: &str) -> ResolvedSymbol {
+        ResolvedSymbol {
+            package: Some("api".to_string()),
+            ..ResolvedSymbol::for_test(name, kind, Language::TypeScript, path)
+        }
+    }
+
+    fn get_sql(query: &SymbolQuery) -> String {
+        let mut statement = sea_query::Query::select();
+
+        statement.column(("symbol", "id")).from("symbol");
+
+        query.apply_sql(&mut statement, !query.needs_post_filter());
+
+        statement.to_string(SqliteQueryBuilder)
+    }
+
+    #[test]
+    pub fn test_compiling_predicates_to_sql() {
+        let query = SymbolQuery::new()
+            .with_name(NameMatcher::Exact("getUser".into()))
+            .with_filter(
+                Predicate::Category(SymbolCategory::Parameter)
+                    .or(Predicate::Language(vec![Language::Go]))
+                    .and(!Predicate::Test)
+                    .and(Predicate::Path("src".into())),
+            )
+            .with_sort(Sort::Name)
+            .with_limit(10);
+
+        assert!(!query.needs_post_filter());
+
+        let sql = get_sql(&query);
+
+        assert!(sql.contains(r#""symbol"."name" = 'getUser'"#));
+        assert!(sql.contains(r#""symbol"."kind" IN ('Parameter'"#));
+        assert!(sql.contains(r#"OR "symbol"."language" IN ('Go')"#));
+        assert!(sql.contains(r#"NOT "symbol"."test""#));
+        assert!(sql.contains(r#""file"."path" LIKE 'src/%' ESCAPE '!'"#));
+        assert!(sql.contains(r#""file"."path" LIKE '%/src/%' ESCAPE '!'"#));
+        assert!(sql.contains(r#"ORDER BY "symbol"."name" ASC, "file"."path" ASC"#));
+        assert!(sql.ends_with("LIMIT 10"));
+    }
+
+    #[test]
+    pub fn test_compiling_prefilters_to_sql() {
+        // Notice, the predicates which can't be evaluated in SQL are left out of the query, as
+        // are any alternatives to them, and sorting and limits are left until after they're
+        // evaluated
+        let query = SymbolQuery::new()
+            .with_filter(
+                Predicate::Kind(vec![SymbolKind::Method])
+                    .and(Predicate::Container(NameMatcher::Fuzzy("Repo".into())))
+                    .and(Predicate::Public.or(Predicate::Package(vec!["api".into()])))
+                    .and(!Predicate::Name(
+                        NameMatcher::regex("^_").expect("Regex should be valid"),
+                    )),
+            )
+            .with_sort(Sort::Name)
+            .with_limit(10);
+
+        assert!(query.needs_post_filter());
+
+        let sql = get_sql(&query);
+
+        assert!(sql.contains(r#""symbol"."kind" IN ('Method')"#));
+        assert!(!sql.contains(r#""file"."package""#));
+        assert!(!sql.contains("ORDER BY"));
+        assert!(!sql.contains("LIMIT"));
+    }
+
+    #[rstest]
+    #[case(Predicate::Kind(vec![SymbolKind::Method]), true)]
+    #[case(Predicate::Category(SymbolCategory::Function), true)]
+    #[case(Predicate::Category(SymbolCategory::Type), false)]
+    #[case(Predicate::Path("src".into()), true)]
+    #[case(Predicate::Path("/workspace/src/api".into()), true)]
+    #[case(Predicate::Path("/workspace/src/ap".into()), false)]
+    #[case(Predicate::Path("api/user.ts".into()), true)]
+    #[case(Predicate::Name(NameMatcher::Fuzzy("gtUsr".into())), true)]
+    #[case(Predicate::Name(NameMatcher::Exact("getuser".into())), false)]
+    #[case(
+        Predicate::Name(NameMatcher::regex("^get[A-Z]").expect("Regex should be valid")),
+        true
+    )]
+    #[case(!Predicate::Package(vec!["api".into()]), false)]
+    #[case(Predicate::Test.or(Predicate::Language(vec![Language::TypeScript])), true)]
+    #[case(Predicate::Test.and(Predicate::Language(vec![Language::TypeScript])), false)]
+    #[case(!!Predicate::Deprecated, false)]
+    pub fn test_matching_predicates(#[case] predicate: Predicate, #[case] expected: bool) {
+        let symbol = get_symbol("getUser", SymbolKind::Method, "/workspace/src/api/user.ts");
+
+        assert_eq!(expected, predicate.matches(&symbol, None));
+    }
+
+    #[rstest]
+    #[case("./src", vec!["/workspace"], "src")]
+    #[case("/workspace/src", vec!["/workspace"], "/workspace/src")]
+    #[case("/elsewhere/src", vec!["tests/fixtures"], "/elsewhere/src")]
+    pub fn test_normalising_paths(
+        #[case] path: &str,
+        #[case] workspaces: Vec<&str>,
+        #[case] expected: &str,
+    ) {
+        let workspaces = workspaces
+            .into_iter()
+            .map(PathBuf::from)
+            .collect::<Vec<_>>();
+
+        assert_eq!(
+            PathBuf::from(expected),
+            super::normalise_path(Path::new(path), &workspaces)
+        );
+    }
+
+    #[test]
+    pub fn test_normalising_paths_inside_relative_workspace() {
+        let workspaces = vec![PathBuf::from("./tests/fixtures")];
+
+        let path = std::path::absolute("tests/fixtures/rust.rs")
+            .expect("Should never fail to make a path absolute");
+
+        assert_eq!(
+            PathBuf::from("tests/fixtures/rust.rs"),
+            super::normalise_path(&path, &workspaces)
+        );
+    }
+
+    #[rstest]
+    #[case(
+        Language::Rust,
+        "pub fn get_user() {}",
+        "get_user",
+        SymbolKind::Function,
+        true
+    )]
+    #[case(
+        Language::Rust,
+        "pub(crate) fn get_user() {}",
+        "get_user",
+        SymbolKind::Function,
+        false
+    )]
+    #[case(
+        Language::Rust,
+        "fn get_user() {}",
+        "get_user",
+        SymbolKind::Function,
+        false
+    )]
+    #[case(
+        Language::TypeScript,
+        "export function getUser() {}",
+        "getUser",
+        SymbolKind::Function,
+        true
+    )]
+    #[case(
+        Language::TypeScript,
+        "function getUser() {}",
+        "getUser",
+        SymbolKind::Function,
+        false
+    )]
+    #[case(
+        Language::Go,
+        "func GetUser() {}",
+        "GetUser",
+        SymbolKind::Function,
+        true
+    )]
+    #[case(
+        Language::Go,
+        "func getUser() {}",
+        "getUser",
+        SymbolKind::Function,
+        false
+    )]
+    #[case(
+        Language::Python,
+        "def _get_user():",
+        "_get_user",
+        SymbolKind::Function,
+        false
+    )]
+    #[case(
+        Language::Lua,
+        "local function get_user() end",
+        "get_user",
+        SymbolKind::Function,
+        false
+    )]
+    #[case(
+        Language::Clojure,
+        "(defn- get-user [])",
+        "get-user",
+        SymbolKind::Function,
+        false
+    )]
+    pub fn test_detecting_public_symbols(
+        #[case] language: Language,
+        #[case] line: &str,
+        #[case] name: &str,
+        #[case] kind: SymbolKind,
+        #[case] expected: bool,
+    ) {
+        let symbol = ResolvedSymbol {
+            language,
+            ..get_symbol(name, kind, "user")
+        };
+
+        assert_eq!(expected, super::is_public(&symbol, &[line]));
+    }
+
+    #[tokio::test]
+    pub async fn test_resolving_structured_query() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        fs::create_dir_all(workspace.path().join("src"))
+            .await
+            .expect("Should never fail to create the src directory");
+
+        fs::write(
+            workspace.path().join("src").join("repository.ts"),
+            "export class UserRepository {\n    find() {}\n\n    private cache() {}\n}\n\nexport class Session {\n    find() {}\n}\n",
+        )
+        .await
+        .expect("Should never fail to write repository.ts");
+
+        fs::write(
+            workspace.path().join("repository.test.ts"),
+            "export class FakeRepository {\n    find() {}\n}\n",
+        )
+        .await
+        .expect("Should never fail to write repository.test.ts");
+
+        let indexer = indexer::DatabaseBackedIndexer::new(storage_path.path(), [workspace.path()])
+            .await
+            .expect("Indexer should be created successfully");
+
+        indexer
+            .index_workspaces()
+            .await
+            .expect("Workspace should be indexed successfully");
+
+        let resolver = DatabaseBackedResolver::new(storage_path.path(), [workspace.path()]);
+
+        let query = SymbolQuery::new()
+            .with_filter(
+                Predicate::Category(SymbolCategory::Function)
+                    .and(Predicate::Container(NameMatcher::Fuzzy("Repo".into())))
+                    .and(Predicate::Path("src".into()))
+                    .and(Predicate::Language(vec![
+                        Language::TypeScript,
+                        Language::Go,
+                    ]))
+                    .and(!Predicate::Test)
+                    .and(Predicate::Public),
+            )
+            .with_sort(Sort::Path);
+
+        let symbols = resolver
+            .search(query, Context::default())
+            .collect::<Vec<_>>()
+            .await;
+
+        assert_eq!(
+            vec![(
+                "find",
+                workspace.path().join("src").join("repository.ts"),
+                2
+            )],
+            symbols
+                .iter()
+                .map(|symbol| (symbol.name.as_str(), symbol.path.clone(), symbol.start_line))
+                .collect::<Vec<_>>()
+        );
+
+        // Notice, sorting and limits apply to every symbol matched, rather than the first found
+        let query = SymbolQuery::new()
+            .with_name(NameMatcher::Exact("find".into()))
+            .with_sort(Sort::Path)
+            .with_limit(2);
+
+        let symbols = resolver
+            .search(query, Context::default())
+            .collect::<Vec<_>>()
+            .await;
+
+        assert_eq!(
+            vec![
+                (workspace.path().join("repository.test.ts"), 2),
+                (workspace.path().join("src").join("repository.ts"), 2),
+            ],
+            symbols
+                .iter()
+                .map(|symbol| (symbol.path.clone(), symbol.start_line))
+                .collect::<Vec<_>>()
+        );
+
+        assert!(
+            symbols
+                .iter()
+                .all(|symbol| symbol.path.starts_with(Path::new(workspace.path())))
+        );
+    }
+
+    #[tokio::test]
+    pub async fn test_resolving_paths_in_relative_workspace() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        // Notice, the files of a relative workspace are stored relative to it too (i.e.
+        // `tests/fixtures/rust.rs`), without a separator before the first component
+        let fixtures = PathBuf::from("tests/fixtures");
+
+        let indexer =
+            indexer::DatabaseBackedIndexer::new(storage_path.path(), [fixtures.as_path()])
+                .await
+                .expect("Indexer should be created successfully");
+
+        indexer
+            .index_workspaces()
+            .await
+            .expect("Workspace should be indexed successfully");
+
+        let resolver = DatabaseBackedResolver::new(storage_path.path(), [fixtures.as_path()]);
+
+        let symbols = resolver
+            .search(
+                SymbolQuery::new().with_filter(Predicate::Path(fixtures.clone())),
+                Context::default(),
+            )
+            .collect::<Vec<_>>()
+            .await;
+
+        assert!(!symbols.is_empty());
+        assert!(
+            symbols
+                .iter()
+                .all(|symbol| symbol.path.starts_with(&fixtures))
+        );
+
+        let file = fixtures.join("rust.rs");
+
+        let symbols = resolver
+            .search(
+                SymbolQuery::new().with_filter(Predicate::Path(
+                    std::path::absolute(&file).expect("Should never fail to make a path absolute"),
+                )),
+                Context::default(),
+            )
+            .collect::<Vec<_>>()
+            .await;
+
+        assert!(!symbols.is_empty());
+        assert!(symbols.iter().all(|symbol| symbol.path == file));
+    }
+}
@@ -0,0 +1,520 @@
+use std::{
+    collections::HashMap,
+    ffi::OsStr,
+    path::{Path, PathBuf},
+};
+
+use crate::{
+    models::{
+        self,
+        parsed::SymbolKind,
+        resolved::{RelatedFile, RelatedSymbol, Relation, ResolvedSymbol},
+    },
+    resolver::{constant, utils},
+};
+
+/// The placeholder in a [`PairingRule`] template, which is substituted for the shared part of
+/// the filename.
+const NAME_PLACEHOLDER: &str = "{name}";
+
+/// The built-in pairing rules, which follow the same conventions as
+/// [`crate::indexer::test_harness::is_part_of_test_harness`].
+const DEFAULT_PAIRING_RULES: [(&str, &str); 34] = [
+    // Rust
+    ("{name}.rs", "{name}_test.rs"),
+    ("{name}.rs", "{name}_tests.rs"),
+    ("{name}.rs", "test_{name}.rs"),
+    // Go
+    ("{name}.go", "{name}_test.go"),
+    // JavaScript / TypeScript
+    ("{name}.js", "{name}.test.js"),
+    ("{name}.js", "{name}.spec.js"),
+    ("{name}.jsx", "{name}.test.jsx"),
+    ("{name}.jsx", "{name}.spec.jsx"),
+    ("{name}.jsx", "{name}.stories.jsx"),
+    ("{name}.ts", "{name}.test.ts"),
+    ("{name}.ts", "{name}.spec.ts"),
+    ("{name}.ts", "{name}.stories.ts"),
+    ("{name}.tsx", "{name}.test.tsx"),
+    ("{name}.tsx", "{name}.spec.tsx"),
+    ("{name}.tsx", "{name}.stories.tsx"),
+    // Python
+    ("{name}.py", "test_{name}.py"),
+    ("{name}.py", "{name}_test.py"),
+    // Lua
+    ("{name}.lua", "{name}_spec.lua"),
+    ("{name}.lua", "{name}_test.lua"),
+    // Clojure
+    ("{name}.clj", "{name}_test.clj"),
+    // PHP
+    ("{name}.php", "{name}Test.php"),
+    // Java
+    ("{name}.java", "{name}Test.java"),
+    // Ruby
+    ("{name}.rb", "{name}_test.rb"),
+    ("{name}.rb", "test_{name}.rb"),
+    ("{name}.rb", "{name}_spec.rb"),
+    // C / C++
+    ("{name}.c", "{name}_test.c"),
+    ("{name}.cpp", "{name}_test.cpp"),
+    ("{name}.cc", "{name}_test.cc"),
+    // C#
+    ("{name}.cs", "{name}Test.cs"),
+    ("{name}.cs", "{name}Tests.cs"),
+    // Kotlin
+    ("{name}.kt", "{name}Test.kt"),
+    ("{name}.kt", "{name}Tests.kt"),
+    // Swift
+    ("{name}.swift", "{name}Tests.swift"),
+    ("{name}.swift", "{name}Test.swift"),
+];
+
+/// A rule which pairs implementation files with their test files, based on their filenames.
+///
+/// Both sides of the rule are filename templates, where `{name}` is substituted for the part of
+/// the filename shared by both files. For example, `{name}.go` and `{name}_test.go` pair
+/// `user.go` with `user_test.go` (and vice versa).
+///
+/// Templates can also include parent directories (i.e. `__tests__/{name}.ts`), which the paired
+/// file must be inside of.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct PairingRule {
+    implementation: String,
+    test: String,
+}
+
+impl PairingRule {
+    /// Create a new pairing rule, from an implementation filename template and a test filename
+    /// template.
+    ///
+    /// Templates without a `{name}` placeholder will never match any files.
+    #[must_use]
+    pub fn new(implementation: impl Into<String>, test: impl Into<String>) -> Self {
+        Self {
+            implementation: implementation.into(),
+            test: test.into(),
+        }
+    }
+
+    /// Get the filename which would be paired with a particular filename under this rule, if
+    /// there is one.
+    ///
+    /// Filenames which match the test template are always treated as tests, even if they also
+    /// match the implementation template (i.e. `user_test.go` also matches `{name}.go`).
+    fn get_pair(&self, filename: &str) -> Option<(String, Relation)> {
+        if let Some(name) = match_template(&self.test, filename) {
+            return Some((
+                self.implementation.replace(NAME_PLACEHOLDER, name),
+                Relation::Implementation,
+            ));
+        }
+
+        match_template(&self.implementation, filename)
+            .map(|name| (self.test.replace(NAME_PLACEHOLDER, name), Relation::Test))
+    }
+}
+
+/// Match a filename against a filename template (i.e. `{name}_test.go`), returning the part of the
+/// filename which was substituted for the placeholder.
+fn match_template<'a>(template: &str, filename: &'a str) -> Option<&'a str> {
+    let (prefix, suffix) = template.split_once(NAME_PLACEHOLDER)?;
+
+    let name = filename.strip_prefix(prefix)?.strip_suffix(suffix)?;
+
+    (!name.is_empty()).then_some(name)
+}
+
+/// Normalise a symbol name so that it can be compared across naming conventions (i.e.
+/// `get_user`, `GetUser`, and `getUser` are all normalised to `getuser`).
+fn normalise_name(name: &str) -> String {
+    name.chars()
+        .filter(|c| c.is_alphanumeric())
+        .flat_map(char::to_lowercase)
+        .collect()
+}
+
+/// Relation resolver, which pairs implementations with their tests (and vice versa) in an
+/// existing index.
+///
+/// Pairing is based on two heuristics:
+///
+/// 1. The filenames of related files follow common naming conventions (see [`PairingRule`]).
+/// 2. Test symbols commonly reference the name of the symbol they test (i.e. `TestGetUser` tests
+///    `GetUser`).
+#[derive(Debug, Clone)]
+pub struct RelationResolver {
+    pool: sqlx::Pool<sqlx::Sqlite>,
+    pairing_rules: Vec<PairingRule>,
+}
+
+impl RelationResolver {
+    /// Initialize a relation resolver at a given database path, for a set of workspaces.
+    ///
+    /// As with [`crate::resolver::DatabaseBackedResolver::new`], the storage path and workspaces
+    /// should match those provided to the indexer.
+    #[must_use]
+    pub fn new<'a, 'b>(
+        storage_path: &'b Path,
+        workspaces: impl IntoIterator<Item = &'a Path>,
+    ) -> Self {
+        Self {
+            pool: utils::get_connection_pool(storage_path, workspaces),
+            pairing_rules: DEFAULT_PAIRING_RULES
+                .iter()
+                .map(|(implementation, test)| PairingRule::new(*implementation, *test))
+                .collect(),
+        }
+    }
+
+    /// Add a custom pairing rule.
+    ///
+    /// Custom rules take precedence over the built-in rules when ranking related files.
+    #[must_use]
+    pub fn with_pairing_rule(mut self, rule: PairingRule) -> Self {
+        self.pairing_rules.insert(0, rule);
+
+        self
+    }
+
+    /// Get the files related to a particular file (i.e. `user.go` and `user_test.go`).
+    ///
+    /// Related files are ranked by how close they are to the file, and then by the precedence
+    /// of the pairing rule which matched them.
+    pub async fn get_related_files(&self, path: &Path) -> Vec<RelatedFile> {
+        let Some(filename) = path.file_name().and_then(OsStr::to_str) else {
+            return Vec::new();
+        };
+
+        // The filenames paired with the file, alongside their relation and rule precedence
+        let mut pairs: HashMap<String, (Relation, usize)> = HashMap::new();
+
+        for (precedence, rule) in self.pairing_rules.iter().enumerate() {
+            if let Some((pair, relation)) = rule.get_pair(filename) {
+                pairs.entry(pair).or_insert((relation, precedence));
+            }
+        }
+
+        if pairs.is_empty() {
+            return Vec::new();
+        }
+
+        let (sql, values) = utils::get_files_by_filename_sql(pairs.keys().map(String::as_str));
+
+        let paths = sqlx::query_scalar_with::<_, String, _>(&sql, values)
+            .fetch_all(&self.pool)
+            .await
+            .unwrap_or_else(|e| {
+                log::error!("Error returned from query listing related files: {e}");
+
+                Vec::new()
+            });
+
+        let mut related_files = paths
+            .into_iter()
+            .map(PathBuf::from)
+            .filter(|related_path| related_path != path)
+            .filter_map(|related_path| {
+                // Filenames are matched case-insensitively by the index, so only exact matches
+                // are kept here
+                let (relation, precedence) = pairs
+                    .iter()
+                    .filter(|(pair, _)| related_path.ends_with(pair))
+                    .map(|(_, rank)| *rank)
+                    .min_by_key(|(_, precedence)| *precedence)?;
+
+                let distance = utils::get_path_distance(path, &related_path)
+                    + utils::get_path_distance(&related_path, path);
+
+                Some((
+                    (distance, precedence),
+                    RelatedFile {
+                        path: related_path,
+                        relation,
+                    },
+                ))
+            })
+            .collect::<Vec<_>>();
+
+        related_files.sort_by(|(a_rank, a), (b_rank, b)| {
+            a_rank.cmp(b_rank).then_with(|| a.path.cmp(&b.path))
+        });
+
+        related_files
+            .into_iter()
+            .map(|(_, related_file)| related_file)
+            .collect()
+    }
+
+    /// Get the symbols related to a particular symbol.
+    ///
+    /// For an implementation, this is its tests: any test modules in the same file (i.e.
+    /// `#[cfg(test)] mod tests`), and test symbols which reference its name, either in the same
+    /// file or in related test files ([`RelationResolver::get_related_files`]).
+    ///
+    /// For a test, this is the implementation symbols whose name it references.
+    ///
+    /// Related symbols are ranked by the rank of the file they are defined in (with the same
+    /// file first), and then by their position in the file.
+    pub async fn get_related_symbols(&self, symbol: &ResolvedSymbol) -> Vec<RelatedSymbol> {
+        let related_files = self.get_related_files(&symbol.path).await;
+
+        let (sql, values) = utils::get_symbols_in_files_sql(
+            std::iter::once(symbol.path.as_path())
+                .chain(related_files.iter().map(|file| file.path.as_path())),
+        );
+
+        let candidates = sqlx::query_as_with::<_, ResolvedSymbol, _>(&sql, values)
+            .fetch_all(&self.pool)
+            .await
+            .unwrap_or_else(|e| {
+                log::error!("Error returned from query listing symbols in related files: {e}");
+
+                Vec::new()
+            });
+
+        let is_test = symbol.test;
+
+        let relation = if is_test {
+            Relation::Implementation
+        } else {
+            Relation::Test
+        };
+
+        let name = normalise_name(&symbol.name);
+
+        let mut related_symbols = candidates
+            .into_iter()
+            .filter(|candidate| candidate.id != symbol.id)
+            .filter_map(|candidate| {
+                let file_rank = if candidate.path == symbol.path {
+                    0
+                } else {
+                    related_files
+                        .iter()
+                        .position(|file| file.path == candidate.path && file.relation == relation)?
+                        + 1
+                };
+
+                let is_related = match (is_test, file_rank) {
+                    // Tests in the same file as an implementation are part of an inline test
+                    // module (i.e. `#[cfg(test)] mod tests`)
+                    (true, 0) => !candidate.test && is_referenced_by(&candidate, &name),
+                    (true, _) => is_referenced_by(&candidate, &name),
+                    (false, 0) => {
+                        candidate.test
+                            && (is_inline_test_module(&candidate)
+                                || references_name(&candidate, &name))
+                    }
+                    (false, _) => references_name(&candidate, &name),
+                };
+
+                is_related.then_some(((file_rank, candidate.start_line), candidate))
+            })
+            .collect::<Vec<_>>();
+
+        related_symbols
+            .sort_by(|(a_rank, a), (b_rank, b)| a_rank.cmp(b_rank).then_with(|| a.cmp(b)));
+
+        related_symbols
+            .into_iter()
+            .map(|(_, symbol)| RelatedSymbol { symbol, relation })
+            .collect()
+    }
+}
+
+/// Check if a symbol is an inline test module (i.e. Rust's `#[cfg(test)] mod tests`).
+fn is_inline_test_module(symbol: &models::resolved::ResolvedSymbol) -> bool {
+    symbol.kind == SymbolKind::Module && symbol.test
+}
+
+/// Check if a symbol's name is referenced by a (normalised) name, i.e. a test's name.
+fn is_referenced_by(symbol: &models::resolved::ResolvedSymbol, name: &str) -> bool {
+    // Symbols like packages and parameters commonly share names with the things they contain, so
+    // would otherwise match far too many tests
+    if matches!(
+        symbol.kind,
+        SymbolKind::Package
+            | SymbolKind::Module
+            | SymbolKind::Parameter
+            | SymbolKind::SelfParameter
+    ) {
+        return false;
+    }
+
+    let symbol_name = normalise_name(&symbol.name);
+
+    symbol_name.len() >= constant::MIN_RELATED_SYMBOL_NAME_LENGTH && name.contains(&symbol_name)
+}
+
+/// Check if a symbol references a (normalised) name, as part of its own name.
+fn references_name(symbol: &models::resolved::ResolvedSymbol, name: &str) -> bool {
+    name.len() >= constant::MIN_RELATED_SYMBOL_NAME_LENGTH
+        && normalise_name(&symbol.name).contains(name)
+}
+
+#[cfg(test)]
+mod tests {
+    use rstest::rstest;
+    use tempfile::tempdir;
+    use tokio::fs;
+    use tokio_stream::StreamExt;
+
+    use crate::{
+        indexer::{self, Indexer},
+        models::resolved::{Relation, ResolvedSymbol},
+        resolver::{self, Resolver},
+    };
+
+    #[rstest]
+    #[case("user.go", vec![("user_test.go", Relation::Test)])]
+    #[case("user_test.go", vec![("user.go", Relation::Implementation)])]
+    #[case("service.py", vec![("test_service.py", Relation::Test), ("service_test.py", Relation::Test)])]
+    #[case("test_service.py", vec![("service.py", Relation::Implementation), ("test_service_test.py", Relation::Test)])]
+    #[case("button.tsx", vec![("button.test.tsx", Relation::Test), ("button.spec.tsx", Relation::Test), ("button.stories.tsx", Relation::Test)])]
+    #[case("README.md", vec![])]
+    pub fn test_pairing_filenames(
+        #[case] filename: &str,
+        #[case] expected_pairs: Vec<(&str, Relation)>,
+    ) {
+        let pairs = super::DEFAULT_PAIRING_RULES
+            .iter()
+            .map(|(implementation, test)| super::PairingRule::new(*implementation, *test))
+            .filter_map(|rule| rule.get_pair(filename))
+            .collect::<Vec<_>>();
+
+        assert_eq!(
+            expected_pairs
+                .into_iter()
+                .map(|(pair, relation)| (pair.to_string(), relation))
+                .collect::<Vec<_>>(),
+            pairs
+        );
+    }
+
+    #[test]
+    pub fn test_custom_pairing_rule() {
+        let rule = super::PairingRule::new("{name}.ts", "__tests__/{name}.ts");
+
+        assert_eq!(
+            Some(("__tests__/user.ts".to_string(), Relation::Test)),
+            rule.get_pair("user.ts")
+        );
+
+        // Templates without a placeholder never match
+        assert!(
+            super::PairingRule::new("user.ts", "user.test.ts")
+                .get_pair("user.ts")
+                .is_none()
+        );
+    }
+
+    #[tokio::test]
+    pub async fn test_resolving_related_files_and_symbols() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        let implementation = workspace.path().join("user.go");
+        let test = workspace.path().join("user_test.go");
+        let inline = workspace.path().join("config.rs");
+
+        fs::write(
+            &implementation,
+            "package user\n\nfunc GetUser() {}\n\nfunc DeleteUser() {}\n",
+        )
+        .await
+        .expect("Should never fail to write a file into the workspace");
+        fs::write(
+            &test,
+            "package user\n\nfunc TestGetUser(t *testing.T) {}\n\nfunc TestDeleteUser(t *testing.T) {}\n",
+        )
+        .await
+        .expect("Should never fail to write a file into the workspace");
+        fs::write(
+            &inline,
+            "fn parse_config() {}\n\n#[cfg(test)]\nmod tests {\n    #[test]\n    fn test_parse_config() {}\n}\n",
+        )
+        .await
+        .expect("Should never fail to write a file into the workspace");
+
+        let workspaces = vec![workspace.path()];
+
+        let indexer = indexer::DatabaseBackedIndexer::new(storage_path.path(), workspaces.clone())
+            .await
+            .expect("Should be able to create the empty index");
+
+        assert!(indexer.index_workspaces().await.is_ok());
+
+        let resolver =
+            resolver::DatabaseBackedResolver::new(storage_path.path(), workspaces.clone());
+        let relation_resolver = super::RelationResolver::new(storage_path.path(), workspaces);
+
+        let related_files = relation_resolver.get_related_files(&implementation).await;
+
+        assert_eq!(1, related_files.len());
+        assert_eq!(test, related_files[0].path);
+        assert_eq!(Relation::Test, related_files[0].relation);
+
+        let related_files = relation_resolver.get_related_files(&test).await;
+
+        assert_eq!(1, related_files.len());
+        assert_eq!(implementation, related_files[0].path);
+        assert_eq!(Relation::Implementation, related_files[0].relation);
+
+        let symbols: Vec<ResolvedSymbol> = resolver
+            .query(String::new(), resolver::Context::default())
+            .collect()
+            .await;
+
+        let get_symbol = |name: &str| {
+            symbols
+                .iter()
+                .find(|symbol| symbol.name == name)
+                .expect("Symbol should have been indexed")
+        };
+
+        // Implementations are paired with the tests that reference them
+        let related_symbols = relation_resolver
+            .get_related_symbols(get_symbol("GetUser"))
+            .await;
+
+        assert_eq!(
+            vec![("TestGetUser", Relation::Test)],
+            related_symbols
+                .iter()
+                .map(|related| (related.symbol.name.as_str(), related.relation))
+                .collect::<Vec<_>>()
+        );
+
+        // ...and tests are paired back with the implementation
+        let related_symbols = relation_resolver
+            .get_related_symbols(get_symbol("TestDeleteUser"))
+            .await;
+
+        assert_eq!(
+            vec![("DeleteUser", Relation::Implementation)],
+            related_symbols
+                .iter()
+                .map(|related| (related.symbol.name.as_str(), related.relation))
+                .collect::<Vec<_>>()
+        );
+
+        // Inline test modules are paired with the implementation in the same file
+        let related_symbols = relation_resolver
+            .get_related_symbols(get_symbol("parse_config"))
+            .await;
+
+        assert_eq!(
+            vec![
+                ("tests", Relation::Test),
+                ("test_parse_config", Relation::Test)
+            ],
+            related_symbols
+                .iter()
+                .map(|related| (related.symbol.name.as_str(), related.relation))
+                .collect::<Vec<_>>()
+        );
+    }
+}
@@ -0,0 +1,435 @@
+use std::path::{Path, PathBuf};
+
+use crate::{
+    models::{
+        parsed::Language,
+        resolved::{
+            ChangeAnnotation, Confidence, Position, Reference, ResolvedSymbol, TextEdit, TextRange,
+            WorkspaceEdit,
+        },
+    },
+    resolver::utils,
+};
+
+/// An occurrence of an identifier in a file.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+struct Occurrence {
+    /// The line of the occurrence, starting from 0.
+    line: usize,
+
+    /// The byte offset of the occurrence on its line, starting from 0.
+    byte: usize,
+
+    /// The range of the occurrence, in the form the Language Server Protocol expects.
+    range: TextRange,
+}
+
+/// The signals used to decide how confident a rename of an occurrence is.
+#[allow(clippy::struct_excessive_bools)]
+#[derive(Debug, Default, Clone, Copy)]
+struct Signals {
+    /// The occurrence is in the file the symbol is defined in.
+    same_file: bool,
+
+    /// The occurrence is in a file of the same language as the symbol.
+    same_language: bool,
+
+    /// The occurrence is in a file of the same package as the symbol.
+    same_package: bool,
+
+    /// The occurrence is in a file which imports the module the symbol is defined in.
+    imports_definition: bool,
+
+    /// The occurrence is in generated code, which should never be edited by hand.
+    generated: bool,
+
+    /// The occurrence is the definition of a different symbol, which shares the same name.
+    other_definition: bool,
+}
+
+impl Signals {
+    /// Decide how confident a rename is, from its signals.
+    const fn get_confidence(self) -> Confidence {
+        if self.generated || self.other_definition {
+            return Confidence::Low;
+        }
+
+        if self.same_file || (self.same_language && self.imports_definition) {
+            return Confidence::High;
+        }
+
+        if (self.same_language && self.same_package) || self.imports_definition {
+            return Confidence::Medium;
+        }
+
+        Confidence::Low
+    }
+}
+
+/// Find every occurrence of an identifier in some content, ignoring occurrences which are only
+/// part of a longer identifier (i.e. `user` in `username`).
+fn find_occurrences(content: &str, name: &str) -> Vec<Occurrence> {
+    let mut occurrences = Vec::new();
+
+    if name.is_empty() {
+        return occurrences;
+    }
+
+    for (line_index, line) in content.lines().enumerate() {
+        for (byte, _) in line.match_indices(name) {
+            let end = byte + name.len();
+
+            let is_bounded = !line[..byte].ends_with(utils::is_identifier_char)
+                && !line[end..].starts_with(utils::is_identifier_char);
+
+            if !is_bounded {
+                continue;
+            }
+
+            let (Ok(line_number), Ok(start), Ok(length)) = (
+                u32::try_from(line_index),
+                u32::try_from(line[..byte].encode_utf16().count()),
+                u32::try_from(name.encode_utf16().count()),
+            ) else {
+                continue;
+            };
+
+            occurrences.push(Occurrence {
+                line: line_index,
+                byte,
+                range: TextRange {
+                    start: Position {
+                        line: line_number,
+                        character: start,
+                    },
+                    end: Position {
+                        line: line_number,
+                        character: start + length,
+                    },
+                },
+            });
+        }
+    }
+
+    occurrences
+}
+
+/// Rename resolver, which proposes the edits needed to rename a symbol across the workspace
+/// (in any language), as an LSP-style [`WorkspaceEdit`].
+///
+/// Renames are best-effort, and based on names alone: every occurrence of the symbol's name in
+/// an indexed file is proposed, grouped by how confident Onoma is that the occurrence refers to
+/// the same symbol. Onoma never applies the edits itself.
+#[derive(Debug, Clone)]
+pub struct RenameResolver {
+    pool: sqlx::Pool<sqlx::Sqlite>,
+}
+
+impl RenameResolver {
+    /// Initialize a rename resolver at a given database path, for a set of workspaces.
+    ///
+    /// As with [`crate::resolver::DatabaseBackedResolver::new`], the storage path and workspaces
+    /// should match those provided to the indexer.
+    #[must_use]
+    pub fn new<'a, 'b>(
+        storage_path: &'b Path,
+        workspaces: impl IntoIterator<Item = &'a Path>,
+    ) -> Self {
+        Self {
+            pool: utils::get_connection_pool(storage_path, workspaces),
+        }
+    }
+
+    /// Get every plausible reference to a symbol (i.e. every occurrence of its name in an
+    /// indexed file, including the definition itself), in the order they appear in each file.
+    ///
+    /// Each reference is annotated with its [`Confidence`], based on whether it's in the same
+    /// file, language and package as the symbol, whether its file imports the symbol's module,
+    /// and whether it's actually the definition of a different symbol with the same name.
+    pub async fn get_references(&self, symbol: &ResolvedSymbol) -> Vec<Reference> {
+        let (sql, values) = utils::get_indexed_files_sql();
+
+        let files = sqlx::query_as_with::<_, (String, Option<String>, bool), _>(&sql, values)
+            .fetch_all(&self.pool)
+            .await
+            .unwrap_or_else(|e| {
+                log::error!("Error returned from query listing indexed files: {e}");
+
+                Vec::new()
+            });
+
+        let (sql, values) = utils::get_symbols_by_name_sql(&symbol.name);
+
+        let definitions = sqlx::query_as_with::<_, ResolvedSymbol, _>(&sql, values)
+            .fetch_all(&self.pool)
+            .await
+            .unwrap_or_else(|e| {
+                log::error!("Error returned from query listing symbols with the same name: {e}");
+
+                Vec::new()
+            });
+
+        let module_name = utils::get_module_name(&symbol.path);
+
+        let mut references = Vec::new();
+
+        for (path, package, generated) in files {
+            let path = PathBuf::from(path);
+
+            let Ok(content) = tokio::fs::read_to_string(&path).await else {
+                log::debug!("Unable to read {} for finding references", path.display());
+
+                continue;
+            };
+
+            let occurrences = find_occurrences(&content, &symbol.name);
+
+            if occurrences.is_empty() {
+                continue;
+            }
+
+            let same_file = path == symbol.path;
+
+            let file_signals = Signals {
+                same_file,
+                same_language: Language::try_from(path.as_path())
+                    .is_ok_and(|language| language == symbol.language),
+                same_package: package.is_some() && package == symbol.package,
+                imports_definition: !same_file
+                    && module_name
+                        .is_some_and(|module_name| utils::imports_module(&content, module_name)),
+                generated,
+                other_definition: false,
+            };
+
+            let lines = content.lines().collect::<Vec<_>>();
+
+            references.extend(occurrences.into_iter().map(|occurrence| {
+                let other_definition = definitions.iter().any(|definition| {
+                    definition.id != symbol.id
+                        && definition.path == path
+                        && usize::try_from(definition.start_line).ok() == Some(occurrence.line + 1)
+                        && usize::try_from(definition.start_column).ok()
+                            == Some(occurrence.byte + 1)
+                        // Redeclarations in the same file (i.e. overloads) are the same symbol
+                        && !(same_file && definition.kind == symbol.kind)
+                        // Imports bind the same symbol under the same name
+                        && !lines
+                            .get(occurrence.line)
+                            .is_some_and(|line| utils::is_import_line(line))
+                });
+
+                let signals = Signals {
+                    other_definition,
+                    ..file_signals
+                };
+
+                Reference {
+                    path: path.clone(),
+                    range: occurrence.range,
+                    confidence: signals.get_confidence(),
+                }
+            }));
+        }
+
+        references
+    }
+
+    /// Get the edits needed to rename a symbol (and every plausible reference to it) to a new
+    /// name.
+    ///
+    /// Each edit is annotated with the [`Confidence`] of the reference it replaces (see
+    /// [`RenameResolver::get_references`]).
+    ///
+    /// Returns [`None`] if the new name is not a valid identifier, or is the same as the
+    /// symbol's current name.
+    pub async fn get_rename_edit(
+        &self,
+        symbol: &ResolvedSymbol,
+        new_name: &str,
+    ) -> Option<WorkspaceEdit> {
+        if new_name.is_empty()
+            || new_name == symbol.name
+            || !new_name
+                .chars()
+                .all(|c| utils::is_identifier_char(c) || c == '-')
+        {
+            return None;
+        }
+
+        let mut edit = WorkspaceEdit::default();
+
+        for reference in self.get_references(symbol).await {
+            edit.change_annotations
+                .entry(reference.confidence)
+                .or_insert_with(|| get_change_annotation(reference.confidence));
+
+            edit.changes
+                .entry(reference.path)
+                .or_default()
+                .push(TextEdit {
+                    range: reference.range,
+                    new_text: new_name.to_string(),
+                    annotation_id: reference.confidence,
+                });
+        }
+
+        Some(edit)
+    }
+}
+
+/// Get the description of a group of edits with a particular confidence.
+fn get_change_annotation(confidence: Confidence) -> ChangeAnnotation {
+    let (label, description) = match confidence {
+        Confidence::High => (
+            "Rename",
+            "Occurrences in the same file as the symbol, or in files of the same language which import it.",
+        ),
+        Confidence::Medium => (
+            "Rename likely references",
+            "Occurrences in files of the same package, or in files which import the symbol's module from another language.",
+        ),
+        Confidence::Low => (
+            "Rename possible references",
+            "Occurrences which may refer to a different symbol with the same name, or which are in generated code.",
+        ),
+    };
+
+    ChangeAnnotation {
+        label: label.to_string(),
+        needs_confirmation: confidence != Confidence::High,
+        description: description.to_string(),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use rstest::rstest;
+    use tempfile::tempdir;
+    use tokio::fs;
+    use tokio_stream::StreamExt;
+
+    use crate::{
+        indexer::{self, Indexer},
+        models::resolved::{Confidence, ResolvedSymbol},
+        resolver::{self, Resolver},
+    };
+
+    #[rstest]
+    #[case("getUser(user, username);", "user", vec![(0, 8)])]
+    #[case("const user = 1;\n// user\nconst é = user;", "user", vec![(0, 6), (1, 3), (2, 10)])]
+    #[case("$user", "user", vec![])]
+    pub fn test_finding_occurrences(
+        #[case] content: &str,
+        #[case] name: &str,
+        #[case] expected_occurrences: Vec<(u32, u32)>,
+    ) {
+        assert_eq!(
+            expected_occurrences,
+            super::find_occurrences(content, name)
+                .iter()
+                .map(|occurrence| (
+                    occurrence.range.start.line,
+                    occurrence.range.start.character
+                ))
+                .collect::<Vec<_>>()
+        );
+    }
+
+    #[tokio::test]
+    pub async fn test_resolving_rename_edits() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        let definition = workspace.path().join("user.ts");
+        let importer = workspace.path().join("app.ts");
+        let unrelated = workspace.path().join("other.py");
+
+        fs::write(
+            &definition,
+            "export function getUser() {}\n\nexport const current = getUser();\n",
+        )
+        .await
+        .expect("Should never fail to write a file into the workspace");
+        fs::write(
+            &importer,
+            "import { getUser } from \"./user\";\n\ngetUser();\n",
+        )
+        .await
+        .expect("Should never fail to write a file into the workspace");
+        fs::write(&unrelated, "def getUser():\n    pass\n")
+            .await
+            .expect("Should never fail to write a file into the workspace");
+
+        let workspaces = vec![workspace.path()];
+
+        let indexer = indexer::DatabaseBackedIndexer::new(storage_path.path(), workspaces.clone())
+            .await
+            .expect("Should be able to create the empty index");
+
+        assert!(indexer.index_workspaces().await.is_ok());
+
+        let resolver =
+            resolver::DatabaseBackedResolver::new(storage_path.path(), workspaces.clone());
+        let rename_resolver = super::RenameResolver::new(storage_path.path(), workspaces);
+
+        let symbols: Vec<ResolvedSymbol> = resolver
+            .query(String::from("getUser"), resolver::Context::default())
+            .collect()
+            .await;
+
+        let symbol = symbols
+            .iter()
+            .find(|symbol| symbol.name == "getUser" && symbol.path == definition)
+            .expect("Symbol should have been indexed");
+
+        // Invalid, or unchanged, names are never renamed
+        assert!(rename_resolver.get_rename_edit(symbol, "").await.is_none());
+        assert!(
+            rename_resolver
+                .get_rename_edit(symbol, "getUser")
+                .await
+                .is_none()
+        );
+
+        let edit = rename_resolver
+            .get_rename_edit(symbol, "findUser")
+            .await
+            .expect("Rename should be valid");
+
+        let get_edits = |path| {
+            edit.changes
+                .get(path)
+                .expect("File should have been edited")
+                .iter()
+                .map(|edit| {
+                    assert_eq!("findUser", edit.new_text);
+
+                    (edit.range.start.line, edit.annotation_id)
+                })
+                .collect::<Vec<_>>()
+        };
+
+        assert_eq!(
+            vec![(0, Confidence::High), (2, Confidence::High)],
+            get_edits(&definition)
+        );
+        assert_eq!(
+            vec![(0, Confidence::High), (2, Confidence::High)],
+            get_edits(&importer)
+        );
+        assert_eq!(vec![(0, Confidence::Low)], get_edits(&unrelated));
+
+        assert!(!edit.change_annotations[&Confidence::High].needs_confirmation);
+        assert!(edit.change_annotations[&Confidence::Low].needs_confirmation);
+
+        // Every edit in the rename corresponds to a reference
+        assert_eq!(
+            edit.changes.values().map(Vec::len).sum::<usize>(),
+            rename_resolver.get_references(symbol).await.len()
+        );
+    }
+}
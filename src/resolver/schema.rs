@@ -0,0 +1,297 @@
+use std::path::Path;
+
+use itertools::Itertools;
+
+use crate::{
+    models::resolved::{ResolvedSymbol, SchemaDefinition},
+    resolver::utils,
+};
+
+/// Prefixes which code generators commonly add to the names of the definitions they generate
+/// code for (i.e. `NewUserServiceClient` in Go, or `useGetUserQuery` in GraphQL Code Generator).
+///
+/// Prefixes are only stripped when followed by an uppercase letter, or are themselves
+/// terminated by an underscore.
+const GENERATED_NAME_PREFIXES: [&str; 6] = [
+    // gRPC
+    "New",
+    "Register",
+    "Unimplemented",
+    "Unsafe",
+    "add_",
+    // GraphQL Code Generator
+    "use",
+];
+
+/// Suffixes which code generators commonly add to the names of the definitions they generate
+/// code for (i.e. `UserServiceClient` in Go, or `GetUserQueryVariables` in GraphQL Code
+/// Generator).
+///
+/// More specific suffixes come first, as only the first matching suffix is stripped at a time.
+const GENERATED_NAME_SUFFIXES: [&str; 24] = [
+    // gRPC
+    "_to_server",
+    "Client",
+    "Server",
+    "Stub",
+    "Servicer",
+    "Handler",
+    // GraphQL Code Generator
+    "LazyQueryHookResult",
+    "QueryHookResult",
+    "MutationHookResult",
+    "QueryVariables",
+    "MutationVariables",
+    "SubscriptionVariables",
+    "QueryResult",
+    "MutationResult",
+    "MutationOptions",
+    "MutationFn",
+    "FragmentDoc",
+    "Document",
+    "Fragment",
+    "LazyQuery",
+    "SuspenseQuery",
+    "Query",
+    "Mutation",
+    "Subscription",
+];
+
+/// Get the names of the schema definitions a generated symbol could originate from, in order of
+/// how likely they are to be the origin.
+///
+/// The symbol's own name always comes first (i.e. the `User` struct generated from the `User`
+/// message), followed by the name without any prefixes and suffixes added by code generators
+/// (i.e. `UserService` for `NewUserServiceClient`). Names which are joined with underscores are
+/// also treated as nested definitions (i.e. `User_Address` for `User.Address`).
+fn get_schema_names(name: &str) -> Vec<String> {
+    let mut names = vec![name];
+
+    let unprefixed = GENERATED_NAME_PREFIXES.iter().find_map(|prefix| {
+        name.strip_prefix(prefix).filter(|rest| {
+            prefix.ends_with('_') || rest.starts_with(|c: char| c.is_ascii_uppercase())
+        })
+    });
+
+    if let Some(unprefixed) = unprefixed {
+        names.push(unprefixed);
+    }
+
+    let mut current = unprefixed.unwrap_or(name);
+
+    while let Some(unsuffixed) = GENERATED_NAME_SUFFIXES
+        .iter()
+        .find_map(|suffix| current.strip_suffix(suffix).filter(|rest| !rest.is_empty()))
+    {
+        names.push(unsuffixed);
+
+        current = unsuffixed;
+    }
+
+    names
+        .into_iter()
+        .flat_map(|name| {
+            // Nested definitions are only joined with underscores when every part is a type
+            // name (i.e. `User_Address`, but not `add_UserServiceServicer_to_server`)
+            let nested = (name.contains('_')
+                && name
+                    .split('_')
+                    .all(|part| part.starts_with(|c: char| c.is_ascii_uppercase())))
+            .then(|| name.replace('_', "."));
+
+            std::iter::once(name.to_string()).chain(nested)
+        })
+        .unique()
+        .collect()
+}
+
+/// Schema resolver, which links the symbols in generated code (i.e. `user.pb.go`) back to the
+/// schema definitions they were generated from (i.e. the `User` message in `user.proto`), in an
+/// existing index.
+///
+/// Links are based on the naming conventions of common code generators, such as `protoc` and
+/// GraphQL Code Generator.
+#[derive(Debug, Clone)]
+pub struct SchemaResolver {
+    pool: sqlx::Pool<sqlx::Sqlite>,
+}
+
+impl SchemaResolver {
+    /// Initialize a schema resolver at a given database path, for a set of workspaces.
+    ///
+    /// As with [`crate::resolver::DatabaseBackedResolver::new`], the storage path and workspaces
+    /// should match those provided to the indexer.
+    #[must_use]
+    pub fn new<'a, 'b>(
+        storage_path: &'b Path,
+        workspaces: impl IntoIterator<Item = &'a Path>,
+    ) -> Self {
+        Self {
+            pool: utils::get_connection_pool(storage_path, workspaces),
+        }
+    }
+
+    /// Get the schema definitions a symbol in generated code originates from, so that they can
+    /// be offered alongside (or instead of) the generated symbol.
+    ///
+    /// Schema definitions are ranked by how closely their name matches the symbol's name (with
+    /// exact matches first), and then by their location.
+    ///
+    /// Symbols which aren't in generated code never have any schema definitions.
+    pub async fn get_schema_definitions(&self, symbol: &ResolvedSymbol) -> Vec<SchemaDefinition> {
+        if !symbol.generated {
+            return Vec::new();
+        }
+
+        let names = get_schema_names(&symbol.name);
+
+        let (sql, values) = utils::get_schema_definitions_sql(names.iter().map(String::as_str));
+
+        let definitions = sqlx::query_as_with::<_, SchemaDefinition, _>(&sql, values)
+            .fetch_all(&self.pool)
+            .await
+            .unwrap_or_else(|e| {
+                log::error!("Error returned from query listing schema definitions: {e}");
+
+                Vec::new()
+            });
+
+        definitions
+            .into_iter()
+            .filter_map(|definition| {
+                let rank = names.iter().position(|name| {
+                    definition.name == *name
+                        || definition
+                            .name
+                            .strip_suffix(name.as_str())
+                            .is_some_and(|parent| parent.ends_with('.'))
+                })?;
+
+                Some((
+                    (rank, definition.path.clone(), definition.start_line),
+                    definition,
+                ))
+            })
+            .sorted_by(|(a_key, _), (b_key, _)| a_key.cmp(b_key))
+            .map(|(_, definition)| definition)
+            .collect()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use rstest::rstest;
+    use tempfile::tempdir;
+    use tokio::fs;
+    use tokio_stream::StreamExt;
+
+    use crate::{
+        indexer::{self, Indexer},
+        models::resolved::ResolvedSymbol,
+        resolver::{self, Resolver},
+    };
+
+    #[rstest]
+    #[case("User", vec!["User"])]
+    #[case("User_Address", vec!["User_Address", "User.Address"])]
+    #[case("NewUserServiceClient", vec!["NewUserServiceClient", "UserServiceClient", "UserService"])]
+    #[case(
+        "add_UserServiceServicer_to_server",
+        vec!["add_UserServiceServicer_to_server", "UserServiceServicer_to_server", "UserServiceServicer", "UserService"]
+    )]
+    #[case("useGetUserLazyQuery", vec!["useGetUserLazyQuery", "GetUserLazyQuery", "GetUser"])]
+    #[case("GetUserQueryVariables", vec!["GetUserQueryVariables", "GetUser"])]
+    #[case("username", vec!["username"])]
+    pub fn test_getting_schema_names(#[case] name: &str, #[case] expected_names: Vec<&str>) {
+        assert_eq!(expected_names, super::get_schema_names(name));
+    }
+
+    #[tokio::test]
+    pub async fn test_resolving_schema_definitions() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        let schema = workspace.path().join("user.proto");
+        let generated = workspace.path().join("user.pb.go");
+        let handwritten = workspace.path().join("server.go");
+
+        fs::write(
+            &schema,
+            "syntax = \"proto3\";\n\nmessage User {\n  message Address {}\n}\n\nservice UserService {\n  rpc GetUser(User) returns (User);\n}\n",
+        )
+        .await
+        .expect("Should never fail to write a file into the workspace");
+        fs::write(
+            &generated,
+            "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage api\n\ntype User struct{}\n\ntype User_Address struct{}\n\nfunc NewUserServiceClient() {}\n",
+        )
+        .await
+        .expect("Should never fail to write a file into the workspace");
+        fs::write(&handwritten, "package api\n\nfunc GetUser() {}\n")
+            .await
+            .expect("Should never fail to write a file into the workspace");
+
+        let workspaces = vec![workspace.path()];
+
+        let indexer = indexer::DatabaseBackedIndexer::new(storage_path.path(), workspaces.clone())
+            .await
+            .expect("Should be able to create the empty index");
+
+        assert!(indexer.index_workspaces().await.is_ok());
+
+        let resolver =
+            resolver::DatabaseBackedResolver::new(storage_path.path(), workspaces.clone());
+        let schema_resolver = super::SchemaResolver::new(storage_path.path(), workspaces);
+
+        let symbols: Vec<ResolvedSymbol> = resolver
+            .query(String::new(), resolver::Context::default())
+            .collect()
+            .await;
+
+        let get_symbol = |name: &str| {
+            symbols
+                .iter()
+                .find(|symbol| symbol.name == name)
+                .expect("Symbol should have been indexed")
+        };
+
+        for (name, expected_definitions) in [
+            ("User", vec!["User"]),
+            ("User_Address", vec!["User.Address"]),
+            ("NewUserServiceClient", vec!["UserService"]),
+        ] {
+            let symbol = get_symbol(name);
+
+            assert!(symbol.generated, "{name} should be generated");
+
+            let definitions = schema_resolver.get_schema_definitions(symbol).await;
+
+            assert_eq!(
+                expected_definitions,
+                definitions
+                    .iter()
+                    .map(|definition| definition.name.as_str())
+                    .collect::<Vec<_>>()
+            );
+            assert!(
+                definitions
+                    .iter()
+                    .all(|definition| definition.path == schema)
+            );
+        }
+
+        // Symbols which aren't generated are never linked, even if they share a name
+        let symbol = get_symbol("GetUser");
+
+        assert!(!symbol.generated);
+        assert!(
+            schema_resolver
+                .get_schema_definitions(symbol)
+                .await
+                .is_empty()
+        );
+    }
+}
@@ -1,10 +1,10 @@
-use std::{ffi::OsStr, path::Path};
+use std::{ffi::OsStr, path::Path, time::Duration};
 
 use crate::{
     models::{self},
     resolver::{
         constant::{self, DEFAULT_SCORE},
-        utils, weight,
+        synonym, utils, weight,
     },
 };
 
@@ -76,21 +76,47 @@ pub fn fuzzy_match(
     )
 }
 
+/// The signals, derived from the [`crate::resolver::Context`] of a query, which influence the
+/// score of every symbol resolved for that query.
+#[derive(Debug, Default, Clone, Copy)]
+pub struct ScoringContext<'a> {
+    /// The currently focused file, when the query began.
+    pub current_file: Option<&'a Path>,
+
+    /// The package which owns the currently focused file, if there is one.
+    pub current_package: Option<&'a str>,
+
+    /// The owners (i.e. the current user, and their teams) the query is being executed on
+    /// behalf of.
+    pub current_owners: &'a [String],
+
+    /// The penalty (in score points) applied to deprecated symbols, if the default penalty has
+    /// been overridden.
+    pub deprecated_symbol_penalty: Option<u16>,
+
+    /// The IDs of the symbols which have been pinned (see [`crate::pin`]).
+    pub pinned_symbols: &'a [i64],
+
+    /// The time the query began, and the half-life of the bonus for recently changed symbols, if
+    /// recency should influence scoring.
+    pub recency: Option<(chrono::DateTime<chrono::Utc>, Duration)>,
+}
+
 /// Calculate a score for a given symbol, using a set of results from fuzzy matching ([`fuzzy_match`]),
-/// the provided query, and the current file which is open (if available).
+/// the provided query, and the context of the query (i.e. the current file which is open, if available).
 ///
 /// In practice, this weights all of these elements, along with derived heuristics like
-/// whether the symbol looks to be part of a test harness ([`utils::is_part_of_test_harness`]),
+/// whether the symbol is part of a test harness ([`models::resolved::ResolvedSymbol::test`]),
 /// or entrypoint file ([`utils::is_entrypoint_file`]) to provide a final sortable score.
 ///
 /// The default score, if no bonuses or penalties are applied is defined as [`constant::DEFAULT_SCORE`].
 /// Any score returned which is _below_ the default can be assumed to have occurred more penalties
 /// than bonuses, and thus not a good match.
-pub fn calculate_score<'a, 'b>(
+pub fn calculate_score<'a>(
     query: &str,
     symbol: &models::resolved::ResolvedSymbol,
     fuzzy_matches: impl Iterator<Item = &'a frizbee::Match>,
-    current_file: Option<&'b Path>,
+    scoring_ctx: &ScoringContext<'_>,
 ) -> i64 {
     let filename = if let Some(Some(filename)) = symbol.path.file_name().map(OsStr::to_str) {
         Some(filename)
@@ -140,7 +166,7 @@ pub fn calculate_score<'a, 'b>(
         _ => 0,
     };
 
-    let test_harness_penalty = if utils::is_part_of_test_harness(symbol.path.as_path()) {
+    let test_harness_penalty = if symbol.test {
         // Penalty for symbols which are part of a test harness (i.e. it's likely a test
         // case, part of a test file, etc.)
         weight::TEST_HARNESS_SCORE_PENALTY
@@ -149,7 +175,7 @@ pub fn calculate_score<'a, 'b>(
     };
 
     // Penalty for each directory distance from the current focused file (up to max of 8 directories - or 8%)
-    let distance_penalty = current_file.map_or(0, |current_file| {
+    let distance_penalty = scoring_ctx.current_file.map_or(0, |current_file| {
         if current_file == symbol.path {
             // Apply a penalty to symbols inside the current file. The idea is that it's likely that the
             // intent of a workspace-wide search is to find symbols which are within close proximity
@@ -164,6 +190,65 @@ pub fn calculate_score<'a, 'b>(
         ))
     });
 
+    // Bonus for symbols in the same package as the current focused file. Directory distance alone
+    // doesn't capture package boundaries (i.e. two sibling packages in a monorepo can be "close",
+    // while being entirely unrelated)
+    let same_package_bonus = match (scoring_ctx.current_package, symbol.package.as_deref()) {
+        (Some(current_package), Some(package)) if current_package == package => {
+            weight::SAME_PACKAGE_SCORE_BONUS
+        }
+        _ => 0,
+    };
+
+    // Bonus for symbols owned by the current user (or their team). In larger organisations, it's
+    // more likely that symbols being searched for are ones which the user works on day-to-day
+    let current_owner_bonus = if symbol
+        .owners
+        .iter()
+        .any(|owner| scoring_ctx.current_owners.contains(owner))
+    {
+        weight::OWNED_BY_CURRENT_OWNER_SCORE_BONUS
+    } else {
+        0
+    };
+
+    let deprecated_penalty = if symbol.deprecated {
+        // Penalty for deprecated symbols, which are likely being phased out in favour of a
+        // replacement
+        scoring_ctx
+            .deprecated_symbol_penalty
+            .map_or(weight::DEPRECATED_SYMBOL_SCORE_PENALTY, |penalty| {
+                -i64::from(penalty)
+            })
+    } else {
+        0
+    };
+
+    // Bonus for symbols in files which changed recently, as they're more likely to be related to
+    // what's currently being worked on
+    let recency_bonus = match (scoring_ctx.recency, symbol.changed_at) {
+        (Some((now, half_life)), Some(changed_at)) => weight::calculate_recency_score_bonus(
+            (now - changed_at).to_std().unwrap_or_default(),
+            half_life,
+        ),
+        _ => 0,
+    };
+
+    // Bonus for symbols which have been pinned, as they've been explicitly marked as key symbols
+    let pinned_bonus = if scoring_ctx.pinned_symbols.contains(&symbol.id) {
+        weight::PINNED_SYMBOL_SCORE_BONUS
+    } else {
+        0
+    };
+
+    // Penalty for symbols which only matched an expansion of the query (i.e. `config` for `cfg`),
+    // so that symbols matching the query as typed are always preferred
+    let synonym_penalty = if symbol.expansion.is_some() {
+        weight::SYNONYM_MATCH_SCORE_PENALTY
+    } else {
+        0
+    };
+
     DEFAULT_SCORE
         .saturating_add(entrypoint_file_penalty)
         .saturating_add(fuzzy_match_bonus)
@@ -171,6 +256,60 @@ pub fn calculate_score<'a, 'b>(
         .saturating_add(symbol_kind_bonus)
         .saturating_add(test_harness_penalty)
         .saturating_add(distance_penalty)
+        .saturating_add(same_package_bonus)
+        .saturating_add(current_owner_bonus)
+        .saturating_add(deprecated_penalty)
+        .saturating_add(recency_bonus)
+        .saturating_add(pinned_bonus)
+        .saturating_add(synonym_penalty)
+}
+
+/// Calculate the best score for a symbol ([`calculate_score`]) across a query, and every expansion
+/// of that query ([`synonym::Synonyms::expand`]), alongside the fuzzy matching config for each.
+///
+/// When an expansion scores highest, it's recorded on the symbol
+/// ([`models::resolved::ResolvedSymbol::expansion`]).
+///
+/// Returns [`None`] if neither the query nor any of its expansions fuzzy matched the symbol.
+pub fn calculate_best_score(
+    query: &str,
+    config: &frizbee::Config,
+    expansions: &[(synonym::Expansion, frizbee::Config)],
+    symbol: &mut models::resolved::ResolvedSymbol,
+    scoring_ctx: &ScoringContext<'_>,
+) -> Option<i64> {
+    let fuzzy_matches = fuzzy_match(query, symbol, config);
+
+    let mut best_score = if query.is_empty() || !fuzzy_matches.is_empty() {
+        Some(calculate_score(
+            query,
+            symbol,
+            fuzzy_matches.iter(),
+            scoring_ctx,
+        ))
+    } else {
+        None
+    };
+
+    for (expansion, config) in expansions {
+        let fuzzy_matches = fuzzy_match(&expansion.query, symbol, config);
+
+        if fuzzy_matches.is_empty() {
+            continue;
+        }
+
+        let previous_expansion = symbol.expansion.replace(expansion.expansion.clone());
+
+        let score = calculate_score(&expansion.query, symbol, fuzzy_matches.iter(), scoring_ctx);
+
+        if best_score.is_none_or(|best_score| score > best_score) {
+            best_score = Some(score);
+        } else {
+            symbol.expansion = previous_expansion;
+        }
+    }
+
+    best_score
 }
 
 /// Apply a bonus to symbols who's [`models::resolved::SymbolKind`] matches the intent
@@ -248,7 +387,7 @@ mod tests {
     use crate::{
         models::{
             parsed::{Language, SymbolKind},
-            resolved::{ResolvedSymbol, Score},
+            resolved::{Owners, QueryExpansion, ResolvedSymbol, Score},
         },
         resolver::scoring::DEFAULT_SCORE,
     };
@@ -261,6 +400,16 @@ mod tests {
             kind: SymbolKind::Struct,
             language: Language::Rust,
             path: PathBuf::from("/some/file/mod.rs"),
+            package: None,
+            owners: Owners::default(),
+            test: false,
+            deprecated: false,
+            deprecation_message: None,
+            signature: None,
+            access: None,
+            generated: false,
+            expansion: None,
+            changed_at: None,
             score: Score::default(),
             start_line: 1,
             start_column: 1,
@@ -268,7 +417,8 @@ mod tests {
             end_column: 14,
         };
 
-        let score = super::calculate_score("", &symbol, Vec::new().iter(), None);
+        let score =
+            super::calculate_score("", &symbol, Vec::new().iter(), &ScoringContext::default());
 
         let mut target_score = DEFAULT_SCORE;
 
@@ -286,6 +436,16 @@ mod tests {
             kind: SymbolKind::Struct,
             language: Language::Rust,
             path: PathBuf::from("/some/file"),
+            package: None,
+            owners: Owners::default(),
+            test: false,
+            deprecated: false,
+            deprecation_message: None,
+            signature: None,
+            access: None,
+            generated: false,
+            expansion: None,
+            changed_at: None,
             score: Score::default(),
             start_line: 1,
             start_column: 1,
@@ -293,7 +453,8 @@ mod tests {
             end_column: 14,
         };
 
-        let score = super::calculate_score("", &symbol, Vec::new().iter(), None);
+        let score =
+            super::calculate_score("", &symbol, Vec::new().iter(), &ScoringContext::default());
 
         let mut target_score = DEFAULT_SCORE;
 
@@ -312,6 +473,16 @@ mod tests {
             kind: SymbolKind::Variable,
             language: Language::Rust,
             path: PathBuf::from_iter(["", "some", "file", "over", "here", "file.rs"]),
+            package: None,
+            owners: Owners::default(),
+            test: false,
+            deprecated: false,
+            deprecation_message: None,
+            signature: None,
+            access: None,
+            generated: false,
+            expansion: None,
+            changed_at: None,
             score: Score::default(),
             start_line: 1,
             start_column: 1,
@@ -323,15 +494,18 @@ mod tests {
             "",
             &symbol,
             Vec::new().iter(),
-            Some(&PathBuf::from_iter([
-                "a",
-                "totally",
-                "different",
-                "file",
-                "over",
-                "there",
-                "file.ts",
-            ])),
+            &ScoringContext {
+                current_file: Some(&PathBuf::from_iter([
+                    "a",
+                    "totally",
+                    "different",
+                    "file",
+                    "over",
+                    "there",
+                    "file.ts",
+                ])),
+                ..Default::default()
+            },
         );
 
         let mut target_score = DEFAULT_SCORE;
@@ -350,6 +524,16 @@ mod tests {
             kind: SymbolKind::Variable,
             language: Language::Rust,
             path: PathBuf::from_iter(["", "some", "file", "over", "here", "file.rs"]),
+            package: None,
+            owners: Owners::default(),
+            test: false,
+            deprecated: false,
+            deprecation_message: None,
+            signature: None,
+            access: None,
+            generated: false,
+            expansion: None,
+            changed_at: None,
             score: Score::default(),
             start_line: 1,
             start_column: 1,
@@ -361,9 +545,12 @@ mod tests {
             "",
             &symbol,
             Vec::new().iter(),
-            Some(&PathBuf::from_iter([
-                "", "some", "file", "over", "here", "file.rs",
-            ])),
+            &ScoringContext {
+                current_file: Some(&PathBuf::from_iter([
+                    "", "some", "file", "over", "here", "file.rs",
+                ])),
+                ..Default::default()
+            },
         );
 
         let mut target_score = DEFAULT_SCORE;
@@ -374,6 +561,172 @@ mod tests {
         assert_eq!(target_score, score);
     }
 
+    #[test]
+    pub fn test_scoring_variable_in_same_package() {
+        let symbol = ResolvedSymbol {
+            id: 1,
+            name: "ResolvedSymbol".to_string(),
+            kind: SymbolKind::Variable,
+            language: Language::TypeScript,
+            path: PathBuf::from_iter(["", "packages", "ui", "src", "button.ts"]),
+            package: Some("@acme/ui".to_string()),
+            owners: Owners::default(),
+            test: false,
+            deprecated: false,
+            deprecation_message: None,
+            signature: None,
+            access: None,
+            generated: false,
+            expansion: None,
+            changed_at: None,
+            score: Score::default(),
+            start_line: 1,
+            start_column: 1,
+            end_line: 1,
+            end_column: 14,
+        };
+
+        let current_file = PathBuf::from_iter(["", "packages", "ui", "src", "form", "input.ts"]);
+
+        let score = super::calculate_score(
+            "",
+            &symbol,
+            Vec::new().iter(),
+            &ScoringContext {
+                current_file: Some(&current_file),
+                current_package: Some("@acme/ui"),
+                ..Default::default()
+            },
+        );
+
+        let mut target_score = DEFAULT_SCORE;
+
+        target_score += 15; // Increase the score by 1.5%, because it is a variable
+        target_score -= 2; // Reduce the score by 0.2% because the symbol is 1 directory apart
+        target_score += 10; // Increase the score by 1%, because it is in the same package
+
+        assert_eq!(target_score, score);
+
+        let score = super::calculate_score(
+            "",
+            &symbol,
+            Vec::new().iter(),
+            &ScoringContext {
+                current_file: Some(&current_file),
+                current_package: Some("@acme/api"),
+                ..Default::default()
+            },
+        );
+
+        // Notice, no bonus when the symbol is in a different package
+        assert_eq!(target_score - 10, score);
+    }
+
+    #[test]
+    pub fn test_scoring_variable_owned_by_current_owner() {
+        let symbol = ResolvedSymbol {
+            id: 1,
+            name: "ResolvedSymbol".to_string(),
+            kind: SymbolKind::Variable,
+            language: Language::TypeScript,
+            path: PathBuf::from_iter(["", "web", "src", "button.ts"]),
+            package: None,
+            owners: Owners::from("@acme/frontend @alice".to_string()),
+            test: false,
+            deprecated: false,
+            deprecation_message: None,
+            signature: None,
+            access: None,
+            generated: false,
+            expansion: None,
+            changed_at: None,
+            score: Score::default(),
+            start_line: 1,
+            start_column: 1,
+            end_line: 1,
+            end_column: 14,
+        };
+
+        let score = super::calculate_score(
+            "",
+            &symbol,
+            Vec::new().iter(),
+            &ScoringContext {
+                current_owners: &["@bob".to_string(), "@acme/frontend".to_string()],
+                ..Default::default()
+            },
+        );
+
+        let mut target_score = DEFAULT_SCORE;
+
+        target_score += 15; // Increase the score by 1.5%, because it is a variable
+        target_score += 10; // Increase the score by 1%, because it is owned by the current team
+
+        assert_eq!(target_score, score);
+
+        let score = super::calculate_score(
+            "",
+            &symbol,
+            Vec::new().iter(),
+            &ScoringContext {
+                current_owners: &["@acme/backend".to_string()],
+                ..Default::default()
+            },
+        );
+
+        // Notice, no bonus when the symbol is owned by someone else
+        assert_eq!(target_score - 10, score);
+    }
+
+    #[test]
+    pub fn test_scoring_deprecated_function() {
+        let symbol = ResolvedSymbol {
+            id: 1,
+            name: "get_user".to_string(),
+            kind: SymbolKind::Function,
+            language: Language::Rust,
+            path: PathBuf::from_iter(["", "src", "user.rs"]),
+            package: None,
+            owners: Owners::default(),
+            test: false,
+            deprecated: true,
+            deprecation_message: Some("Use `find_user` instead".to_string()),
+            signature: None,
+            access: None,
+            generated: false,
+            expansion: None,
+            changed_at: None,
+            score: Score::default(),
+            start_line: 1,
+            start_column: 1,
+            end_line: 1,
+            end_column: 9,
+        };
+
+        let score =
+            super::calculate_score("", &symbol, Vec::new().iter(), &ScoringContext::default());
+
+        let mut target_score = DEFAULT_SCORE;
+
+        target_score += 35; // Increase the score by 3.5%, because it is a function
+        target_score -= 20; // Decrease the score by 2%, because it is deprecated
+
+        assert_eq!(target_score, score);
+
+        let score = super::calculate_score(
+            "",
+            &symbol,
+            Vec::new().iter(),
+            &ScoringContext {
+                deprecated_symbol_penalty: Some(0),
+                ..Default::default()
+            },
+        );
+
+        // Notice, no penalty when the penalty has been disabled for the query
+        assert_eq!(target_score + 20, score);
+    }
+
     #[test]
     pub fn test_scoring_module_symbol() {
         let symbol = ResolvedSymbol {
@@ -382,6 +735,16 @@ mod tests {
             kind: SymbolKind::Module,
             language: Language::Rust,
             path: PathBuf::from("some_module.rs"),
+            package: None,
+            owners: Owners::default(),
+            test: false,
+            deprecated: false,
+            deprecation_message: None,
+            signature: None,
+            access: None,
+            generated: false,
+            expansion: None,
+            changed_at: None,
             score: Score::default(),
             start_line: 1,
             start_column: 1,
@@ -389,7 +752,8 @@ mod tests {
             end_column: 14,
         };
 
-        let score = super::calculate_score("", &symbol, Vec::new().iter(), None);
+        let score =
+            super::calculate_score("", &symbol, Vec::new().iter(), &ScoringContext::default());
 
         let mut target_score = DEFAULT_SCORE;
 
@@ -406,6 +770,16 @@ mod tests {
             kind: SymbolKind::Class,
             language: Language::TypeScript,
             path: PathBuf::from("some_file.test.ts"),
+            package: None,
+            owners: Owners::default(),
+            test: true,
+            deprecated: false,
+            deprecation_message: None,
+            signature: None,
+            access: None,
+            generated: false,
+            expansion: None,
+            changed_at: None,
             score: Score::default(),
             start_line: 1,
             start_column: 1,
@@ -413,7 +787,8 @@ mod tests {
             end_column: 9,
         };
 
-        let score = super::calculate_score("", &symbol, Vec::new().iter(), None);
+        let score =
+            super::calculate_score("", &symbol, Vec::new().iter(), &ScoringContext::default());
 
         let mut target_score = DEFAULT_SCORE;
 
@@ -423,6 +798,159 @@ mod tests {
         assert_eq!(target_score, score);
     }
 
+    #[test]
+    pub fn test_scoring_pinned_symbol() {
+        let symbol = ResolvedSymbol {
+            id: 7,
+            name: "Router".to_string(),
+            kind: SymbolKind::Class,
+            language: Language::TypeScript,
+            path: PathBuf::from("router.ts"),
+            package: None,
+            owners: Owners::default(),
+            test: false,
+            deprecated: false,
+            deprecation_message: None,
+            signature: None,
+            access: None,
+            generated: false,
+            expansion: None,
+            changed_at: None,
+            score: Score::default(),
+            start_line: 1,
+            start_column: 1,
+            end_line: 1,
+            end_column: 6,
+        };
+
+        let score = super::calculate_score(
+            "",
+            &symbol,
+            Vec::new().iter(),
+            &ScoringContext {
+                pinned_symbols: &[3, 7],
+                ..Default::default()
+            },
+        );
+
+        let mut target_score = DEFAULT_SCORE;
+
+        target_score += 35; // Increase the score by 3.5%, because it is a Class
+        target_score += 100; // Increase the score by 10%, because it has been pinned
+
+        assert_eq!(target_score, score);
+
+        let score = super::calculate_score(
+            "",
+            &symbol,
+            Vec::new().iter(),
+            &ScoringContext {
+                pinned_symbols: &[3],
+                ..Default::default()
+            },
+        );
+
+        // Notice, no bonus when a different symbol has been pinned
+        assert_eq!(target_score - 100, score);
+    }
+
+    #[test]
+    pub fn test_scoring_recently_changed_symbol() {
+        let now = chrono::DateTime::from_timestamp(1_800_000_000, 0).unwrap();
+        let half_life = Duration::from_secs(30 * 24 * 60 * 60);
+
+        let mut symbol = ResolvedSymbol {
+            id: 1,
+            name: "Router".to_string(),
+            kind: SymbolKind::Class,
+            language: Language::TypeScript,
+            path: PathBuf::from("router.ts"),
+            package: None,
+            owners: Owners::default(),
+            test: false,
+            deprecated: false,
+            deprecation_message: None,
+            signature: None,
+            access: None,
+            generated: false,
+            expansion: None,
+            changed_at: Some(now),
+            score: Score::default(),
+            start_line: 1,
+            start_column: 1,
+            end_line: 1,
+            end_column: 6,
+        };
+
+        let ctx = ScoringContext {
+            recency: Some((now, half_life)),
+            ..Default::default()
+        };
+
+        let mut target_score = DEFAULT_SCORE;
+
+        target_score += 35; // Increase the score by 3.5%, because it is a Class
+        target_score += 30; // Increase the score by 3%, because it changed just now
+
+        assert_eq!(
+            target_score,
+            super::calculate_score("", &symbol, Vec::new().iter(), &ctx)
+        );
+
+        symbol.changed_at = Some(now - chrono::Duration::days(30));
+
+        // Notice, only half the bonus after a single half-life
+        assert_eq!(
+            target_score - 15,
+            super::calculate_score("", &symbol, Vec::new().iter(), &ctx)
+        );
+
+        // Notice, no bonus when recency isn't being considered
+        assert_eq!(
+            target_score - 30,
+            super::calculate_score("", &symbol, Vec::new().iter(), &ScoringContext::default())
+        );
+    }
+
+    #[test]
+    pub fn test_scoring_symbol_matched_through_expansion() {
+        let symbol = ResolvedSymbol {
+            id: 1,
+            name: "ConfigLoader".to_string(),
+            kind: SymbolKind::Class,
+            language: Language::TypeScript,
+            path: PathBuf::from("config_loader.ts"),
+            package: None,
+            owners: Owners::default(),
+            test: false,
+            deprecated: false,
+            deprecation_message: None,
+            signature: None,
+            access: None,
+            generated: false,
+            expansion: Some(QueryExpansion {
+                term: "cfg".to_string(),
+                synonym: "config".to_string(),
+            }),
+            changed_at: None,
+            score: Score::default(),
+            start_line: 1,
+            start_column: 1,
+            end_line: 1,
+            end_column: 12,
+        };
+
+        let score =
+            super::calculate_score("", &symbol, Vec::new().iter(), &ScoringContext::default());
+
+        let mut target_score = DEFAULT_SCORE;
+
+        target_score += 35; // Increase the score by 3.5%, because it is a Class
+        target_score -= 10; // Decrease the score by 1.0%, because it only matched a synonym
+
+        assert_eq!(target_score, score);
+    }
+
     #[test]
     pub fn test_scoring_fuzzy_matched_symbol() {
         let query = "Lem";
@@ -436,6 +964,16 @@ mod tests {
             kind: SymbolKind::Lemma,
             language: Language::TypeScript,
             path: path.clone(),
+            package: None,
+            owners: Owners::default(),
+            test: false,
+            deprecated: false,
+            deprecation_message: None,
+            signature: None,
+            access: None,
+            generated: false,
+            expansion: None,
+            changed_at: None,
             score: Score::default(),
             start_line: 1,
             start_column: 1,
@@ -461,7 +999,12 @@ mod tests {
             &config,
         );
 
-        let score = super::calculate_score(&query, &symbol, fuzzy_matches.iter(), None);
+        let score = super::calculate_score(
+            &query,
+            &symbol,
+            fuzzy_matches.iter(),
+            &ScoringContext::default(),
+        );
 
         let mut target_score = DEFAULT_SCORE;
 
@@ -483,6 +1026,16 @@ mod tests {
             kind: SymbolKind::Lemma,
             language: Language::Clojure,
             path: path.clone(),
+            package: None,
+            owners: Owners::default(),
+            test: false,
+            deprecated: false,
+            deprecation_message: None,
+            signature: None,
+            access: None,
+            generated: false,
+            expansion: None,
+            changed_at: None,
             score: Score::default(),
             start_line: 1,
             start_column: 1,
@@ -508,7 +1061,12 @@ mod tests {
             &config,
         );
 
-        let score = super::calculate_score(&query, &symbol, fuzzy_matches.iter(), None);
+        let score = super::calculate_score(
+            &query,
+            &symbol,
+            fuzzy_matches.iter(),
+            &ScoringContext::default(),
+        );
 
         let mut target_score = DEFAULT_SCORE;
 
@@ -534,6 +1092,16 @@ mod tests {
             kind,
             language: Language::Rust,
             path: PathBuf::from("src/lib.rs"),
+            package: None,
+            owners: Owners::default(),
+            test: false,
+            deprecated: false,
+            deprecation_message: None,
+            signature: None,
+            access: None,
+            generated: false,
+            expansion: None,
+            changed_at: None,
             score: Score::default(),
             start_line: 1,
             start_column: 1,
@@ -556,6 +1124,16 @@ mod tests {
             kind: SymbolKind::Constant,
             language: Language::Rust,
             path: PathBuf::from("src/lib.rs"),
+            package: None,
+            owners: Owners::default(),
+            test: false,
+            deprecated: false,
+            deprecation_message: None,
+            signature: None,
+            access: None,
+            generated: false,
+            expansion: None,
+            changed_at: None,
             score: Score::default(),
             start_line: 1,
             start_column: 1,
@@ -590,6 +1168,16 @@ mod tests {
             kind,
             language: Language::Rust,
             path: PathBuf::from("src/lib.rs"),
+            package: None,
+            owners: Owners::default(),
+            test: false,
+            deprecated: false,
+            deprecation_message: None,
+            signature: None,
+            access: None,
+            generated: false,
+            expansion: None,
+            changed_at: None,
             score: Score::default(),
             start_line: 1,
             start_column: 1,
@@ -612,6 +1200,16 @@ mod tests {
             kind: SymbolKind::Struct,
             language: Language::Rust,
             path: PathBuf::from("src/lib.rs"),
+            package: None,
+            owners: Owners::default(),
+            test: false,
+            deprecated: false,
+            deprecation_message: None,
+            signature: None,
+            access: None,
+            generated: false,
+            expansion: None,
+            changed_at: None,
             score: Score::default(),
             start_line: 1,
             start_column: 1,
@@ -633,6 +1231,16 @@ mod tests {
             kind: SymbolKind::Function,
             language: Language::Rust,
             path: PathBuf::from("src/lib.rs"),
+            package: None,
+            owners: Owners::default(),
+            test: false,
+            deprecated: false,
+            deprecation_message: None,
+            signature: None,
+            access: None,
+            generated: false,
+            expansion: None,
+            changed_at: None,
             score: Score::default(),
             start_line: 1,
             start_column: 1,
@@ -659,6 +1267,16 @@ mod tests {
             kind,
             language: Language::Rust,
             path: PathBuf::from("src/lib.rs"),
+            package: None,
+            owners: Owners::default(),
+            test: false,
+            deprecated: false,
+            deprecation_message: None,
+            signature: None,
+            access: None,
+            generated: false,
+            expansion: None,
+            changed_at: None,
             score: Score::default(),
             start_line: 1,
             start_column: 1,
@@ -682,6 +1300,16 @@ mod tests {
             kind,
             language: Language::Rust,
             path: PathBuf::from("src/lib.rs"),
+            package: None,
+            owners: Owners::default(),
+            test: false,
+            deprecated: false,
+            deprecation_message: None,
+            signature: None,
+            access: None,
+            generated: false,
+            expansion: None,
+            changed_at: None,
             score: Score::default(),
             start_line: 1,
             start_column: 1,
@@ -703,6 +1331,16 @@ mod tests {
             kind: SymbolKind::Function,
             language: Language::Rust,
             path: PathBuf::from("src/lib.rs"),
+            package: None,
+            owners: Owners::default(),
+            test: false,
+            deprecated: false,
+            deprecation_message: None,
+            signature: None,
+            access: None,
+            generated: false,
+            expansion: None,
+            changed_at: None,
             score: Score::default(),
             start_line: 1,
             start_column: 1,
@@ -0,0 +1,260 @@
+use crate::models::parsed::{self, Signature};
+
+/// A single parameter in a [`SignaturePattern`].
+#[derive(Debug, Clone, PartialEq, Eq)]
+enum ParameterPattern {
+    /// A parameter of a (fuzzily matched) type.
+    Type(String),
+
+    /// Exactly one parameter, of any type (`_`).
+    Any,
+
+    /// Any number of parameters, of any type (`..`).
+    Rest,
+}
+
+/// A pattern which matches the shape of function signatures (i.e. "functions which take a
+/// `Path` and return a `Result`").
+///
+/// Patterns are written in a loose, language-agnostic, form:
+///
+/// - `(&Path) -> Result` matches functions taking a single `Path`, which return a `Result`.
+/// - `fn(str) -> bool` or `func(string) bool` match functions taking a string, which return a
+///   boolean.
+/// - `(_, Context) -> _` matches functions taking exactly two parameters, the second of which is a
+///   `Context`.
+/// - `(.., Context, ..)`, or just `Context`, matches functions taking a `Context` anywhere in
+///   their parameters.
+///
+/// Types are matched fuzzily (i.e. `Path` matches `PathBuf`, and `str` matches `String`), and
+/// generic arguments only need to match if the pattern includes them (i.e. `Result` matches
+/// `Result<Vec<u8>>`, but `Result<String>` does not). Patterns without a return type match any
+/// return type.
+///
+/// Parameters without a declared type (i.e. in dynamically typed languages) can only be matched
+/// by wildcards, so matching is best-effort for those languages.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct SignaturePattern {
+    parameters: Vec<ParameterPattern>,
+    return_type: Option<String>,
+}
+
+impl SignaturePattern {
+    /// Parse a signature pattern.
+    ///
+    /// Returns [`None`] if the pattern is empty, or its parameter list is unbalanced.
+    #[must_use]
+    pub fn parse(pattern: &str) -> Option<Self> {
+        let pattern = pattern.trim();
+
+        // Leading keywords are ignored, so patterns can be written like declarations in most
+        // languages (i.e. `fn(str) -> bool`)
+        let pattern = ["fn", "func", "function", "def"]
+            .iter()
+            .find_map(|keyword| {
+                pattern
+                    .strip_prefix(keyword)
+                    .filter(|rest| rest.trim_start().starts_with('('))
+            })
+            .unwrap_or(pattern)
+            .trim_start();
+
+        if pattern.is_empty() {
+            return None;
+        }
+
+        if !pattern.starts_with('(') {
+            // A bare type is shorthand for "functions taking a particular type"
+            return Some(Self {
+                parameters: vec![
+                    ParameterPattern::Rest,
+                    ParameterPattern::Type(parsed::normalise_type(pattern)),
+                    ParameterPattern::Rest,
+                ],
+                return_type: None,
+            });
+        }
+
+        let (parameters, return_type) = parsed::split_parameters(pattern)?;
+
+        let return_type = return_type.trim();
+        let return_type = ["->", "=>", ":"]
+            .iter()
+            .find_map(|separator| return_type.strip_prefix(separator))
+            .unwrap_or(return_type)
+            .trim();
+
+        Some(Self {
+            parameters: parsed::split_top_level(parameters)
+                .into_iter()
+                .map(|parameter| match parameter {
+                    "_" => ParameterPattern::Any,
+                    ".." | "..." | "*" => ParameterPattern::Rest,
+                    parameter => {
+                        // Parameters can optionally be named (i.e. `path: &Path`)
+                        let parameter = match parameter.split_once(':') {
+                            Some((_, rest)) if !rest.starts_with(':') => rest,
+                            _ => parameter,
+                        };
+
+                        ParameterPattern::Type(parsed::normalise_type(parameter))
+                    }
+                })
+                .collect(),
+            return_type: match return_type {
+                "" | "_" => None,
+                return_type => Some(parsed::normalise_type(return_type)),
+            },
+        })
+    }
+
+    /// Check if a normalised signature (i.e. [`crate::models::resolved::ResolvedSymbol::signature`])
+    /// matches the pattern.
+    #[must_use]
+    pub fn matches(&self, signature: &str) -> bool {
+        let Ok(signature) = signature.parse::<Signature>() else {
+            return false;
+        };
+
+        let is_return_type_match = match (&self.return_type, &signature.return_type) {
+            (None, _) => true,
+            // Explicitly returning nothing (i.e. `-> ()`)
+            (Some(pattern), None) => pattern == "()" || pattern == "void",
+            (Some(pattern), Some(return_type)) => is_type_match(pattern, return_type),
+        };
+
+        is_return_type_match && is_parameters_match(&self.parameters, &signature.parameters)
+    }
+}
+
+/// Check if a list of parameter patterns matches a list of (normalised) parameter types.
+fn is_parameters_match(patterns: &[ParameterPattern], parameters: &[String]) -> bool {
+    match (patterns.split_first(), parameters.split_first()) {
+        (None, None) => true,
+        (Some((ParameterPattern::Rest, rest)), _) => {
+            // Try consuming no parameters, and then progressively more parameters
+            (0..=parameters.len()).any(|skip| is_parameters_match(rest, &parameters[skip..]))
+        }
+        (Some((ParameterPattern::Any, rest)), Some((_, parameters))) => {
+            is_parameters_match(rest, parameters)
+        }
+        (Some((ParameterPattern::Type(pattern), rest)), Some((parameter, parameters))) => {
+            is_type_match(pattern, parameter) && is_parameters_match(rest, parameters)
+        }
+        _ => false,
+    }
+}
+
+/// Check if a (normalised) type pattern fuzzily matches a (normalised) type.
+fn is_type_match(pattern: &str, candidate: &str) -> bool {
+    if candidate == parsed::UNKNOWN_TYPE {
+        return false;
+    }
+
+    let (pattern_name, pattern_arguments) = split_type(pattern);
+    let (candidate_name, candidate_arguments) = split_type(candidate);
+
+    let pattern_name = normalise_type_name(pattern_name);
+    let candidate_name = normalise_type_name(candidate_name);
+
+    if !candidate_name.contains(&pattern_name) {
+        return false;
+    }
+
+    match (pattern_arguments, candidate_arguments) {
+        (None, _) => true,
+        (Some(pattern_arguments), Some(candidate_arguments)) => {
+            let pattern_arguments = parsed::split_top_level(pattern_arguments);
+            let candidate_arguments = parsed::split_top_level(candidate_arguments);
+
+            pattern_arguments.len() == candidate_arguments.len()
+                && pattern_arguments
+                    .iter()
+                    .zip(candidate_arguments)
+                    .all(|(pattern, candidate)| is_type_match(pattern, candidate))
+        }
+        (Some(_), None) => false,
+    }
+}
+
+/// Split a (normalised) type into its name, and its generic arguments (i.e. `Vec<u8>` is split
+/// into `Vec` and `u8`, and `list[str]` into `list` and `str`).
+fn split_type(text: &str) -> (&str, Option<&str>) {
+    let Some(start) = text.find(['<', '[']) else {
+        return (text, None);
+    };
+
+    // Types which start with a bracket (i.e. `[]byte` in Go, or `[u8]` in Rust) don't have a
+    // name to compare, so are compared as a whole
+    if start == 0 {
+        return (text, None);
+    }
+
+    let arguments = text[start + 1..]
+        .strip_suffix(['>', ']'])
+        .unwrap_or(&text[start + 1..]);
+
+    (&text[..start], Some(arguments))
+}
+
+/// Normalise the name of a type, so that it can be fuzzily compared across languages (i.e.
+/// `std::path::Path` is normalised to `path`, and `boolean` to `bool`).
+fn normalise_type_name(name: &str) -> String {
+    let name = name
+        .rsplit(['.', ':'])
+        .next()
+        .unwrap_or(name)
+        .to_lowercase();
+
+    match name.as_str() {
+        "str" => "string".to_string(),
+        "boolean" => "bool".to_string(),
+        _ => name,
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use rstest::rstest;
+
+    use super::SignaturePattern;
+
+    #[rstest]
+    #[case("(&Path) -> Result", "(Path) -> Result<Vec<u8>,Error>", true)]
+    #[case("(&Path) -> Result", "(PathBuf) -> Result<()>", true)]
+    #[case("(&Path) -> Result", "(Path, Context) -> Result<()>", false)]
+    #[case("(&Path) -> Result", "(Path)", false)]
+    #[case("fn(str) -> bool", "(String) -> bool", true)]
+    #[case("func(string) bool", "(str) -> boolean", true)]
+    #[case("function(string): boolean", "(string) -> boolean", true)]
+    #[case("(_, Context)", "(u8, ParseContext) -> Option<u8>", true)]
+    #[case("(_, Context)", "(ParseContext)", false)]
+    #[case("Context", "(Path, Context) -> bool", true)]
+    #[case("(.., Context, ..)", "(Context)", true)]
+    #[case("Context", "(Path, u8)", false)]
+    #[case("Context", "(_, _)", false)]
+    #[case("(_, _)", "(_, _)", true)]
+    #[case("() -> ()", "()", true)]
+    #[case("(std::path::Path)", "(path.Path)", true)]
+    #[case("() -> Result<String>", "() -> Result<String>", true)]
+    #[case("() -> Result<String>", "() -> Result<u8>", false)]
+    #[case("() -> Result<String>", "() -> Result", false)]
+    #[case("(path: &Path)", "(Path)", true)]
+    pub fn test_matching_signatures(
+        #[case] pattern: &str,
+        #[case] signature: &str,
+        #[case] expected_match: bool,
+    ) {
+        let pattern = SignaturePattern::parse(pattern).expect("Pattern should be valid");
+
+        assert_eq!(expected_match, pattern.matches(signature));
+    }
+
+    #[rstest]
+    #[case("")]
+    #[case("fn(Path")]
+    #[case("(Path")]
+    pub fn test_invalid_signature_patterns(#[case] pattern: &str) {
+        assert!(SignaturePattern::parse(pattern).is_none());
+    }
+}
@@ -0,0 +1,244 @@
+use std::collections::HashMap;
+
+use crate::{models::resolved::QueryExpansion, resolver::constant};
+
+/// A table of synonyms and abbreviations (i.e. `cfg`, `config` and `configuration`) which are
+/// used interchangeably in code, and so are used to expand the terms of a query.
+///
+/// Terms are grouped, with every term in a group being a synonym of every other term in it. A term
+/// can be part of many groups (i.e. `auth` for both `authentication` and `authorization`), without
+/// the other terms of those groups becoming synonyms of each other.
+///
+/// The default table contains a set of common abbreviations ([`constant::DEFAULT_SYNONYMS`]),
+/// which can be extended with [`Synonyms::with_group`], or replaced entirely by starting from
+/// [`Synonyms::empty`].
+///
+/// Resolvers use the default table unless another is given, with
+/// [`crate::resolver::Context::with_synonyms`].
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct Synonyms {
+    terms: HashMap<String, Vec<String>>,
+}
+
+/// A query, with one of its terms replaced by a synonym.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct Expansion {
+    /// The expanded query (i.e. `configLoader` for a query of `cfgLoader`).
+    pub query: String,
+
+    /// The term which was replaced, and what it was replaced with.
+    pub expansion: QueryExpansion,
+}
+
+impl Default for Synonyms {
+    fn default() -> Self {
+        constant::DEFAULT_SYNONYMS
+            .iter()
+            .fold(Self::empty(), |synonyms, group| {
+                synonyms.with_group(group.iter().copied())
+            })
+    }
+}
+
+impl Synonyms {
+    /// Create a table without any synonyms, which will never expand a query.
+    #[must_use]
+    pub fn empty() -> Self {
+        Self {
+            terms: HashMap::new(),
+        }
+    }
+
+    /// Add a group of terms which are all synonyms of each other (i.e. `["repo", "repository"]`).
+    ///
+    /// Terms are matched regardless of their case.
+    #[must_use]
+    pub fn with_group(mut self, group: impl IntoIterator<Item = impl AsRef<str>>) -> Self {
+        let group = group
+            .into_iter()
+            .map(|term| term.as_ref().trim().to_lowercase())
+            .filter(|term| !term.is_empty())
+            .collect::<Vec<_>>();
+
+        for term in &group {
+            let synonyms = self.terms.entry(term.clone()).or_default();
+
+            for synonym in &group {
+                if synonym != term && !synonyms.contains(synonym) {
+                    synonyms.push(synonym.clone());
+                }
+            }
+        }
+
+        self
+    }
+
+    /// Get the synonyms of a term (i.e. `config` and `configuration` for `cfg`).
+    #[must_use]
+    pub fn get(&self, term: &str) -> &[String] {
+        self.terms
+            .get(&term.to_lowercase())
+            .map(Vec::as_slice)
+            .unwrap_or_default()
+    }
+
+    /// Expand a query into every query which replaces one of its terms with a synonym.
+    ///
+    /// Terms are the words of a query, split on anything which isn't alphanumeric and on case
+    /// changes (i.e. `cfgLoader` and `cfg_loader` are both `cfg` and `loader`). Replacements keep
+    /// the case of the term they replace, so that `CfgLoader` expands to `ConfigLoader`.
+    ///
+    /// Only one term is replaced in each expansion, which keeps the number of expansions in line
+    /// with the number of synonyms, rather than growing with every combination of them.
+    #[must_use]
+    pub fn expand(&self, query: &str) -> Vec<Expansion> {
+        let mut expansions: Vec<Expansion> = Vec::new();
+
+        for (start, end) in get_terms(query) {
+            let term = &query[start..end];
+
+            for synonym in self.get(term) {
+                let expanded_query = format!(
+                    "{}{}{}",
+                    &query[..start],
+                    match_case(term, synonym),
+                    &query[end..]
+                );
+
+                if expansions
+                    .iter()
+                    .any(|expansion| expansion.query == expanded_query)
+                {
+                    continue;
+                }
+
+                expansions.push(Expansion {
+                    query: expanded_query,
+                    expansion: QueryExpansion {
+                        term: term.to_string(),
+                        synonym: synonym.clone(),
+                    },
+                });
+            }
+        }
+
+        expansions
+    }
+}
+
+/// Get the (byte) ranges of every term in a query, split on anything which isn't alphanumeric,
+/// and on a lowercase letter (or digit) followed by an uppercase letter.
+fn get_terms(query: &str) -> Vec<(usize, usize)> {
+    let mut terms = Vec::new();
+    let mut start = None;
+    let mut previous: Option<char> = None;
+
+    for (index, c) in query.char_indices() {
+        if !c.is_alphanumeric() {
+            if let Some(start) = start.take() {
+                terms.push((start, index));
+            }
+        } else if let Some(term_start) = start {
+            if c.is_uppercase() && previous.is_some_and(|p| p.is_lowercase() || p.is_numeric()) {
+                terms.push((term_start, index));
+                start = Some(index);
+            }
+        } else {
+            start = Some(index);
+        }
+
+        previous = Some(c);
+    }
+
+    if let Some(start) = start {
+        terms.push((start, query.len()));
+    }
+
+    terms
+}
+
+/// Get a synonym in the same case as the term it's replacing (i.e. `CONFIG` for `CFG`, or
+/// `Config` for `Cfg`).
+fn match_case(term: &str, synonym: &str) -> String {
+    let letters = term.chars().filter(|c| c.is_alphabetic()).count();
+
+    if letters > 1 && !term.chars().any(char::is_lowercase) {
+        return synonym.to_uppercase();
+    }
+
+    let mut synonym_chars = synonym.chars();
+
+    match (term.chars().next(), synonym_chars.next()) {
+        (Some(first), Some(synonym_first)) if first.is_uppercase() => {
+            synonym_first.to_uppercase().chain(synonym_chars).collect()
+        }
+        _ => synonym.to_string(),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use rstest::rstest;
+
+    use super::Synonyms;
+
+    #[rstest]
+    #[case("cfg", vec!["conf", "config", "configuration"])]
+    #[case("cfgLoader", vec!["confLoader", "configLoader", "configurationLoader"])]
+    #[case("CfgLoader", vec!["ConfLoader", "ConfigLoader", "ConfigurationLoader"])]
+    #[case("MAX_CTX_LEN", vec!["MAX_CONTEXT_LEN", "MAX_CTX_LENGTH"])]
+    #[case("load_repo", vec!["load_repository"])]
+    #[case("getMsgCtx", vec!["getMessageCtx", "getMsgContext"])]
+    #[case("auth", vec!["authentication", "authorization"])]
+    #[case("configure", vec![])]
+    #[case("", vec![])]
+    pub fn test_expanding_query(#[case] query: &str, #[case] expected: Vec<&str>) {
+        let expansions = Synonyms::default().expand(query);
+
+        assert_eq!(
+            expected,
+            expansions
+                .iter()
+                .map(|expansion| expansion.query.as_str())
+                .collect::<Vec<_>>()
+        );
+    }
+
+    #[test]
+    pub fn test_expansions_record_term_and_synonym() {
+        let expansions = Synonyms::default().expand("CfgLoader");
+
+        assert_eq!("Cfg", expansions[1].expansion.term);
+        assert_eq!("config", expansions[1].expansion.synonym);
+    }
+
+    #[test]
+    pub fn test_custom_groups() {
+        let synonyms = Synonyms::empty()
+            .with_group(["acct", "account"])
+            .with_group(["usr", "user"]);
+
+        assert_eq!(vec!["account"], synonyms.get("ACCT"));
+        assert!(synonyms.get("cfg").is_empty());
+
+        assert_eq!(
+            vec!["accountUsr", "acctUser"],
+            synonyms
+                .expand("acctUsr")
+                .into_iter()
+                .map(|expansion| expansion.query)
+                .collect::<Vec<_>>()
+        );
+    }
+
+    #[test]
+    pub fn test_terms_in_many_groups_are_not_transitive() {
+        let synonyms = Synonyms::default();
+
+        assert!(
+            !synonyms
+                .get("authentication")
+                .contains(&"authorization".to_string())
+        );
+    }
+}
@@ -0,0 +1,209 @@
+use std::{collections::HashSet, path::Path};
+
+use crate::{
+    models::resolved::{ResolvedSymbol, Translation, TranslationLocations, TranslationReport},
+    resolver::utils,
+};
+
+/// Translation resolver, which links the translation keys used in source code (i.e.
+/// `t("checkout.payment.title")`) with the locale files which define them (i.e.
+/// `locales/en.json`), in an existing index.
+#[derive(Debug, Clone)]
+pub struct TranslationResolver {
+    pool: sqlx::Pool<sqlx::Sqlite>,
+}
+
+impl TranslationResolver {
+    /// Initialize a translation resolver at a given database path, for a set of workspaces.
+    ///
+    /// As with [`crate::resolver::DatabaseBackedResolver::new`], the storage path and workspaces
+    /// should match those provided to the indexer.
+    #[must_use]
+    pub fn new<'a, 'b>(
+        storage_path: &'b Path,
+        workspaces: impl IntoIterator<Item = &'a Path>,
+    ) -> Self {
+        Self {
+            pool: utils::get_connection_pool(storage_path, workspaces),
+        }
+    }
+
+    /// Get every location a translation key is defined (in each locale file) and used (in
+    /// source code).
+    ///
+    /// Both are ordered by the file they are in, and then by their position in the file.
+    pub async fn get_translation_locations(&self, key: &str) -> TranslationLocations {
+        TranslationLocations {
+            definitions: self.get_translations(Some(key)).await,
+            usages: self.get_usages(Some(key)).await,
+        }
+    }
+
+    /// Get a report of the translation keys which are used in source code but not defined in any
+    /// locale file, and the translation keys which are defined in locale files but never used.
+    ///
+    /// Only keys passed to translation functions as string literals are known to be used, so
+    /// keys which are built dynamically (i.e. `` t(`errors.${code}`) ``) will be reported as
+    /// unused.
+    pub async fn get_translation_report(&self) -> TranslationReport {
+        let translations = self.get_translations(None).await;
+        let usages = self.get_usages(None).await;
+
+        let defined_keys = translations
+            .iter()
+            .map(|translation| translation.key.as_str())
+            .collect::<HashSet<_>>();
+
+        let used_keys = usages
+            .iter()
+            .map(|usage| usage.name.as_str())
+            .collect::<HashSet<_>>();
+
+        let unused = translations
+            .iter()
+            .filter(|translation| !used_keys.contains(translation.key.as_str()))
+            .cloned()
+            .collect();
+
+        let undefined = usages
+            .into_iter()
+            .filter(|usage| !defined_keys.contains(usage.name.as_str()))
+            .collect();
+
+        TranslationReport { undefined, unused }
+    }
+
+    /// Get the translations defined in locale files, optionally for only a single key.
+    async fn get_translations(&self, key: Option<&str>) -> Vec<Translation> {
+        let (sql, values) = utils::get_translations_sql(key);
+
+        let mut translations = sqlx::query_as_with::<_, Translation, _>(&sql, values)
+            .fetch_all(&self.pool)
+            .await
+            .unwrap_or_else(|e| {
+                log::error!("Error returned from query listing translations: {e}");
+
+                Vec::new()
+            });
+
+        translations.sort_by(|a, b| {
+            (&a.path, a.start_line, a.start_column).cmp(&(&b.path, b.start_line, b.start_column))
+        });
+
+        translations
+    }
+
+    /// Get the usages of translation keys in source code, optionally for only a single key.
+    async fn get_usages(&self, key: Option<&str>) -> Vec<ResolvedSymbol> {
+        let (sql, values) = utils::get_translation_key_usages_sql(key);
+
+        let mut usages = sqlx::query_as_with::<_, ResolvedSymbol, _>(&sql, values)
+            .fetch_all(&self.pool)
+            .await
+            .unwrap_or_else(|e| {
+                log::error!("Error returned from query listing translation key usages: {e}");
+
+                Vec::new()
+            });
+
+        usages.sort_by(|a, b| {
+            (&a.path, a.start_line, a.start_column).cmp(&(&b.path, b.start_line, b.start_column))
+        });
+
+        usages
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use tempfile::tempdir;
+    use tokio::fs;
+
+    use crate::indexer::{self, Indexer};
+
+    #[tokio::test]
+    pub async fn test_resolving_translation_keys() {
+        let storage_path = tempdir()
+            .expect("Should never fail when creating a temporary path for testing indexing");
+
+        let workspace =
+            tempdir().expect("Should never fail when creating a temp directory for the workspace");
+
+        fs::create_dir_all(workspace.path().join("locales"))
+            .await
+            .expect("Should never fail to create the locales directory");
+
+        let english = workspace.path().join("locales").join("en.json");
+        let french = workspace.path().join("locales").join("fr.yaml");
+        let checkout = workspace.path().join("checkout.ts");
+
+        fs::write(
+            &english,
+            "{\n  \"checkout\": {\n    \"title\": \"Payment\",\n    \"legacy\": \"Old\"\n  }\n}\n",
+        )
+        .await
+        .expect("Should never fail to write a file into the workspace");
+        fs::write(&french, "fr:\n  checkout:\n    title: Paiement\n")
+            .await
+            .expect("Should never fail to write a file into the workspace");
+        fs::write(
+            &checkout,
+            "const title = t(\"checkout.title\");\nconst missing = t(\"checkout.missing\");\n",
+        )
+        .await
+        .expect("Should never fail to write a file into the workspace");
+
+        let workspaces = vec![workspace.path()];
+
+        let indexer = indexer::DatabaseBackedIndexer::new(storage_path.path(), workspaces.clone())
+            .await
+            .expect("Should be able to create the empty index");
+
+        assert!(indexer.index_workspaces().await.is_ok());
+
+        let resolver = super::TranslationResolver::new(storage_path.path(), workspaces);
+
+        // Keys are linked to their definition in every locale, and every usage
+        let locations = resolver.get_translation_locations("checkout.title").await;
+
+        assert_eq!(
+            vec![("en", english.clone(), 3), ("fr", french, 3)],
+            locations
+                .definitions
+                .iter()
+                .map(|translation| (
+                    translation.locale.as_str(),
+                    translation.path.clone(),
+                    translation.start_line
+                ))
+                .collect::<Vec<_>>()
+        );
+        assert_eq!(
+            vec![(checkout.clone(), 1)],
+            locations
+                .usages
+                .iter()
+                .map(|usage| (usage.path.clone(), usage.start_line))
+                .collect::<Vec<_>>()
+        );
+
+        let report = resolver.get_translation_report().await;
+
+        assert_eq!(
+            vec![("checkout.missing", checkout, 2)],
+            report
+                .undefined
+                .iter()
+                .map(|usage| (usage.name.as_str(), usage.path.clone(), usage.start_line))
+                .collect::<Vec<_>>()
+        );
+        assert_eq!(
+            vec![("checkout.legacy", english)],
+            report
+                .unused
+                .iter()
+                .map(|translation| (translation.key.as_str(), translation.path.clone()))
+                .collect::<Vec<_>>()
+        );
+    }
+}
@@ -3,6 +3,7 @@ use std::{
     fmt::Debug,
     path::{Path, PathBuf},
     sync::Arc,
+    time::Duration,
 };
 
 #[cfg(test)]
@@ -74,6 +75,54 @@ pub struct Context {
     ///
     /// Queries where the context provides [`Option::None`] will return symbols of any kind.
     pub symbol_kinds: Arc<Option<SymbolKindFilter>>,
+
+    /// The packages (i.e. crates, npm packages, Go modules, etc.) which symbols should be
+    /// returned from.
+    ///
+    /// Queries where the context provides [`Option::None`] will return symbols from any package,
+    /// including symbols from files which are not part of a package.
+    pub packages: Arc<Option<Vec<String>>>,
+
+    /// The owners (i.e. `@user`, `@org/team`, or an email address) which symbols should be
+    /// returned from, as declared by the `CODEOWNERS` file of each workspace.
+    ///
+    /// Queries where the context provides [`Option::None`] will return symbols regardless of
+    /// who owns them, including symbols from files which have no owners.
+    pub owners: Arc<Option<Vec<String>>>,
+
+    /// The owners (i.e. `@user`, `@org/team`, or an email address) the query is being executed on
+    /// behalf of.
+    ///
+    /// This helps influence scoring to favor symbols which are owned by the current user, or
+    /// their team.
+    pub current_owners: Arc<Option<Vec<String>>>,
+
+    /// The penalty (in score points) applied to deprecated symbols.
+    ///
+    /// Queries where the context provides [`Option::None`] will apply the default penalty
+    /// ([`crate::resolver::weight::DEPRECATED_SYMBOL_SCORE_PENALTY`]). A penalty of `0` will
+    /// rank deprecated symbols alongside every other symbol.
+    pub deprecated_symbol_penalty: Arc<Option<u16>>,
+
+    /// The pattern which the signatures of symbols should match (i.e. `(&Path) -> Result`).
+    ///
+    /// Queries where the context provides [`Option::None`] will return symbols regardless of
+    /// their signature, including symbols which don't have a signature.
+    pub signature: Arc<Option<super::SignaturePattern>>,
+
+    /// The synonyms and abbreviations (i.e. `cfg` for `config`) which terms in the query should
+    /// be expanded to.
+    ///
+    /// Queries where the context provides [`Option::None`] will use the built-in synonyms
+    /// ([`super::Synonyms::default`]). [`super::Synonyms::empty`] will disable expansion entirely.
+    pub synonyms: Arc<Option<super::Synonyms>>,
+
+    /// The half-life of the bonus for symbols in recently changed files (i.e. with a half-life of
+    /// 30 days, a file changed 30 days ago receives half the bonus of a file changed today).
+    ///
+    /// Queries where the context provides [`Option::None`] will score symbols regardless of when
+    /// they last changed.
+    pub recency_half_life: Arc<Option<Duration>>,
 }
 
 impl Context {
@@ -92,4 +141,63 @@ impl Context {
 
         self
     }
+
+    /// Set the packages.
+    #[must_use]
+    pub fn with_packages(mut self, packages: Vec<String>) -> Self {
+        self.packages = Arc::new(Some(packages));
+
+        self
+    }
+
+    /// Set the owners.
+    #[must_use]
+    pub fn with_owners(mut self, owners: Vec<String>) -> Self {
+        self.owners = Arc::new(Some(owners));
+
+        self
+    }
+
+    /// Set the current owners (i.e. the current user, and their teams).
+    #[must_use]
+    pub fn with_current_owners(mut self, current_owners: Vec<String>) -> Self {
+        self.current_owners = Arc::new(Some(current_owners));
+
+        self
+    }
+
+    /// Set the penalty (in score points) applied to deprecated symbols.
+    #[must_use]
+    pub fn with_deprecated_symbol_penalty(mut self, deprecated_symbol_penalty: u16) -> Self {
+        self.deprecated_symbol_penalty = Arc::new(Some(deprecated_symbol_penalty));
+
+        self
+    }
+
+    /// Set the signature pattern.
+    #[must_use]
+    pub fn with_signature(mut self, signature: super::SignaturePattern) -> Self {
+        self.signature = Arc::new(Some(signature));
+
+        self
+    }
+
+    /// Set the synonyms which terms in queries are expanded to, in place of the built-in ones.
+    #[must_use]
+    pub fn with_synonyms(mut self, synonyms: super::Synonyms) -> Self {
+        self.synonyms = Arc::new(Some(synonyms));
+
+        self
+    }
+
+    /// Set the half-life of the bonus for symbols in recently changed files.
+    ///
+    /// For files in git repositories, the time of their last commit is only used when the indexer
+    /// reads the git history ([`crate::indexer::DatabaseBackedIndexer::with_git_history`]).
+    #[must_use]
+    pub fn with_recency_half_life(mut self, recency_half_life: Duration) -> Self {
+        self.recency_half_life = Arc::new(Some(recency_half_life));
+
+        self
+    }
 }
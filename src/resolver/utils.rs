@@ -1,15 +1,53 @@
-use std::{ffi::OsStr, path::Path, string::ToString};
+use std::{
+    ffi::OsStr,
+    path::{MAIN_SEPARATOR, MAIN_SEPARATOR_STR, Path},
+    string::ToString,
+};
 
-use sea_query::{Cond, Expr, ExprTrait, SqliteQueryBuilder};
+use sea_query::{Cond, Expr, ExprTrait, LikeExpr, Order, SelectStatement, SqliteQueryBuilder};
 use sea_query_sqlx::SqlxBinder;
+use sqlx::sqlite::{SqliteConnectOptions, SqlitePoolOptions};
+
+use crate::{
+    models,
+    resolver::{
+        DatabaseBackedResolver, NameMatcher, Resolver, Sort, SymbolKindFilter, SymbolQuery,
+        constant,
+    },
+    utils::get_database_path,
+};
+
+/// Create a lazily connected pool for the database of a given set of workspaces.
+pub fn get_connection_pool<'a>(
+    storage_path: &Path,
+    workspaces: impl IntoIterator<Item = &'a Path>,
+) -> sqlx::Pool<sqlx::Sqlite> {
+    let database_path = get_database_path(storage_path, workspaces);
+
+    if let Err(e) = std::fs::create_dir_all(storage_path) {
+        log::error!(
+            "Failed to create storage directory for resolver at {}: {e:?}",
+            storage_path.display()
+        );
+    }
 
-use crate::resolver::{DatabaseBackedResolver, Resolver, SymbolKindFilter};
+    log::info!(
+        "Initializing database for resolver at path: {:?}",
+        &database_path
+    );
 
-/// Get the SQL for resolving symbols with specific parameters (namely, query and
-/// symbol kinds) for [`DatabaseBackedResolver::query`].
-pub fn get_resolver_query_sql(
-    ctx: &<DatabaseBackedResolver as Resolver>::QueryContext,
-) -> (String, sea_query_sqlx::SqlxValues) {
+    let options = SqliteConnectOptions::new()
+        .create_if_missing(false)
+        .filename(database_path)
+        .journal_mode(sqlx::sqlite::SqliteJournalMode::Wal)
+        .synchronous(sqlx::sqlite::SqliteSynchronous::Normal);
+
+    SqlitePoolOptions::new().connect_lazy_with(options)
+}
+
+/// Get a query which selects all of the columns needed for a
+/// [`crate::models::resolved::ResolvedSymbol`].
+fn select_resolved_symbols() -> SelectStatement {
     let mut query = sea_query::Query::select();
 
     query
@@ -18,12 +56,29 @@ pub fn get_resolver_query_sql(
             ("symbol", "kind"),
             ("symbol", "language"),
             ("file", "path"),
+            ("file", "package"),
+            ("file", "generated"),
             ("symbol", "name"),
+            ("symbol", "test"),
+            ("symbol", "deprecated"),
+            ("symbol", "deprecation_message"),
+            ("symbol", "signature"),
+            ("symbol", "access"),
             ("symbol", "start_line"),
             ("symbol", "end_line"),
             ("symbol", "start_column"),
             ("symbol", "end_column"),
         ])
+        .expr_as(
+            Expr::cust(
+                "COALESCE((SELECT group_concat(file_owner.owner, ' ') FROM file_owner WHERE file_owner.file_id = file.id), '')",
+            ),
+            "owners",
+        )
+        .expr_as(
+            Expr::cust("COALESCE(file.committed_at, file.modified_at)"),
+            "changed_at",
+        )
         .from("symbol")
         .join(
             sea_query::JoinType::InnerJoin,
@@ -31,6 +86,74 @@ pub fn get_resolver_query_sql(
             Expr::col(("symbol", "file_id")).equals(("file", "id")),
         );
 
+    query
+}
+
+/// Get the SQL for resolving the symbols matching a structured query (including the fuzzy
+/// queries of [`DatabaseBackedResolver::query`]), with the filters of its context.
+///
+/// Sorting and limits are only applied in SQL when every symbol selected is returned by the
+/// resolver, meaning nothing is filtered out once symbols have been read from the index.
+pub fn get_symbol_query_sql(
+    query: &SymbolQuery,
+    ctx: &<DatabaseBackedResolver as Resolver>::QueryContext,
+) -> (String, sea_query_sqlx::SqlxValues) {
+    let mut statement = select_resolved_symbols();
+
+    filter_by_context(&mut statement, ctx);
+
+    let push_down = query
+        .name
+        .as_ref()
+        .is_none_or(|name| matches!(name, NameMatcher::Exact(_)))
+        && !query.needs_post_filter()
+        && ctx.signature.is_none()
+        && query.sort != Some(Sort::Score);
+
+    query.apply_sql(&mut statement, push_down);
+
+    statement.build_sqlx(SqliteQueryBuilder)
+}
+
+/// Get the SQL for resolving the candidates for completing a prefix (i.e. `getU` for `getUser`),
+/// with the shortest names first.
+///
+/// Symbols which are only ever used inside strings (environment variables and translation keys)
+/// are never candidates.
+pub fn get_completion_candidates_sql(
+    prefix: &str,
+    ctx: &<DatabaseBackedResolver as Resolver>::QueryContext,
+) -> (String, sea_query_sqlx::SqlxValues) {
+    let mut query = select_resolved_symbols();
+
+    filter_by_context(&mut query, ctx);
+
+    // Names can contain underscores, which would otherwise act as wildcards
+    let escaped_prefix = prefix
+        .replace('!', "!!")
+        .replace('%', "!%")
+        .replace('_', "!_");
+
+    query
+        .and_where(
+            Expr::col(("symbol", "name"))
+                .like(LikeExpr::new(format!("{escaped_prefix}%")).escape('!')),
+        )
+        .and_where(Expr::col(("symbol", "kind")).is_not_in([
+            models::parsed::SymbolKind::EnvironmentVariable.to_string(),
+            models::parsed::SymbolKind::TranslationKey.to_string(),
+        ]))
+        .order_by_expr(Expr::cust("length(symbol.name)"), Order::Asc)
+        .limit(constant::MAX_COMPLETION_CANDIDATES)
+        .build_sqlx(SqliteQueryBuilder)
+}
+
+/// Restrict a query to only the symbols which match the filters of a query's context (namely,
+/// symbol kinds, packages, signatures and owners).
+fn filter_by_context(
+    query: &mut SelectStatement,
+    ctx: &<DatabaseBackedResolver as Resolver>::QueryContext,
+) {
     match &*ctx.symbol_kinds {
         Some(SymbolKindFilter::Global(symbol_kinds)) => {
             query.and_where(Expr::col(("symbol", "kind")).is_in(symbol_kinds.as_slice()));
@@ -61,9 +184,229 @@ pub fn get_resolver_query_sql(
         None => {}
     }
 
+    if let Some(packages) = &*ctx.packages {
+        query.and_where(Expr::col(("file", "package")).is_in(packages.iter().map(String::as_str)));
+    }
+
+    if ctx.signature.is_some() {
+        // Signatures are matched against the pattern once resolved, but only function-like
+        // symbols can match at all
+        query.and_where(Expr::col(("symbol", "signature")).is_not_null());
+    }
+
+    if let Some(owners) = &*ctx.owners {
+        query.and_where(
+            Expr::col(("file", "id")).in_subquery(
+                sea_query::Query::select()
+                    .column(("file_owner", "file_id"))
+                    .from("file_owner")
+                    .and_where(
+                        Expr::col(("file_owner", "owner")).is_in(owners.iter().map(String::as_str)),
+                    )
+                    .take(),
+            ),
+        );
+    }
+}
+
+/// Get the SQL for resolving every symbol defined in a set of (indexed) files.
+pub fn get_symbols_in_files_sql<'a>(
+    paths: impl IntoIterator<Item = &'a Path>,
+) -> (String, sea_query_sqlx::SqlxValues) {
+    select_resolved_symbols()
+        .and_where(
+            Expr::col(("file", "path")).is_in(
+                paths
+                    .into_iter()
+                    .map(|path| path.to_string_lossy().to_string()),
+            ),
+        )
+        .build_sqlx(SqliteQueryBuilder)
+}
+
+/// Get the SQL for resolving every symbol with a particular name.
+pub fn get_symbols_by_name_sql(name: &str) -> (String, sea_query_sqlx::SqlxValues) {
+    select_resolved_symbols()
+        .and_where(Expr::col(("symbol", "name")).eq(name))
+        .build_sqlx(SqliteQueryBuilder)
+}
+
+/// Get the SQL for listing every indexed file, alongside its package and whether it's generated
+/// code.
+pub fn get_indexed_files_sql() -> (String, sea_query_sqlx::SqlxValues) {
+    sea_query::Query::select()
+        .columns([("file", "path"), ("file", "package"), ("file", "generated")])
+        .from("file")
+        .build_sqlx(SqliteQueryBuilder)
+}
+
+/// Get the SQL for resolving the usages of translation keys, optionally for only a single key.
+pub fn get_translation_key_usages_sql(key: Option<&str>) -> (String, sea_query_sqlx::SqlxValues) {
+    let mut query = select_resolved_symbols();
+
+    query.and_where(
+        Expr::col(("symbol", "kind")).eq(models::parsed::SymbolKind::TranslationKey.to_string()),
+    );
+
+    if let Some(key) = key {
+        query.and_where(Expr::col(("symbol", "name")).eq(key));
+    }
+
+    query.build_sqlx(SqliteQueryBuilder)
+}
+
+/// Get the SQL for resolving the translations defined in locale files (as
+/// [`crate::models::resolved::Translation`]), optionally for only a single key.
+pub fn get_translations_sql(key: Option<&str>) -> (String, sea_query_sqlx::SqlxValues) {
+    let mut query = sea_query::Query::select();
+
+    query
+        .columns([
+            ("translation", "key"),
+            ("translation", "locale"),
+            ("file", "path"),
+            ("translation", "start_line"),
+            ("translation", "end_line"),
+            ("translation", "start_column"),
+            ("translation", "end_column"),
+        ])
+        .from("translation")
+        .join(
+            sea_query::JoinType::InnerJoin,
+            "file",
+            Expr::col(("translation", "file_id")).equals(("file", "id")),
+        );
+
+    if let Some(key) = key {
+        query.and_where(Expr::col(("translation", "key")).eq(key));
+    }
+
     query.build_sqlx(SqliteQueryBuilder)
 }
 
+/// Get the SQL for resolving the schema definitions (as
+/// [`crate::models::resolved::SchemaDefinition`]) with one of a set of names.
+///
+/// Names also match nested definitions (i.e. `GetUser` matches `UserService.GetUser`).
+pub fn get_schema_definitions_sql<'a>(
+    names: impl IntoIterator<Item = &'a str>,
+) -> (String, sea_query_sqlx::SqlxValues) {
+    let mut condition = Cond::any();
+
+    for name in names {
+        // Names can contain underscores, which would otherwise act as wildcards
+        let escaped_name = name
+            .replace('!', "!!")
+            .replace('%', "!%")
+            .replace('_', "!_");
+
+        condition = condition
+            .add(Expr::col(("schema_definition", "name")).eq(name))
+            .add(
+                Expr::col(("schema_definition", "name"))
+                    .like(LikeExpr::new(format!("%.{escaped_name}")).escape('!')),
+            );
+    }
+
+    select_schema_definitions()
+        .cond_where(condition)
+        .build_sqlx(SqliteQueryBuilder)
+}
+
+/// Get a query which selects all of the columns needed for a
+/// [`crate::models::resolved::SchemaDefinition`].
+fn select_schema_definitions() -> SelectStatement {
+    let mut query = sea_query::Query::select();
+
+    query
+        .columns([
+            ("schema_definition", "name"),
+            ("schema_definition", "kind"),
+            ("file", "path"),
+            ("schema_definition", "start_line"),
+            ("schema_definition", "end_line"),
+            ("schema_definition", "start_column"),
+            ("schema_definition", "end_column"),
+        ])
+        .from("schema_definition")
+        .join(
+            sea_query::JoinType::InnerJoin,
+            "file",
+            Expr::col(("schema_definition", "file_id")).equals(("file", "id")),
+        );
+
+    query
+}
+
+/// Get the SQL for listing every indexed file (as [`crate::dump::File`]).
+pub fn get_dumped_files_sql() -> (String, sea_query_sqlx::SqlxValues) {
+    sea_query::Query::select()
+        .columns([
+            ("file", "path"),
+            ("file", "package"),
+            ("file", "package_root"),
+            ("file", "generated"),
+            ("file", "ephemeral"),
+        ])
+        .expr_as(
+            Expr::cust(
+                "COALESCE((SELECT group_concat(file_owner.owner, ' ') FROM file_owner WHERE file_owner.file_id = file.id), '')",
+            ),
+            "owners",
+        )
+        .from("file")
+        .build_sqlx(SqliteQueryBuilder)
+}
+
+/// Get the SQL for resolving every symbol in the index.
+pub fn get_all_symbols_sql() -> (String, sea_query_sqlx::SqlxValues) {
+    select_resolved_symbols().build_sqlx(SqliteQueryBuilder)
+}
+
+/// Get the SQL for resolving every schema definition in the index.
+pub fn get_all_schema_definitions_sql() -> (String, sea_query_sqlx::SqlxValues) {
+    select_schema_definitions().build_sqlx(SqliteQueryBuilder)
+}
+
+/// Get the SQL for finding indexed files which have one of a set of filenames, in any directory.
+///
+/// Filenames can also include parent directories (i.e. `__tests__/user.ts`), separated by `/`.
+pub fn get_files_by_filename_sql<'a>(
+    filenames: impl IntoIterator<Item = &'a str>,
+) -> (String, sea_query_sqlx::SqlxValues) {
+    let mut matches_filename = Cond::any();
+
+    for filename in filenames {
+        // Filenames commonly contain underscores, which would otherwise act as wildcards. A
+        // backslash can't be used as the escape character, as it's the separator on Windows
+        let filename = filename
+            .replace('!', "!!")
+            .replace('%', "!%")
+            .replace('_', "!_")
+            .replace('/', MAIN_SEPARATOR_STR);
+
+        matches_filename = matches_filename.add(
+            Expr::col(("file", "path"))
+                .like(LikeExpr::new(format!("%{MAIN_SEPARATOR}{filename}")).escape('!')),
+        );
+    }
+
+    sea_query::Query::select()
+        .column(("file", "path"))
+        .from("file")
+        .cond_where(matches_filename)
+        .build_sqlx(SqliteQueryBuilder)
+}
+
+/// Get the SQL for finding the package which owns a particular (indexed) file.
+pub fn get_file_package_sql(path: &Path) -> (String, sea_query_sqlx::SqlxValues) {
+    sea_query::Query::select()
+        .column(("file", "package"))
+        .from("file")
+        .and_where(Expr::col(("file", "path")).eq(path.to_string_lossy().to_string()))
+        .build_sqlx(SqliteQueryBuilder)
+}
+
 /// Check if a given file (i.e. `path/to/some/file/lib.rs`) is in what would
 /// traditionally be an entrypoint file in various programming languages.
 ///
@@ -93,72 +436,6 @@ pub fn is_entrypoint_file(filename: &str) -> bool {
     )
 }
 
-/// Check if a given file (i.e. `path/to/some/file/file.test.ts`) is in what would
-/// generally be used in a test harness (i.e. Jest, PHP Unit, Rust unit/integration tests,
-/// etc.) in various programming languages.
-///
-/// Though not a guarantee (also, not all cases are testable in this mechanism), these files are
-/// _generally_ used for testing, and as such don't house business logic of their own.
-pub fn is_part_of_test_harness(path: &Path) -> bool {
-    // Common test file patterns
-    let test_file_patterns = [
-        // JavaScript / TypeScript
-        ".test.js",
-        ".spec.js",
-        ".test.ts",
-        ".spec.ts",
-        // Python
-        "test_",
-        "_test.py",
-        // PHP
-        "test",
-        "test.php",
-        // Java
-        "test",
-        "Test.java",
-        // Ruby
-        "_test.rb",
-        "test_",
-        // Rust
-        "tests.rs",
-        "_test.rs",
-        "test_",
-        // Go
-        "_test.go",
-        // C / C++
-        "_test.c",
-        "_test.cpp",
-        "_test.cc",
-        // C#
-        "test",
-        "tests",
-        "Test.cs",
-        // Kotlin
-        "Test.kt",
-        // Swift
-        "Tests.swift",
-    ];
-
-    // Check if the filename or directory matches test heuristics
-    if let Some(filename) = path.file_name().and_then(OsStr::to_str)
-        && test_file_patterns
-            .iter()
-            .any(|pattern| filename.ends_with(pattern))
-    {
-        return true;
-    }
-
-    // Check if any parent directory is named "tests" (common in Rust, PHP, Python, Go)
-    if path
-        .ancestors()
-        .any(|ancestor| ancestor.file_name().is_some_and(|name| name == "tests"))
-    {
-        return true;
-    }
-
-    false
-}
-
 /// Get the number of path's distance between two files.
 ///
 /// It is assumed that the caller has passed in **file paths** for both `a` and `b`.
@@ -193,10 +470,137 @@ pub fn get_path_distance(a: &Path, b: &Path) -> usize {
     a_parts.len() - common_parts
 }
 
+/// Check if a character can be part of an identifier, in any supported language.
+pub fn is_identifier_char(c: char) -> bool {
+    c.is_alphanumeric() || c == '_' || c == '$'
+}
+
+/// Check if some text contains an identifier, ignoring matches which are only part of a longer
+/// identifier (i.e. `user` in `username`).
+pub fn contains_identifier(text: &str, name: &str) -> bool {
+    !name.is_empty()
+        && text.match_indices(name).any(|(start, _)| {
+            !text[..start].ends_with(is_identifier_char)
+                && !text[start + name.len()..].starts_with(is_identifier_char)
+        })
+}
+
+/// Get the identifier at a position in some content (i.e. the identifier under the cursor),
+/// alongside its range.
+///
+/// Positions at the very end of an identifier are also considered to be on it, as editors
+/// commonly place the cursor just after the word being typed or hovered.
+pub fn get_identifier_at(
+    content: &str,
+    position: models::resolved::Position,
+) -> Option<(String, models::resolved::TextRange)> {
+    let line = content.lines().nth(usize::try_from(position.line).ok()?)?;
+
+    // Positions are counted in UTF-16 code units, so must be converted into a byte offset
+    let mut characters = 0;
+    let offset = line
+        .char_indices()
+        .find(|(_, c)| {
+            let is_past = characters >= position.character;
+
+            characters += u32::try_from(c.len_utf16()).unwrap_or(1);
+
+            is_past
+        })
+        .map_or(line.len(), |(offset, _)| offset);
+
+    let start = line[..offset]
+        .char_indices()
+        .rev()
+        .take_while(|(_, c)| is_identifier_char(*c))
+        .last()
+        .map_or(offset, |(start, _)| start);
+
+    let end = line[offset..]
+        .char_indices()
+        .find(|(_, c)| !is_identifier_char(*c))
+        .map_or(line.len(), |(end, _)| offset + end);
+
+    if start == end {
+        return None;
+    }
+
+    let get_character = |byte: usize| u32::try_from(line[..byte].encode_utf16().count()).ok();
+
+    Some((
+        line[start..end].to_string(),
+        models::resolved::TextRange {
+            start: models::resolved::Position {
+                line: position.line,
+                character: get_character(start)?,
+            },
+            end: models::resolved::Position {
+                line: position.line,
+                character: get_character(end)?,
+            },
+        },
+    ))
+}
+
+/// Get the name a module is imported by (i.e. `user` for `src/user.ts`, or `api` for
+/// `src/api/mod.rs`).
+pub fn get_module_name(path: &Path) -> Option<&str> {
+    let filename = path.file_name().and_then(OsStr::to_str)?;
+
+    if is_entrypoint_file(filename) {
+        return path
+            .parent()
+            .and_then(Path::file_name)
+            .and_then(OsStr::to_str);
+    }
+
+    path.file_stem().and_then(OsStr::to_str)
+}
+
+/// Check if a line is (part of) an import (i.e. `import { getUser } from "./user"`, `use
+/// crate::user`, or `local user = require("user")`).
+pub fn is_import_line(line: &str) -> bool {
+    let line = line.trim();
+
+    line.starts_with("import")
+        || line.starts_with("from ")
+        || line.starts_with("use ")
+        || line.starts_with("pub use ")
+        || line.starts_with("(ns")
+        || line.starts_with("(:require")
+        || line.contains("require(")
+        // Go imports inside an `import (…)` block are only the quoted path
+        || (line.starts_with('"') && line.ends_with('"'))
+}
+
+/// Check if some content imports a module (see [`is_import_line`]).
+pub fn imports_module(content: &str, module_name: &str) -> bool {
+    content
+        .lines()
+        .any(|line| is_import_line(line) && contains_identifier(line, module_name))
+}
+
+/// Check if a symbol is only the import of another symbol (i.e. `getUser` in `import { getUser }
+/// from "./user"`), rather than a definition of its own.
+pub async fn is_import(symbol: &models::resolved::ResolvedSymbol) -> bool {
+    let Ok(content) = tokio::fs::read_to_string(&symbol.path).await else {
+        return false;
+    };
+
+    usize::try_from(symbol.start_line - 1)
+        .ok()
+        .and_then(|index| content.lines().nth(index))
+        .is_some_and(is_import_line)
+}
+
 #[cfg(test)]
 mod tests {
     use std::path::PathBuf;
 
+    use rstest::rstest;
+
+    use crate::models::resolved::Position;
+
     #[test]
     pub fn test_distance_between_files() {
         let distance = super::get_path_distance(
@@ -240,30 +644,26 @@ mod tests {
         assert_eq!(1, distance);
     }
 
-    #[test]
-    pub fn test_in_tests_folder() {
-        let file = PathBuf::from_iter(["some", "root", "tests", "SomeFileTest.php"]);
-
-        let is_in_test_harness = super::is_part_of_test_harness(file.as_path());
-
-        assert!(is_in_test_harness);
-    }
-
-    #[test]
-    pub fn test_in_test_harness_file_js() {
-        let file = PathBuf::from_iter(["some", "root", "some_file.test.js"]);
-
-        let is_in_test_harness = super::is_part_of_test_harness(file.as_path());
-
-        assert!(is_in_test_harness);
-    }
-
-    #[test]
-    pub fn test_not_in_test_harness() {
-        let file = PathBuf::from_iter(["some", "root", "just_a_normal_file.py"]);
-
-        let is_in_test_harness = super::is_part_of_test_harness(file.as_path());
-
-        assert!(!is_in_test_harness);
+    #[rstest]
+    #[case("getUser(id);", 3, Some(("getUser", 0, 7)))]
+    #[case("getUser(id);", 7, Some(("getUser", 0, 7)))]
+    #[case("getUser(id);", 9, Some(("id", 8, 10)))]
+    #[case("const é = $user;", 12, Some(("$user", 10, 15)))]
+    #[case("getUser( id);", 8, None)]
+    pub fn test_getting_identifier_at_position(
+        #[case] line: &str,
+        #[case] character: u32,
+        #[case] expected: Option<(&str, u32, u32)>,
+    ) {
+        let identifier = super::get_identifier_at(line, Position { line: 0, character });
+
+        assert_eq!(
+            expected,
+            identifier.as_ref().map(|(name, range)| (
+                name.as_str(),
+                range.start.character,
+                range.end.character
+            ))
+        );
     }
 }
@@ -1,3 +1,5 @@
+use std::time::Duration;
+
 use crate::resolver::constant::{self, DEFAULT_SCORE};
 
 /// 8 point bonus during fuzzy matching when the fuzzy match is case-sensitive (i.e. query includes
@@ -42,6 +44,67 @@ pub const ENTRYPOINT_FILE_SCORE_PENALTY: i64 = -((constant::DEFAULT_SCORE * 25)
 /// just navigate to that symbol in line). However, how true is this in practice?
 pub const SAME_FILE_PENALTY: i64 = -((constant::DEFAULT_SCORE * 10) / 1000);
 
+/// 1% bonus for symbols defined in the same package (i.e. crate, npm package, Go module, etc.)
+/// as the one currently focussed.
+///
+/// Directory distance ([`calculate_distance_score_penalty`]) alone doesn't capture package
+/// boundaries, and so this helps to favour symbols which are likely to be related.
+pub const SAME_PACKAGE_SCORE_BONUS: i64 = (constant::DEFAULT_SCORE * 10) / 1000;
+
+/// 1% bonus for symbols defined in a file owned (through `CODEOWNERS`) by the current user, or
+/// one of their teams.
+pub const OWNED_BY_CURRENT_OWNER_SCORE_BONUS: i64 = (constant::DEFAULT_SCORE * 10) / 1000;
+
+/// 2% penalty for symbols which have been marked as deprecated, as they're generally being
+/// phased out in favour of a replacement.
+///
+/// This can be overridden for a query with [`crate::resolver::Context::with_deprecated_symbol_penalty`].
+pub const DEPRECATED_SYMBOL_SCORE_PENALTY: i64 = -((constant::DEFAULT_SCORE * 20) / 1000);
+
+/// 10% bonus for symbols which have been pinned (see [`crate::pin`]), so that they float to the
+/// top of every query they match.
+pub const PINNED_SYMBOL_SCORE_BONUS: i64 = (constant::DEFAULT_SCORE * 100) / 1000;
+
+/// 1% penalty for symbols which only matched an expansion of the query (i.e. `config` for a query
+/// of `cfg`), so that symbols matching the query as typed are always preferred.
+pub const SYNONYM_MATCH_SCORE_PENALTY: i64 = -((constant::DEFAULT_SCORE * 10) / 1000);
+
+/// 3% bonus for completion candidates defined in the same language as the file being edited,
+/// as symbols from other languages can rarely be used directly.
+pub const SAME_LANGUAGE_COMPLETION_SCORE_BONUS: i64 = (constant::DEFAULT_SCORE * 30) / 1000;
+
+/// 3% bonus for completion candidates defined in a module the file being edited already imports,
+/// as they can be used without any further imports.
+pub const IMPORTED_FILE_COMPLETION_SCORE_BONUS: i64 = (constant::DEFAULT_SCORE * 30) / 1000;
+
+/// 3% bonus for symbols in files which have only just changed, which decays over time (see
+/// [`calculate_recency_score_bonus`]).
+pub const RECENCY_SCORE_BONUS: i64 = (constant::DEFAULT_SCORE * 30) / 1000;
+
+/// A bonus for symbols in files which changed recently ([`RECENCY_SCORE_BONUS`]), which halves for
+/// every half-life since the file last changed.
+///
+/// Between each half-life the bonus decays linearly, which keeps scoring to integer arithmetic.
+pub fn calculate_recency_score_bonus(age: Duration, half_life: Duration) -> i64 {
+    let half_life = half_life.as_secs();
+
+    if half_life == 0 {
+        return 0;
+    }
+
+    let age = age.as_secs();
+
+    let bonus = u32::try_from(age / half_life)
+        .ok()
+        .and_then(|halvings| RECENCY_SCORE_BONUS.checked_shr(halvings))
+        .unwrap_or(0);
+
+    let remainder = i64::try_from(age % half_life).unwrap_or(0);
+    let half_life = i64::try_from(half_life).unwrap_or(i64::MAX);
+
+    bonus - ((bonus - bonus / 2) * remainder) / half_life
+}
+
 /// 2% penalty for each directory distance from the current focused file (up to max of
 /// 8 directories - aka a 12% penalty)
 pub fn calculate_distance_score_penalty(distance: usize) -> i64 {
@@ -82,6 +145,8 @@ pub fn calculate_fuzzy_match_bonus(fuzzy_match: &frizbee::Match) -> i64 {
 
 #[cfg(test)]
 mod tests {
+    use std::time::Duration;
+
     use rstest::rstest;
 
     #[rstest]
@@ -102,4 +167,29 @@ mod tests {
             super::calculate_distance_score_penalty(distance)
         );
     }
+
+    #[rstest]
+    #[case(0, 10, 30)]
+    #[case(5, 10, 23)]
+    #[case(10, 10, 15)]
+    #[case(20, 10, 7)]
+    #[case(30, 10, 3)]
+    #[case(100, 10, 0)]
+    #[case(u64::MAX, 1, 0)]
+    #[case(10, 0, 0)]
+    pub fn test_recency_weighting(
+        #[case] age_days: u64,
+        #[case] half_life_days: u64,
+        #[case] expected_bonus: i64,
+    ) {
+        const DAY: u64 = 60 * 60 * 24;
+
+        assert_eq!(
+            expected_bonus,
+            super::calculate_recency_score_bonus(
+                Duration::from_secs(age_days.saturating_mul(DAY)),
+                Duration::from_secs(half_life_days * DAY)
+            )
+        );
+    }
 }
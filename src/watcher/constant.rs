@@ -1,3 +1,9 @@
 /// The number of seconds the Debouncer will group together file system events which occur in quick
 /// succession for.
 pub const DEBOUNCED_EVENT_TIMEOUT_SECS: u64 = 2;
+
+/// The number of seconds between each eviction of expired ephemeral files, while the watcher is
+/// running.
+///
+/// See [`crate::indexer::Indexer::evict_expired_ephemeral`].
+pub const EPHEMERAL_EVICTION_INTERVAL_SECS: u64 = 60;
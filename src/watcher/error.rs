@@ -29,4 +29,8 @@ pub enum Error {
     /// An error occurred while de-indexing a file.
     #[error("An error occurred when attempting to deindex a file: {0}")]
     DeindexingFailed(indexer::Error),
+
+    /// An error occurred while evicting (or marking as accessed) an ephemeral file.
+    #[error("An error occurred when attempting to evict an ephemeral file: {0}")]
+    EvictionFailed(indexer::Error),
 }
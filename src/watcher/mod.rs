@@ -1,6 +1,10 @@
 //! Incremental indexing using [`crate::indexer::Indexer`] and filesystem events.
 
-use std::{path::Path, sync::Arc, time::Duration};
+use std::{
+    path::Path,
+    sync::{Arc, Weak},
+    time::Duration,
+};
 
 use ignored::is_ignored;
 use itertools::Itertools;
@@ -41,6 +45,7 @@ where
 {
     debouncer: Arc<Mutex<Option<Debouncer<RecommendedWatcher>>>>,
     handle: Arc<Mutex<Option<JoinHandle<()>>>>,
+    eviction: Arc<Mutex<Option<JoinHandle<()>>>>,
     indexer: Arc<Mutex<I>>,
 }
 
@@ -57,6 +62,7 @@ where
         Self {
             debouncer: Arc::default(),
             handle: Arc::default(),
+            eviction: Arc::default(),
             indexer: Arc::new(Mutex::new(indexer)),
         }
     }
@@ -88,6 +94,9 @@ where
     /// Begin watching for file changes in the indexer's workspaces, and trigger a re-index of
     /// any relevant files which have changed.
     ///
+    /// While watching, ephemeral files which have expired are also evicted periodically (see
+    /// [`Indexer::evict_expired_ephemeral`]).
+    ///
     /// # Errors
     ///
     /// Returns an error if the Watcher could not be started. Generally this occurs if the
@@ -127,6 +136,14 @@ where
 
         *self.handle.lock().await = Some(handle);
 
+        let eviction = tokio::spawn(Self::evict_expired_periodically(Arc::downgrade(
+            &self.indexer,
+        )));
+
+        if let Some(previous) = self.eviction.lock().await.replace(eviction) {
+            previous.abort();
+        }
+
         Ok(())
     }
 
@@ -137,6 +154,11 @@ where
         let debouncer = self.debouncer.lock().await.take();
         let handle = self.handle.lock().await.take();
 
+        if let Some(eviction) = self.eviction.lock().await.take() {
+            // Unlike watching, eviction runs on a timer, so never stops on its own
+            eviction.abort();
+        }
+
         // They'll both be dropped and safely shut down when they go
         // out of scope, but just for verbosity, drop them explicitly
         drop(handle);
@@ -145,6 +167,84 @@ where
         log::debug!("Watcher stopped");
     }
 
+    /// Index a file on demand, even if it is outside the indexer's workspaces.
+    ///
+    /// This is generally called when a file is opened in an editor. Files outside of a workspace
+    /// are not watched for changes, so this should also be called whenever they are saved.
+    ///
+    /// See [`Indexer::index_ephemeral`].
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the file could not be indexed successfully.
+    pub async fn index_ephemeral(&self, path: &Path) -> Result<()> {
+        self.indexer
+            .lock()
+            .await
+            .index_ephemeral(path)
+            .await
+            .map_err(watcher::Error::IndexingFailed)
+    }
+
+    /// Evict a file previously indexed with [`Watcher::index_ephemeral`], usually when the file
+    /// has been closed.
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the file could not be evicted successfully.
+    pub async fn evict_ephemeral(&self, path: &Path) -> Result<()> {
+        self.indexer
+            .lock()
+            .await
+            .evict_ephemeral(path)
+            .await
+            .map_err(watcher::Error::EvictionFailed)
+    }
+
+    /// Mark a file previously indexed with [`Watcher::index_ephemeral`] as accessed, usually
+    /// when the file is focused (or read) in an editor, so that it doesn't expire while it's
+    /// still open.
+    ///
+    /// See [`Indexer::touch_ephemeral`].
+    ///
+    /// # Errors
+    ///
+    /// Returns an error if the file could not be marked as accessed.
+    pub async fn touch_ephemeral(&self, path: &Path) -> Result<()> {
+        self.indexer
+            .lock()
+            .await
+            .touch_ephemeral(path)
+            .await
+            .map_err(watcher::Error::EvictionFailed)
+    }
+
+    /// Evict expired ephemeral files on a fixed interval, until the watcher (and so its indexer)
+    /// is dropped.
+    async fn evict_expired_periodically(indexer: Weak<Mutex<I>>) {
+        let period = Duration::from_secs(constant::EPHEMERAL_EVICTION_INTERVAL_SECS);
+        let mut interval = tokio::time::interval_at(tokio::time::Instant::now() + period, period);
+
+        loop {
+            interval.tick().await;
+
+            let Some(strong_indexer) = indexer.upgrade() else {
+                break;
+            };
+
+            let locked_indexer = strong_indexer.lock().await;
+
+            match locked_indexer
+                .evict_expired_ephemeral(locked_indexer.get_ephemeral_file_ttl())
+                .await
+            {
+                Ok(0) => {}
+                Ok(evicted) => log::debug!("Evicted {evicted} expired ephemeral files."),
+                Err(e) => log::error!("Eviction error: {e:?}"),
+            }
+        }
+    }
+
     /// Process any events received from the debouncer, by triggering the indexer for
     /// all files.
     ///